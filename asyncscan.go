@@ -0,0 +1,106 @@
+package gocql
+
+import "sync"
+
+// AsyncScanner decodes the rows of an iterator on a background goroutine
+// while the consumer processes the previous rows, see Iter.ScanAsync.
+type AsyncScanner struct {
+	iter *Iter
+	rows [][]interface{}
+	cur  int
+
+	// ready receives the rows decoded by the worker, free the rows the
+	// consumer is done with.
+	ready chan int
+	free  chan int
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// ScanAsync returns a scanner decoding up to buffer rows ahead of the row
+// being processed on a background goroutine, which overlaps the unmarshalling
+// of wide rows with the work of the consumer.
+//
+// newRow is called buffer+1 times up front and returns the destinations of a
+// row, as passed to Scan. The destinations are reused, a row is valid until
+// the next call of Next. The iterator must not be used directly until the
+// scanner is closed.
+func (iter *Iter) ScanAsync(buffer int, newRow func() []interface{}) *AsyncScanner {
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	s := &AsyncScanner{
+		iter:  iter,
+		rows:  make([][]interface{}, buffer+1),
+		cur:   -1,
+		ready: make(chan int, buffer+1),
+		free:  make(chan int, buffer+1),
+		done:  make(chan struct{}),
+	}
+	for i := range s.rows {
+		s.rows[i] = newRow()
+		s.free <- i
+	}
+
+	s.wg.Add(1)
+	go s.decode()
+	return s
+}
+
+func (s *AsyncScanner) decode() {
+	defer s.wg.Done()
+	defer close(s.ready)
+
+	for {
+		var i int
+		select {
+		case i = <-s.free:
+		case <-s.done:
+			return
+		}
+		if !s.iter.Scan(s.rows[i]...) {
+			return
+		}
+		select {
+		case s.ready <- i:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Next advances to the next decoded row, waiting for it to be decoded. It
+// returns false at the end of the rows or if an error occurred, Close returns
+// the error.
+func (s *AsyncScanner) Next() bool {
+	if s.cur >= 0 {
+		s.free <- s.cur
+		s.cur = -1
+	}
+	i, ok := <-s.ready
+	if !ok {
+		return false
+	}
+	s.cur = i
+	return true
+}
+
+// Row returns the destinations the current row was decoded into.
+func (s *AsyncScanner) Row() []interface{} {
+	if s.cur < 0 {
+		return nil
+	}
+	return s.rows[s.cur]
+}
+
+// Close stops decoding and closes the iterator, returning its error.
+func (s *AsyncScanner) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+	return s.iter.Close()
+}
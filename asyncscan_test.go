@@ -0,0 +1,82 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestIterScanAsync(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var rows [][]interface{}
+	for i := 0; i < 50; i++ {
+		rows = append(rows, []interface{}{i, "event"})
+	}
+	srv.On(`SELECT id, name FROM events`).Rows([]gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "name", Type: gocqltest.Text},
+	}, rows...)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	newRow := func() []interface{} {
+		return []interface{}{new(int), new(string)}
+	}
+
+	scanner := session.Query(`SELECT id, name FROM events`).PageSize(7).Iter().ScanAsync(4, newRow)
+	var n int
+	for scanner.Next() {
+		row := scanner.Row()
+		if id := *row[0].(*int); id != n {
+			t.Fatalf("expected row %d, got %d", n, id)
+		}
+		if name := *row[1].(*string); name != "event" {
+			t.Fatalf("unexpected name %q", name)
+		}
+		n++
+	}
+	if err := scanner.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(rows) {
+		t.Fatalf("expected %d rows, got %d", len(rows), n)
+	}
+
+	// closing early stops the decoding
+	scanner = session.Query(`SELECT id, name FROM events`).PageSize(7).Iter().ScanAsync(2, newRow)
+	if !scanner.Next() {
+		t.Fatal("expected a row")
+	}
+	if err := scanner.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIterScanAsyncError(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, []interface{}{1})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	// too many destinations
+	scanner := session.Query(`SELECT id FROM events`).Iter().ScanAsync(1, func() []interface{} {
+		return []interface{}{new(int), new(int)}
+	})
+	if scanner.Next() {
+		t.Fatal("expected no rows")
+	}
+	if err := scanner.Close(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
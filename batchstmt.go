@@ -0,0 +1,108 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Statement is a statement built by a query builder, such as the builders of
+// github.com/scylladb/gocqlx/qb, which returns the CQL of the statement and
+// the names of its bind markers.
+type Statement interface {
+	ToCql() (stmt string, names []string)
+}
+
+// AddStmt adds the statement of a query builder to the batch operation with
+// the values of its bind markers, in the order of the markers.
+func (b *Batch) AddStmt(stmt Statement, args ...interface{}) {
+	cql, _ := stmt.ToCql()
+	b.Query(cql, args...)
+}
+
+// AddStruct adds an INSERT into table of the fields of the struct v, or of
+// the struct v points to, to the batch operation. The table may be qualified
+// by its keyspace. The fields are mapped to the columns like ScanAll does:
+// every exported field is inserted into the column of the same name, in lower
+// case, or named by its cql tag, and fields tagged cql:"-" are ignored:
+//
+//	type User struct {
+//		ID        gocql.UUID `cql:"id"`
+//		FirstName string     `cql:"first_name"`
+//		Password  string     `cql:"-"`
+//	}
+//
+//	batch.AddStruct("users", user) // INSERT INTO users ("id","first_name") VALUES (?,?)
+//
+// If table or v are invalid, executing the batch fails with the error.
+func (b *Batch) AddStruct(table string, v interface{}) {
+	stmt, args, err := structInsert(table, v)
+	if err != nil {
+		if b.entryErr == nil {
+			b.entryErr = err
+		}
+		return
+	}
+	b.Query(stmt, args...)
+}
+
+// structInsert returns the INSERT into table of the fields of the struct v
+// and its values.
+func structInsert(table string, v interface{}) (string, []interface{}, error) {
+	if err := validateQualifiedTable(table); err != nil {
+		return "", nil, err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("gocql: AddStruct expects a struct or a pointer to a struct, got %T", v)
+	}
+
+	var (
+		names []string
+		args  []interface{}
+		seen  = make(map[string]bool)
+	)
+	t := rv.Type()
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous || throughPointer(t, sf.Index) {
+			continue
+		}
+		name := sf.Tag.Get("cql")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		name = strings.ToLower(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, QuoteIdentifier(name))
+		args = append(args, rv.FieldByIndex(sf.Index).Interface())
+	}
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("gocql: AddStruct: %s has no field to insert", t)
+	}
+
+	markers := strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ","), markers)
+	return stmt, args, nil
+}
+
+// validateQualifiedTable returns an error if name is not a valid table name,
+// optionally qualified by a keyspace.
+func validateQualifiedTable(name string) error {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		if err := ValidateKeyspaceName(name[:i]); err != nil {
+			return err
+		}
+		name = name[i+1:]
+	}
+	return ValidateTableName(name)
+}
@@ -0,0 +1,99 @@
+package gocql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type insertStmt struct {
+	table   string
+	columns []string
+}
+
+func (s insertStmt) ToCql() (string, []string) {
+	markers := strings.TrimSuffix(strings.Repeat("?,", len(s.columns)), ",")
+	return "INSERT INTO " + s.table + " (" + strings.Join(s.columns, ",") + ") VALUES (" + markers + ")", s.columns
+}
+
+type batchUser struct {
+	ID       int    `cql:"id"`
+	Name     string `cql:"name"`
+	Password string `cql:"-"`
+	internal int
+}
+
+func TestBatchAddStmtAndStruct(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	params := []gocqltest.Column{{Name: "id", Type: gocqltest.Int}, {Name: "name", Type: gocqltest.Varchar}}
+	srv.On(`INSERT INTO ks.users (id,name) VALUES (?,?)`).Params(params...).Rows(nil)
+	srv.On(`INSERT INTO ks.users ("id","name") VALUES (?,?)`).Params(params...).Rows(nil)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.AddStmt(insertStmt{table: "ks.users", columns: []string{"id", "name"}}, 1, "alice")
+	b.AddStruct("ks.users", &batchUser{ID: 2, Name: "bob", Password: "secret"})
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, req := range srv.Requests() {
+		if !req.Batch {
+			continue
+		}
+		var (
+			id   int
+			name string
+		)
+		if err := req.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, req.Statement+" "+name)
+	}
+	want := []string{
+		`INSERT INTO ks.users (id,name) VALUES (?,?) alice`,
+		`INSERT INTO ks.users ("id","name") VALUES (?,?) bob`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected batch entries:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestBatchAddStructInvalid(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		table string
+		v     interface{}
+	}{
+		{"ks.users", 1},
+		{"ks.users", (*batchUser)(nil)},
+		{"ks.users;", batchUser{}},
+		{"bad-ks.users", batchUser{}},
+		{"ks.users", struct{ internal int }{}},
+	}
+	for _, test := range tests {
+		b := session.NewBatch(gocql.LoggedBatch)
+		b.AddStruct(test.table, test.v)
+		if err := session.ExecuteBatch(b); err == nil {
+			t.Errorf("AddStruct(%q, %#v): expected an error", test.table, test.v)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gocql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BindMap sets the values of the query by the names of its bind markers,
+// instead of their position:
+//
+//	session.Query(`INSERT INTO users (id, name) VALUES (:id, :name)`).BindMap(map[string]interface{}{
+//		"id":   id,
+//		"name": name,
+//	})
+//
+// The names are those of the prepared metadata of the statement: the names of
+// the named markers, in lower case unless quoted, and the names of the columns
+// of the ? markers. A marker used several times is bound to the same value.
+// Executing the query fails if a marker has no value, or if a value has no
+// marker, and statements which aren't prepared can't be bound by name.
+func (q *Query) BindMap(values map[string]interface{}) *Query {
+	q.values = nil
+	q.namedValues = values
+	q.binding = func(info *QueryInfo) ([]interface{}, error) {
+		return bindNamedValues(info.Args, values)
+	}
+	q.pageState = nil
+	return q
+}
+
+// bindNamedValues returns the values of args by their name.
+func bindNamedValues(args []ColumnInfo, named map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+	bound := make(map[string]bool, len(args))
+	var missing []string
+	for i, arg := range args {
+		v, ok := named[arg.Name]
+		if !ok {
+			if !bound[arg.Name] {
+				missing = append(missing, arg.Name)
+			}
+			bound[arg.Name] = true
+			continue
+		}
+		bound[arg.Name] = true
+		values[i] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("gocql: no value bound to the markers %s", strings.Join(missing, ", "))
+	}
+
+	var extra []string
+	for name := range named {
+		if !bound[name] {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		return nil, fmt.Errorf("gocql: no marker for the values bound to %s", strings.Join(extra, ", "))
+	}
+	return values, nil
+}
+
+// namedRoutingValues returns the values of the routing key columns of info
+// bound by name, at their index, ok is false if a column has no value.
+func namedRoutingValues(info *routingKeyInfo, named map[string]interface{}) (values []interface{}, ok bool) {
+	for i, index := range info.indexes {
+		v, ok := named[info.names[i]]
+		if !ok {
+			return nil, false
+		}
+		if index >= len(values) {
+			values = append(values, make([]interface{}, index+1-len(values))...)
+		}
+		values[index] = v
+	}
+	return values, true
+}
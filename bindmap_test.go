@@ -0,0 +1,71 @@
+package gocql_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestQueryBindMap(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	const stmt = `UPDATE users SET name = :name WHERE id = :id`
+	srv.On(stmt).
+		Table("users").
+		Params(
+			gocqltest.Column{Name: "name", Type: gocqltest.Text},
+			gocqltest.Column{Name: "id", Type: gocqltest.Int},
+		).
+		PartitionKey(1).
+		Rows(nil)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	q := session.Query(stmt).BindMap(map[string]interface{}{"id": 42, "name": "alice"})
+	key, err := q.GetRoutingKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0, 0, 42}; !bytes.Equal(key, want) {
+		t.Fatalf("expected routing key %x, got %x", want, key)
+	}
+	if err := q.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	var (
+		name string
+		id   int
+	)
+	if err := reqs[0].Scan(&name, &id); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" || id != 42 {
+		t.Fatalf("expected alice and 42, got %q and %d", name, id)
+	}
+
+	for _, test := range []struct {
+		values map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"id": 42}, "no value bound to the markers name"},
+		{map[string]interface{}{"id": 42, "name": "alice", "age": 30}, "no marker for the values bound to age"},
+	} {
+		err := session.Query(stmt).BindMap(test.values).Exec()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected an error containing %q binding %v, got %v", test.err, test.values, err)
+		}
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("expected the queries failing to bind not to be sent, got %d requests", n)
+	}
+}
@@ -0,0 +1,325 @@
+// Package blob stores large blobs split into chunk rows.
+//
+// Single cells of more than a few megabytes cause timeouts and heap spikes on
+// both the client and the server. A Store splits a blob into chunks of
+// ChunkSize bytes, each stored in its own partition, and records the size and
+// number of chunks in a manifest row once all chunks are written:
+//
+//	store := &blob.Store{Session: session, Table: "files"}
+//	w := store.NewWriter(ctx, "report.pdf")
+//	if _, err := io.Copy(w, f); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := w.Close(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	r, err := store.NewReader(ctx, "report.pdf")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	io.Copy(os.Stdout, r)
+//
+// Every write of a blob creates a new version of its chunks, so readers see
+// either the previous or the new content. The chunks of the previous version
+// are deleted after the manifest has been replaced.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrClosed is returned by Writer.Write after the writer was closed.
+var ErrClosed = errors.New("blob: writer is closed")
+
+const defaultChunkSize = 1 << 20
+
+// Store reads and writes chunked blobs with a session.
+type Store struct {
+	// Session is used to read and write blobs.
+	Session *gocql.Session
+
+	// Keyspace of the tables. If empty the tables of the keyspace of the
+	// session are used.
+	Keyspace string
+
+	// Table holds the chunks. The manifests are held in a table with the
+	// same name suffixed with _manifest.
+	// Default: blobs
+	Table string
+
+	// ChunkSize is the size of the chunks of new blobs. Blobs written with a
+	// different chunk size can still be read.
+	// Default: 1MiB
+	ChunkSize int
+}
+
+func (s *Store) chunkTable() string {
+	table := s.Table
+	if table == "" {
+		table = "blobs"
+	}
+	if s.Keyspace != "" {
+		return s.Keyspace + "." + table
+	}
+	return table
+}
+
+func (s *Store) manifestTable() string {
+	return s.chunkTable() + "_manifest"
+}
+
+func (s *Store) chunkSize() int {
+	if s.ChunkSize <= 0 {
+		return defaultChunkSize
+	}
+	return s.ChunkSize
+}
+
+// CreateTables creates the chunk and manifest tables if they don't exist.
+func (s *Store) CreateTables(ctx context.Context) error {
+	table := s.Table
+	if table == "" {
+		table = "blobs"
+	}
+
+	err := s.Session.CreateTable(ctx, gocql.TableSchema{
+		Keyspace: s.Keyspace,
+		Name:     table,
+		Columns: []gocql.ColumnSchema{
+			{Name: "id", Type: "text"},
+			{Name: "version", Type: "timeuuid"},
+			{Name: "chunk", Type: "int"},
+			{Name: "data", Type: "blob"},
+		},
+		PartitionKey: []string{"id", "version", "chunk"},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Session.CreateTable(ctx, gocql.TableSchema{
+		Keyspace: s.Keyspace,
+		Name:     table + "_manifest",
+		Columns: []gocql.ColumnSchema{
+			{Name: "id", Type: "text"},
+			{Name: "version", Type: "timeuuid"},
+			{Name: "size", Type: "bigint"},
+			{Name: "chunks", Type: "int"},
+			{Name: "chunk_size", Type: "int"},
+		},
+		PartitionKey: []string{"id"},
+	})
+}
+
+// manifest describes a version of a blob.
+type manifest struct {
+	version   gocql.UUID
+	size      int64
+	chunks    int
+	chunkSize int
+}
+
+func (s *Store) manifest(ctx context.Context, id string) (manifest, error) {
+	var m manifest
+	err := s.Session.Query(`SELECT version, size, chunks, chunk_size FROM `+s.manifestTable()+` WHERE id = ?`, id).
+		WithContext(ctx).Scan(&m.version, &m.size, &m.chunks, &m.chunkSize)
+	return m, err
+}
+
+func (s *Store) deleteChunks(ctx context.Context, id string, version gocql.UUID, chunks int) error {
+	for i := 0; i < chunks; i++ {
+		err := s.Session.Query(`DELETE FROM `+s.chunkTable()+` WHERE id = ? AND version = ? AND chunk = ?`, id, version, i).
+			WithContext(ctx).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes a blob. Deleting a blob which doesn't exist is not an error.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	m, err := s.manifest(ctx, id)
+	if err == gocql.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := s.Session.Query(`DELETE FROM `+s.manifestTable()+` WHERE id = ?`, id).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	return s.deleteChunks(ctx, id, m.version, m.chunks)
+}
+
+// NewWriter returns a writer replacing the content of the blob id. The blob
+// is replaced when the writer is closed.
+func (s *Store) NewWriter(ctx context.Context, id string) *Writer {
+	return &Writer{
+		ctx:     ctx,
+		store:   s,
+		id:      id,
+		version: gocql.TimeUUID(),
+		buf:     make([]byte, 0, s.chunkSize()),
+	}
+}
+
+// Writer writes a blob in chunks. It is not safe for concurrent use.
+type Writer struct {
+	ctx     context.Context
+	store   *Store
+	id      string
+	version gocql.UUID
+
+	buf    []byte
+	chunks int
+	size   int64
+
+	err    error
+	closed bool
+}
+
+// Write writes p into the blob, storing every full chunk.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var n int
+	for len(p) > 0 {
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		p = p[m:]
+		n += m
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *Writer) flush() error {
+	err := w.store.Session.Query(`INSERT INTO `+w.store.chunkTable()+` (id, version, chunk, data) VALUES (?, ?, ?, ?)`,
+		w.id, w.version, w.chunks, w.buf).WithContext(w.ctx).Exec()
+	if err != nil {
+		w.err = fmt.Errorf("blob: write chunk %d of %s: %w", w.chunks, w.id, err)
+		return w.err
+	}
+	w.chunks++
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close stores the last chunk and replaces the manifest of the blob, then
+// deletes the chunks of the previous version. If writing failed, the chunks
+// written so far are deleted and the error is returned.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+
+	if w.err == nil && len(w.buf) > 0 {
+		w.flush()
+	}
+	if w.err != nil {
+		w.store.deleteChunks(w.ctx, w.id, w.version, w.chunks)
+		return w.err
+	}
+
+	previous, err := w.store.manifest(w.ctx, w.id)
+	if err != nil && err != gocql.ErrNotFound {
+		return err
+	}
+	hasPrevious := err == nil
+
+	err = w.store.Session.Query(`INSERT INTO `+w.store.manifestTable()+` (id, version, size, chunks, chunk_size) VALUES (?, ?, ?, ?, ?)`,
+		w.id, w.version, w.size, w.chunks, cap(w.buf)).WithContext(w.ctx).Exec()
+	if err != nil {
+		w.store.deleteChunks(w.ctx, w.id, w.version, w.chunks)
+		return err
+	}
+
+	if hasPrevious && previous.version != w.version {
+		return w.store.deleteChunks(w.ctx, w.id, previous.version, previous.chunks)
+	}
+	return nil
+}
+
+// NewReader returns a reader of the current version of the blob id. It
+// returns gocql.ErrNotFound if the blob doesn't exist.
+func (s *Store) NewReader(ctx context.Context, id string) (*Reader, error) {
+	m, err := s.manifest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{ctx: ctx, store: s, id: id, manifest: m}, nil
+}
+
+// Reader reads a blob chunk by chunk. It is not safe for concurrent use.
+type Reader struct {
+	ctx      context.Context
+	store    *Store
+	id       string
+	manifest manifest
+
+	buf   []byte
+	chunk int
+	read  int64
+}
+
+// Size returns the size of the blob in bytes.
+func (r *Reader) Size() int64 {
+	return r.manifest.size
+}
+
+// Read reads from the blob, fetching the next chunk when the current one is
+// consumed.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.chunk >= r.manifest.chunks {
+			if r.read != r.manifest.size {
+				return 0, fmt.Errorf("blob: %s has %d bytes, expected %d", r.id, r.read, r.manifest.size)
+			}
+			return 0, io.EOF
+		}
+		if err := r.fetch(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *Reader) fetch() error {
+	var data []byte
+	err := r.store.Session.Query(`SELECT data FROM `+r.store.chunkTable()+` WHERE id = ? AND version = ? AND chunk = ?`,
+		r.id, r.manifest.version, r.chunk).WithContext(r.ctx).Scan(&data)
+	if err == gocql.ErrNotFound {
+		return fmt.Errorf("blob: chunk %d of %s is missing", r.chunk, r.id)
+	} else if err != nil {
+		return err
+	}
+	if len(data) > r.manifest.chunkSize {
+		return fmt.Errorf("blob: chunk %d of %s has %d bytes, more than the chunk size %d", r.chunk, r.id, len(data), r.manifest.chunkSize)
+	}
+
+	r.buf = data
+	r.chunk++
+	r.read += int64(len(data))
+	return nil
+}
@@ -0,0 +1,288 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type chunkKey struct {
+	id      string
+	version gocql.UUID
+	chunk   int
+}
+
+// fakeStore stubs the statements of a Store on a fake server and keeps the
+// chunk and manifest tables in memory.
+type fakeStore struct {
+	srv *gocqltest.Server
+
+	mu        sync.Mutex
+	chunks    map[chunkKey][]byte
+	manifests map[string]manifest
+	failWrite int
+}
+
+func newFakeStore(t *testing.T) *fakeStore {
+	t.Helper()
+
+	f := &fakeStore{
+		srv:       gocqltest.NewServer(),
+		chunks:    make(map[chunkKey][]byte),
+		manifests: make(map[string]manifest),
+		failWrite: -1,
+	}
+	t.Cleanup(f.srv.Close)
+
+	id := gocqltest.Column{Name: "id", Type: gocqltest.Text}
+	version := gocqltest.Column{Name: "version", Type: gocqltest.TimeUUID}
+	chunk := gocqltest.Column{Name: "chunk", Type: gocqltest.Int}
+	data := gocqltest.Column{Name: "data", Type: gocqltest.Blob}
+	size := gocqltest.Column{Name: "size", Type: gocqltest.BigInt}
+	chunks := gocqltest.Column{Name: "chunks", Type: gocqltest.Int}
+	chunkSize := gocqltest.Column{Name: "chunk_size", Type: gocqltest.Int}
+
+	f.srv.On(`INSERT INTO blobs (id, version, chunk, data) VALUES (?, ?, ?, ?)`).
+		Params(id, version, chunk, data).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var key chunkKey
+			var data []byte
+			if err := req.Scan(&key.id, &key.version, &key.chunk, &data); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if key.chunk == f.failWrite {
+				return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeWriteTimeout, Message: "timeout"}}
+			}
+			f.chunks[key] = data
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`SELECT data FROM blobs WHERE id = ? AND version = ? AND chunk = ?`).
+		Params(id, version, chunk).
+		Handle([]gocqltest.Column{data}, func(req *gocqltest.Request) gocqltest.Response {
+			var key chunkKey
+			if err := req.Scan(&key.id, &key.version, &key.chunk); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if data, ok := f.chunks[key]; ok {
+				return gocqltest.Response{Rows: [][]interface{}{{data}}}
+			}
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`DELETE FROM blobs WHERE id = ? AND version = ? AND chunk = ?`).
+		Params(id, version, chunk).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var key chunkKey
+			if err := req.Scan(&key.id, &key.version, &key.chunk); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			delete(f.chunks, key)
+			f.mu.Unlock()
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`SELECT version, size, chunks, chunk_size FROM blobs_manifest WHERE id = ?`).
+		Params(id).
+		Handle([]gocqltest.Column{version, size, chunks, chunkSize}, func(req *gocqltest.Request) gocqltest.Response {
+			var id string
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if m, ok := f.manifests[id]; ok {
+				return gocqltest.Response{Rows: [][]interface{}{{m.version, m.size, m.chunks, m.chunkSize}}}
+			}
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`INSERT INTO blobs_manifest (id, version, size, chunks, chunk_size) VALUES (?, ?, ?, ?, ?)`).
+		Params(id, version, size, chunks, chunkSize).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var id string
+			var m manifest
+			if err := req.Scan(&id, &m.version, &m.size, &m.chunks, &m.chunkSize); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			f.manifests[id] = m
+			f.mu.Unlock()
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`DELETE FROM blobs_manifest WHERE id = ?`).
+		Params(id).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var id string
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			delete(f.manifests, id)
+			f.mu.Unlock()
+			return gocqltest.Response{}
+		})
+
+	return f
+}
+
+func (f *fakeStore) store(t *testing.T, chunkSize int) *Store {
+	t.Helper()
+	session, err := f.srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return &Store{Session: session, ChunkSize: chunkSize}
+}
+
+func write(t *testing.T, store *Store, id string, data []byte) error {
+	t.Helper()
+	w := store.NewWriter(context.Background(), id)
+	// write in pieces which don't line up with the chunks
+	for len(data) > 0 {
+		n := 7
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			w.Close()
+			return err
+		}
+		data = data[n:]
+	}
+	return w.Close()
+}
+
+func TestStoreWriteRead(t *testing.T) {
+	f := newFakeStore(t)
+	store := f.store(t, 16)
+
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	if err := write(t, store, "file", data); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.chunks) != 7 {
+		t.Fatalf("expected 7 chunks, got %d", len(f.chunks))
+	}
+
+	r, err := store.NewReader(context.Background(), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Size() != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), r.Size())
+	}
+	read, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatalf("expected %q, got %q", data, read)
+	}
+}
+
+func TestStoreOverwrite(t *testing.T) {
+	f := newFakeStore(t)
+	store := f.store(t, 16)
+
+	if err := write(t, store, "file", bytes.Repeat([]byte("a"), 100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(t, store, "file", []byte("short")); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.chunks) != 1 {
+		t.Fatalf("expected the chunks of the previous version to be deleted, got %d chunks", len(f.chunks))
+	}
+
+	r, err := store.NewReader(context.Background(), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read, err := ioutil.ReadAll(r); err != nil || string(read) != "short" {
+		t.Fatalf("expected short, got %q: %v", read, err)
+	}
+}
+
+func TestStoreFailedWrite(t *testing.T) {
+	f := newFakeStore(t)
+	store := f.store(t, 16)
+	f.failWrite = 3
+
+	if err := write(t, store, "file", bytes.Repeat([]byte("a"), 100)); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if len(f.chunks) != 0 || len(f.manifests) != 0 {
+		t.Fatalf("expected written chunks to be deleted, got %d chunks and %d manifests", len(f.chunks), len(f.manifests))
+	}
+	if _, err := store.NewReader(context.Background(), "file"); err != gocql.ErrNotFound {
+		t.Fatalf("expected %v, got %v", gocql.ErrNotFound, err)
+	}
+}
+
+func TestStoreMissingChunk(t *testing.T) {
+	f := newFakeStore(t)
+	store := f.store(t, 16)
+
+	if err := write(t, store, "file", bytes.Repeat([]byte("a"), 40)); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	for key := range f.chunks {
+		if key.chunk == 1 {
+			delete(f.chunks, key)
+		}
+	}
+	f.mu.Unlock()
+
+	r, err := store.NewReader(context.Background(), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(ioutil.Discard, r); err == nil {
+		t.Fatal("expected an error for the missing chunk")
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	f := newFakeStore(t)
+	store := f.store(t, 16)
+
+	if err := write(t, store, "file", bytes.Repeat([]byte("a"), 40)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(context.Background(), "file"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.chunks) != 0 || len(f.manifests) != 0 {
+		t.Fatalf("expected the blob to be deleted, got %d chunks and %d manifests", len(f.chunks), len(f.manifests))
+	}
+	if err := store.Delete(context.Background(), "file"); err != nil {
+		t.Fatalf("expected deleting a missing blob to succeed, got %v", err)
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	f := newFakeStore(t)
+	w := f.store(t, 16).NewWriter(context.Background(), "file")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("a")); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected %v, got %v", ErrClosed, err)
+	}
+}
@@ -0,0 +1,331 @@
+package gocql
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+// ClusterBuilder builds a ClusterConfig with chained calls, validating every
+// setting when it is set. It complements ClusterConfig, which remains the way
+// to reach the less common settings, see Configure:
+//
+//	session, err := gocql.NewClusterBuilder().
+//		Hosts("10.0.0.1", "10.0.0.2").
+//		Keyspace("example").
+//		Consistency(gocql.LocalQuorum).
+//		TLS(&tls.Config{ServerName: "cassandra"}).
+//		HostSelectionPolicy(gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy("dc1"))).
+//		CreateSession()
+//
+// The first invalid setting is kept as the error of the builder, returned by
+// Err, Build and CreateSession, and the settings after it are ignored.
+type ClusterBuilder struct {
+	cfg *ClusterConfig
+	err error
+}
+
+// NewClusterBuilder returns a builder of a ClusterConfig with the defaults of
+// NewCluster.
+func NewClusterBuilder() *ClusterBuilder {
+	return &ClusterBuilder{cfg: NewCluster()}
+}
+
+// set applies fn unless an earlier setting failed, keeping the error of fn
+// for the setting name.
+func (b *ClusterBuilder) set(name string, fn func(cfg *ClusterConfig) error) *ClusterBuilder {
+	if b.err != nil {
+		return b
+	}
+	if err := fn(b.cfg); err != nil {
+		b.err = fmt.Errorf("gocql: ClusterBuilder.%s: %w", name, err)
+	}
+	return b
+}
+
+// Err returns the error of the first invalid setting, or nil.
+func (b *ClusterBuilder) Err() error {
+	return b.err
+}
+
+// Hosts adds contact points, see ClusterConfig.Hosts.
+func (b *ClusterBuilder) Hosts(hosts ...string) *ClusterBuilder {
+	return b.set("Hosts", func(cfg *ClusterConfig) error {
+		for _, host := range hosts {
+			if host == "" {
+				return fmt.Errorf("empty host")
+			}
+		}
+		cfg.Hosts = append(cfg.Hosts, hosts...)
+		return nil
+	})
+}
+
+// Port sets the port of the hosts which don't include one.
+func (b *ClusterBuilder) Port(port int) *ClusterBuilder {
+	return b.set("Port", func(cfg *ClusterConfig) error {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+		cfg.Port = port
+		return nil
+	})
+}
+
+// Keyspace sets the initial keyspace of the session.
+func (b *ClusterBuilder) Keyspace(keyspace string) *ClusterBuilder {
+	return b.set("Keyspace", func(cfg *ClusterConfig) error {
+		cfg.Keyspace = keyspace
+		return nil
+	})
+}
+
+// ProtoVersion sets the version of the native protocol, 0 to discover it.
+func (b *ClusterBuilder) ProtoVersion(version int) *ClusterBuilder {
+	return b.set("ProtoVersion", func(cfg *ClusterConfig) error {
+		if version != 0 && (version < protoVersion1 || version > protoVersion5) {
+			return fmt.Errorf("unsupported protocol version %d", version)
+		}
+		cfg.ProtoVersion = version
+		return nil
+	})
+}
+
+// Consistency sets the default consistency of queries and batches.
+func (b *ClusterBuilder) Consistency(cons Consistency) *ClusterBuilder {
+	return b.set("Consistency", func(cfg *ClusterConfig) error {
+		if cons > LocalOne {
+			return fmt.Errorf("invalid consistency %v", cons)
+		}
+		cfg.Consistency = cons
+		return nil
+	})
+}
+
+// SerialConsistency sets the default serial consistency of conditional
+// queries, Serial or LocalSerial.
+func (b *ClusterBuilder) SerialConsistency(cons SerialConsistency) *ClusterBuilder {
+	return b.set("SerialConsistency", func(cfg *ClusterConfig) error {
+		if cons != Serial && cons != LocalSerial {
+			return fmt.Errorf("invalid serial consistency %v", cons)
+		}
+		cfg.SerialConsistency = cons
+		return nil
+	})
+}
+
+// Timeout sets the timeout of queries, see ClusterConfig.Timeout.
+func (b *ClusterBuilder) Timeout(timeout time.Duration) *ClusterBuilder {
+	return b.set("Timeout", func(cfg *ClusterConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout %v", timeout)
+		}
+		cfg.Timeout = timeout
+		return nil
+	})
+}
+
+// ConnectTimeout sets the timeout of the setup of connections, see
+// ClusterConfig.ConnectTimeout.
+func (b *ClusterBuilder) ConnectTimeout(timeout time.Duration) *ClusterBuilder {
+	return b.set("ConnectTimeout", func(cfg *ClusterConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid timeout %v", timeout)
+		}
+		cfg.ConnectTimeout = timeout
+		return nil
+	})
+}
+
+// NumConns sets the number of connections per host.
+func (b *ClusterBuilder) NumConns(n int) *ClusterBuilder {
+	return b.set("NumConns", func(cfg *ClusterConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of connections %d", n)
+		}
+		cfg.NumConns = n
+		return nil
+	})
+}
+
+// NumRemoteConns sets the number of connections per remote host, see
+// ClusterConfig.NumRemoteConns.
+func (b *ClusterBuilder) NumRemoteConns(n int) *ClusterBuilder {
+	return b.set("NumRemoteConns", func(cfg *ClusterConfig) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of connections %d", n)
+		}
+		cfg.NumRemoteConns = n
+		return nil
+	})
+}
+
+// PageSize sets the default page size of queries, 0 to disable paging.
+func (b *ClusterBuilder) PageSize(n int) *ClusterBuilder {
+	return b.set("PageSize", func(cfg *ClusterConfig) error {
+		if n < 0 {
+			return fmt.Errorf("invalid page size %d", n)
+		}
+		cfg.PageSize = n
+		return nil
+	})
+}
+
+// TLS enables TLS with config, see ClusterConfig.SslOpts for the finer
+// options such as certificate files.
+func (b *ClusterBuilder) TLS(config *tls.Config) *ClusterBuilder {
+	return b.set("TLS", func(cfg *ClusterConfig) error {
+		if config == nil {
+			return fmt.Errorf("nil TLS config")
+		}
+		cfg.SslOpts = &SslOptions{Config: config, EnableHostVerification: !config.InsecureSkipVerify}
+		return nil
+	})
+}
+
+// PasswordAuth authenticates with username and password, see
+// PasswordAuthenticator.
+func (b *ClusterBuilder) PasswordAuth(username, password string) *ClusterBuilder {
+	return b.Authenticator(PasswordAuthenticator{Username: username, Password: password})
+}
+
+// Authenticator sets the authenticator of the connections.
+func (b *ClusterBuilder) Authenticator(auth Authenticator) *ClusterBuilder {
+	return b.set("Authenticator", func(cfg *ClusterConfig) error {
+		if auth == nil {
+			return fmt.Errorf("nil authenticator")
+		}
+		cfg.Authenticator = auth
+		return nil
+	})
+}
+
+// Compressor sets the compression of frames, such as SnappyCompressor.
+func (b *ClusterBuilder) Compressor(c Compressor) *ClusterBuilder {
+	return b.set("Compressor", func(cfg *ClusterConfig) error {
+		cfg.Compressor = c
+		return nil
+	})
+}
+
+// HostSelectionPolicy sets the policy choosing the hosts of queries, such as
+// TokenAwareHostPolicy.
+func (b *ClusterBuilder) HostSelectionPolicy(policy HostSelectionPolicy) *ClusterBuilder {
+	return b.set("HostSelectionPolicy", func(cfg *ClusterConfig) error {
+		if policy == nil {
+			return fmt.Errorf("nil policy")
+		}
+		cfg.PoolConfig.HostSelectionPolicy = policy
+		return nil
+	})
+}
+
+// RetryPolicy sets the default retry policy of queries, such as
+// SimpleRetryPolicy.
+func (b *ClusterBuilder) RetryPolicy(policy RetryPolicy) *ClusterBuilder {
+	return b.set("RetryPolicy", func(cfg *ClusterConfig) error {
+		cfg.RetryPolicy = policy
+		return nil
+	})
+}
+
+// ReconnectionPolicy sets the policy of the reconnections to hosts, such as
+// ExponentialReconnectionPolicy.
+func (b *ClusterBuilder) ReconnectionPolicy(policy ReconnectionPolicy) *ClusterBuilder {
+	return b.set("ReconnectionPolicy", func(cfg *ClusterConfig) error {
+		if policy == nil {
+			return fmt.Errorf("nil policy")
+		}
+		cfg.ReconnectionPolicy = policy
+		return nil
+	})
+}
+
+// HedgedReads sends slow SELECT queries to further hosts, see HedgedReads.
+func (b *ClusterBuilder) HedgedReads(h HedgedReads) *ClusterBuilder {
+	return b.set("HedgedReads", func(cfg *ClusterConfig) error {
+		if h.Delay <= 0 {
+			return fmt.Errorf("invalid delay %v", h.Delay)
+		}
+		cfg.HedgedReads = &h
+		return nil
+	})
+}
+
+// QueryObserver sets the observer of the queries of the session.
+func (b *ClusterBuilder) QueryObserver(o QueryObserver) *ClusterBuilder {
+	return b.set("QueryObserver", func(cfg *ClusterConfig) error {
+		cfg.QueryObserver = o
+		return nil
+	})
+}
+
+// BatchObserver sets the observer of the batches of the session.
+func (b *ClusterBuilder) BatchObserver(o BatchObserver) *ClusterBuilder {
+	return b.set("BatchObserver", func(cfg *ClusterConfig) error {
+		cfg.BatchObserver = o
+		return nil
+	})
+}
+
+// ConnectObserver sets the observer of the connection attempts of the session.
+func (b *ClusterBuilder) ConnectObserver(o ConnectObserver) *ClusterBuilder {
+	return b.set("ConnectObserver", func(cfg *ClusterConfig) error {
+		cfg.ConnectObserver = o
+		return nil
+	})
+}
+
+// Middleware adds middleware wrapping the queries and batches of the session,
+// see ClusterConfig.Middleware.
+func (b *ClusterBuilder) Middleware(m ...Middleware) *ClusterBuilder {
+	return b.set("Middleware", func(cfg *ClusterConfig) error {
+		cfg.Middleware = append(cfg.Middleware, m...)
+		return nil
+	})
+}
+
+// Logger sets the logger of the session.
+func (b *ClusterBuilder) Logger(logger StdLogger) *ClusterBuilder {
+	return b.set("Logger", func(cfg *ClusterConfig) error {
+		cfg.Logger = logger
+		return nil
+	})
+}
+
+// Configure calls fn to set the fields of the ClusterConfig without a method
+// of the builder.
+func (b *ClusterBuilder) Configure(fn func(cfg *ClusterConfig)) *ClusterBuilder {
+	return b.set("Configure", func(cfg *ClusterConfig) error {
+		fn(cfg)
+		return nil
+	})
+}
+
+// Build returns the ClusterConfig, or the error of the first invalid setting.
+// It fails with ErrNoHosts if no host was set. Every call returns a copy of
+// the ClusterConfig of the builder, sharing its policies and observers.
+func (b *ClusterBuilder) Build() (*ClusterConfig, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.cfg.Hosts) == 0 {
+		return nil, ErrNoHosts
+	}
+	if b.cfg.WriteTimeout > b.cfg.Timeout {
+		return nil, fmt.Errorf("gocql: ClusterBuilder: WriteTimeout %v exceeds Timeout %v", b.cfg.WriteTimeout, b.cfg.Timeout)
+	}
+	cfg := *b.cfg
+	cfg.Hosts = append([]string(nil), b.cfg.Hosts...)
+	cfg.Middleware = append([]Middleware(nil), b.cfg.Middleware...)
+	return &cfg, nil
+}
+
+// CreateSession builds the ClusterConfig and creates a session with it.
+func (b *ClusterBuilder) CreateSession() (*Session, error) {
+	cfg, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.CreateSession()
+}
@@ -0,0 +1,71 @@
+package gocql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestClusterBuilder(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, []interface{}{1})
+
+	b := gocql.NewClusterBuilder().
+		Hosts(srv.Addr).
+		Keyspace("example").
+		Consistency(gocql.LocalQuorum).
+		Timeout(5 * time.Second).
+		NumConns(1)
+	cfg, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keyspace != "example" || cfg.Consistency != gocql.LocalQuorum || cfg.Timeout != 5*time.Second || cfg.NumConns != 1 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.PageSize != 5000 {
+		t.Fatalf("expected the defaults of NewCluster, got page size %d", cfg.PageSize)
+	}
+
+	session, err := b.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	var id int
+	if err := session.Query(`SELECT id FROM events`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterBuilderInvalid(t *testing.T) {
+	b := gocql.NewClusterBuilder().
+		Hosts("10.0.0.1").
+		Port(0).
+		NumConns(-1)
+	if err := b.Err(); err == nil || !strings.Contains(err.Error(), "ClusterBuilder.Port") {
+		t.Fatalf("expected the error of the first invalid setting, got %v", err)
+	}
+	if _, err := b.Build(); err != b.Err() {
+		t.Fatalf("expected Build to return the error of the builder, got %v", err)
+	}
+	if _, err := b.CreateSession(); err != b.Err() {
+		t.Fatalf("expected CreateSession to return the error of the builder, got %v", err)
+	}
+
+	if _, err := gocql.NewClusterBuilder().Build(); !errors.Is(err, gocql.ErrNoHosts) {
+		t.Fatalf("expected ErrNoHosts, got %v", err)
+	}
+	if err := gocql.NewClusterBuilder().SerialConsistency(gocql.SerialConsistency(gocql.Quorum)).Err(); err == nil {
+		t.Fatal("expected an invalid serial consistency to fail")
+	}
+	if err := gocql.NewClusterBuilder().TLS(nil).Err(); err == nil {
+		t.Fatal("expected a nil TLS config to fail")
+	}
+}
@@ -0,0 +1,200 @@
+package gocql
+
+import "fmt"
+
+// ConsistencyCheck reports whether the replicas of a keyspace can satisfy a
+// consistency, see Session.CanAchieve.
+type ConsistencyCheck struct {
+	Keyspace    string
+	Consistency Consistency
+	// Ranges are the token ranges of the ring, in the order of their tokens.
+	Ranges []TokenRangeCheck
+}
+
+// TokenRangeCheck reports whether the replicas of a token range can satisfy
+// the consistency of a ConsistencyCheck.
+type TokenRangeCheck struct {
+	// StartToken and EndToken bound the range, StartToken excluded. The first
+	// range wraps around the ring, it starts at the end of the last range.
+	StartToken string
+	EndToken   string
+	// Replicas are the replicas of the range, Down those of them which are
+	// not up.
+	Replicas []*HostInfo
+	Down     []*HostInfo
+	// Achievable is true if the replicas which are up satisfy the
+	// consistency.
+	Achievable bool
+}
+
+// Achievable reports whether the replicas of every token range can satisfy
+// the consistency.
+func (c *ConsistencyCheck) Achievable() bool {
+	for _, r := range c.Ranges {
+		if !r.Achievable {
+			return false
+		}
+	}
+	return true
+}
+
+// Unachievable returns the token ranges the replicas of which can't satisfy
+// the consistency.
+func (c *ConsistencyCheck) Unachievable() []TokenRangeCheck {
+	var ranges []TokenRangeCheck
+	for _, r := range c.Ranges {
+		if !r.Achievable {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// CanAchieve reports whether the replicas of each token range of keyspace
+// which the session knows to be up can satisfy cons, for example to check
+// that a node can be taken down for maintenance:
+//
+//	check, err := session.CanAchieve("ks", gocql.Quorum)
+//	if err != nil {
+//		return err
+//	}
+//	if !check.Achievable() {
+//		return fmt.Errorf("%d token ranges would be unavailable", len(check.Unachievable()))
+//	}
+//
+// The replicas are computed from the tokens of the hosts and the replication
+// strategy of the keyspace, SimpleStrategy or NetworkTopologyStrategy, as the
+// token aware policy does. The local datacenters of the local consistencies
+// are those of the hosts the host selection policy considers local: with a
+// policy which isn't datacenter aware the local consistencies must be
+// satisfied in every datacenter. ANY is achievable as long as a host is up.
+// The serial phase of conditional updates with SERIAL and LOCAL_SERIAL needs
+// as many replicas as QUORUM and LOCAL_QUORUM.
+func (s *Session) CanAchieve(keyspace string, cons Consistency) (*ConsistencyCheck, error) {
+	ks, err := s.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	strat := getStrategy(ks, s.logger)
+	if strat == nil {
+		return nil, fmt.Errorf("gocql: unable to compute the replicas of keyspace %s with strategy %s", keyspace, ks.StrategyClass)
+	}
+	ring, err := newTokenRing(s.Partitioner(), s.ring.allHosts())
+	if err != nil {
+		return nil, fmt.Errorf("gocql: unable to compute the replicas of keyspace %s: %w", keyspace, err)
+	}
+	if len(ring.tokens) == 0 {
+		return nil, fmt.Errorf("gocql: unable to compute the replicas of keyspace %s: the tokens of the hosts are not known", keyspace)
+	}
+	return checkConsistency(keyspace, cons, strat, ring, s.policy.IsLocal), nil
+}
+
+// checkConsistency checks cons against the replicas of the token ranges of
+// ring placed by strat.
+func checkConsistency(keyspace string, cons Consistency, strat placementStrategy, ring *tokenRing, isLocal func(*HostInfo) bool) *ConsistencyCheck {
+	var (
+		totalRF  int
+		dcs      map[string]int
+		localDCs = make(map[string]bool)
+		anyUp    bool
+	)
+	switch strat := strat.(type) {
+	case *networkTopology:
+		dcs = strat.dcs
+		for _, rf := range dcs {
+			totalRF += rf
+		}
+	default:
+		totalRF = strat.replicationFactor("")
+	}
+	for _, host := range ring.hosts {
+		if isLocal(host) {
+			localDCs[host.DataCenter()] = true
+		}
+		anyUp = anyUp || host.IsUp()
+	}
+
+	satisfied := func(replicas []*HostInfo) bool {
+		live := func(match func(*HostInfo) bool) int {
+			var n int
+			for _, host := range replicas {
+				if host.IsUp() && match(host) {
+					n++
+				}
+			}
+			return n
+		}
+		all := func(*HostInfo) bool { return true }
+		inDC := func(dc string) func(*HostInfo) bool {
+			return func(host *HostInfo) bool { return host.DataCenter() == dc }
+		}
+		// quorums are computed per datacenter with NetworkTopologyStrategy,
+		// for all the replicas matching with SimpleStrategy
+		quorums := func(rfs map[string]int, match func(*HostInfo) bool) bool {
+			if dcs == nil {
+				return live(match) >= totalRF/2+1
+			}
+			for dc, rf := range rfs {
+				if live(inDC(dc)) < rf/2+1 {
+					return false
+				}
+			}
+			return true
+		}
+
+		switch cons {
+		case Any:
+			return anyUp
+		case One:
+			return live(all) >= 1
+		case Two:
+			return live(all) >= 2
+		case Three:
+			return live(all) >= 3
+		case Quorum:
+			return live(all) >= totalRF/2+1
+		case All:
+			return live(all) >= totalRF
+		case LocalOne:
+			return live(isLocal) >= 1
+		case LocalQuorum:
+			local := make(map[string]int, len(localDCs))
+			for dc := range localDCs {
+				local[dc] = dcs[dc]
+			}
+			return len(localDCs) > 0 && quorums(local, isLocal)
+		case EachQuorum:
+			each := make(map[string]int, len(dcs))
+			for dc, rf := range dcs {
+				if rf > 0 {
+					each[dc] = rf
+				}
+			}
+			return quorums(each, all)
+		}
+		return false
+	}
+
+	replicas := strat.replicaMap(ring)
+	check := &ConsistencyCheck{
+		Keyspace:    keyspace,
+		Consistency: cons,
+		Ranges:      make([]TokenRangeCheck, len(replicas)),
+	}
+	for i, r := range replicas {
+		prev := replicas[(i+len(replicas)-1)%len(replicas)]
+		rc := TokenRangeCheck{
+			StartToken: prev.token.String(),
+			EndToken:   r.token.String(),
+			Replicas:   r.hosts,
+			Achievable: satisfied(r.hosts),
+		}
+		for _, host := range r.hosts {
+			if !host.IsUp() {
+				rc.Down = append(rc.Down, host)
+			}
+		}
+		check.Ranges[i] = rc
+	}
+	return check
+}
@@ -0,0 +1,55 @@
+package gocql
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	hosts := []*HostInfo{
+		{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1), tokens: []string{"00"}, dataCenter: "local", state: NodeUp},
+		{hostId: "1", connectAddress: net.IPv4(10, 0, 0, 2), tokens: []string{"10"}, dataCenter: "remote", state: NodeUp},
+		{hostId: "2", connectAddress: net.IPv4(10, 0, 0, 3), tokens: []string{"20"}, dataCenter: "local", state: NodeDown},
+		{hostId: "3", connectAddress: net.IPv4(10, 0, 0, 4), tokens: []string{"30"}, dataCenter: "remote", state: NodeUp},
+		{hostId: "4", connectAddress: net.IPv4(10, 0, 0, 5), tokens: []string{"40"}, dataCenter: "local", state: NodeUp},
+		{hostId: "5", connectAddress: net.IPv4(10, 0, 0, 6), tokens: []string{"50"}, dataCenter: "remote", state: NodeUp},
+	}
+	ring, err := newTokenRing("OrderedPartitioner", hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isLocal := func(host *HostInfo) bool { return host.DataCenter() == "local" }
+
+	tests := []struct {
+		strat placementStrategy
+		cons  Consistency
+		// unachievable are the end tokens of the ranges which can't satisfy cons
+		unachievable []string
+	}{
+		{&simpleStrategy{rf: 2}, One, nil},
+		{&simpleStrategy{rf: 2}, Two, []string{"10", "20"}},
+		{&simpleStrategy{rf: 3}, Quorum, nil},
+		{&simpleStrategy{rf: 3}, All, []string{"00", "10", "20"}},
+		{&simpleStrategy{rf: 2}, LocalOne, []string{"10", "20"}},
+		{&networkTopology{dcs: map[string]int{"local": 3, "remote": 3}}, Quorum, nil},
+		{&networkTopology{dcs: map[string]int{"local": 3, "remote": 3}}, LocalQuorum, nil},
+		{&networkTopology{dcs: map[string]int{"local": 3, "remote": 3}}, EachQuorum, nil},
+		{&networkTopology{dcs: map[string]int{"local": 3, "remote": 3}}, All, []string{"00", "10", "20", "30", "40", "50"}},
+		{&networkTopology{dcs: map[string]int{"local": 2, "remote": 2}}, LocalQuorum, []string{"00", "10", "20", "50"}},
+		{&networkTopology{dcs: map[string]int{"remote": 2}}, LocalOne, []string{"10", "30", "50"}},
+		{&networkTopology{dcs: map[string]int{"remote": 2}}, Any, nil},
+	}
+	for _, test := range tests {
+		check := checkConsistency("ks", test.cons, test.strat, ring, isLocal)
+		var got []string
+		for _, r := range check.Unachievable() {
+			got = append(got, r.EndToken)
+		}
+		description := fmt.Sprintf("unachievable ranges of %v with %+v", test.cons, test.strat)
+		assertDeepEqual(t, description, test.unachievable, got)
+		if check.Achievable() != (len(test.unachievable) == 0) {
+			t.Errorf("%s: expected Achievable to be %v", description, len(test.unachievable) == 0)
+		}
+	}
+}
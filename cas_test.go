@@ -0,0 +1,88 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestMapScanCAS(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`UPDATE users SET name = 'bob' WHERE id = 1 IF name = 'carol'`).
+		Rows([]gocqltest.Column{{Name: "[applied]", Type: gocqltest.Boolean}, {Name: "name", Type: gocqltest.Text}},
+			[]interface{}{false, "alice"})
+	srv.On(`SELECT name FROM users WHERE id = 1`).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	previous := make(map[string]interface{})
+	applied, err := session.Query(`UPDATE users SET name = 'bob' WHERE id = 1 IF name = 'carol'`).MapScanCAS(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied {
+		t.Fatal("expected update not to be applied")
+	}
+	if len(previous) != 1 || previous["name"] != "alice" {
+		t.Fatalf("unexpected previous values %v", previous)
+	}
+
+	_, err = session.Query(`SELECT name FROM users WHERE id = 1`).MapScanCAS(make(map[string]interface{}))
+	if err != gocql.ErrNotLWT {
+		t.Fatalf("expected %v, got %v", gocql.ErrNotLWT, err)
+	}
+}
+
+func TestExecCAS(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	applied := []gocqltest.Column{{Name: "[applied]", Type: gocqltest.Boolean}}
+	srv.On(`INSERT INTO users (id, name) VALUES (1, 'bob') IF NOT EXISTS`).
+		Rows(append(applied, gocqltest.Column{Name: "id", Type: gocqltest.Int}, gocqltest.Column{Name: "name", Type: gocqltest.Text}),
+			[]interface{}{false, 1, "alice"})
+	srv.On(`INSERT INTO users (id, name) VALUES (2, 'bob') IF NOT EXISTS`).
+		Rows(applied, []interface{}{true})
+	srv.On(`INSERT INTO users (id, name) VALUES (3, 'bob')`)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	res, err := session.Query(`INSERT INTO users (id, name) VALUES (1, 'bob') IF NOT EXISTS`).ExecCAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Conditional || res.Applied {
+		t.Fatalf("expected a conditional statement which was not applied, got %+v", res)
+	}
+	if len(res.Previous) != 2 || res.Previous["name"] != "alice" {
+		t.Fatalf("unexpected previous values %v", res.Previous)
+	}
+
+	res, err = session.Query(`INSERT INTO users (id, name) VALUES (2, 'bob') IF NOT EXISTS`).ExecCAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Conditional || !res.Applied || res.Previous != nil {
+		t.Fatalf("expected an applied conditional statement, got %+v", res)
+	}
+
+	res, err = session.Query(`INSERT INTO users (id, name) VALUES (3, 'bob')`).ExecCAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Conditional || !res.Applied {
+		t.Fatalf("expected an unconditional statement to be applied, got %+v", res)
+	}
+}
@@ -0,0 +1,371 @@
+// Package cdc reads the change data capture logs of Scylla tables.
+//
+// Scylla writes the changes of a table with CDC enabled to a log table,
+// partitioned by streams. The set of streams changes with the topology of the
+// cluster, every set being a generation starting at a point in time. A Reader
+// discovers the generations, reads the changes of every stream in order and
+// hands them to a handler, checkpointing the position of every stream so that
+// a restarted reader resumes where it stopped:
+//
+//	reader := &cdc.Reader{
+//		Session:      session,
+//		Keyspace:     "shop",
+//		Table:        "orders",
+//		Checkpointer: &cdc.TableCheckpointer{Session: session, Table: "shop.cdc_checkpoints"},
+//	}
+//	err := reader.Run(ctx, func(ctx context.Context, c cdc.Change) error {
+//		log.Printf("%v %v", c.Operation, c.Columns["id"])
+//		return nil
+//	})
+//
+// The changes of a stream are delivered in order, the streams are read one
+// after the other.
+package cdc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Operation is the kind of a change.
+type Operation int8
+
+// Operations of the cdc$operation column.
+const (
+	PreImage                  Operation = 0
+	Update                    Operation = 1
+	Insert                    Operation = 2
+	RowDelete                 Operation = 3
+	PartitionDelete           Operation = 4
+	RangeDeleteStartInclusive Operation = 5
+	RangeDeleteStartExclusive Operation = 6
+	RangeDeleteEndInclusive   Operation = 7
+	RangeDeleteEndExclusive   Operation = 8
+	PostImage                 Operation = 9
+)
+
+var operationNames = [...]string{
+	"PRE_IMAGE", "UPDATE", "INSERT", "ROW_DELETE", "PARTITION_DELETE",
+	"RANGE_DELETE_START_INCLUSIVE", "RANGE_DELETE_START_EXCLUSIVE",
+	"RANGE_DELETE_END_INCLUSIVE", "RANGE_DELETE_END_EXCLUSIVE", "POST_IMAGE",
+}
+
+func (o Operation) String() string {
+	if o >= 0 && int(o) < len(operationNames) {
+		return operationNames[o]
+	}
+	return fmt.Sprintf("UNKNOWN_OPERATION_%d", int8(o))
+}
+
+// Change is a row of a CDC log table.
+type Change struct {
+	StreamID []byte
+	// Time identifies the change, the rows of a batch share it.
+	Time       gocql.UUID
+	BatchSeqNo int
+	Operation  Operation
+	// TTL is the TTL of the write in seconds, 0 if it had none.
+	TTL        int64
+	EndOfBatch bool
+
+	// Columns are the columns of the base table by name, the columns not set
+	// by the change have their zero value.
+	Columns map[string]interface{}
+	// Deleted are the names of the columns of the base table deleted by the
+	// change.
+	Deleted []string
+}
+
+// Generation is a set of streams which are written from Time on.
+type Generation struct {
+	Time    time.Time
+	Streams [][]byte
+}
+
+// Generations returns the stream generations of the cluster, oldest first.
+func Generations(ctx context.Context, session *gocql.Session) ([]Generation, error) {
+	var times []time.Time
+	iter := session.Query(`SELECT time FROM system_distributed.cdc_generation_timestamps WHERE key = 'timestamps'`).
+		WithContext(ctx).Iter()
+	var t time.Time
+	for iter.Scan(&t) {
+		times = append(times, t)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("cdc: read generations: %w", err)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	generations := make([]Generation, 0, len(times))
+	for _, t := range times {
+		g := Generation{Time: t}
+		iter := session.Query(`SELECT streams FROM system_distributed.cdc_streams_descriptions_v2 WHERE time = ?`, t).
+			WithContext(ctx).Iter()
+		var streams [][]byte
+		for iter.Scan(&streams) {
+			g.Streams = append(g.Streams, streams...)
+		}
+		if err := iter.Close(); err != nil {
+			return nil, fmt.Errorf("cdc: read streams of generation %v: %w", t, err)
+		}
+		generations = append(generations, g)
+	}
+	return generations, nil
+}
+
+// Checkpointer stores the position of the streams of a log table. The
+// position of a stream is the time of the last change handled.
+type Checkpointer interface {
+	// Load returns the position of the stream, false if there is none.
+	Load(ctx context.Context, logTable string, streamID []byte) (gocql.UUID, bool, error)
+	// Save stores the position of the stream.
+	Save(ctx context.Context, logTable string, streamID []byte, position gocql.UUID) error
+}
+
+// MemoryCheckpointer keeps the positions of the streams in memory, so a
+// restarted reader reads the streams from the start again.
+type MemoryCheckpointer struct {
+	mu        sync.Mutex
+	positions map[string]gocql.UUID
+}
+
+func checkpointKey(logTable string, streamID []byte) string {
+	return logTable + "/" + hex.EncodeToString(streamID)
+}
+
+func (m *MemoryCheckpointer) Load(ctx context.Context, logTable string, streamID []byte) (gocql.UUID, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	position, ok := m.positions[checkpointKey(logTable, streamID)]
+	return position, ok, nil
+}
+
+func (m *MemoryCheckpointer) Save(ctx context.Context, logTable string, streamID []byte, position gocql.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.positions == nil {
+		m.positions = make(map[string]gocql.UUID)
+	}
+	m.positions[checkpointKey(logTable, streamID)] = position
+	return nil
+}
+
+// TableCheckpointer stores the positions of the streams in a table with the
+// columns log_table text, stream_id blob and position timeuuid, and the
+// primary key (log_table, stream_id), see CreateTable.
+type TableCheckpointer struct {
+	Session *gocql.Session
+	// Table is the name of the table, optionally with its keyspace.
+	Table string
+}
+
+// CreateTable creates the table of the checkpointer if it doesn't exist.
+func (t *TableCheckpointer) CreateTable(ctx context.Context) error {
+	return t.Session.Query(`CREATE TABLE IF NOT EXISTS ` + t.Table +
+		` (log_table text, stream_id blob, position timeuuid, PRIMARY KEY (log_table, stream_id))`).
+		WithContext(ctx).Exec()
+}
+
+func (t *TableCheckpointer) Load(ctx context.Context, logTable string, streamID []byte) (gocql.UUID, bool, error) {
+	var position gocql.UUID
+	err := t.Session.Query(`SELECT position FROM `+t.Table+` WHERE log_table = ? AND stream_id = ?`, logTable, streamID).
+		WithContext(ctx).Scan(&position)
+	if err == gocql.ErrNotFound {
+		return position, false, nil
+	}
+	return position, err == nil, err
+}
+
+func (t *TableCheckpointer) Save(ctx context.Context, logTable string, streamID []byte, position gocql.UUID) error {
+	return t.Session.Query(`INSERT INTO `+t.Table+` (log_table, stream_id, position) VALUES (?, ?, ?)`, logTable, streamID, position).
+		WithContext(ctx).Exec()
+}
+
+// Reader reads the CDC log of a table.
+type Reader struct {
+	Session  *gocql.Session
+	Keyspace string
+	// Table is the base table, its log table is Table suffixed with
+	// _scylla_cdc_log.
+	Table string
+
+	// Checkpointer stores the position of the streams.
+	// Default: a MemoryCheckpointer
+	Checkpointer Checkpointer
+
+	// PollInterval is the time between reads of the streams once all changes
+	// were read.
+	// Default: 5s
+	PollInterval time.Duration
+
+	// ConfidenceWindow is how far behind the current time changes are read.
+	// Changes are written with the time of their coordinator, changes more
+	// recent than the window might still be written with an earlier time.
+	// Default: 30s
+	ConfidenceWindow time.Duration
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+func (r *Reader) logTable() string {
+	table := r.Table + "_scylla_cdc_log"
+	if r.Keyspace != "" {
+		return r.Keyspace + "." + table
+	}
+	return table
+}
+
+// Handler handles a change. Returning an error stops the reader, the change
+// is handled again by the next reader.
+type Handler func(ctx context.Context, c Change) error
+
+// Run reads the changes of the table and calls handler with every change,
+// until ctx is done or handler returns an error. It returns the error of ctx
+// or of handler.
+func (r *Reader) Run(ctx context.Context, handler Handler) error {
+	if r.Checkpointer == nil {
+		r.Checkpointer = &MemoryCheckpointer{}
+	}
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := r.Poll(ctx, handler); err != nil {
+			return err
+		}
+		timer.Reset(interval)
+	}
+}
+
+// Poll reads the changes of all streams up to the confidence window once.
+func (r *Reader) Poll(ctx context.Context, handler Handler) error {
+	if r.Checkpointer == nil {
+		r.Checkpointer = &MemoryCheckpointer{}
+	}
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	window := r.ConfidenceWindow
+	if window <= 0 {
+		window = 30 * time.Second
+	}
+	end := now().Add(-window)
+
+	generations, err := Generations(ctx, r.Session)
+	if err != nil {
+		return err
+	}
+	for i, g := range generations {
+		if !g.Time.Before(end) {
+			break
+		}
+		genEnd := end
+		if i+1 < len(generations) && generations[i+1].Time.Before(genEnd) {
+			genEnd = generations[i+1].Time
+		}
+		for _, stream := range g.Streams {
+			if err := r.readStream(ctx, handler, stream, g.Time, genEnd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readStream hands the changes of stream from the checkpoint, or start, up
+// to end to handler.
+func (r *Reader) readStream(ctx context.Context, handler Handler, stream []byte, start, end time.Time) error {
+	logTable := r.logTable()
+	from, ok, err := r.Checkpointer.Load(ctx, logTable, stream)
+	if err != nil {
+		return fmt.Errorf("cdc: load checkpoint of stream %x: %w", stream, err)
+	}
+	if !ok {
+		from = gocql.MinTimeUUID(start)
+	}
+	if !from.Time().Before(end) {
+		return nil
+	}
+
+	iter := r.Session.Query(`SELECT * FROM `+logTable+` WHERE "cdc$stream_id" = ? AND "cdc$time" > ? AND "cdc$time" < ?`,
+		stream, from, gocql.MinTimeUUID(end)).WithContext(ctx).Iter()
+	for {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		c := newChange(row)
+		if err := handler(ctx, c); err != nil {
+			iter.Close()
+			return err
+		}
+		if c.EndOfBatch {
+			if err := r.Checkpointer.Save(ctx, logTable, stream, c.Time); err != nil {
+				iter.Close()
+				return fmt.Errorf("cdc: save checkpoint of stream %x: %w", stream, err)
+			}
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("cdc: read stream %x: %w", stream, err)
+	}
+	return nil
+}
+
+const (
+	deletedPrefix         = "cdc$deleted_"
+	deletedElementsPrefix = "cdc$deleted_elements_"
+)
+
+func newChange(row map[string]interface{}) Change {
+	c := Change{Columns: make(map[string]interface{})}
+	for name, value := range row {
+		switch name {
+		case "cdc$stream_id":
+			c.StreamID, _ = value.([]byte)
+		case "cdc$time":
+			c.Time, _ = value.(gocql.UUID)
+		case "cdc$batch_seq_no":
+			c.BatchSeqNo, _ = value.(int)
+		case "cdc$operation":
+			op, _ := value.(int8)
+			c.Operation = Operation(op)
+		case "cdc$ttl":
+			c.TTL, _ = value.(int64)
+		case "cdc$end_of_batch":
+			c.EndOfBatch, _ = value.(bool)
+		default:
+			switch {
+			case strings.HasPrefix(name, deletedElementsPrefix):
+				// the deleted elements of collections are kept as columns
+				c.Columns[name] = value
+			case strings.HasPrefix(name, deletedPrefix):
+				if deleted, _ := value.(bool); deleted {
+					c.Deleted = append(c.Deleted, strings.TrimPrefix(name, deletedPrefix))
+				}
+			default:
+				c.Columns[name] = value
+			}
+		}
+	}
+	sort.Strings(c.Deleted)
+	return c
+}
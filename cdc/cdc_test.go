@@ -0,0 +1,226 @@
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type logRow struct {
+	stream     []byte
+	time       gocql.UUID
+	seq        int
+	endOfBatch bool
+	op         Operation
+	id         int
+	name       string
+	deleted    bool
+}
+
+// fakeLog stubs the generation tables and the log table of shop.orders on a
+// fake server.
+type fakeLog struct {
+	srv *gocqltest.Server
+
+	mu          sync.Mutex
+	generations map[time.Time][][]byte
+	rows        []logRow
+}
+
+func newFakeLog(t *testing.T) *fakeLog {
+	t.Helper()
+
+	f := &fakeLog{
+		srv:         gocqltest.NewServer(),
+		generations: make(map[time.Time][][]byte),
+	}
+	t.Cleanup(f.srv.Close)
+
+	genTime := gocqltest.Column{Name: "time", Type: gocqltest.Timestamp}
+	f.srv.On(`SELECT time FROM system_distributed.cdc_generation_timestamps WHERE key = 'timestamps'`).
+		Handle([]gocqltest.Column{genTime}, func(req *gocqltest.Request) gocqltest.Response {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var rows [][]interface{}
+			for t := range f.generations {
+				rows = append(rows, []interface{}{t})
+			}
+			return gocqltest.Response{Rows: rows}
+		})
+	f.srv.On(`SELECT streams FROM system_distributed.cdc_streams_descriptions_v2 WHERE time = ?`).
+		Params(genTime).
+		Handle([]gocqltest.Column{{Name: "streams", Type: gocqltest.List(gocqltest.Blob)}}, func(req *gocqltest.Request) gocqltest.Response {
+			var t time.Time
+			if err := req.Scan(&t); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			for genTime, streams := range f.generations {
+				if genTime.Equal(t) {
+					return gocqltest.Response{Rows: [][]interface{}{{streams}}}
+				}
+			}
+			return gocqltest.Response{}
+		})
+
+	f.srv.On(`SELECT * FROM shop.orders_scylla_cdc_log WHERE "cdc$stream_id" = ? AND "cdc$time" > ? AND "cdc$time" < ?`).
+		Params(
+			gocqltest.Column{Name: "cdc$stream_id", Type: gocqltest.Blob},
+			gocqltest.Column{Name: "cdc$time", Type: gocqltest.TimeUUID},
+			gocqltest.Column{Name: "cdc$time", Type: gocqltest.TimeUUID},
+		).
+		Handle([]gocqltest.Column{
+			{Name: "cdc$stream_id", Type: gocqltest.Blob},
+			{Name: "cdc$time", Type: gocqltest.TimeUUID},
+			{Name: "cdc$batch_seq_no", Type: gocqltest.Int},
+			{Name: "cdc$end_of_batch", Type: gocqltest.Boolean},
+			{Name: "cdc$operation", Type: gocqltest.TinyInt},
+			{Name: "cdc$ttl", Type: gocqltest.BigInt},
+			{Name: "id", Type: gocqltest.Int},
+			{Name: "name", Type: gocqltest.Text},
+			{Name: "cdc$deleted_name", Type: gocqltest.Boolean},
+		}, func(req *gocqltest.Request) gocqltest.Response {
+			var (
+				stream   []byte
+				from, to gocql.UUID
+			)
+			if err := req.Scan(&stream, &from, &to); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var rows [][]interface{}
+			for _, row := range f.rows {
+				if bytes.Equal(row.stream, stream) && row.time.Time().After(from.Time()) && row.time.Time().Before(to.Time()) {
+					rows = append(rows, []interface{}{row.stream, row.time, row.seq, row.endOfBatch, int8(row.op), int64(0), row.id, row.name, row.deleted})
+				}
+			}
+			return gocqltest.Response{Rows: rows}
+		})
+
+	return f
+}
+
+func (f *fakeLog) reader(t *testing.T) *Reader {
+	t.Helper()
+	session, err := f.srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return &Reader{Session: session, Keyspace: "shop", Table: "orders"}
+}
+
+func TestReaderPoll(t *testing.T) {
+	f := newFakeLog(t)
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s1, s2, s3 := []byte{1}, []byte{2}, []byte{3}
+	f.generations[t0] = [][]byte{s1, s2}
+	f.generations[t0.Add(10*time.Minute)] = [][]byte{s3}
+
+	batch := gocql.UUIDFromTime(t0.Add(time.Minute))
+	f.rows = []logRow{
+		{stream: s1, time: batch, seq: 0, op: Insert, id: 1, name: "a"},
+		{stream: s1, time: batch, seq: 1, endOfBatch: true, op: Insert, id: 2, name: "b"},
+		{stream: s1, time: gocql.UUIDFromTime(t0.Add(2 * time.Minute)), endOfBatch: true, op: Update, id: 1, deleted: true},
+		{stream: s2, time: gocql.UUIDFromTime(t0.Add(3 * time.Minute)), endOfBatch: true, op: RowDelete, id: 3},
+		{stream: s3, time: gocql.UUIDFromTime(t0.Add(11 * time.Minute)), endOfBatch: true, op: Insert, id: 4, name: "d"},
+		// within the confidence window of the first poll
+		{stream: s3, time: gocql.UUIDFromTime(t0.Add(19*time.Minute + 45*time.Second)), endOfBatch: true, op: Insert, id: 5, name: "e"},
+	}
+
+	reader := f.reader(t)
+	now := t0.Add(20 * time.Minute)
+	reader.now = func() time.Time { return now }
+
+	var changes []Change
+	handler := func(ctx context.Context, c Change) error {
+		changes = append(changes, c)
+		return nil
+	}
+	if err := reader.Poll(context.Background(), handler); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, c := range changes {
+		ids = append(ids, c.Columns["id"].(int))
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 1, 3, 4}) {
+		t.Fatalf("unexpected changes %v", ids)
+	}
+	if c := changes[2]; c.Operation != Update || !reflect.DeepEqual(c.Deleted, []string{"name"}) || !bytes.Equal(c.StreamID, s1) {
+		t.Fatalf("unexpected change %+v", c)
+	}
+	if c := changes[0]; c.Operation != Insert || c.BatchSeqNo != 0 || c.EndOfBatch || c.Time != batch || c.Columns["name"] != "a" {
+		t.Fatalf("unexpected change %+v", c)
+	}
+	if s := changes[3].Operation.String(); s != "ROW_DELETE" {
+		t.Fatalf("unexpected operation %s", s)
+	}
+
+	// the checkpoints keep the changes from being handled again
+	changes = nil
+	if err := reader.Poll(context.Background(), handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %d", len(changes))
+	}
+
+	now = now.Add(time.Minute)
+	if err := reader.Poll(context.Background(), handler); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Columns["id"] != 5 {
+		t.Fatalf("expected the change which left the confidence window, got %+v", changes)
+	}
+}
+
+func TestReaderHandlerError(t *testing.T) {
+	f := newFakeLog(t)
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := []byte{1}
+	f.generations[t0] = [][]byte{stream}
+	f.rows = []logRow{
+		{stream: stream, time: gocql.UUIDFromTime(t0.Add(time.Minute)), endOfBatch: true, op: Insert, id: 1},
+		{stream: stream, time: gocql.UUIDFromTime(t0.Add(2 * time.Minute)), endOfBatch: true, op: Insert, id: 2},
+	}
+
+	reader := f.reader(t)
+	reader.now = func() time.Time { return t0.Add(time.Hour) }
+
+	errHandler := errors.New("handler failed")
+	var ids []int
+	err := reader.Poll(context.Background(), func(ctx context.Context, c Change) error {
+		if c.Columns["id"] == 2 {
+			return errHandler
+		}
+		ids = append(ids, c.Columns["id"].(int))
+		return nil
+	})
+	if err != errHandler {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+
+	err = reader.Poll(context.Background(), func(ctx context.Context, c Change) error {
+		ids = append(ids, c.Columns["id"].(int))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Fatalf("expected the failed change to be handled again, got %v", ids)
+	}
+}
@@ -0,0 +1,88 @@
+package gocql
+
+import "time"
+
+// Clock is the source of time of a session, set with ClusterConfig.Clock. It
+// is used for the timeouts of requests, the delays of speculative executions
+// and backoffs, the debouncing of events and the default timestamps of
+// queries, so that tests can replace it with a fake clock to run the timeout
+// and retry behavior of their code instantly and deterministically, see
+// gocqltest.Clock.
+//
+// The deadlines of the network connections are enforced by the operating
+// system and always follow the system clock.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a timer sending the current time on its channel after
+	// d, like time.NewTimer.
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a ticker sending the current time on its channel
+	// every d, like time.NewTicker.
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f in its own goroutine after d, like time.AfterFunc.
+	// The channel of the timer it returns is nil.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer of a Clock, see time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a ticker of a Clock, see time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+func (systemClock) NewTicker(d time.Duration) Ticker { return systemTicker{time.NewTicker(d)} }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// clock returns the Clock of the config, the system clock by default.
+func (cfg *ClusterConfig) clock() Clock {
+	if cfg.Clock == nil {
+		return systemClock{}
+	}
+	return cfg.Clock
+}
+
+// clock returns the Clock of the session of the connection.
+func (c *Conn) clock() Clock {
+	if c.session == nil {
+		return systemClock{}
+	}
+	return c.session.cfg.clock()
+}
+
+// timestamp returns the default timestamp of a request, in microseconds,
+// from the clock unless it is set.
+func (c *Conn) timestamp(ts int64) int64 {
+	if ts != 0 {
+		return ts
+	}
+	return c.clock().Now().UnixNano() / 1000
+}
@@ -0,0 +1,86 @@
+package gocql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestClockRequestTimeout(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	srv.On(`SELECT id FROM slow`).Handle(nil, func(*gocqltest.Request) gocqltest.Response {
+		started <- struct{}{}
+		<-release
+		return gocqltest.Response{}
+	})
+
+	clock := gocqltest.NewClock(time.Now())
+	cluster := srv.ClusterConfig()
+	cluster.Clock = clock
+	cluster.NumConns = 1
+	cluster.Timeout = time.Hour
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- session.Query(`SELECT id FROM slow`).Exec()
+	}()
+	<-started
+	clock.WaitForTimers(1)
+	clock.Advance(time.Hour)
+
+	select {
+	case err := <-errc:
+		if err != gocql.ErrTimeoutNoResponse {
+			t.Fatalf("expected ErrTimeoutNoResponse, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query didn't time out when the clock was advanced")
+	}
+}
+
+func TestClockTimestamp(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO events (id) VALUES (1)`).Rows(nil)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster := srv.ClusterConfig()
+	cluster.Clock = gocqltest.NewClock(now)
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`INSERT INTO events (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO events (id) VALUES (1)`)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	for _, req := range reqs {
+		if want := now.UnixNano() / 1000; req.Timestamp != want {
+			t.Fatalf("expected the timestamp of the clock %d, got %d", want, req.Timestamp)
+		}
+	}
+}
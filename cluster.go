@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"net"
+	"strconv"
 	"time"
 )
 
@@ -37,6 +38,11 @@ type ClusterConfig struct {
 	// address, which is used to index connected hosts. If the domain name specified
 	// resolves to more than 1 IP address then the driver may connect multiple times to
 	// the same host, and will not mark the node being down or up from events.
+	//
+	// Addresses may include a port, such as 10.0.0.1:9043 or [2001:db8::1]:9043,
+	// otherwise Port is used. The port of a contact point is also used for the
+	// host discovered at its address, so that nodes listening on different
+	// ports can be reached.
 	Hosts []string
 
 	// CQL version (default: 3.0.0)
@@ -47,8 +53,9 @@ type ClusterConfig struct {
 	// should be set to a known version (2,3,4) for the cluster being connected to.
 	//
 	// If it is 0 or unset (the default) then the driver will attempt to discover the
-	// highest supported protocol for the cluster. In clusters with nodes of different
-	// versions the protocol selected is not defined (ie, it can be any of the supported in the cluster)
+	// highest supported protocol for the cluster, up to 4. In clusters with nodes of
+	// different versions, such as during a rolling upgrade, the highest protocol
+	// supported by all nodes in system.local and system.peers is used.
 	ProtoVersion int
 
 	// Timeout limits the time spent on the client side while executing a query.
@@ -70,6 +77,16 @@ type ClusterConfig struct {
 	// ConnectTimeout has a default value of 11 seconds.
 	ConnectTimeout time.Duration
 
+	// InitialConnectRetry, if set, keeps connecting to the contact points
+	// with backoff when none of them can be reached while the session is
+	// created, for a bounded time, instead of failing right away. Use it when
+	// the application may start before the cluster, as with containers
+	// started together. Errors of the credentials or ClusterName aren't
+	// retried. Once the session is created the pools reconnect according to
+	// ReconnectionPolicy.
+	// Default: nil, no retries.
+	InitialConnectRetry *InitialConnectRetry
+
 	// WriteTimeout limits the time the driver waits to write a request to a network connection.
 	// WriteTimeout should be lower than or equal to Timeout.
 	// WriteTimeout defaults to the value of Timeout.
@@ -80,12 +97,29 @@ type ClusterConfig struct {
 	Port int
 
 	// Initial keyspace. Optional.
+	// The keyspace is set on every connection of the session when it is
+	// opened, including connections re-established after a node restarted.
 	Keyspace string
 
 	// Number of connections per host.
 	// Default: 2
 	NumConns int
 
+	// NumRemoteConns is the number of connections per host at
+	// HostDistanceRemote, such as the hosts of the other datacenters with
+	// DCAwareRoundRobinPolicy, which only get queries the local hosts could
+	// not serve. NumConns applies to the local hosts, see HostDistancer.
+	// Default: 0, the remote hosts get NumConns connections as well
+	NumRemoteConns int
+
+	// MaxOrphanedStreams is the number of streams of a connection which can
+	// be orphaned, by requests which timed out or were canceled before their
+	// response arrived, before the connection is closed and reopened. The
+	// stream of an orphaned request can't be reused until the node responds,
+	// so a slow node could otherwise exhaust the streams of its connections.
+	// Default: 0, connections are never closed for orphaned streams.
+	MaxOrphanedStreams int
+
 	// Default consistency level.
 	// Default: Quorum
 	Consistency Consistency
@@ -94,6 +128,12 @@ type ClusterConfig struct {
 	// Default: nil
 	Compressor Compressor
 
+	// CompressionThreshold is the size of the body of the smallest request
+	// frames compressed, compressing small frames costs more time than it
+	// saves bytes. Queries and batches can override it, see Query.Compression.
+	// Default: 0, all frames are compressed
+	CompressionThreshold int
+
 	// Default: nil
 	Authenticator Authenticator
 
@@ -116,7 +156,8 @@ type ClusterConfig struct {
 	// SocketKeepalive is used to set up the default dialer and is ignored if Dialer or HostDialer is provided.
 	SocketKeepalive time.Duration
 
-	// Maximum cache size for prepared statements globally for gocql.
+	// Maximum cache size for prepared statements of each session, the least
+	// recently used statements are evicted first.
 	// Default: 1000
 	MaxPreparedStmts int
 
@@ -128,6 +169,12 @@ type ClusterConfig struct {
 	// Default: 5000
 	PageSize int
 
+	// MaxConcurrentPrefetches limits the number of pages fetched in the
+	// background for all queries of the session, see Query.PrefetchPages.
+	// Pages which can't be prefetched are fetched once they are read.
+	// Default: 0, no limit
+	MaxConcurrentPrefetches int
+
 	// Consistency for the serial part of queries, values can be either SERIAL or LOCAL_SERIAL.
 	// Default: unset
 	SerialConsistency SerialConsistency
@@ -144,6 +191,17 @@ type ClusterConfig struct {
 	// configuration of host selection and connection selection policies.
 	PoolConfig PoolConfig
 
+	// ExecutionProfiles are named groups of query settings which can be
+	// selected per query with Query.Profile or per batch with Batch.Profile.
+	// Default: no profiles.
+	ExecutionProfiles map[string]*ExecutionProfile
+
+	// Statements are named statements, prepared on every host as soon as the
+	// session connects to it and executed with Session.Stmt, see
+	// RegisterStatement.
+	// Default: no statements.
+	Statements map[string]string
+
 	// If not zero, gocql attempt to reconnect known DOWN nodes in every ReconnectInterval.
 	ReconnectInterval time.Duration
 
@@ -156,6 +214,12 @@ type ClusterConfig struct {
 	// via Discovery
 	HostFilter HostFilter
 
+	// ControlConnHostFilter restricts the hosts the control connection may
+	// use, in addition to HostFilter, for example DataCentreHostFilter to keep
+	// it in the local DC. Contact points are filtered once connected, as their
+	// DC is only known then.
+	ControlConnHostFilter HostFilter
+
 	// AddressTranslator will translate addresses found on peer discovery and/or
 	// node change events.
 	AddressTranslator AddressTranslator
@@ -202,6 +266,11 @@ type ClusterConfig struct {
 	// Use it to collect metrics / stats from batch queries by providing an implementation of BatchObserver.
 	BatchObserver BatchObserver
 
+	// TraceSampling enables the tracing of a sample of the queries and
+	// batches which have no Tracer of their own.
+	// Default: nil, only the queries with a Tracer are traced.
+	TraceSampling *TraceSampling
+
 	// ConnectObserver will set the provided connect observer on all queries
 	// created from this session.
 	ConnectObserver ConnectObserver
@@ -210,10 +279,50 @@ type ClusterConfig struct {
 	// Use it to collect metrics / stats from frames by providing an implementation of FrameHeaderObserver.
 	FrameHeaderObserver FrameHeaderObserver
 
+	// MetricsRecorder, if set, records the latency, retries and timeouts of the
+	// attempts of the queries of the session, the size of its connection pools
+	// and its connection errors, per host.
+	MetricsRecorder MetricsRecorder
+
+	// UnsafeFrameInterceptor, if set, returns the interceptor of the frames
+	// of every connection opened to host, or nil to leave them alone. It is
+	// meant for fault injection tests only, see FrameInterceptor.
+	UnsafeFrameInterceptor func(host *HostInfo) FrameInterceptor
+
 	// StreamObserver will be notified of stream state changes.
 	// This can be used to track in-flight protocol requests and responses.
 	StreamObserver StreamObserver
 
+	// ControlConnObserver will be notified of the connection attempts of the
+	// control connection. The state of the control connection is also
+	// available from Session.ControlConnState.
+	ControlConnObserver ControlConnObserver
+
+	// Middleware wraps the execution of every query and batch of the session,
+	// the first middleware being the outermost.
+	Middleware []Middleware
+
+	// TagPayloadPrefix, if set, sends the tags of queries and batches to the
+	// server in the custom payload of requests, each tag under the key
+	// TagPayloadPrefix followed by the tag key. Custom payloads require
+	// protocol version 4 or later.
+	TagPayloadPrefix string
+
+	// PropagateDeadline makes the queries with a context deadline wait for
+	// their response until the deadline instead of for Timeout, and adds the
+	// time remaining until the deadline as USING TIMEOUT to their statement
+	// when connected to Scylla, so that the server abandons the query once
+	// the client gave up. The timeout of prepared statements is bound to a
+	// marker, so that they are prepared once. It is rounded down to the
+	// millisecond. Statements that already have a timeout and batches are not
+	// changed.
+	// Default: false
+	PropagateDeadline bool
+
+	// ReadCache, if set, enables a client side read-through cache for queries
+	// marked with Query.Cached. See ReadCache.
+	ReadCache *ReadCacheConfig
+
 	// Default idempotence for queries
 	DefaultIdempotence bool
 
@@ -224,6 +333,104 @@ type ClusterConfig struct {
 	// (default: 200 microseconds)
 	WriteCoalesceWaitTime time.Duration
 
+	// NodeUpDelay is the time to wait before connecting to a node which was
+	// reported up by an event, for Cassandra versions before 2.2 which report
+	// nodes up before they accept connections (CASSANDRA-8236). The
+	// connections are made in the background. Set to 0 to connect immediately.
+	//
+	// (default: 10 seconds)
+	NodeUpDelay time.Duration
+
+	// ClusterName, if set, is the name the cluster must report in system.local.
+	// Connections to hosts reporting another name, including the control
+	// connection, are refused with a *ClusterMismatchError, so that contact
+	// points of another environment aren't used by mistake.
+	ClusterName string
+
+	// Partitioners, if set, are the partitioners the cluster may use, such as
+	// Murmur3Partitioner or org.apache.cassandra.dht.Murmur3Partitioner.
+	// Connections to hosts reporting another partitioner are refused like for
+	// ClusterName.
+	Partitioners []string
+
+	// ReadOnly rejects the queries and batches which don't start with SELECT,
+	// LIST or DESCRIBE, such as INSERT, UPDATE, DELETE, TRUNCATE and schema
+	// changes, with a *ReadOnlyError before they are sent. Use it for
+	// analytics services and migration dry-runs which must not write.
+	ReadOnly bool
+
+	// TruncateTimeout limits Session.Truncate, which waits for all replicas
+	// to truncate the table. It replaces ClusterConfig.Timeout if it is
+	// longer. Set it above truncate_request_timeout of the server, 60 seconds
+	// by default.
+	//
+	// (default: 2 minutes)
+	TruncateTimeout time.Duration
+
+	// MaxRequestSize limits the size in bytes of the statements and values of
+	// a query or batch, and MaxRequestValues the number of its bound values.
+	// Requests exceeding them fail with a *RequestTooLargeError before they
+	// are sent, instead of tying up a connection until the server rejects
+	// them. Zero disables the limit.
+	MaxRequestSize   int
+	MaxRequestValues int
+
+	// MaxResponseSize limits the size in bytes of the response frames, after
+	// decompression. Larger responses are discarded without being decoded and
+	// the request fails with a *ResponseTooLargeError. It applies to the
+	// queries of the driver too, such as the schema queries, it should be
+	// well above the size of their responses. MaxRows limits the rows returned
+	// by the pages of a query, see Query.MaxRows. Zero disables the limits.
+	MaxResponseSize int
+	MaxRows         int
+
+	// DrainTimeout is how long the connections of a host removed from the
+	// ring, such as a decommissioned node, are kept open for the requests in
+	// flight on them. No new request is sent to the host meanwhile. Zero
+	// closes them right away.
+	//
+	// (default: 10 seconds)
+	DrainTimeout time.Duration
+
+	// TableDefaults are the settings applied to the queries of tables, by
+	// keyspace.table, unless the query or its execution profile sets them.
+	// The table of a query is known from the metadata of its prepared
+	// statement, so the defaults don't apply to queries without values.
+	TableDefaults map[string]TableDefaults
+
+	// DCConsistency, if set, maps the consistency of queries and batches
+	// depending on the datacenter of the host they are sent to, for example to
+	// use QUORUM instead of LOCAL_QUORUM once they fail over to another
+	// datacenter, see DCConsistency.
+	DCConsistency *DCConsistency
+
+	// ColumnTransformers transform the values of columns, by
+	// keyspace.table.column, for example to encrypt them on the client, see
+	// ColumnTransformer.
+	ColumnTransformers map[string]ColumnTransformer
+
+	// OverloadedBackoff is the delay before retrying a query which failed
+	// because the coordinator was overloaded, doubled on every attempt up to
+	// 10 seconds, so that clients shed load instead of retrying immediately.
+	// When 0 queries are retried immediately.
+	//
+	// (default: 0)
+	OverloadedBackoff time.Duration
+
+	// HedgedReads sends the SELECT queries of the session to further hosts
+	// when the first host is slow to respond, see HedgedReads. Queries
+	// setting their own SpeculativeExecutionPolicy aren't affected.
+	HedgedReads *HedgedReads
+
+	// Experiments enables new behaviors of the driver which are off by
+	// default, see Experiment. Session.Experiments returns the experiments
+	// enabled for a session.
+	Experiments []Experiment
+
+	// FireAndForget configures the queue of the writes executed with
+	// Query.FireAndForget.
+	FireAndForget FireAndForget
+
 	// Dialer will be used to establish all connections created for this Cluster.
 	// If not provided, a default dialer configured with ConnectTimeout will be used.
 	// Dialer is ignored if HostDialer is provided.
@@ -235,8 +442,16 @@ type ClusterConfig struct {
 
 	// Logger for this ClusterConfig.
 	// If not specified, defaults to the global gocql.Logger.
+	// A logger implementing StructuredLogger receives the messages at their
+	// level with structured fields, see NewSlogLogger.
 	Logger StdLogger
 
+	// Clock is the source of time of the session, for the timeouts of
+	// requests, the delays of speculative executions and the default
+	// timestamps of queries, see Clock. Tests may set a fake clock.
+	// If not specified, defaults to the system clock.
+	Clock Clock
+
 	// internal config for testing
 	disableControlConn bool
 }
@@ -272,6 +487,9 @@ func NewCluster(hosts ...string) *ClusterConfig {
 		ConvictionPolicy:       &SimpleConvictionPolicy{},
 		ReconnectionPolicy:     &ConstantReconnectionPolicy{MaxRetries: 3, Interval: 1 * time.Second},
 		WriteCoalesceWaitTime:  200 * time.Microsecond,
+		NodeUpDelay:            10 * time.Second,
+		TruncateTimeout:        2 * time.Minute,
+		DrainTimeout:           10 * time.Second,
 	}
 	return cfg
 }
@@ -298,9 +516,8 @@ func (cfg *ClusterConfig) translateAddressPort(addr net.IP, port int) (net.IP, i
 		return addr, port
 	}
 	newAddr, newPort := cfg.AddressTranslator.Translate(addr, port)
-	if gocqlDebug {
-		cfg.logger().Printf("gocql: translating address '%v:%d' to '%v:%d'", addr, port, newAddr, newPort)
-	}
+	logDebug(cfg.logger(), "translating address", LogField{"from", net.JoinHostPort(addr.String(), strconv.Itoa(port))},
+		LogField{"to", net.JoinHostPort(newAddr.String(), strconv.Itoa(newPort))})
 	return newAddr, newPort
 }
 
@@ -308,6 +525,10 @@ func (cfg *ClusterConfig) filterHost(host *HostInfo) bool {
 	return !(cfg.HostFilter == nil || cfg.HostFilter.Accept(host))
 }
 
+func (cfg *ClusterConfig) filterControlHost(host *HostInfo) bool {
+	return !(cfg.ControlConnHostFilter == nil || cfg.ControlConnHostFilter.Accept(host))
+}
+
 var (
 	ErrNoHosts              = errors.New("no hosts provided")
 	ErrNoConnectionsStarted = errors.New("no connections were made when creating the session")
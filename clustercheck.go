@@ -0,0 +1,63 @@
+package gocql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ClusterMismatchError is returned when connecting to a host which reports
+// another cluster name than ClusterConfig.ClusterName, or a partitioner not in
+// ClusterConfig.Partitioners.
+type ClusterMismatchError struct {
+	Host *HostInfo
+	// ClusterName and Partitioner are reported by the host.
+	ClusterName string
+	Partitioner string
+
+	reason string
+}
+
+func (e *ClusterMismatchError) Error() string {
+	return fmt.Sprintf("gocql: refusing to connect to %s: %s", e.Host.ConnectAddressAndPort(), e.reason)
+}
+
+// verifyCluster checks that the host of c belongs to the configured cluster,
+// when ClusterConfig.ClusterName or Partitioners is set.
+func (c *Conn) verifyCluster(ctx context.Context) error {
+	cfg := &c.session.cfg
+	if cfg.ClusterName == "" && len(cfg.Partitioners) == 0 {
+		return nil
+	}
+
+	row := make(map[string]interface{})
+	iter := c.query(ctx, "SELECT cluster_name, partitioner FROM system.local WHERE key='local'")
+	iter.MapScan(row)
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("gocql: unable to verify the cluster of %s: %w", c.host.ConnectAddressAndPort(), err)
+	}
+	name, _ := row["cluster_name"].(string)
+	partitioner, _ := row["partitioner"].(string)
+
+	mismatch := &ClusterMismatchError{Host: c.host, ClusterName: name, Partitioner: partitioner}
+	if cfg.ClusterName != "" && name != cfg.ClusterName {
+		mismatch.reason = fmt.Sprintf("cluster name %q, expected %q", name, cfg.ClusterName)
+		return mismatch
+	}
+	if len(cfg.Partitioners) > 0 && !partitionerAllowed(partitioner, cfg.Partitioners) {
+		mismatch.reason = fmt.Sprintf("partitioner %q, expected one of %s", partitioner, strings.Join(cfg.Partitioners, ", "))
+		return mismatch
+	}
+	return nil
+}
+
+// partitionerAllowed reports whether partitioner is one of allowed, which may
+// be full class names or class names without their package.
+func partitionerAllowed(partitioner string, allowed []string) bool {
+	for _, p := range allowed {
+		if partitioner == p || strings.HasSuffix(partitioner, "."+p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package gocql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestClusterVerification(t *testing.T) {
+	srv := gocqltest.NewUnstartedServer()
+	srv.ClusterName = "production"
+	srv.Start()
+	defer srv.Close()
+
+	for _, test := range []struct {
+		name         string
+		clusterName  string
+		partitioners []string
+		err          string
+	}{
+		{"matching", "production", []string{"Murmur3Partitioner"}, ""},
+		{"full partitioner name", "", []string{"org.apache.cassandra.dht.Murmur3Partitioner"}, ""},
+		{"other cluster", "staging", nil, `cluster name "production", expected "staging"`},
+		{"other partitioner", "", []string{"RandomPartitioner"}, `partitioner "org.apache.cassandra.dht.Murmur3Partitioner"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := srv.ClusterConfig()
+			cluster.ClusterName = test.clusterName
+			cluster.Partitioners = test.partitioners
+			session, err := cluster.CreateSession()
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				session.Close()
+				return
+			}
+			if err == nil {
+				session.Close()
+				t.Fatal("expected the session to be refused")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
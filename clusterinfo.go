@@ -0,0 +1,58 @@
+package gocql
+
+// ClusterName returns the name of the cluster as reported in system.local, or
+// an empty string if it isn't known, for example when the initial host lookup
+// is disabled.
+func (s *Session) ClusterName() string {
+	for _, host := range s.ring.allHosts() {
+		if name := host.ClusterName(); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// CassandraVersion returns the lowest and highest versions of Cassandra run by
+// the known hosts, to enable features depending on the version at runtime:
+//
+//	if min, _ := session.CassandraVersion(); min.AtLeast(4, 0, 0) {
+//		// all hosts support duration columns and the protocol v5
+//	}
+//
+// Both versions are zero if the versions of the hosts aren't known. During a
+// rolling upgrade the versions differ, features should be gated on min.
+func (s *Session) CassandraVersion() (min, max cassVersion) {
+	found := false
+	for _, host := range s.ring.allHosts() {
+		v := host.Version()
+		if v == (cassVersion{}) {
+			continue
+		}
+		if !found || v.Before(min.Major, min.Minor, min.Patch) {
+			min = v
+		}
+		if !found || max.Before(v.Major, v.Minor, v.Patch) {
+			max = v
+		}
+		found = true
+	}
+	return min, max
+}
+
+// Partitioner returns the name of the partitioner of the cluster, such as
+// org.apache.cassandra.dht.Murmur3Partitioner, as reported in system.local, or
+// an empty string if it isn't known.
+func (s *Session) Partitioner() string {
+	s.metadata.mu.RLock()
+	partitioner := s.metadata.partitioner
+	s.metadata.mu.RUnlock()
+	if partitioner != "" {
+		return partitioner
+	}
+	for _, host := range s.ring.allHosts() {
+		if partitioner := host.Partitioner(); partitioner != "" {
+			return partitioner
+		}
+	}
+	return ""
+}
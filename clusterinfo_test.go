@@ -0,0 +1,35 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestClusterInfo(t *testing.T) {
+	srv := gocqltest.NewUnstartedServer()
+	srv.ClusterName = "shop"
+	srv.ReleaseVersion = "4.0.11"
+	srv.Start()
+	defer srv.Close()
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if name := session.ClusterName(); name != "shop" {
+		t.Errorf("expected cluster name shop, got %q", name)
+	}
+	if p := session.Partitioner(); p != "org.apache.cassandra.dht.Murmur3Partitioner" {
+		t.Errorf("expected the Murmur3 partitioner, got %q", p)
+	}
+	min, max := session.CassandraVersion()
+	if min.String() != "v4.0.11" || max.String() != "v4.0.11" {
+		t.Errorf("expected v4.0.11, got %v and %v", min, max)
+	}
+	if !min.AtLeast(4, 0, 0) || min.AtLeast(4, 1, 0) {
+		t.Errorf("unexpected comparison of %v", min)
+	}
+}
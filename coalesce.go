@@ -0,0 +1,82 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// coalescer collapses identical concurrent queries into a single request, see
+// Query.Coalesce.
+type coalescer struct {
+	stats *sessionCounters
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is an execution of a query shared by the identical queries
+// executed while it is in flight.
+type coalescedCall struct {
+	done chan struct{}
+	// entry holds the rows of the query, it is nil if they don't fit a single
+	// page or the execution failed with err.
+	entry *readCacheEntry
+	err   error
+}
+
+func newCoalescer(stats *sessionCounters) *coalescer {
+	return &coalescer{stats: stats, calls: make(map[string]*coalescedCall)}
+}
+
+// coalesceKey identifies the queries returning the same rows.
+func coalesceKey(qry *Query) string {
+	return readCacheKey(qry.session.Keyspace(), qry.cons, readCacheValuesKey(qry.stmt, qry.values)) + "\x00" + strconv.Itoa(qry.pageSize)
+}
+
+// do returns the rows of the identical query in flight if there is one,
+// otherwise it executes qry with execute and shares its rows with the identical
+// queries executed in the meantime. Queries whose rows can't be shared, as
+// they span several pages, are executed on their own.
+func (c *coalescer) do(qry *Query, execute func() *Iter) *Iter {
+	key := coalesceKey(qry)
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+
+		ctx := qry.Context()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return &Iter{err: ctx.Err()}
+		}
+		if call.err == nil && call.entry == nil {
+			return execute()
+		}
+		c.stats.coalesce()
+		if call.err != nil {
+			return &Iter{err: call.err}
+		}
+		return call.entry.iter()
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	iter := execute()
+	// the context of the query isn't the context of the others, they execute
+	// on their own instead
+	if iter.err != nil && !errors.Is(iter.err, context.Canceled) && !errors.Is(iter.err, context.DeadlineExceeded) {
+		call.err = iter.err
+	} else {
+		call.entry = newReadCacheEntry(qry.stmt, iter)
+	}
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return iter
+}
@@ -0,0 +1,85 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestCoalesce(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	stmt := `SELECT stock FROM products WHERE id = ?`
+	srv.On(stmt).
+		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+		Handle([]gocqltest.Column{{Name: "stock", Type: gocqltest.Int}}, func(req *gocqltest.Request) gocqltest.Response {
+			var id int
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			time.Sleep(200 * time.Millisecond)
+			if id == 2 {
+				return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeInvalid, Message: "no stock"}}
+			}
+			return gocqltest.Response{Rows: [][]interface{}{{id * 10}}}
+		})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	const n = 10
+	var (
+		wg     sync.WaitGroup
+		stocks [n]int
+		errs   [n]error
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// the last query has other values and is sent on its own
+			id := 1
+			if i == n-1 {
+				id = 2
+			}
+			errs[i] = session.Query(stmt, id).Coalesce(true).Scan(&stocks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n-1; i++ {
+		if errs[i] != nil || stocks[i] != 10 {
+			t.Fatalf("query %d: expected 10, got %d, %v", i, stocks[i], errs[i])
+		}
+	}
+	if errs[n-1] == nil {
+		t.Fatal("expected the query of the other product to fail")
+	}
+
+	var requests int
+	for _, req := range srv.Requests() {
+		if req.Statement == stmt {
+			requests++
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+	if coalesced := session.Stats().Coalesced; coalesced != n-2 {
+		t.Fatalf("expected %d coalesced queries, got %d", n-2, coalesced)
+	}
+
+	// queries are not coalesced once the first one is done
+	if err := session.Query(stmt, 1).Coalesce(true).Scan(&stocks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if coalesced := session.Stats().Coalesced; coalesced != n-2 {
+		t.Fatalf("expected %d coalesced queries, got %d", n-2, coalesced)
+	}
+}
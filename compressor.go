@@ -1,6 +1,10 @@
 package gocql
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/golang/snappy"
 )
 
@@ -10,6 +14,32 @@ type Compressor interface {
 	Decode(data []byte) ([]byte, error)
 }
 
+// AppendEncoder is an optional interface of a Compressor which appends the
+// encoding of data to dst, growing it if needed. Connections use it to encode
+// frames into a reused buffer instead of allocating one per frame.
+type AppendEncoder interface {
+	AppendEncode(dst, data []byte) ([]byte, error)
+}
+
+// Compression overrides whether the request of a query or batch is
+// compressed, see Query.Compression and Batch.Compression. Requests are only
+// compressed by connections which negotiated compression at startup, see
+// ClusterConfig.Compressor, the protocol doesn't allow compressing others.
+// Responses are compressed as the server chooses.
+type Compression int
+
+const (
+	// CompressionDefault compresses the requests whose body is at least
+	// ClusterConfig.CompressionThreshold bytes.
+	CompressionDefault Compression = iota
+	// CompressionDisabled sends the request uncompressed, for example for
+	// values which are already compressed.
+	CompressionDisabled
+	// CompressionForced compresses the request even if it is smaller than
+	// ClusterConfig.CompressionThreshold.
+	CompressionForced
+)
+
 // SnappyCompressor implements the Compressor interface and can be used to
 // compress incoming and outgoing frames. The snappy compression algorithm
 // aims for very high speeds and reasonable compression.
@@ -23,6 +53,164 @@ func (s SnappyCompressor) Encode(data []byte) ([]byte, error) {
 	return snappy.Encode(nil, data), nil
 }
 
+func (s SnappyCompressor) AppendEncode(dst, data []byte) ([]byte, error) {
+	n := snappy.MaxEncodedLen(len(data))
+	if n < 0 {
+		return nil, snappy.ErrTooLarge
+	}
+	dst = growBytes(dst, n)
+	encoded := snappy.Encode(dst[len(dst):len(dst)+n], data)
+	return dst[:len(dst)+len(encoded)], nil
+}
+
 func (s SnappyCompressor) Decode(data []byte) ([]byte, error) {
 	return snappy.Decode(nil, data)
 }
+
+// growBytes returns b with room for at least n more bytes.
+func growBytes(b []byte, n int) []byte {
+	if cap(b)-len(b) >= n {
+		return b
+	}
+	grown := make([]byte, len(b), len(b)+n)
+	copy(grown, b)
+	return grown
+}
+
+// maxPooledEncodeBufSize is the largest buffer kept in encodeBufPool, so that a
+// few huge frames don't pin memory.
+const maxPooledEncodeBufSize = 1 << 20
+
+// encodeBufPool holds the buffers frames are compressed into before being
+// copied back into the frame.
+var encodeBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, defaultBufSize)
+		return &b
+	},
+}
+
+// CompressionStats are the totals of the frames compressed and decompressed
+// by the connections of a session, see Session.CompressionStats.
+type CompressionStats struct {
+	// EncodedFrames is the number of frames compressed.
+	EncodedFrames int64
+	// EncodeBytesIn and EncodeBytesOut are the sizes of the frame bodies
+	// before and after compression.
+	EncodeBytesIn  int64
+	EncodeBytesOut int64
+	// EncodeTime is the time spent compressing frames.
+	EncodeTime time.Duration
+
+	// DecodedFrames is the number of frames decompressed.
+	DecodedFrames int64
+	// DecodeBytesIn and DecodeBytesOut are the sizes of the frame bodies
+	// before and after decompression.
+	DecodeBytesIn  int64
+	DecodeBytesOut int64
+	// DecodeTime is the time spent decompressing frames.
+	DecodeTime time.Duration
+}
+
+// compressionCounters are updated atomically, compressionCounters must be
+// allocated on its own to be 64-bit aligned.
+type compressionCounters struct {
+	encodedFrames  int64
+	encodeBytesIn  int64
+	encodeBytesOut int64
+	encodeNanos    int64
+	decodedFrames  int64
+	decodeBytesIn  int64
+	decodeBytesOut int64
+	decodeNanos    int64
+}
+
+func (c *compressionCounters) encoded(in, out int, start time.Time) {
+	atomic.AddInt64(&c.encodedFrames, 1)
+	atomic.AddInt64(&c.encodeBytesIn, int64(in))
+	atomic.AddInt64(&c.encodeBytesOut, int64(out))
+	atomic.AddInt64(&c.encodeNanos, int64(time.Since(start)))
+}
+
+func (c *compressionCounters) decoded(in, out int, start time.Time) {
+	atomic.AddInt64(&c.decodedFrames, 1)
+	atomic.AddInt64(&c.decodeBytesIn, int64(in))
+	atomic.AddInt64(&c.decodeBytesOut, int64(out))
+	atomic.AddInt64(&c.decodeNanos, int64(time.Since(start)))
+}
+
+func (c *compressionCounters) stats() CompressionStats {
+	return CompressionStats{
+		EncodedFrames:  atomic.LoadInt64(&c.encodedFrames),
+		EncodeBytesIn:  atomic.LoadInt64(&c.encodeBytesIn),
+		EncodeBytesOut: atomic.LoadInt64(&c.encodeBytesOut),
+		EncodeTime:     time.Duration(atomic.LoadInt64(&c.encodeNanos)),
+		DecodedFrames:  atomic.LoadInt64(&c.decodedFrames),
+		DecodeBytesIn:  atomic.LoadInt64(&c.decodeBytesIn),
+		DecodeBytesOut: atomic.LoadInt64(&c.decodeBytesOut),
+		DecodeTime:     time.Duration(atomic.LoadInt64(&c.decodeNanos)),
+	}
+}
+
+// meteredCompressor records the compression done by a connection.
+type meteredCompressor struct {
+	Compressor
+	counters *compressionCounters
+}
+
+func (m *meteredCompressor) Encode(data []byte) ([]byte, error) {
+	start := time.Now()
+	encoded, err := m.Compressor.Encode(data)
+	if err == nil {
+		m.counters.encoded(len(data), len(encoded), start)
+	}
+	return encoded, err
+}
+
+func (m *meteredCompressor) Decode(data []byte) ([]byte, error) {
+	start := time.Now()
+	decoded, err := m.Compressor.Decode(data)
+	if err == nil {
+		m.counters.decoded(len(data), len(decoded), start)
+	}
+	return decoded, err
+}
+
+// meteredAppendCompressor is a meteredCompressor of a compressor which
+// implements AppendEncoder.
+type meteredAppendCompressor struct {
+	*meteredCompressor
+	appender AppendEncoder
+}
+
+func (m *meteredAppendCompressor) AppendEncode(dst, data []byte) ([]byte, error) {
+	start := time.Now()
+	n := len(dst)
+	dst, err := m.appender.AppendEncode(dst, data)
+	if err == nil {
+		m.counters.encoded(len(data), len(dst)-n, start)
+	}
+	return dst, err
+}
+
+// meterCompressor wraps c to record its work in counters, keeping the
+// AppendEncoder implementation of c.
+func meterCompressor(c Compressor, counters *compressionCounters) Compressor {
+	if c == nil || counters == nil {
+		return c
+	}
+	m := &meteredCompressor{Compressor: c, counters: counters}
+	if appender, ok := c.(AppendEncoder); ok {
+		return &meteredAppendCompressor{meteredCompressor: m, appender: appender}
+	}
+	return m
+}
+
+// CompressionStats returns the totals of the frames compressed and
+// decompressed by the connections of the session.
+func (s *Session) CompressionStats() CompressionStats {
+	if s.compression == nil {
+		return CompressionStats{}
+	}
+	return s.compression.stats()
+}
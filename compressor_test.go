@@ -36,3 +36,133 @@ func TestSnappyCompressor(t *testing.T) {
 		t.Fatal("failed to match the expected decoded value with the result decoded value.")
 	}
 }
+
+func TestSnappyAppendEncode(t *testing.T) {
+	c := SnappyCompressor{}
+	data := bytes.Repeat([]byte("My Test String"), 100)
+
+	prefix := []byte("prefix")
+	res, err := c.AppendEncode(prefix, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(res, prefix) {
+		t.Fatalf("expected the encoding to be appended to %q, got %q", prefix, res[:len(prefix)])
+	}
+	if expected := snappy.Encode(nil, data); !bytes.Equal(res[len(prefix):], expected) {
+		t.Fatal("failed to match the expected encoded value with the appended encoded value.")
+	}
+
+	// a buffer large enough is reused
+	buf := make([]byte, 0, snappy.MaxEncodedLen(len(data)))
+	res, err = c.AppendEncode(buf, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &res[0] != &buf[:1][0] {
+		t.Fatal("expected the encoding to reuse the buffer")
+	}
+}
+
+func TestCompressionStats(t *testing.T) {
+	counters := &compressionCounters{}
+	compressor := meterCompressor(SnappyCompressor{}, counters)
+	if _, ok := compressor.(AppendEncoder); !ok {
+		t.Fatal("expected the metered compressor to keep implementing AppendEncoder")
+	}
+	if c := meterCompressor(nil, counters); c != nil {
+		t.Fatalf("expected no compressor, got %v", c)
+	}
+
+	body := bytes.Repeat([]byte("a"), 1000)
+	for i := 0; i < 2; i++ {
+		f := newFramer(compressor, protoVersion4)
+		f.writeHeader(f.flags, opQuery, 1)
+		f.writeBytes(body)
+		if err := f.finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := bytes.NewReader(f.buf)
+		head, err := readHeader(r, make([]byte, 9))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf := newFramer(compressor, protoVersion4)
+		if err := rf.readFrame(r, &head); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rf.readBytes(), body) {
+			t.Fatal("expected the frame body to survive compression")
+		}
+	}
+
+	stats := counters.stats()
+	if stats.EncodedFrames != 2 || stats.DecodedFrames != 2 {
+		t.Fatalf("expected 2 frames encoded and decoded, got %+v", stats)
+	}
+	if stats.EncodeBytesIn != 2*1004 || stats.DecodeBytesOut != 2*1004 {
+		t.Fatalf("expected 2008 uncompressed bytes, got %+v", stats)
+	}
+	if stats.EncodeBytesOut != stats.DecodeBytesIn || stats.EncodeBytesOut >= stats.EncodeBytesIn {
+		t.Fatalf("expected the frames to be compressed, got %+v", stats)
+	}
+	if stats.EncodeTime <= 0 && stats.DecodeTime <= 0 {
+		t.Fatalf("expected the compression time to be recorded, got %+v", stats)
+	}
+}
+
+func TestFrameCompressionOverride(t *testing.T) {
+	small, large := make([]byte, 10), bytes.Repeat([]byte("a"), 1000)
+	tests := []struct {
+		compression Compression
+		body        []byte
+		compressed  bool
+	}{
+		{CompressionDefault, small, false},
+		{CompressionDefault, large, true},
+		{CompressionDisabled, large, false},
+		{CompressionForced, small, true},
+	}
+
+	for _, test := range tests {
+		f := newFramer(SnappyCompressor{}, protoVersion4)
+		f.compressThreshold = 100
+		q := &writeQueryFrame{statement: string(test.body), compression: test.compression}
+		if err := q.buildFrame(f, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		r := bytes.NewReader(f.buf)
+		head, err := readHeader(r, make([]byte, 9))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compressed := head.flags&flagCompress != 0; compressed != test.compressed {
+			t.Errorf("%d byte frame with compression %d: expected compressed %v, got %v", len(test.body), test.compression, test.compressed, compressed)
+			continue
+		}
+		rf := newFramer(SnappyCompressor{}, protoVersion4)
+		if err := rf.readFrame(r, &head); err != nil {
+			t.Fatal(err)
+		}
+		if got := rf.readLongString(); got != string(test.body) {
+			t.Errorf("%d byte frame with compression %d: expected the statement to survive", len(test.body), test.compression)
+		}
+	}
+}
+
+func BenchmarkFramerCompress(b *testing.B) {
+	body := bytes.Repeat([]byte("some compressible frame content "), 256)
+	compressor := SnappyCompressor{}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		f := newFramer(compressor, protoVersion4)
+		f.writeHeader(f.flags, opQuery, 1)
+		f.writeBytes(body)
+		if err := f.finish(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
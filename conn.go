@@ -187,15 +187,21 @@ type Conn struct {
 
 	errorHandler ConnErrorHandler
 	compressor   Compressor
+	compressMin  int // see ClusterConfig.CompressionThreshold
+	maxOrphaned  int // see ClusterConfig.MaxOrphanedStreams
 	auth         Authenticator
 	addr         string
 
-	version         uint8
+	version uint8
+	// currentKeyspace is protected by mu, see keyspace.
 	currentKeyspace string
 	host            *HostInfo
 	isSchemaV2      bool
+	// isScylla is set if the node advertised Scylla options on startup.
+	isScylla bool
 
 	session *Session
+	stats   *sessionCounters
 
 	// true if connection close process for the connection started.
 	// closed is protected by mu.
@@ -204,6 +210,9 @@ type Conn struct {
 	cancel context.CancelFunc
 
 	timeouts int64
+	// orphaned is the number of streams of requests abandoned before their
+	// response, see ClusterConfig.MaxOrphanedStreams.
+	orphaned int64
 
 	logger StdLogger
 }
@@ -222,6 +231,9 @@ func (s *Session) dial(ctx context.Context, host *HostInfo, connConfig *ConnConf
 	}
 
 	conn, err := s.dialWithoutObserver(ctx, host, connConfig, errorHandler)
+	if err != nil && s.cfg.MetricsRecorder != nil {
+		s.cfg.MetricsRecorder.RecordConnectionError(host, err)
+	}
 
 	if s.connectObserver != nil {
 		obs.End = time.Now()
@@ -240,6 +252,13 @@ func (s *Session) dialWithoutObserver(ctx context.Context, host *HostInfo, cfg *
 	if err != nil {
 		return nil, err
 	}
+	if s.cfg.UnsafeFrameInterceptor != nil {
+		if interceptor := s.cfg.UnsafeFrameInterceptor(host); interceptor != nil {
+			intercepted := *dialedHost
+			intercepted.Conn = newInterceptedConn(dialedHost.Conn, interceptor)
+			dialedHost = &intercepted
+		}
+	}
 
 	writeTimeout := cfg.Timeout
 	if cfg.WriteTimeout > 0 {
@@ -248,30 +267,33 @@ func (s *Session) dialWithoutObserver(ctx context.Context, host *HostInfo, cfg *
 
 	ctx, cancel := context.WithCancel(ctx)
 	c := &Conn{
-		conn:          dialedHost.Conn,
-		r:             bufio.NewReader(dialedHost.Conn),
-		cfg:           cfg,
-		calls:         make(map[int]*callReq),
-		version:       uint8(cfg.ProtoVersion),
-		addr:          dialedHost.Conn.RemoteAddr().String(),
-		errorHandler:  errorHandler,
-		compressor:    cfg.Compressor,
-		session:       s,
-		streams:       streams.New(cfg.ProtoVersion),
-		host:          host,
-		isSchemaV2:    true, // Try using "system.peers_v2" until proven otherwise
-		frameObserver: s.frameObserver,
+		conn:           dialedHost.Conn,
+		r:              bufio.NewReader(dialedHost.Conn),
+		cfg:            cfg,
+		calls:          make(map[int]*callReq),
+		version:        uint8(cfg.ProtoVersion),
+		addr:           dialedHost.Conn.RemoteAddr().String(),
+		errorHandler:   errorHandler,
+		compressor:     meterCompressor(cfg.Compressor, s.compression),
+		compressMin:    s.cfg.CompressionThreshold,
+		maxOrphaned:    s.cfg.MaxOrphanedStreams,
+		session:        s,
+		stats:          s.stats,
+		streams:        streams.New(cfg.ProtoVersion),
+		host:           host,
+		isSchemaV2:     true, // Try using "system.peers_v2" until proven otherwise
+		frameObserver:  s.frameObserver,
+		ctx:            ctx,
+		cancel:         cancel,
+		logger:         cfg.logger(),
+		streamObserver: s.streamObserver,
+		writeTimeout:   writeTimeout,
 		w: &deadlineContextWriter{
 			w:         dialedHost.Conn,
 			timeout:   writeTimeout,
 			semaphore: make(chan struct{}, 1),
 			quit:      make(chan struct{}),
 		},
-		ctx:            ctx,
-		cancel:         cancel,
-		logger:         cfg.logger(),
-		streamObserver: s.streamObserver,
-		writeTimeout:   writeTimeout,
 	}
 
 	if err := c.init(ctx, dialedHost); err != nil {
@@ -280,6 +302,11 @@ func (s *Session) dialWithoutObserver(ctx context.Context, host *HostInfo, cfg *
 		return nil, err
 	}
 
+	if err := c.verifyCluster(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -415,6 +442,11 @@ func (s *startupCoordinator) options(ctx context.Context) error {
 		return err
 	}
 
+	if err, ok := frame.(error); ok {
+		// such as the protocol error of a server not supporting the version
+		return err
+	}
+
 	supported, ok := frame.(*supportedFrame)
 	if !ok {
 		return NewErrProtocol("Unknown type of response to startup frame: %T", frame)
@@ -424,6 +456,13 @@ func (s *startupCoordinator) options(ctx context.Context) error {
 }
 
 func (s *startupCoordinator) startup(ctx context.Context, supported map[string][]string) error {
+	for option := range supported {
+		if strings.HasPrefix(option, "SCYLLA_") {
+			s.conn.isScylla = true
+			break
+		}
+	}
+
 	m := map[string]string{
 		"CQL_VERSION":    s.conn.cfg.CQLVersion,
 		"DRIVER_NAME":    driverName,
@@ -529,7 +568,7 @@ func (c *Conn) closeWithError(err error) {
 	for _, req := range callsToClose {
 		// we need to send the error to all waiting queries.
 		select {
-		case req.resp <- callResp{err: err}:
+		case req.resp <- callResp{err: err, streamID: req.streamID}:
 		case <-req.timeout:
 		}
 		if req.streamObserverContext != nil {
@@ -656,6 +695,12 @@ func (c *Conn) recv(ctx context.Context) error {
 		return err
 	}
 
+	headSize := 9
+	if head.version.version() < protoVersion3 {
+		headSize = 8
+	}
+	c.stats.read(headSize + head.length)
+
 	if c.frameObserver != nil {
 		c.frameObserver.ObserveFrameHeader(context.Background(), ObservedFrameHeader{
 			Version: protoVersion(head.version),
@@ -706,7 +751,7 @@ func (c *Conn) recv(ctx context.Context) error {
 	delete(c.calls, head.stream)
 	c.mu.Unlock()
 	if call == nil || !ok {
-		c.logger.Printf("gocql: received response for stream which has no handler: header=%v\n", head)
+		logWarn(c.logger, "received response for stream which has no handler", LogField{"header", head})
 		return c.discardFrame(head)
 	} else if head.stream != call.streamID {
 		panic(fmt.Sprintf("call has incorrect streamID: got %d expected %d", call.streamID, head.stream))
@@ -714,20 +759,29 @@ func (c *Conn) recv(ctx context.Context) error {
 
 	framer := newFramer(c.compressor, c.version)
 
-	err = framer.readFrame(c, &head)
-	if err != nil {
+	if err = c.checkResponseSize(head.length); err != nil {
+		// the frame is skipped so that the connection can be used again
+		if derr := c.discardFrame(head); derr != nil {
+			return derr
+		}
+	} else if err = framer.readFrame(c, &head); err != nil {
 		// only net errors should cause the connection to be closed. Though
 		// cassandra returning corrupt frames will be returned here as well.
 		if _, ok := err.(net.Error); ok {
 			return err
 		}
+	} else {
+		// compressed frames are checked again once they are decompressed
+		err = c.checkResponseSize(len(framer.buf))
 	}
 
 	// we either, return a response to the caller, the caller timedout, or the
 	// connection has closed. Either way we should never block indefinatly here
 	select {
-	case call.resp <- callResp{framer: framer, err: err}:
+	case call.resp <- callResp{framer: framer, err: err, streamID: call.streamID}:
 	case <-call.timeout:
+		// the response of an orphaned stream arrived
+		atomic.AddInt64(&c.orphaned, -1)
 		c.releaseStream(call)
 	case <-ctx.Done():
 	}
@@ -757,13 +811,29 @@ func (c *Conn) handleTimeout() {
 	}
 }
 
+// orphan counts n requests abandoned before their response arrived, whose
+// streams stay in use until it does, and closes the connection if more than
+// maxOrphaned streams are orphaned.
+func (c *Conn) orphan(n int) {
+	if n == 0 {
+		return
+	}
+	c.stats.orphan(n)
+	orphaned := atomic.AddInt64(&c.orphaned, int64(n))
+	if c.maxOrphaned > 0 && orphaned > int64(c.maxOrphaned) {
+		logWarn(c.logger, "closing connection with too many orphaned streams",
+			LogField{"host", c.addr}, LogField{"orphaned", orphaned})
+		c.closeWithError(ErrTooManyOrphanedStreams)
+	}
+}
+
 type callReq struct {
 	// resp will receive the frame that was sent as a response to this stream.
 	resp     chan callResp
 	timeout  chan struct{} // indicates to recv() that a call has timed out
 	streamID int           // current stream in use
 
-	timer *time.Timer
+	timer Timer
 
 	// streamObserverContext is notified about events regarding this stream
 	streamObserverContext StreamObserverContext
@@ -779,6 +849,9 @@ type callResp struct {
 	framer *framer
 	// err is error encountered, if any.
 	err error
+	// streamID is the stream of the call, used to match responses delivered on
+	// a channel shared by several calls.
+	streamID int
 }
 
 // contextWriter is like io.Writer, but takes context as well.
@@ -1007,109 +1080,35 @@ func (c *Conn) addCall(call *callReq) error {
 }
 
 func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer) (*framer, error) {
+	return c.execTimeout(ctx, req, tracer, c.timeout)
+}
+
+// execTimeout is like exec but waits up to timeout for the response instead of
+// the timeout of the connection.
+func (c *Conn) execTimeout(ctx context.Context, req frameBuilder, tracer Tracer, timeout time.Duration) (*framer, error) {
 	if ctxErr := ctx.Err(); ctxErr != nil {
 		return nil, ctxErr
 	}
 
-	// TODO: move tracer onto conn
-	stream, ok := c.streams.GetStream()
-	if !ok {
-		return nil, ErrNoStreams
-	}
-
-	// resp is basically a waiting semaphore protecting the framer
-	framer := newFramer(c.compressor, c.version)
-
-	call := &callReq{
-		timeout:  make(chan struct{}),
-		streamID: stream,
-		resp:     make(chan callResp),
-	}
-
-	if c.streamObserver != nil {
-		call.streamObserverContext = c.streamObserver.StreamContext(ctx)
-	}
-
-	if err := c.addCall(call); err != nil {
-		return nil, err
-	}
-
-	// After this point, we need to either read from call.resp or close(call.timeout)
-	// since closeWithError can try to write a connection close error to call.resp.
-	// If we don't close(call.timeout) or read from call.resp, closeWithError can deadlock.
-
-	if tracer != nil {
-		framer.trace()
-	}
-
-	if call.streamObserverContext != nil {
-		call.streamObserverContext.StreamStarted(ObservedStream{
-			Host: c.host,
-		})
-	}
-
-	err := req.buildFrame(framer, stream)
+	call, err := c.startCall(ctx, req, tracer, make(chan callResp))
 	if err != nil {
-		// closeWithError will block waiting for this stream to either receive a response
-		// or for us to timeout.
-		close(call.timeout)
-		// We failed to serialize the frame into a buffer.
-		// This should not affect the connection as we didn't write anything. We just free the current call.
-		c.mu.Lock()
-		if !c.closed {
-			delete(c.calls, call.streamID)
-		}
-		c.mu.Unlock()
-		// We need to release the stream after we remove the call from c.calls, otherwise the existingCall != nil
-		// check above could fail.
-		c.releaseStream(call)
-		return nil, err
-	}
-
-	n, err := c.w.writeContext(ctx, framer.buf)
-	if err != nil {
-		// closeWithError will block waiting for this stream to either receive a response
-		// or for us to timeout, close the timeout chan here. Im not entirely sure
-		// but we should not get a response after an error on the write side.
-		close(call.timeout)
-		if (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) && n == 0 {
-			// We have not started to write this frame.
-			// Release the stream as no response can come from the server on the stream.
-			c.mu.Lock()
-			if !c.closed {
-				delete(c.calls, call.streamID)
-			}
-			c.mu.Unlock()
-			// We need to release the stream after we remove the call from c.calls, otherwise the existingCall != nil
-			// check above could fail.
-			c.releaseStream(call)
-		} else {
-			// I think this is the correct thing to do, im not entirely sure. It is not
-			// ideal as readers might still get some data, but they probably wont.
-			// Here we need to be careful as the stream is not available and if all
-			// writes just timeout or fail then the pool might use this connection to
-			// send a frame on, with all the streams used up and not returned.
-			c.closeWithError(err)
-		}
 		return nil, err
 	}
 
 	var timeoutCh <-chan time.Time
-	if c.timeout > 0 {
+	if timeout > 0 {
 		if call.timer == nil {
-			call.timer = time.NewTimer(0)
-			<-call.timer.C
+			call.timer = c.clock().NewTimer(timeout)
 		} else {
 			if !call.timer.Stop() {
 				select {
-				case <-call.timer.C:
+				case <-call.timer.C():
 				default:
 				}
 			}
+			call.timer.Reset(timeout)
 		}
-
-		call.timer.Reset(c.timeout)
-		timeoutCh = call.timer.C
+		timeoutCh = call.timer.C()
 	}
 
 	var ctxDone <-chan struct{}
@@ -1145,10 +1144,12 @@ func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer) (*fram
 		return resp.framer, nil
 	case <-timeoutCh:
 		close(call.timeout)
+		c.orphan(1)
 		c.handleTimeout()
 		return nil, ErrTimeoutNoResponse
 	case <-ctxDone:
 		close(call.timeout)
+		c.orphan(1)
 		return nil, ctx.Err()
 	case <-c.ctx.Done():
 		close(call.timeout)
@@ -1156,6 +1157,121 @@ func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer) (*fram
 	}
 }
 
+// startCall allocates a stream for req and writes it to the connection. The
+// response is delivered on resp, which may be shared by several calls. After
+// it returns successfully the caller must either read the response or close
+// call.timeout.
+func (c *Conn) startCall(ctx context.Context, req frameBuilder, tracer Tracer, resp chan callResp) (*callReq, error) {
+	call, framer, err := c.newCall(ctx, req, tracer, resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.writeCalls(ctx, c.w, framer.buf, call); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// newCall allocates a stream for req and builds its frame without writing it.
+// The caller must either write the frame with writeCalls or cancel the call.
+func (c *Conn) newCall(ctx context.Context, req frameBuilder, tracer Tracer, resp chan callResp) (*callReq, *framer, error) {
+	// TODO: move tracer onto conn
+	stream, ok := c.streams.GetStream()
+	if !ok {
+		return nil, nil, ErrNoStreams
+	}
+
+	// resp is basically a waiting semaphore protecting the framer
+	framer := newFramer(c.compressor, c.version)
+	framer.compressThreshold = c.compressMin
+
+	call := &callReq{
+		timeout:  make(chan struct{}),
+		streamID: stream,
+		resp:     resp,
+	}
+
+	if c.streamObserver != nil {
+		call.streamObserverContext = c.streamObserver.StreamContext(ctx)
+	}
+
+	if err := c.addCall(call); err != nil {
+		return nil, nil, err
+	}
+
+	// After this point, we need to either read from call.resp or close(call.timeout)
+	// since closeWithError can try to write a connection close error to call.resp.
+	// If we don't close(call.timeout) or read from call.resp, closeWithError can deadlock.
+
+	if tracer != nil {
+		framer.trace()
+	}
+
+	if call.streamObserverContext != nil {
+		call.streamObserverContext.StreamStarted(ObservedStream{
+			Host: c.host,
+		})
+	}
+
+	err := req.buildFrame(framer, stream)
+	if err != nil {
+		// We failed to serialize the frame into a buffer.
+		// This should not affect the connection as we didn't write anything. We just free the current call.
+		c.cancelCall(call)
+		return nil, nil, err
+	}
+
+	return call, framer, nil
+}
+
+// cancelCall frees a call whose frame was not written.
+func (c *Conn) cancelCall(call *callReq) {
+	// closeWithError will block waiting for this stream to either receive a response
+	// or for us to timeout.
+	close(call.timeout)
+	c.mu.Lock()
+	if !c.closed {
+		delete(c.calls, call.streamID)
+	}
+	c.mu.Unlock()
+	// We need to release the stream after we remove the call from c.calls, otherwise the existingCall != nil
+	// check in addCall could fail.
+	c.releaseStream(call)
+}
+
+// writeCalls writes p, holding the frames of calls, with w. If the write fails
+// the calls are freed or the connection is closed.
+func (c *Conn) writeCalls(ctx context.Context, w contextWriter, p []byte, calls ...*callReq) error {
+	n, err := w.writeContext(ctx, p)
+	c.stats.written(n)
+	if err == nil {
+		return nil
+	}
+
+	if (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) && n == 0 {
+		// We have not started to write the frames.
+		// Release the streams as no response can come from the server on them.
+		for _, call := range calls {
+			c.cancelCall(call)
+		}
+		return err
+	}
+
+	// closeWithError will block waiting for the streams to either receive a response
+	// or for us to timeout, close the timeout chans here. Im not entirely sure
+	// but we should not get a response after an error on the write side.
+	for _, call := range calls {
+		close(call.timeout)
+	}
+	// I think this is the correct thing to do, im not entirely sure. It is not
+	// ideal as readers might still get some data, but they probably wont.
+	// Here we need to be careful as the stream is not available and if all
+	// writes just timeout or fail then the pool might use this connection to
+	// send a frame on, with all the streams used up and not returned.
+	c.closeWithError(err)
+	return err
+}
+
 // ObservedStream observes a single request/response stream.
 type ObservedStream struct {
 	// Host of the connection used to send the stream.
@@ -1216,7 +1332,8 @@ type inflightPrepare struct {
 }
 
 func (c *Conn) prepareStatement(ctx context.Context, stmt string, tracer Tracer) (*preparedStatment, error) {
-	stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, stmt)
+	keyspace := c.keyspace()
+	stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), keyspace, stmt)
 	flight, ok := c.session.stmtsLRU.execIfMissing(stmtCacheKey, func(lru *lru.Cache) *inflightPrepare {
 		flight := &inflightPrepare{
 			done: make(chan struct{}),
@@ -1233,7 +1350,7 @@ func (c *Conn) prepareStatement(ctx context.Context, stmt string, tracer Tracer)
 				statement: stmt,
 			}
 			if c.version > protoVersion4 {
-				prep.keyspace = c.currentKeyspace
+				prep.keyspace = keyspace
 			}
 
 			// we won the race to do the load, if our context is canceled we shouldnt
@@ -1310,15 +1427,45 @@ func marshalQueryValue(typ TypeInfo, value interface{}, dst *queryValues) error
 	return nil
 }
 
+// appendQueryValue is like marshalQueryValue but encodes common values into
+// the shared buffer buf, returning the extended buffer. buf must not be nil so
+// that empty values are not sent as null.
+func appendQueryValue(typ TypeInfo, value interface{}, dst *queryValues, buf []byte) ([]byte, error) {
+	if named, ok := value.(*namedValue); ok {
+		dst.name = named.name
+		value = named.value
+	}
+
+	if _, ok := value.(unsetColumn); ok {
+		dst.isUnset = true
+		return buf, nil
+	}
+
+	start := len(buf)
+	if next, ok := marshalAppend(typ, buf, value); ok {
+		dst.value = next[start:len(next):len(next)]
+		return next, nil
+	}
+
+	val, err := Marshal(typ, value)
+	if err != nil {
+		return buf, err
+	}
+	dst.value = val
+	return buf, nil
+}
+
 func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 	params := queryParams{
-		consistency: qry.cons,
+		consistency: c.session.dcConsistency.consistency(qry.cons, c.host),
 	}
 
 	// frame checks that it is not 0
 	params.serialConsistency = qry.serialCons
 	params.defaultTimestamp = qry.defaultTimestamp
-	params.defaultTimestampValue = qry.defaultTimestampValue
+	if qry.defaultTimestamp {
+		params.defaultTimestampValue = c.timestamp(qry.defaultTimestampValue)
+	}
 
 	if len(qry.pageState) > 0 {
 		params.pagingState = qry.pageState
@@ -1327,29 +1474,47 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 		params.pageSize = qry.pageSize
 	}
 	if c.version > protoVersion4 {
-		params.keyspace = c.currentKeyspace
+		params.keyspace = c.keyspace()
 	}
 
 	var (
 		frame frameBuilder
 		info  *preparedStatment
+		stmt  = qry.stmt
 	)
+	remaining, propagate := c.propagatedDeadline(ctx)
 
 	if !qry.skipPrepare && qry.shouldPrepare() {
+		// The timeout propagated to Scylla is bound to a marker, so that the
+		// statement is prepared once whatever the deadline.
+		timeoutIndex := -1
+		if propagate && c.isScylla {
+			stmt, timeoutIndex = stmtWithTimeoutMarker(stmt)
+		}
+
 		// Prepare all DML queries. Other queries can not be prepared.
 		var err error
-		info, err = c.prepareStatement(ctx, qry.stmt, qry.trace)
+		info, err = c.prepareStatement(ctx, stmt, qry.trace)
 		if err != nil {
 			return &Iter{err: err}
 		}
 
+		args, pkeyColumns, colCount := info.request.columns, info.request.pkeyColumns, info.request.actualColCount
+		if timeoutIndex >= 0 {
+			if timeoutIndex >= len(args) {
+				return &Iter{err: NewErrProtocol("no bind marker for USING TIMEOUT of %q", stmt)}
+			}
+			args, pkeyColumns = withoutArg(args, pkeyColumns, timeoutIndex)
+			colCount--
+		}
+
 		values := qry.values
 		if qry.binding != nil {
 			values, err = qry.binding(&QueryInfo{
 				Id:          info.id,
-				Args:        info.request.columns,
+				Args:        args,
 				Rval:        info.response.columns,
-				PKeyColumns: info.request.pkeyColumns,
+				PKeyColumns: pkeyColumns,
 			})
 
 			if err != nil {
@@ -1357,26 +1522,47 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 			}
 		}
 
-		if len(values) != info.request.actualColCount {
-			return &Iter{err: fmt.Errorf("gocql: expected %d values send got %d", info.request.actualColCount, len(values))}
+		if len(values) != colCount {
+			return &Iter{err: fmt.Errorf("gocql: expected %d values send got %d", colCount, len(values))}
+		}
+		if timeoutIndex >= 0 {
+			withTimeout := make([]interface{}, 0, len(values)+1)
+			withTimeout = append(withTimeout, values[:timeoutIndex]...)
+			withTimeout = append(withTimeout, serverTimeout(remaining))
+			values = append(withTimeout, values[timeoutIndex:]...)
 		}
 
+		transformers := c.session.columnTransformers(info.request.columns)
 		params.values = make([]queryValues, len(values))
 		for i := 0; i < len(values); i++ {
 			v := &params.values[i]
 			value := values[i]
-			typ := info.request.columns[i].TypeInfo
-			if err := marshalQueryValue(typ, value, v); err != nil {
+			col := info.request.columns[i]
+			if transformers != nil && transformers[i] != nil {
+				if err := marshalTransformedValue(transformers[i], col, value, v); err != nil {
+					return &Iter{err: err}
+				}
+			} else if err := marshalQueryValue(col.TypeInfo, value, v); err != nil {
 				return &Iter{err: err}
 			}
 		}
 
+		if err := checkUnset(c.version, params.values); err != nil {
+			return &Iter{err: err}
+		}
+		var size requestSize
+		size.add(stmt, params.values)
+		if err := c.session.checkRequestSize(stmt, size); err != nil {
+			return &Iter{err: err}
+		}
+
 		params.skipMeta = !(c.session.cfg.DisableSkipMetadata || qry.disableSkipMetadata)
 
 		frame = &writeExecuteFrame{
 			preparedID:    info.id,
 			params:        params,
-			customPayload: qry.customPayload,
+			customPayload: qry.requestPayload(),
+			compression:   qry.compression,
 		}
 
 		// Set "keyspace" and "table" property in the query if it is present in preparedMetadata
@@ -1385,14 +1571,40 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 		qry.routingInfo.table = info.request.table
 		qry.routingInfo.mu.Unlock()
 	} else {
+		if propagate && c.isScylla {
+			stmt = stmtWithTimeout(stmt, remaining)
+		}
+
+		var size requestSize
+		size.add(stmt, nil)
+		if err := c.session.checkRequestSize(stmt, size); err != nil {
+			return &Iter{err: err}
+		}
+
 		frame = &writeQueryFrame{
-			statement:     qry.stmt,
+			statement:     stmt,
 			params:        params,
-			customPayload: qry.customPayload,
+			customPayload: qry.requestPayload(),
+			compression:   qry.compression,
 		}
 	}
 
-	framer, err := c.exec(ctx, frame, qry.trace)
+	timeout := c.timeout
+	if timeout > 0 && qry.connTimeout > timeout {
+		timeout = qry.connTimeout
+	}
+	if propagate {
+		// the response is waited for until the deadline of the context, which
+		// cancels the request, rather than for the timeout of the connection
+		timeout = 0
+	}
+	tracer := qry.trace
+	if qry.conn == nil {
+		// the queries of the driver itself, kept on a connection, such as
+		// reading traces, are not sampled
+		tracer = c.session.traceSampling.tracer(qry.trace, qry.stmt, qry.Attempts())
+	}
+	framer, err := c.execTimeout(ctx, frame, tracer, timeout)
 	if err != nil {
 		return &Iter{err: err}
 	}
@@ -1402,18 +1614,24 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 		return &Iter{err: err}
 	}
 
-	if len(framer.traceID) > 0 && qry.trace != nil {
-		qry.trace.Trace(framer.traceID)
+	if len(framer.traceID) > 0 && tracer != nil {
+		tracer.Trace(framer.traceID)
 	}
 
 	switch x := resp.(type) {
 	case *resultVoidFrame:
 		return &Iter{framer: framer}
 	case *resultRowsFrame:
+		fetchedRows := qry.fetchedRows + x.numRows
+		if qry.maxRows > 0 && fetchedRows > qry.maxRows {
+			return &Iter{framer: framer, err: &TooManyRowsError{Statement: qry.stmt, Rows: fetchedRows, MaxRows: qry.maxRows}}
+		}
+
 		iter := &Iter{
-			meta:    x.meta,
-			framer:  framer,
-			numRows: x.numRows,
+			meta:      x.meta,
+			framer:    framer,
+			numRows:   x.numRows,
+			pageBytes: framer.header.length,
 		}
 
 		if params.skipMeta {
@@ -1426,11 +1644,13 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 		} else {
 			iter.meta = x.meta
 		}
+		iter.transformColumns(c.session.columnTransformers(iter.meta.columns))
 
 		if x.meta.morePages() && !qry.disableAutoPage {
 			newQry := new(Query)
 			*newQry = *qry
 			newQry.pageState = copyBytes(x.meta.pagingState)
+			newQry.fetchedRows = fetchedRows
 			newQry.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
 
 			iter.next = &nextIter{
@@ -1450,14 +1670,14 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 		iter := &Iter{framer: framer}
 		if err := c.awaitSchemaAgreement(ctx); err != nil {
 			// TODO: should have this behind a flag
-			c.logger.Println(err)
+			logWarn(c.logger, err.Error(), qry.LogFields()...)
 		}
 		// dont return an error from this, might be a good idea to give a warning
 		// though. The impact of this returning an error would be that the cluster
 		// is not consistent with regards to its schema.
 		return iter
 	case *RequestErrUnprepared:
-		stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, qry.stmt)
+		stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.keyspace(), stmt)
 		c.session.stmtsLRU.evictPreparedID(stmtCacheKey, x.StatementId)
 		return c.executeQuery(ctx, qry)
 	case error:
@@ -1470,6 +1690,40 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) *Iter {
 	}
 }
 
+// propagatedDeadline returns the time remaining until the deadline of ctx if
+// ClusterConfig.PropagateDeadline is set and ctx has a deadline.
+func (c *Conn) propagatedDeadline(ctx context.Context) (time.Duration, bool) {
+	if c.session == nil || !c.session.cfg.PropagateDeadline {
+		return 0, false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	// deadlines of contexts are wall clock times, whatever the clock of the
+	// session
+	return time.Until(deadline), true
+}
+
+// withoutArg returns the bind markers of a prepared statement without the
+// marker at index, and its partition key columns shifted accordingly.
+func withoutArg(args []ColumnInfo, pkeyColumns []int, index int) ([]ColumnInfo, []int) {
+	without := make([]ColumnInfo, 0, len(args)-1)
+	without = append(without, args[:index]...)
+	without = append(without, args[index+1:]...)
+
+	pkey := make([]int, 0, len(pkeyColumns))
+	for _, i := range pkeyColumns {
+		switch {
+		case i < index:
+			pkey = append(pkey, i)
+		case i > index:
+			pkey = append(pkey, i-1)
+		}
+	}
+	return without, pkey
+}
+
 func (c *Conn) Pick(qry *Query) *Conn {
 	if c.Closed() {
 		return nil
@@ -1487,15 +1741,33 @@ func (c *Conn) Address() string {
 	return c.addr
 }
 
+// inFlight returns the number of requests waiting for their response.
+func (c *Conn) inFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
+
 func (c *Conn) AvailableStreams() int {
 	return c.streams.Available()
 }
 
+// keyspace returns the keyspace the connection uses.
+func (c *Conn) keyspace() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentKeyspace
+}
+
 func (c *Conn) UseKeyspace(keyspace string) error {
-	q := &writeQueryFrame{statement: `USE "` + keyspace + `"`}
+	return c.useKeyspace(c.ctx, keyspace)
+}
+
+func (c *Conn) useKeyspace(ctx context.Context, keyspace string) error {
+	q := &writeQueryFrame{statement: "USE " + QuoteIdentifier(keyspace)}
 	q.params.consistency = c.session.cons
 
-	framer, err := c.exec(c.ctx, q, nil)
+	framer, err := c.exec(ctx, q, nil)
 	if err != nil {
 		return err
 	}
@@ -1513,11 +1785,17 @@ func (c *Conn) UseKeyspace(keyspace string) error {
 		return NewErrProtocol("unknown frame in response to USE: %v", x)
 	}
 
+	c.mu.Lock()
 	c.currentKeyspace = keyspace
+	c.mu.Unlock()
 
 	return nil
 }
 
+// batchValueBufSize is the initial space reserved per batch entry for the
+// encoded values.
+const batchValueBufSize = 64
+
 func (c *Conn) executeBatch(ctx context.Context, batch *Batch) *Iter {
 	if c.version == protoVersion1 {
 		return &Iter{err: ErrUnsupported}
@@ -1525,17 +1803,25 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) *Iter {
 
 	n := len(batch.Entries)
 	req := &writeBatchFrame{
-		typ:                   batch.Type,
-		statements:            make([]batchStatment, n),
-		consistency:           batch.Cons,
-		serialConsistency:     batch.serialCons,
-		defaultTimestamp:      batch.defaultTimestamp,
-		defaultTimestampValue: batch.defaultTimestampValue,
-		customPayload:         batch.CustomPayload,
+		typ:               batch.Type,
+		statements:        make([]batchStatment, n),
+		consistency:       c.session.dcConsistency.consistency(batch.Cons, c.host),
+		serialConsistency: batch.serialCons,
+		defaultTimestamp:  batch.defaultTimestamp,
+		customPayload:     batch.requestPayload(),
+		compression:       batch.compression,
+	}
+	if batch.defaultTimestamp {
+		req.defaultTimestampValue = c.timestamp(batch.defaultTimestampValue)
 	}
 
 	stmts := make(map[string]string, len(batch.Entries))
 
+	// values of all entries are encoded into one buffer, which is grown as
+	// needed, instead of allocating each value separately
+	buf := make([]byte, 0, batchValueBufSize*n)
+	var size requestSize
+
 	for i := 0; i < n; i++ {
 		entry := &batch.Entries[i]
 		b := &req.statements[i]
@@ -1570,20 +1856,36 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) *Iter {
 
 			b.values = make([]queryValues, info.request.actualColCount)
 
+			transformers := c.session.columnTransformers(info.request.columns)
 			for j := 0; j < info.request.actualColCount; j++ {
 				v := &b.values[j]
 				value := values[j]
-				typ := info.request.columns[j].TypeInfo
-				if err := marshalQueryValue(typ, value, v); err != nil {
+				col := info.request.columns[j]
+				if transformers != nil && transformers[j] != nil {
+					if err := marshalTransformedValue(transformers[j], col, value, v); err != nil {
+						return &Iter{err: err}
+					}
+				} else if buf, err = appendQueryValue(col.TypeInfo, value, v, buf); err != nil {
 					return &Iter{err: err}
 				}
 			}
+			if err := checkUnset(c.version, b.values); err != nil {
+				return &Iter{err: err}
+			}
 		} else {
 			b.statement = entry.Stmt
 		}
+		size.add(entry.Stmt, b.values)
 	}
 
-	framer, err := c.exec(batch.Context(), req, batch.trace)
+	if n > 0 {
+		if err := c.session.checkRequestSize(batch.Entries[0].Stmt, size); err != nil {
+			return &Iter{err: err}
+		}
+	}
+
+	tracer := c.session.traceSampling.tracer(batch.trace, "", batch.Attempts())
+	framer, err := c.exec(batch.Context(), req, tracer)
 	if err != nil {
 		return &Iter{err: err}
 	}
@@ -1593,25 +1895,26 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) *Iter {
 		return &Iter{err: err, framer: framer}
 	}
 
-	if len(framer.traceID) > 0 && batch.trace != nil {
-		batch.trace.Trace(framer.traceID)
+	if len(framer.traceID) > 0 && tracer != nil {
+		tracer.Trace(framer.traceID)
 	}
 
 	switch x := resp.(type) {
 	case *resultVoidFrame:
-		return &Iter{}
+		return &Iter{framer: framer}
 	case *RequestErrUnprepared:
 		stmt, found := stmts[string(x.StatementId)]
 		if found {
-			key := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, stmt)
+			key := c.session.stmtsLRU.keyFor(c.host.HostID(), c.keyspace(), stmt)
 			c.session.stmtsLRU.evictPreparedID(key, x.StatementId)
 		}
 		return c.executeBatch(ctx, batch)
 	case *resultRowsFrame:
 		iter := &Iter{
-			meta:    x.meta,
-			framer:  framer,
-			numRows: x.numRows,
+			meta:      x.meta,
+			framer:    framer,
+			numRows:   x.numRows,
+			pageBytes: framer.header.length,
 		}
 
 		return iter
@@ -1690,7 +1993,7 @@ func (c *Conn) awaitSchemaAgreement(ctx context.Context) (err error) {
 				goto cont
 			}
 			if !isValidPeer(host) || host.schemaVersion == "" {
-				c.logger.Printf("invalid peer or peer with empty schema_version: peer=%q", host)
+				logWarn(c.logger, "invalid peer or peer with empty schema_version", LogField{"peer", host})
 				continue
 			}
 
@@ -1743,3 +2046,7 @@ var (
 	ErrConnectionClosed  = errors.New("gocql: connection closed waiting for response")
 	ErrNoStreams         = errors.New("gocql: no streams available on connection")
 )
+
+// ErrTooManyOrphanedStreams closes a connection with more orphaned streams than
+// ClusterConfig.MaxOrphanedStreams.
+var ErrTooManyOrphanedStreams = errors.New("gocql: too many orphaned streams on the connection")
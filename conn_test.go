@@ -606,6 +606,74 @@ func BenchmarkSingleConn(b *testing.B) {
 	})
 }
 
+func TestConnExecPipelined(t *testing.T) {
+	srv := NewTestServer(t, defaultProto, context.Background())
+	defer srv.Stop()
+
+	db, err := newTestSession(defaultProto, srv.Address)
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	reqs := make([]frameBuilder, 100)
+	for i := range reqs {
+		reqs[i] = &writeQueryFrame{statement: "void", params: queryParams{consistency: One}}
+	}
+
+	conn := db.getConn()
+	framers, err := conn.execPipelined(context.Background(), reqs, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(framers) != len(reqs) {
+		t.Fatalf("expected %d responses, got %d", len(reqs), len(framers))
+	}
+	for i, framer := range framers {
+		frame, err := framer.parseFrame()
+		if err != nil {
+			t.Fatalf("response %d: %v", i, err)
+		}
+		if _, ok := frame.(*resultVoidFrame); !ok {
+			t.Fatalf("response %d: expected a void result, got %T", i, frame)
+		}
+	}
+
+	// stream 0 is reserved
+	if n := conn.AvailableStreams(); n != conn.streams.NumStreams-1 {
+		t.Fatalf("expected all %d streams to be released, %d are available", conn.streams.NumStreams-1, n)
+	}
+}
+
+func BenchmarkPipelinedConn(b *testing.B) {
+	srv := NewTestServer(b, 3, context.Background())
+	defer srv.Stop()
+
+	cluster := testCluster(3, srv.Address)
+	cluster.NumConns = 1
+	db, err := cluster.CreateSession()
+	if err != nil {
+		b.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+	conn := db.getConn()
+
+	req := &writeQueryFrame{statement: "void", params: queryParams{consistency: One}}
+	for _, depth := range []int{1, 8, 64, 512} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			reqs := make([]frameBuilder, b.N)
+			for i := range reqs {
+				reqs[i] = req
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			if _, err := conn.execPipelined(context.Background(), reqs, depth); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestQueryTimeoutReuseStream(t *testing.T) {
 	t.Skip("no longer tests anything")
 	// TODO(zariel): move this to conn test, we really just want to check what
@@ -79,11 +79,13 @@ func setupTLSConfig(sslOpts *SslOptions) (*tls.Config, error) {
 type policyConnPool struct {
 	session *Session
 
-	port     int
-	numConns int
-	keyspace string
+	port        int
+	numConns    int
+	remoteConns int
 
-	mu            sync.RWMutex
+	mu sync.RWMutex
+	// keyspace is protected by mu, see Session.SetKeyspace.
+	keyspace      string
 	hostConnPools map[string]*hostConnPool
 }
 
@@ -144,6 +146,7 @@ func newPolicyConnPool(session *Session) *policyConnPool {
 		session:       session,
 		port:          session.cfg.Port,
 		numConns:      session.cfg.NumConns,
+		remoteConns:   session.cfg.NumRemoteConns,
 		keyspace:      session.cfg.Keyspace,
 		hostConnPools: map[string]*hostConnPool{},
 	}
@@ -167,6 +170,10 @@ func (p *policyConnPool) SetHosts(hosts []*HostInfo) {
 			// don't create a connection pool for a down host
 			continue
 		}
+		size := p.size(host)
+		if size == 0 {
+			continue
+		}
 		hostID := host.HostID()
 		if _, exists := p.hostConnPools[hostID]; exists {
 			// still have this host, so don't remove it
@@ -175,16 +182,16 @@ func (p *policyConnPool) SetHosts(hosts []*HostInfo) {
 		}
 
 		createCount++
-		go func(host *HostInfo) {
+		go func(host *HostInfo, size int) {
 			// create a connection pool for the host
 			pools <- newHostConnPool(
 				p.session,
 				host,
 				p.port,
-				p.numConns,
+				size,
 				p.keyspace,
 			)
-		}(host)
+		}(host, size)
 	}
 
 	// add created pools
@@ -204,6 +211,24 @@ func (p *policyConnPool) SetHosts(hosts []*HostInfo) {
 	}
 }
 
+// size returns the number of connections of the pool of host, after its
+// distance, 0 if it is ignored.
+func (p *policyConnPool) size(host *HostInfo) int {
+	if p.session.policy == nil {
+		return p.numConns
+	}
+	switch hostDistance(p.session.policy, host) {
+	case HostDistanceLocal:
+		return p.numConns
+	case HostDistanceRemote:
+		if p.remoteConns <= 0 {
+			return p.numConns
+		}
+		return p.remoteConns
+	}
+	return 0
+}
+
 func (p *policyConnPool) Size() int {
 	p.mu.RLock()
 	count := 0
@@ -215,6 +240,29 @@ func (p *policyConnPool) Size() int {
 	return count
 }
 
+func (p *policyConnPool) getKeyspace() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.keyspace
+}
+
+// setKeyspace makes the connections opened from now on use keyspace and
+// returns the connections of the pools opened so far.
+func (p *policyConnPool) setKeyspace(keyspace string) []*Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keyspace = keyspace
+	var conns []*Conn
+	for _, pool := range p.hostConnPools {
+		pool.mu.Lock()
+		pool.keyspace = keyspace
+		conns = append(conns, pool.conns...)
+		pool.mu.Unlock()
+	}
+	return conns
+}
+
 func (p *policyConnPool) getPool(host *HostInfo) (pool *hostConnPool, ok bool) {
 	hostID := host.HostID()
 	p.mu.RLock()
@@ -235,6 +283,11 @@ func (p *policyConnPool) Close() {
 }
 
 func (p *policyConnPool) addHost(host *HostInfo) {
+	size := p.size(host)
+	if size == 0 {
+		return
+	}
+
 	hostID := host.HostID()
 	p.mu.Lock()
 	pool, ok := p.hostConnPools[hostID]
@@ -243,7 +296,7 @@ func (p *policyConnPool) addHost(host *HostInfo) {
 			p.session,
 			host,
 			host.Port(), // TODO: if port == 0 use pool.port?
-			p.numConns,
+			size,
 			p.keyspace,
 		)
 
@@ -268,19 +321,40 @@ func (p *policyConnPool) removeHost(hostID string) {
 	go pool.Close()
 }
 
+// drainHost removes the pool of the host like removeHost, but closes its
+// connections once the requests in flight on them are done, or after timeout,
+// for hosts which are leaving the cluster gracefully.
+func (p *policyConnPool) drainHost(hostID string, timeout time.Duration) {
+	p.mu.Lock()
+	pool, ok := p.hostConnPools[hostID]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	delete(p.hostConnPools, hostID)
+	p.mu.Unlock()
+
+	go pool.drain(timeout)
+}
+
 // hostConnPool is a connection pool for a single host.
 // Connection selection is based on a provided ConnSelectionPolicy
 type hostConnPool struct {
-	session  *Session
-	host     *HostInfo
-	port     int
-	size     int
+	session *Session
+	host    *HostInfo
+	port    int
+	size    int
+	// keyspace is protected by mu, see policyConnPool.setKeyspace.
 	keyspace string
 	// protection for conns, closed, filling
 	mu      sync.RWMutex
 	conns   []*Conn
 	closed  bool
 	filling bool
+	// draining is set once the pool is removed and waits for the requests in
+	// flight to close, see drain.
+	draining bool
 
 	pos    uint32
 	logger StdLogger
@@ -317,7 +391,7 @@ func (pool *hostConnPool) Pick() *Conn {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
-	if pool.closed {
+	if pool.closed || pool.draining {
 		return nil
 	}
 
@@ -350,6 +424,48 @@ func (pool *hostConnPool) Pick() *Conn {
 	return leastBusyConn
 }
 
+// drain stops picking and reconnecting the connections of the pool, and closes
+// them once they have no request in flight or after timeout.
+func (pool *hostConnPool) drain(timeout time.Duration) {
+	pool.mu.Lock()
+	pool.draining = true
+	pool.mu.Unlock()
+
+	if timeout <= 0 {
+		pool.Close()
+		return
+	}
+	clock := pool.session.cfg.clock()
+	deadline := clock.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := clock.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for pool.inFlight() > 0 {
+		select {
+		case <-ticker.C():
+		case <-deadline.C():
+			pool.Close()
+			return
+		case <-pool.session.ctx.Done():
+			pool.Close()
+			return
+		}
+	}
+	pool.Close()
+}
+
+// inFlight returns the number of requests in flight on the connections of the
+// pool.
+func (pool *hostConnPool) inFlight() int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	n := 0
+	for _, conn := range pool.conns {
+		n += conn.inFlight()
+	}
+	return n
+}
+
 // Size returns the number of connections currently active in the pool
 func (pool *hostConnPool) Size() int {
 	pool.mu.RLock()
@@ -382,6 +498,9 @@ func (pool *hostConnPool) Close() {
 	pool.conns = nil
 
 	pool.mu.Unlock()
+	if len(conns) > 0 {
+		pool.recordSize(0)
+	}
 
 	// close the connections
 	for _, conn := range conns {
@@ -392,8 +511,8 @@ func (pool *hostConnPool) Close() {
 // Fill the connection pool
 func (pool *hostConnPool) fill() {
 	pool.mu.RLock()
-	// avoid filling a closed pool, or concurrent filling
-	if pool.closed || pool.filling {
+	// avoid filling a closed or draining pool, or concurrent filling
+	if pool.closed || pool.draining || pool.filling {
 		pool.mu.RUnlock()
 		return
 	}
@@ -466,21 +585,17 @@ func (pool *hostConnPool) logConnectErr(err error) {
 	if opErr, ok := err.(*net.OpError); ok && (opErr.Op == "dial" || opErr.Op == "read") {
 		// connection refused
 		// these are typical during a node outage so avoid log spam.
-		if gocqlDebug {
-			pool.logger.Printf("gocql: unable to dial %q: %v\n", pool.host, err)
-		}
+		logDebug(pool.logger, "unable to dial", LogField{"host", pool.host}, LogField{"err", err})
 	} else if err != nil {
 		// unexpected error
-		pool.logger.Printf("error: failed to connect to %q due to error: %v", pool.host, err)
+		logWarn(pool.logger, "failed to connect", LogField{"host", pool.host}, LogField{"err", err})
 	}
 }
 
 // transition back to a not-filling state.
 func (pool *hostConnPool) fillingStopped(err error) {
 	if err != nil {
-		if gocqlDebug {
-			pool.logger.Printf("gocql: filling stopped %q: %v\n", pool.host.ConnectAddress(), err)
-		}
+		logDebug(pool.logger, "filling stopped", LogField{"host", pool.host.ConnectAddress()}, LogField{"err", err})
 		// wait for some time to avoid back-to-back filling
 		// this provides some time between failed attempts
 		// to fill the pool for the host to recover
@@ -496,9 +611,7 @@ func (pool *hostConnPool) fillingStopped(err error) {
 
 	// if we errored and the size is now zero, make sure the host is marked as down
 	// see https://github.com/gocql/gocql/issues/1614
-	if gocqlDebug {
-		pool.logger.Printf("gocql: conns of pool after stopped %q: %v\n", host.ConnectAddress(), count)
-	}
+	logDebug(pool.logger, "conns of pool after stopped", LogField{"host", host.ConnectAddress()}, LogField{"conns", count})
 	if err != nil && count == 0 {
 		if pool.session.cfg.ConvictionPolicy.AddFailure(err, host) {
 			pool.session.handleNodeDown(host.ConnectAddress(), port)
@@ -554,10 +667,8 @@ func (pool *hostConnPool) connect() (err error) {
 				break
 			}
 		}
-		if gocqlDebug {
-			pool.logger.Printf("gocql: connection failed %q: %v, reconnecting with %T\n",
-				pool.host.ConnectAddress(), err, reconnectionPolicy)
-		}
+		logDebug(pool.logger, "connection failed, reconnecting", LogField{"host", pool.host.ConnectAddress()},
+			LogField{"err", err}, LogField{"policy", fmt.Sprintf("%T", reconnectionPolicy)})
 		time.Sleep(reconnectionPolicy.GetInterval(i))
 	}
 
@@ -565,26 +676,47 @@ func (pool *hostConnPool) connect() (err error) {
 		return err
 	}
 
-	if pool.keyspace != "" {
-		// set the keyspace
-		if err = conn.UseKeyspace(pool.keyspace); err != nil {
-			conn.Close()
-			return err
+	pool.mu.RLock()
+	keyspace := pool.keyspace
+	pool.mu.RUnlock()
+	for {
+		if keyspace != "" {
+			// set the keyspace
+			if err = conn.UseKeyspace(keyspace); err != nil {
+				conn.Close()
+				return err
+			}
 		}
-	}
+		pool.session.prepareStatements(conn)
 
-	// add the Conn to the pool
-	pool.mu.Lock()
-	defer pool.mu.Unlock()
+		// add the Conn to the pool
+		pool.mu.Lock()
+		if pool.closed {
+			pool.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		if pool.keyspace != keyspace {
+			// the keyspace of the session changed in the meantime
+			keyspace = pool.keyspace
+			pool.mu.Unlock()
+			continue
+		}
 
-	if pool.closed {
-		conn.Close()
+		pool.conns = append(pool.conns, conn)
+		size := len(pool.conns)
+		pool.mu.Unlock()
+		pool.recordSize(size)
 		return nil
 	}
+}
 
-	pool.conns = append(pool.conns, conn)
-
-	return nil
+// recordSize records the number of connections of the pool, see
+// MetricsRecorder.RecordPoolSize.
+func (pool *hostConnPool) recordSize(conns int) {
+	if metrics := pool.session.cfg.MetricsRecorder; metrics != nil {
+		metrics.RecordPoolSize(pool.host, conns)
+	}
 }
 
 // handle any error from a Conn
@@ -604,8 +736,9 @@ func (pool *hostConnPool) HandleError(conn *Conn, err error, closed bool) {
 		return
 	}
 
-	if gocqlDebug {
-		pool.logger.Printf("gocql: pool connection error %q: %v\n", conn.addr, err)
+	logDebug(pool.logger, "pool connection error", LogField{"host", conn.addr}, LogField{"err", err})
+	if metrics := pool.session.cfg.MetricsRecorder; metrics != nil && err != nil {
+		metrics.RecordConnectionError(pool.host, err)
 	}
 
 	// find the connection index
@@ -613,6 +746,7 @@ func (pool *hostConnPool) HandleError(conn *Conn, err error, closed bool) {
 		if candidate == conn {
 			// remove the connection, not preserving order
 			pool.conns[i], pool.conns = pool.conns[len(pool.conns)-1], pool.conns[:len(pool.conns)-1]
+			pool.recordSize(len(pool.conns))
 
 			// lost a connection, so fill the pool
 			go pool.fill()
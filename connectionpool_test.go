@@ -4,8 +4,11 @@
 package gocql
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"testing"
+	"time"
 )
 
 func TestSetupTLSConfig(t *testing.T) {
@@ -83,3 +86,68 @@ func TestSetupTLSConfig(t *testing.T) {
 		})
 	}
 }
+
+func newDrainTestConn(t *testing.T, inFlight int) *Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{conn: client, ctx: ctx, cancel: cancel, calls: make(map[int]*callReq)}
+	for i := 0; i < inFlight; i++ {
+		c.calls[i] = &callReq{}
+	}
+	return c
+}
+
+func (c *Conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestHostConnPoolDrain(t *testing.T) {
+	conn := newDrainTestConn(t, 1)
+	session := &Session{ctx: context.Background()}
+	pool := &hostConnPool{session: session, conns: []*Conn{conn}}
+
+	done := make(chan struct{})
+	go func() {
+		pool.drain(time.Minute)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if c := pool.Pick(); c != nil {
+		t.Fatal("picked a connection of a draining pool")
+	}
+	if conn.isClosed() {
+		t.Fatal("connection closed with a request in flight")
+	}
+
+	conn.mu.Lock()
+	delete(conn.calls, 0)
+	conn.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("pool not closed once the requests in flight were done")
+	}
+	if !conn.isClosed() {
+		t.Fatal("connection not closed")
+	}
+}
+
+func TestHostConnPoolDrainTimeout(t *testing.T) {
+	conn := newDrainTestConn(t, 1)
+	session := &Session{ctx: context.Background()}
+	pool := &hostConnPool{session: session, conns: []*Conn{conn}}
+
+	start := time.Now()
+	pool.drain(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("pool closed after %v, before the timeout", elapsed)
+	}
+	if !conn.isClosed() {
+		t.Fatal("connection not closed after the timeout")
+	}
+}
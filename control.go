@@ -10,6 +10,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -38,6 +39,12 @@ const (
 // Ensure that the atomic variable is aligned to a 64bit boundary
 // so that atomic operations can be applied on 32bit architectures.
 type controlConn struct {
+	// lastEvent is the time of the last event received, in nanoseconds
+	// since the epoch.
+	lastEvent         int64
+	reconnectAttempts int64
+	failedReconnects  int64
+
 	state        int32
 	reconnecting int32
 
@@ -105,17 +112,30 @@ func (c *controlConn) heartBeat() {
 
 var hostLookupPreferV4 = os.Getenv("GOCQL_HOST_LOOKUP_PREFER_V4") == "true"
 
-func hostInfo(addr string, defaultPort int) ([]*HostInfo, error) {
-	var port int
+// splitContactPoint splits a contact point into its host and port, which is
+// defaultPort if addr has none. IPv6 literals are accepted with or without
+// brackets when they have no port, such as ::1 or [::1], and in brackets
+// otherwise, such as [::1]:9042.
+func splitContactPoint(addr string, defaultPort int) (string, int, error) {
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
 		host = addr
-		port = defaultPort
-	} else {
-		port, err = strconv.Atoi(portStr)
-		if err != nil {
-			return nil, err
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
 		}
+		return host, defaultPort, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q in contact point %q", portStr, addr)
+	}
+	return host, port, nil
+}
+
+func hostInfo(addr string, defaultPort int) ([]*HostInfo, error) {
+	host, port, err := splitContactPoint(addr, defaultPort)
+	if err != nil {
+		return nil, err
 	}
 
 	var hosts []*HostInfo
@@ -188,11 +208,18 @@ func parseProtocolFromError(err error) int {
 	return max
 }
 
+// maxDiscoveredProtocol is the highest protocol version discoverProtocol
+// attempts.
+const maxDiscoveredProtocol = protoVersion4
+
+// discoverProtocol returns the highest protocol version supported by the first
+// host connected to. Versions are attempted from maxDiscoveredProtocol, or 5
+// with ExperimentProtocolV5, down, moving to the version suggested by the
+// error of the server if any.
 func (c *controlConn) discoverProtocol(hosts []*HostInfo) (int, error) {
 	hosts = shuffleHosts(hosts)
 
 	connCfg := *c.session.connCfg
-	connCfg.ProtoVersion = 4 // TODO: define maxProtocol
 
 	handler := connErrorHandlerFn(func(c *Conn, err error, closed bool) {
 		// we should never get here, but if we do it means we connected to a
@@ -204,24 +231,68 @@ func (c *controlConn) discoverProtocol(hosts []*HostInfo) (int, error) {
 
 	var err error
 	for _, host := range hosts {
-		var conn *Conn
-		conn, err = c.session.dial(c.session.ctx, host, &connCfg, handler)
-		if conn != nil {
-			conn.Close()
+		proto := maxDiscoveredProtocol
+		if c.session.ExperimentEnabled(ExperimentProtocolV5) {
+			proto = protoVersion5
 		}
+		for proto >= protoVersion1 {
+			connCfg.ProtoVersion = proto
+			var conn *Conn
+			conn, err = c.session.dial(c.session.ctx, host, &connCfg, handler)
+			if conn != nil {
+				conn.Close()
+			}
 
-		if err == nil {
-			return connCfg.ProtoVersion, nil
-		}
+			if err == nil {
+				return proto, nil
+			}
 
-		if proto := parseProtocolFromError(err); proto > 0 {
-			return proto, nil
+			next := parseProtocolFromError(err)
+			if next <= 0 {
+				// not a protocol error, try the next host
+				break
+			} else if next >= proto {
+				next = proto - 1
+			}
+			proto = next
 		}
 	}
 
 	return 0, err
 }
 
+// highestProtocol returns the highest protocol version supported by a version
+// of Cassandra, or 0 if the version is unknown.
+func highestProtocol(v cassVersion) int {
+	switch {
+	case v == cassVersion{}:
+		return 0
+	case v.Before(2, 0, 0):
+		return protoVersion1
+	case v.Before(2, 1, 0):
+		return protoVersion2
+	case v.Before(2, 2, 0):
+		return protoVersion3
+	case v.Before(4, 0, 0):
+		return protoVersion4
+	default:
+		return protoVersion5
+	}
+}
+
+// clusterProtocol returns the highest protocol version supported by all hosts
+// of known versions, so that nodes not yet upgraded can be connected to in
+// clusters of mixed versions, or 0 if no version is known.
+func clusterProtocol(hosts []*HostInfo) int {
+	proto := 0
+	for _, host := range hosts {
+		if p := highestProtocol(host.Version()); p > 0 && (proto == 0 || p < proto) {
+			proto = p
+		}
+	}
+	return proto
+}
+
 func (c *controlConn) connect(hosts []*HostInfo) error {
 	if len(hosts) == 0 {
 		return errors.New("control: no endpoints specified")
@@ -237,21 +308,24 @@ func (c *controlConn) connect(hosts []*HostInfo) error {
 	var conn *Conn
 	var err error
 	for _, host := range hosts {
+		start := time.Now()
 		conn, err = c.session.dial(c.session.ctx, host, &cfg, c)
 		if err != nil {
-			c.session.logger.Printf("gocql: unable to dial control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+			logWarn(c.session.logger, "unable to dial control conn", LogField{"host", host.ConnectAddressAndPort()}, LogField{"err", err})
+			c.observe(host, start, false, err)
 			continue
 		}
 		err = c.setupConn(conn)
+		c.observe(host, start, false, err)
 		if err == nil {
 			break
 		}
-		c.session.logger.Printf("gocql: unable setup control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+		logWarn(c.session.logger, "unable setup control conn", LogField{"host", host.ConnectAddressAndPort()}, LogField{"err", err})
 		conn.Close()
 		conn = nil
 	}
 	if conn == nil {
-		return fmt.Errorf("unable to connect to initial hosts: %v", err)
+		return fmt.Errorf("unable to connect to initial hosts: %w", err)
 	}
 
 	// we could fetch the initial ring here and update initial host data. So that
@@ -280,6 +354,9 @@ func (c *controlConn) setupConn(conn *Conn) error {
 	if c.session.cfg.filterHost(host) {
 		return fmt.Errorf("host was filtered: %v", host.ConnectAddress())
 	}
+	if c.session.cfg.filterControlHost(host) {
+		return fmt.Errorf("host was filtered for control connection: %v", host.ConnectAddress())
+	}
 
 	if err := c.registerEvents(conn); err != nil {
 		return fmt.Errorf("register events: %v", err)
@@ -347,22 +424,35 @@ func (c *controlConn) reconnect() {
 	}
 	defer atomic.StoreInt32(&c.reconnecting, 0)
 
+	atomic.AddInt64(&c.reconnectAttempts, 1)
 	conn, err := c.attemptReconnect()
 
 	if conn == nil {
-		c.session.logger.Printf("gocql: unable to reconnect control connection: %v\n", err)
+		atomic.AddInt64(&c.failedReconnects, 1)
+		logError(c.session.logger, "unable to reconnect control connection", LogField{"err", err})
 		return
 	}
 
 	err = c.session.refreshRing()
 	if err != nil {
-		c.session.logger.Printf("gocql: unable to refresh ring: %v\n", err)
+		logError(c.session.logger, "unable to refresh ring", LogField{"err", err})
 	}
 }
 
 func (c *controlConn) attemptReconnect() (*Conn, error) {
 	hosts := c.session.ring.allHosts()
 	hosts = shuffleHosts(hosts)
+	if c.session.cfg.ControlConnHostFilter != nil {
+		// skip the known hosts the control connection may not use, instead of
+		// dialing them only to reject them in setupConn
+		filtered := hosts[:0]
+		for _, host := range hosts {
+			if !c.session.cfg.filterControlHost(host) {
+				filtered = append(filtered, host)
+			}
+		}
+		hosts = filtered
+	}
 
 	// keep the old behavior of connecting to the old host first by moving it to
 	// the front of the slice
@@ -383,8 +473,8 @@ func (c *controlConn) attemptReconnect() (*Conn, error) {
 		return conn, err
 	}
 
-	c.session.logger.Printf("gocql: unable to connect to any ring node: %v\n", err)
-	c.session.logger.Printf("gocql: control falling back to initial contact points.\n")
+	logWarn(c.session.logger, "unable to connect to any ring node, control falling back to initial contact points",
+		LogField{"err", err})
 	// Fallback to initial contact points, as it may be the case that all known initialHosts
 	// changed their IPs while keeping the same hostname(s).
 	initialHosts, resolvErr := addrsToHosts(c.session.cfg.Hosts, c.session.cfg.Port, c.session.logger)
@@ -399,16 +489,19 @@ func (c *controlConn) attemptReconnectToAnyOfHosts(hosts []*HostInfo) (*Conn, er
 	var conn *Conn
 	var err error
 	for _, host := range hosts {
+		start := time.Now()
 		conn, err = c.session.connect(c.session.ctx, host, c)
 		if err != nil {
-			c.session.logger.Printf("gocql: unable to dial control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+			logWarn(c.session.logger, "unable to dial control conn", LogField{"host", host.ConnectAddressAndPort()}, LogField{"err", err})
+			c.observe(host, start, true, err)
 			continue
 		}
 		err = c.setupConn(conn)
+		c.observe(host, start, true, err)
 		if err == nil {
 			break
 		}
-		c.session.logger.Printf("gocql: unable setup control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+		logWarn(c.session.logger, "unable setup control conn", LogField{"host", host.ConnectAddressAndPort()}, LogField{"err", err})
 		conn.Close()
 		conn = nil
 	}
@@ -431,6 +524,25 @@ func (c *controlConn) HandleError(conn *Conn, err error, closed bool) {
 	c.reconnect()
 }
 
+func (c *controlConn) observe(host *HostInfo, start time.Time, reconnect bool, err error) {
+	observer := c.session.cfg.ControlConnObserver
+	if observer == nil {
+		return
+	}
+	observer.ObserveControlConn(ObservedControlConn{
+		Host:      host,
+		Reconnect: reconnect,
+		Start:     start,
+		End:       time.Now(),
+		Err:       err,
+	})
+}
+
+// eventReceived records that an event was received on the control connection.
+func (c *controlConn) eventReceived() {
+	atomic.StoreInt64(&c.lastEvent, time.Now().UnixNano())
+}
+
 func (c *controlConn) getConn() *connHost {
 	return c.conn.Load().(*connHost)
 }
@@ -489,8 +601,8 @@ func (c *controlConn) query(statement string, values ...interface{}) (iter *Iter
 			return conn.executeQuery(context.TODO(), q)
 		})
 
-		if gocqlDebug && iter.err != nil {
-			c.session.logger.Printf("control: error executing %q: %v\n", statement, iter.err)
+		if iter.err != nil {
+			logDebug(c.session.logger, "control: error executing statement", LogField{"statement", statement}, LogField{"err", iter.err})
 		}
 
 		q.AddAttempts(1, c.getConn().host)
@@ -520,3 +632,66 @@ func (c *controlConn) close() {
 }
 
 var errNoControl = errors.New("gocql: no control connection available")
+
+// ObservedControlConn is an attempt of the control connection to connect to a
+// host.
+type ObservedControlConn struct {
+	// Host is the host connected to. It is the contact point the connection was
+	// attempted to, which has no topology information until connected.
+	Host *HostInfo
+
+	// Reconnect is true if the control connection was lost and is being
+	// reconnected, false when the session is created.
+	Reconnect bool
+
+	Start time.Time // time immediately before the dial is called
+	End   time.Time // time immediately after the connection was set up
+
+	// Err is the error of the attempt, nil if the control connection now uses
+	// Host.
+	Err error
+}
+
+// ControlConnObserver is the interface implemented by control connection
+// observers.
+type ControlConnObserver interface {
+	// ObserveControlConn gets called after every attempt of the control
+	// connection to connect to a host.
+	ObserveControlConn(ObservedControlConn)
+}
+
+// ControlConnState is the state of the control connection of a session, which
+// receives the topology, status and schema events of the cluster.
+type ControlConnState struct {
+	// Host is the host of the control connection, nil if it isn't connected.
+	Host *HostInfo
+	// LastEvent is the time the last event was received, zero if none was.
+	LastEvent time.Time
+	// ReconnectAttempts is the number of times the control connection was
+	// lost and reconnected, FailedReconnects the number of times it couldn't
+	// connect to any host.
+	ReconnectAttempts int64
+	FailedReconnects  int64
+}
+
+func (c *controlConn) connState() ControlConnState {
+	var state ControlConnState
+	if ch := c.getConn(); ch != nil && !ch.conn.Closed() {
+		state.Host = ch.host
+	}
+	if nanos := atomic.LoadInt64(&c.lastEvent); nanos != 0 {
+		state.LastEvent = time.Unix(0, nanos)
+	}
+	state.ReconnectAttempts = atomic.LoadInt64(&c.reconnectAttempts)
+	state.FailedReconnects = atomic.LoadInt64(&c.failedReconnects)
+	return state
+}
+
+// ControlConnState returns the state of the control connection of the
+// session, the zero value if the session has no control connection.
+func (s *Session) ControlConnState() ControlConnState {
+	if s.control == nil {
+		return ControlConnState{}
+	}
+	return s.control.connState()
+}
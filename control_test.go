@@ -31,6 +31,74 @@ func TestHostInfo_Lookup(t *testing.T) {
 	}
 }
 
+func TestSplitContactPoint(t *testing.T) {
+	tests := [...]struct {
+		addr string
+		host string
+		port int
+	}{
+		{"10.0.0.1", "10.0.0.1", 9042},
+		{"10.0.0.1:9043", "10.0.0.1", 9043},
+		{"cassandra.local:19042", "cassandra.local", 19042},
+		{"::1", "::1", 9042},
+		{"[::1]", "::1", 9042},
+		{"[2001:db8::1]:9043", "2001:db8::1", 9043},
+	}
+	for _, test := range tests {
+		host, port, err := splitContactPoint(test.addr, 9042)
+		if err != nil {
+			t.Errorf("%q: %v", test.addr, err)
+		} else if host != test.host || port != test.port {
+			t.Errorf("%q: expected %s and %d, got %s and %d", test.addr, test.host, test.port, host, port)
+		}
+	}
+
+	for _, addr := range []string{"10.0.0.1:port", "10.0.0.1:0", "[::1]:70000"} {
+		if _, _, err := splitContactPoint(addr, 9042); err == nil {
+			t.Errorf("%q: expected an invalid port", addr)
+		}
+	}
+}
+
+func TestContactPorts(t *testing.T) {
+	hosts, err := addrsToHosts([]string{"10.0.0.1:9043", "10.0.0.2", "10.0.0.3:9043", "10.0.0.3:9044"}, 9042, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports := contactPorts(hosts)
+	if len(ports) != 2 || ports["10.0.0.1"] != 9043 || ports["10.0.0.2"] != 9042 {
+		t.Fatalf("unexpected ports %v", ports)
+	}
+}
+
+func TestClusterProtocol(t *testing.T) {
+	host := func(version string) *HostInfo {
+		h := &HostInfo{}
+		if err := h.version.Set(version); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	tests := [...]struct {
+		hosts []*HostInfo
+		proto int
+	}{
+		{nil, 0},
+		{[]*HostInfo{host("")}, 0},
+		{[]*HostInfo{host("3.11.4"), host("")}, 4},
+		{[]*HostInfo{host("3.11.4"), host("4.0.1")}, 4},
+		{[]*HostInfo{host("4.1.0")}, 5},
+		{[]*HostInfo{host("2.2.19"), host("2.1.22"), host("2.2.19")}, 3},
+		{[]*HostInfo{host("2.0.17")}, 2},
+		{[]*HostInfo{host("1.2.19")}, 1},
+	}
+	for i, test := range tests {
+		if proto := clusterProtocol(test.hosts); proto != test.proto {
+			t.Errorf("%d: expected protocol %d, got %d", i, test.proto, proto)
+		}
+	}
+}
+
 func TestParseProtocol(t *testing.T) {
 	tests := [...]struct {
 		err   error
@@ -0,0 +1,81 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type controlConnRecorder struct {
+	mu       sync.Mutex
+	attempts []gocql.ObservedControlConn
+}
+
+func (r *controlConnRecorder) ObserveControlConn(o gocql.ObservedControlConn) {
+	r.mu.Lock()
+	r.attempts = append(r.attempts, o)
+	r.mu.Unlock()
+}
+
+func (r *controlConnRecorder) observed() []gocql.ObservedControlConn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]gocql.ObservedControlConn(nil), r.attempts...)
+}
+
+func TestControlConnState(t *testing.T) {
+	srv := gocqltest.NewServer()
+	t.Cleanup(srv.Close)
+
+	recorder := &controlConnRecorder{}
+	cluster := srv.ClusterConfig()
+	cluster.ControlConnObserver = recorder
+	cluster.ControlConnHostFilter = gocql.DataCentreHostFilter("datacenter1")
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	state := session.ControlConnState()
+	if state.Host == nil || state.Host.DataCenter() != "datacenter1" {
+		t.Fatalf("expected the control connection to be connected to datacenter1, got %+v", state.Host)
+	}
+	if state.ReconnectAttempts != 0 || state.FailedReconnects != 0 {
+		t.Fatalf("unexpected reconnects: %+v", state)
+	}
+
+	attempts := recorder.observed()
+	if len(attempts) != 1 {
+		t.Fatalf("expected 1 connection attempt, got %d", len(attempts))
+	}
+	if o := attempts[0]; o.Err != nil || o.Reconnect || o.End.Before(o.Start) {
+		t.Fatalf("unexpected connection attempt: %+v", o)
+	}
+
+	session.Close()
+	if state := session.ControlConnState(); state.Host != nil {
+		t.Fatalf("expected no control connection host after close, got %v", state.Host)
+	}
+}
+
+func TestControlConnHostFilter(t *testing.T) {
+	srv := gocqltest.NewServer()
+	t.Cleanup(srv.Close)
+
+	recorder := &controlConnRecorder{}
+	cluster := srv.ClusterConfig()
+	cluster.ControlConnObserver = recorder
+	cluster.ControlConnHostFilter = gocql.DataCentreHostFilter("datacenter2")
+	if session, err := cluster.CreateSession(); err == nil {
+		session.Close()
+		t.Fatal("expected the session to fail without a host the control connection may use")
+	}
+
+	attempts := recorder.observed()
+	if len(attempts) != 1 || attempts[0].Err == nil {
+		t.Fatalf("expected 1 failed connection attempt, got %+v", attempts)
+	}
+}
@@ -0,0 +1,475 @@
+package gocql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// parsedStatement is what parseStatement extracts from the text of a
+// statement: its table and the terms its columns are set or restricted to.
+type parsedStatement struct {
+	keyspace string
+	table    string
+	// columns are the terms the columns are set to by an INSERT or restricted
+	// to by an equality of the WHERE clause.
+	columns map[string]cqlTerm
+}
+
+// cqlTerm is a bind marker or a literal of a statement.
+type cqlTerm struct {
+	// bind is the position of the bind marker among the markers of the
+	// statement, -1 for a literal.
+	bind    int
+	literal interface{}
+}
+
+type cqlTokenKind int
+
+const (
+	cqlIdentifier cqlTokenKind = iota
+	cqlString
+	cqlNumber
+	cqlUUID
+	cqlBlob
+	cqlMarker
+	cqlSymbol
+)
+
+type cqlToken struct {
+	kind cqlTokenKind
+	// text is the name of identifiers, lower cased unless they are quoted,
+	// the value of strings and the text of the other tokens.
+	text   string
+	quoted bool
+	// bind is the position of markers.
+	bind int
+}
+
+// cqlTokens classifies the tokens of stmt, see tokenizeStmt. ok is false if
+// stmt has an unterminated string, quoted identifier or comment.
+func cqlTokens(stmt string) (tokens []cqlToken, ok bool) {
+	stmtTokens, ok := tokenizeStmt(stmt)
+	if !ok {
+		return nil, false
+	}
+	tokens = make([]cqlToken, len(stmtTokens))
+	var markers int
+	for i := range stmtTokens {
+		tok := &stmtTokens[i]
+		text := tok.text
+		switch {
+		case tok.isMarker():
+			tokens[i] = cqlToken{kind: cqlMarker, text: text, bind: markers}
+			markers++
+		case text[0] == '\'':
+			tokens[i] = cqlToken{kind: cqlString, text: strings.ReplaceAll(text[1:len(text)-1], "''", "'")}
+		case strings.HasPrefix(text, "$$"):
+			tokens[i] = cqlToken{kind: cqlString, text: text[2 : len(text)-2]}
+		case text[0] == '"':
+			tokens[i] = cqlToken{kind: cqlIdentifier, text: strings.ReplaceAll(text[1:len(text)-1], `""`, `"`), quoted: true}
+		case len(text) == 36 && isCQLUUID(text):
+			tokens[i] = cqlToken{kind: cqlUUID, text: text}
+		case len(text) > 1 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X') && isCQLHex(text[2:]):
+			tokens[i] = cqlToken{kind: cqlBlob, text: text}
+		case isCQLDigit(text[0]) || len(text) > 1 && (text[0] == '-' || text[0] == '.'):
+			tokens[i] = cqlToken{kind: cqlNumber, text: text}
+		case isCQLNameChar(text[0]):
+			tokens[i] = cqlToken{kind: cqlIdentifier, text: strings.ToLower(text)}
+		default:
+			tokens[i] = cqlToken{kind: cqlSymbol, text: text}
+		}
+	}
+	return tokens, true
+}
+
+func isCQLHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isCQLDigit(c) && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isCQLUUID reports whether s, 36 bytes long, is a UUID literal.
+func isCQLUUID(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isCQLHex(s[i : i+1]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cqlParser parses the tokens of a statement.
+type cqlParser struct {
+	tokens []cqlToken
+	pos    int
+}
+
+func (p *cqlParser) peek(n int) *cqlToken {
+	if p.pos+n >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos+n]
+}
+
+func isKeyword(t *cqlToken, keywords ...string) bool {
+	if t == nil || t.kind != cqlIdentifier || t.quoted {
+		return false
+	}
+	for _, k := range keywords {
+		if t.text == k {
+			return true
+		}
+	}
+	return false
+}
+
+func isSymbol(t *cqlToken, symbol string) bool {
+	return t != nil && t.kind == cqlSymbol && t.text == symbol
+}
+
+// keyword consumes the next token if it is the keyword k.
+func (p *cqlParser) keyword(k string) bool {
+	if isKeyword(p.peek(0), k) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it is the symbol s.
+func (p *cqlParser) symbol(s string) bool {
+	if isSymbol(p.peek(0), s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipTo skips the tokens up to one of the keywords out of parentheses,
+// brackets and braces, and consumes it. It returns false if there is none.
+func (p *cqlParser) skipTo(keywords ...string) bool {
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		t := &p.tokens[p.pos]
+		switch {
+		case isSymbol(t, "(") || isSymbol(t, "[") || isSymbol(t, "{"):
+			depth++
+		case isSymbol(t, ")") || isSymbol(t, "]") || isSymbol(t, "}"):
+			depth--
+		case depth == 0 && isKeyword(t, keywords...):
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// tableName consumes a table name, qualified by its keyspace or not.
+func (p *cqlParser) tableName() (keyspace, table string, ok bool) {
+	t := p.peek(0)
+	if t == nil || t.kind != cqlIdentifier {
+		return "", "", false
+	}
+	p.pos++
+	if !isSymbol(p.peek(0), ".") {
+		return "", t.text, true
+	}
+	name := p.peek(1)
+	if name == nil || name.kind != cqlIdentifier {
+		return "", "", false
+	}
+	p.pos += 2
+	return t.text, name.text, true
+}
+
+// term returns the term of the token t, ok is false if it is not a bind marker
+// or a literal of a native type.
+func (t *cqlToken) term() (term cqlTerm, ok bool) {
+	if t == nil {
+		return cqlTerm{}, false
+	}
+	term.bind = -1
+	switch t.kind {
+	case cqlMarker:
+		term.bind = t.bind
+	case cqlString:
+		term.literal = t.text
+	case cqlNumber:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			term.literal = n
+		} else if n, ok := new(big.Int).SetString(t.text, 10); ok {
+			term.literal = n
+		} else if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+			term.literal = f
+		} else {
+			return cqlTerm{}, false
+		}
+	case cqlUUID:
+		u, err := ParseUUID(t.text)
+		if err != nil {
+			return cqlTerm{}, false
+		}
+		term.literal = u
+	case cqlBlob:
+		b, err := hex.DecodeString(t.text[2:])
+		if err != nil {
+			return cqlTerm{}, false
+		}
+		term.literal = b
+	case cqlIdentifier:
+		if !isKeyword(t, "true", "false") {
+			return cqlTerm{}, false
+		}
+		term.literal = t.text == "true"
+	default:
+		return cqlTerm{}, false
+	}
+	return term, true
+}
+
+// relations parses a WHERE clause and records the columns restricted by an
+// equality to a single term. The conditions of lightweight transactions are
+// not restrictions.
+func (p *cqlParser) relations(columns map[string]cqlTerm) {
+	for {
+		name, eq := p.peek(0), p.peek(1)
+		if name != nil && name.kind == cqlIdentifier && isSymbol(eq, "=") {
+			next := p.peek(3)
+			end := next == nil || isSymbol(next, ";") ||
+				isKeyword(next, "and", "if", "order", "group", "per", "limit", "allow")
+			if term, ok := p.peek(2).term(); ok && end {
+				if _, seen := columns[name.text]; !seen {
+					columns[name.text] = term
+				}
+			}
+		}
+		if !p.skipTo("and", "if", "order", "group", "per", "limit", "allow") {
+			return
+		}
+		if !isKeyword(&p.tokens[p.pos-1], "and") {
+			return
+		}
+	}
+}
+
+// insertValues parses the columns and values of an INSERT statement and
+// records the columns set to a single term.
+func (p *cqlParser) insertValues(columns map[string]cqlTerm) bool {
+	if !p.symbol("(") {
+		return false
+	}
+	var names []string
+	for {
+		t := p.peek(0)
+		if t == nil || t.kind != cqlIdentifier {
+			return false
+		}
+		names = append(names, t.text)
+		p.pos++
+		if p.symbol(")") {
+			break
+		}
+		if !p.symbol(",") {
+			return false
+		}
+	}
+	if !p.keyword("values") || !p.symbol("(") {
+		return false
+	}
+	for _, name := range names {
+		next := p.peek(1)
+		if term, ok := p.peek(0).term(); ok && (isSymbol(next, ",") || isSymbol(next, ")")) {
+			columns[name] = term
+			p.pos++
+		} else {
+			// skip the value, such as a collection or a function call
+			depth := 0
+			for ; p.pos < len(p.tokens); p.pos++ {
+				t := &p.tokens[p.pos]
+				if depth == 0 && (isSymbol(t, ",") || isSymbol(t, ")")) {
+					break
+				}
+				switch {
+				case isSymbol(t, "(") || isSymbol(t, "[") || isSymbol(t, "{"):
+					depth++
+				case isSymbol(t, ")") || isSymbol(t, "]") || isSymbol(t, "}"):
+					depth--
+				}
+			}
+		}
+		if !p.symbol(",") {
+			break
+		}
+	}
+	return true
+}
+
+// parseStatement extracts the table of a SELECT, INSERT, UPDATE or DELETE
+// statement, and the terms its columns are restricted to, without preparing
+// it. ok is false if stmt is another kind of statement or can't be parsed.
+func parseStatement(stmt string) (parsed *parsedStatement, ok bool) {
+	tokens, ok := cqlTokens(stmt)
+	if !ok {
+		return nil, false
+	}
+	p := &cqlParser{tokens: tokens}
+	parsed = &parsedStatement{columns: make(map[string]cqlTerm)}
+
+	switch {
+	case p.keyword("select"):
+		if !p.skipTo("from") {
+			return nil, false
+		}
+		if parsed.keyspace, parsed.table, ok = p.tableName(); !ok {
+			return nil, false
+		}
+		if p.skipTo("where") {
+			p.relations(parsed.columns)
+		}
+	case p.keyword("insert"):
+		if !p.keyword("into") {
+			return nil, false
+		}
+		if parsed.keyspace, parsed.table, ok = p.tableName(); !ok {
+			return nil, false
+		}
+		if !p.insertValues(parsed.columns) {
+			return nil, false
+		}
+	case p.keyword("update"):
+		if parsed.keyspace, parsed.table, ok = p.tableName(); !ok {
+			return nil, false
+		}
+		if p.skipTo("where") {
+			p.relations(parsed.columns)
+		}
+	case p.keyword("delete"):
+		if !p.skipTo("from") {
+			return nil, false
+		}
+		if parsed.keyspace, parsed.table, ok = p.tableName(); !ok {
+			return nil, false
+		}
+		if p.skipTo("where") {
+			p.relations(parsed.columns)
+		}
+	default:
+		return nil, false
+	}
+	return parsed, true
+}
+
+// routingKey returns the routing key of the partition of table the statement
+// is restricted to, with values bound to its markers, or nil if the statement
+// doesn't restrict every column of the partition key of the table or one of
+// its literals can't be marshalled.
+func (s *parsedStatement) routingKey(table *TableMetadata, values []interface{}) ([]byte, error) {
+	if len(table.PartitionKey) == 0 {
+		return nil, nil
+	}
+
+	encoded := make([][]byte, len(table.PartitionKey))
+	for i, column := range table.PartitionKey {
+		term, ok := s.columns[column.Name]
+		if !ok {
+			return nil, nil
+		}
+		if term.bind >= 0 {
+			if term.bind >= len(values) {
+				return nil, nil
+			}
+			b, err := Marshal(column.Type, values[term.bind])
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = b
+			continue
+		}
+		// a literal of another type than its column fails the statement,
+		// there is no routing key to report
+		b, err := Marshal(column.Type, term.literal)
+		if err != nil {
+			return nil, nil
+		}
+		encoded[i] = b
+	}
+
+	if len(encoded) == 1 {
+		return encoded[0], nil
+	}
+	// composite routing key, as in createRoutingKey
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	for _, b := range encoded {
+		lenBuf := []byte{0x00, 0x00}
+		binary.BigEndian.PutUint16(lenBuf, uint16(len(b)))
+		buf.Write(lenBuf)
+		buf.Write(b)
+		buf.WriteByte(0x00)
+	}
+	return buf.Bytes(), nil
+}
+
+// parsedRoutingKey computes the routing key of stmt from its text and the
+// metadata of its table, for the statements whose prepared metadata doesn't
+// describe their partition key, as when it is restricted by literals. keyspace
+// is the keyspace of tables which are not qualified by theirs. The table is nil
+// if the statement can't be parsed or its table is unknown, the key is nil if
+// it can't be determined.
+func (s *Session) parsedRoutingKey(stmt, keyspace string, values []interface{}) ([]byte, *TableMetadata, error) {
+	parsed := s.parsedStatement(stmt)
+	if parsed == nil {
+		return nil, nil, nil
+	}
+	if parsed.keyspace != "" {
+		keyspace = parsed.keyspace
+	}
+	if keyspace == "" {
+		return nil, nil, nil
+	}
+	// the routing key of statements which are not prepared is best effort,
+	// the lookup of the metadata failing leaves it unknown
+	keyspaceMetadata, err := s.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil, nil, nil
+	}
+	table, ok := keyspaceMetadata.Tables[parsed.table]
+	if !ok {
+		return nil, nil, nil
+	}
+	routingKey, err := parsed.routingKey(table, values)
+	return routingKey, table, err
+}
+
+// parsedStatement returns the cached parse of stmt, nil if it can't be
+// parsed.
+func (s *Session) parsedStatement(stmt string) *parsedStatement {
+	s.parsedStmtCache.mu.Lock()
+	cached, ok := s.parsedStmtCache.lru.Get(stmt)
+	s.parsedStmtCache.mu.Unlock()
+	if ok {
+		return cached.(*parsedStatement)
+	}
+
+	parsed, ok := parseStatement(stmt)
+	if !ok {
+		parsed = nil
+	}
+	s.parsedStmtCache.mu.Lock()
+	s.parsedStmtCache.lru.Add(stmt, parsed)
+	s.parsedStmtCache.mu.Unlock()
+	return parsed
+}
@@ -0,0 +1,139 @@
+package gocql
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql/internal/lru"
+)
+
+func TestParseStatement(t *testing.T) {
+	id, err := ParseUUID("550e8400-e29b-41d4-a716-446655440000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		stmt     string
+		keyspace string
+		table    string
+		columns  map[string]cqlTerm
+	}{
+		{
+			stmt:    `SELECT name, token(id) FROM users WHERE id = ? AND bucket = 3`,
+			table:   "users",
+			columns: map[string]cqlTerm{"id": {bind: 0}, "bucket": {bind: -1, literal: int64(3)}},
+		},
+		{
+			stmt:     `select * from KS."Users" where "Id" = 'it''s' and day > ? and kind IN (1, 2) limit 10`,
+			keyspace: "ks",
+			table:    "Users",
+			columns:  map[string]cqlTerm{"Id": {bind: -1, literal: "it's"}},
+		},
+		{
+			stmt:    `INSERT INTO users (id, tags, name, raw) VALUES (?, {'a', ?}, :name, 0xcafe) USING TTL ?`,
+			table:   "users",
+			columns: map[string]cqlTerm{"id": {bind: 0}, "name": {bind: 2}, "raw": {bind: -1, literal: []byte{0xca, 0xfe}}},
+		},
+		{
+			stmt:     `UPDATE ks.users USING TTL ? SET name = ? WHERE id = 550e8400-e29b-41d4-a716-446655440000 IF name = ?`,
+			keyspace: "ks",
+			table:    "users",
+			columns:  map[string]cqlTerm{"id": {bind: -1, literal: id}},
+		},
+		{
+			stmt:    "DELETE tags['a'] FROM users -- comment\nWHERE id = ? AND active = true;",
+			table:   "users",
+			columns: map[string]cqlTerm{"id": {bind: 0}, "active": {bind: -1, literal: true}},
+		},
+	}
+	for _, test := range tests {
+		parsed, ok := parseStatement(test.stmt)
+		if !ok {
+			t.Errorf("%s: expected the statement to be parsed", test.stmt)
+			continue
+		}
+		if parsed.keyspace != test.keyspace || parsed.table != test.table {
+			t.Errorf("%s: expected table %s.%s, got %s.%s", test.stmt, test.keyspace, test.table, parsed.keyspace, parsed.table)
+		}
+		if !reflect.DeepEqual(parsed.columns, test.columns) {
+			t.Errorf("%s: expected columns %v, got %v", test.stmt, test.columns, parsed.columns)
+		}
+	}
+
+	for _, stmt := range []string{
+		`TRUNCATE users`,
+		`BEGIN BATCH INSERT INTO users (id) VALUES (1) APPLY BATCH`,
+		`SELECT * FROM users WHERE name = 'unterminated`,
+	} {
+		if _, ok := parseStatement(stmt); ok {
+			t.Errorf("%s: expected the statement not to be parsed", stmt)
+		}
+	}
+}
+
+func TestParsedStatementRoutingKey(t *testing.T) {
+	table := &TableMetadata{
+		Keyspace: "ks",
+		Name:     "events",
+		PartitionKey: []*ColumnMetadata{
+			{Name: "id", Type: NativeType{proto: 4, typ: TypeText}},
+			{Name: "bucket", Type: NativeType{proto: 4, typ: TypeInt}},
+		},
+	}
+
+	parsed, ok := parseStatement(`SELECT * FROM events WHERE id = 'a' AND bucket = ?`)
+	if !ok {
+		t.Fatal("expected the statement to be parsed")
+	}
+	key, err := parsed.routingKey(table, []interface{}{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := createRoutingKey(&routingKeyInfo{
+		indexes: []int{0, 1},
+		types:   []TypeInfo{table.PartitionKey[0].Type, table.PartitionKey[1].Type},
+	}, []interface{}{"a", 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, want) {
+		t.Fatalf("expected routing key %x, got %x", want, key)
+	}
+
+	// the value of a marker is missing
+	if key, err := parsed.routingKey(table, nil); key != nil || err != nil {
+		t.Fatalf("expected no routing key, got %x, %v", key, err)
+	}
+	// the partition key is restricted partially
+	parsed, _ = parseStatement(`SELECT * FROM events WHERE id = 'a'`)
+	if key, err := parsed.routingKey(table, nil); key != nil || err != nil {
+		t.Fatalf("expected no routing key, got %x, %v", key, err)
+	}
+	// the literal doesn't match the type of its column
+	parsed, _ = parseStatement(`SELECT * FROM events WHERE id = 'a' AND bucket = 'b'`)
+	if key, err := parsed.routingKey(table, nil); key != nil || err != nil {
+		t.Fatalf("expected no routing key, got %x, %v", key, err)
+	}
+}
+
+func TestSessionParsedStatementCached(t *testing.T) {
+	s := &Session{}
+	s.parsedStmtCache.lru = lru.New(10)
+
+	stmt := `SELECT * FROM events WHERE id = 'a'`
+	parsed := s.parsedStatement(stmt)
+	if parsed == nil {
+		t.Fatal("expected the statement to be parsed")
+	}
+	if again := s.parsedStatement(stmt); again != parsed {
+		t.Fatal("expected the parsed statement to be cached")
+	}
+
+	if s.parsedStatement(`TRUNCATE events`) != nil || s.parsedStatement(`TRUNCATE events`) != nil {
+		t.Fatal("expected the statement not to be parsed")
+	}
+	if n := s.parsedStmtCache.lru.Len(); n != 2 {
+		t.Fatalf("expected the statements which can't be parsed to be cached too, got %d entries", n)
+	}
+}
@@ -0,0 +1,63 @@
+package gocql
+
+// DCConsistency maps the consistency of queries and batches depending on the
+// datacenter of the host they are sent to, see ClusterConfig.DCConsistency.
+// For example, to read and write with LOCAL_QUORUM in the local datacenter and
+// with QUORUM when the requests fail over to another datacenter:
+//
+//	cluster.Consistency = gocql.LocalQuorum
+//	cluster.DCConsistency = &gocql.DCConsistency{
+//		LocalDC: "eu-west",
+//		Remote:  map[gocql.Consistency]gocql.Consistency{gocql.LocalQuorum: gocql.Quorum},
+//	}
+//
+// Consistencies missing from the map of a datacenter are sent as they are, as
+// are requests to hosts of unknown datacenters.
+type DCConsistency struct {
+	// LocalDC is the name of the local datacenter.
+	LocalDC string
+
+	// Local maps the consistencies of the requests sent to hosts of LocalDC,
+	// Remote the consistencies of the requests sent to other datacenters.
+	Local  map[Consistency]Consistency
+	Remote map[Consistency]Consistency
+}
+
+// newDCConsistency copies the maps of d so that the configuration can't be
+// changed after the session is created.
+func newDCConsistency(d *DCConsistency) *DCConsistency {
+	if d == nil {
+		return nil
+	}
+	c := &DCConsistency{
+		LocalDC: d.LocalDC,
+		Local:   make(map[Consistency]Consistency, len(d.Local)),
+		Remote:  make(map[Consistency]Consistency, len(d.Remote)),
+	}
+	for k, v := range d.Local {
+		c.Local[k] = v
+	}
+	for k, v := range d.Remote {
+		c.Remote[k] = v
+	}
+	return c
+}
+
+// consistency returns the consistency of a request with cons sent to host.
+func (d *DCConsistency) consistency(cons Consistency, host *HostInfo) Consistency {
+	if d == nil || host == nil {
+		return cons
+	}
+	dc := host.DataCenter()
+	if dc == "" {
+		return cons
+	}
+	m := d.Remote
+	if dc == d.LocalDC {
+		m = d.Local
+	}
+	if mapped, ok := m[cons]; ok {
+		return mapped
+	}
+	return cons
+}
@@ -0,0 +1,58 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestDCConsistency(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		localDC string
+		cons    gocql.Consistency
+		want    gocql.Consistency
+	}{
+		{"local", "datacenter1", gocql.Quorum, gocql.LocalQuorum},
+		{"remote", "other", gocql.LocalQuorum, gocql.Quorum},
+		{"unmapped", "datacenter1", gocql.One, gocql.One},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := gocqltest.NewServer()
+			defer srv.Close()
+
+			cluster := srv.ClusterConfig()
+			cluster.Consistency = test.cons
+			cluster.DCConsistency = &gocql.DCConsistency{
+				LocalDC: test.localDC,
+				Local:   map[gocql.Consistency]gocql.Consistency{gocql.Quorum: gocql.LocalQuorum},
+				Remote:  map[gocql.Consistency]gocql.Consistency{gocql.LocalQuorum: gocql.Quorum},
+			}
+			session, err := cluster.CreateSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer session.Close()
+
+			if err := session.Query(`INSERT INTO orders (id) VALUES (1)`).Exec(); err != nil {
+				t.Fatal(err)
+			}
+			batch := session.NewBatch(gocql.LoggedBatch)
+			batch.Query(`INSERT INTO orders (id) VALUES (2)`)
+			if err := session.ExecuteBatch(batch); err != nil {
+				t.Fatal(err)
+			}
+
+			reqs := srv.Requests()
+			if len(reqs) != 2 {
+				t.Fatalf("expected 2 requests, got %d", len(reqs))
+			}
+			for _, req := range reqs {
+				if req.Consistency != test.want {
+					t.Fatalf("expected %v for %q, got %v", test.want, req.Statement, req.Consistency)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReplicationStrategy is the replication of a keyspace created with
+// Session.CreateKeyspace.
+type ReplicationStrategy struct {
+	// Class is the replication strategy class, SimpleStrategy or
+	// NetworkTopologyStrategy.
+	Class string
+	// ReplicationFactor is the number of replicas of SimpleStrategy.
+	ReplicationFactor int
+	// DataCenters is the number of replicas per data center of
+	// NetworkTopologyStrategy.
+	DataCenters map[string]int
+}
+
+// SimpleStrategy returns a replication strategy placing factor replicas
+// without considering data centers.
+func SimpleStrategy(factor int) ReplicationStrategy {
+	return ReplicationStrategy{Class: "SimpleStrategy", ReplicationFactor: factor}
+}
+
+// NetworkTopologyStrategy returns a replication strategy with the given
+// number of replicas per data center.
+func NetworkTopologyStrategy(dataCenters map[string]int) ReplicationStrategy {
+	return ReplicationStrategy{Class: "NetworkTopologyStrategy", DataCenters: dataCenters}
+}
+
+// String returns the replication as a CQL map literal.
+func (r ReplicationStrategy) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "{'class': '%s'", EscapeString(r.Class))
+	if r.ReplicationFactor > 0 {
+		fmt.Fprintf(&b, ", 'replication_factor': %d", r.ReplicationFactor)
+	}
+
+	dcs := make([]string, 0, len(r.DataCenters))
+	for dc := range r.DataCenters {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+	for _, dc := range dcs {
+		fmt.Fprintf(&b, ", '%s': %d", EscapeString(dc), r.DataCenters[dc])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// ColumnSchema is a column of a TableSchema.
+type ColumnSchema struct {
+	Name string
+	// Type is the CQL type of the column, for example text or map<text, int>.
+	Type string
+}
+
+// ClusteringColumn is a clustering column of a TableSchema and its order.
+type ClusteringColumn struct {
+	Name  string
+	Order ColumnOrder
+}
+
+// TableSchema describes a table created with Session.CreateTable.
+type TableSchema struct {
+	// Keyspace of the table. If empty the table is created in the keyspace
+	// of the session.
+	Keyspace string
+	Name     string
+	Columns  []ColumnSchema
+	// PartitionKey are the names of the partition key columns.
+	PartitionKey []string
+	// ClusteringColumns are the clustering columns in order.
+	ClusteringColumns []ClusteringColumn
+	// Options are table options with their CQL values, for example
+	// {"default_time_to_live": "3600"}.
+	Options map[string]string
+}
+
+func (t TableSchema) name() (string, error) {
+	if err := ValidateTableName(t.Name); err != nil {
+		return "", err
+	}
+	if t.Keyspace == "" {
+		return t.Name, nil
+	}
+	if err := ValidateKeyspaceName(t.Keyspace); err != nil {
+		return "", err
+	}
+	return t.Keyspace + "." + t.Name, nil
+}
+
+func (t TableSchema) statement() (string, error) {
+	name, err := t.name()
+	if err != nil {
+		return "", err
+	}
+	if len(t.Columns) == 0 {
+		return "", fmt.Errorf("gocql: table %s has no columns", name)
+	}
+	if len(t.PartitionKey) == 0 {
+		return "", fmt.Errorf("gocql: table %s has no partition key", name)
+	}
+
+	columns := make(map[string]bool, len(t.Columns))
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (", name)
+	for _, col := range t.Columns {
+		if err := validateColumnName(col.Name); err != nil {
+			return "", err
+		}
+		if col.Type == "" {
+			return "", fmt.Errorf("gocql: column %s has no type", col.Name)
+		}
+		columns[col.Name] = true
+		fmt.Fprintf(&b, "%s %s, ", col.Name, col.Type)
+	}
+
+	keys := append([]string(nil), t.PartitionKey...)
+	for _, col := range t.ClusteringColumns {
+		keys = append(keys, col.Name)
+	}
+	for _, key := range keys {
+		if !columns[key] {
+			return "", fmt.Errorf("gocql: primary key column %s of table %s is not a column", key, name)
+		}
+	}
+
+	fmt.Fprintf(&b, "PRIMARY KEY ((%s)", strings.Join(t.PartitionKey, ", "))
+	for _, col := range t.ClusteringColumns {
+		fmt.Fprintf(&b, ", %s", col.Name)
+	}
+	b.WriteString("))")
+
+	var with []string
+	if len(t.ClusteringColumns) > 0 {
+		order := make([]string, len(t.ClusteringColumns))
+		for i, col := range t.ClusteringColumns {
+			order[i] = col.Name + " ASC"
+			if col.Order == DESC {
+				order[i] = col.Name + " DESC"
+			}
+		}
+		with = append(with, fmt.Sprintf("CLUSTERING ORDER BY (%s)", strings.Join(order, ", ")))
+	}
+
+	options := make([]string, 0, len(t.Options))
+	for option := range t.Options {
+		if err := validateColumnName(option); err != nil {
+			return "", fmt.Errorf("gocql: invalid table option %q", option)
+		}
+		options = append(options, option)
+	}
+	sort.Strings(options)
+	for _, option := range options {
+		with = append(with, option+" = "+t.Options[option])
+	}
+
+	if len(with) > 0 {
+		b.WriteString(" WITH ")
+		b.WriteString(strings.Join(with, " AND "))
+	}
+	return b.String(), nil
+}
+
+func validateColumnName(name string) error {
+	if name == "" {
+		return errors.New("gocql: column name is empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return fmt.Errorf("gocql: invalid column name %q", name)
+		}
+	}
+	return nil
+}
+
+// CreateKeyspace creates a keyspace with the given replication if it doesn't
+// exist and waits for schema agreement.
+func (s *Session) CreateKeyspace(ctx context.Context, name string, replication ReplicationStrategy) error {
+	if err := ValidateKeyspaceName(name); err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = %s", name, replication)
+	return s.execDDL(ctx, stmt)
+}
+
+// CreateTable creates a table if it doesn't exist and waits for schema
+// agreement.
+func (s *Session) CreateTable(ctx context.Context, table TableSchema) error {
+	stmt, err := table.statement()
+	if err != nil {
+		return err
+	}
+	return s.execDDL(ctx, stmt)
+}
+
+// Truncate removes all data of a table, given as table or keyspace.table, and
+// waits for schema agreement. As truncations routinely take longer than other
+// requests, the truncation is limited by ClusterConfig.TruncateTimeout instead
+// of the timeouts of the connection and of the session. It fails with a
+// *RequestErrTruncate if a replica failed to truncate the table.
+func (s *Session) Truncate(ctx context.Context, table string) error {
+	name := table
+	if i := strings.IndexByte(table, '.'); i >= 0 {
+		if err := ValidateKeyspaceName(table[:i]); err != nil {
+			return err
+		}
+		name = table[i+1:]
+	}
+	if err := ValidateTableName(name); err != nil {
+		return err
+	}
+	q := s.Query("TRUNCATE " + table).WithContext(ctx)
+	q.timeout = s.cfg.TruncateTimeout
+	q.connTimeout = s.cfg.TruncateTimeout
+	if err := q.Exec(); err != nil {
+		return err
+	}
+	return s.AwaitSchemaAgreement(ctx)
+}
+
+func (s *Session) execDDL(ctx context.Context, stmt string) error {
+	if err := s.Query(stmt).WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	return s.AwaitSchemaAgreement(ctx)
+}
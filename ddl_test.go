@@ -0,0 +1,60 @@
+package gocql
+
+import "testing"
+
+func TestReplicationStrategyString(t *testing.T) {
+	tests := []struct {
+		replication ReplicationStrategy
+		expected    string
+	}{
+		{SimpleStrategy(3), `{'class': 'SimpleStrategy', 'replication_factor': 3}`},
+		{NetworkTopologyStrategy(map[string]int{"dc2": 2, "dc1": 3}), `{'class': 'NetworkTopologyStrategy', 'dc1': 3, 'dc2': 2}`},
+	}
+	for _, test := range tests {
+		if s := test.replication.String(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
+
+func TestTableSchemaStatement(t *testing.T) {
+	table := TableSchema{
+		Keyspace: "example",
+		Name:     "events",
+		Columns: []ColumnSchema{
+			{Name: "tenant", Type: "text"},
+			{Name: "day", Type: "date"},
+			{Name: "at", Type: "timestamp"},
+			{Name: "tags", Type: "map<text, text>"},
+		},
+		PartitionKey:      []string{"tenant", "day"},
+		ClusteringColumns: []ClusteringColumn{{Name: "at", Order: DESC}},
+		Options:           map[string]string{"default_time_to_live": "3600", "comment": "'events'"},
+	}
+
+	stmt, err := table.statement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "CREATE TABLE IF NOT EXISTS example.events (tenant text, day date, at timestamp, tags map<text, text>, " +
+		"PRIMARY KEY ((tenant, day), at)) WITH CLUSTERING ORDER BY (at DESC) AND comment = 'events' AND default_time_to_live = 3600"
+	if stmt != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, stmt)
+	}
+}
+
+func TestTableSchemaStatementInvalid(t *testing.T) {
+	tests := map[string]TableSchema{
+		"no columns":       {Name: "t", PartitionKey: []string{"id"}},
+		"no partition key": {Name: "t", Columns: []ColumnSchema{{Name: "id", Type: "int"}}},
+		"unknown key":      {Name: "t", Columns: []ColumnSchema{{Name: "id", Type: "int"}}, PartitionKey: []string{"other"}},
+		"invalid name":     {Name: "t;", Columns: []ColumnSchema{{Name: "id", Type: "int"}}, PartitionKey: []string{"id"}},
+		"invalid column":   {Name: "t", Columns: []ColumnSchema{{Name: "id int)", Type: "int"}}, PartitionKey: []string{"id"}},
+		"no column type":   {Name: "t", Columns: []ColumnSchema{{Name: "id"}}, PartitionKey: []string{"id"}},
+	}
+	for name, table := range tests {
+		if _, err := table.statement(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package gocql_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestPropagateDeadline(t *testing.T) {
+	for _, scylla := range []bool{false, true} {
+		srv := gocqltest.NewUnstartedServer()
+		srv.Scylla = scylla
+		srv.Start()
+		defer srv.Close()
+
+		id := gocqltest.Column{Name: "id", Type: gocqltest.Int}
+		srv.On(`DELETE FROM users WHERE id = ?`).Params(id)
+		srv.On(`DELETE FROM users USING TIMEOUT ? WHERE id = ?`).Params(id)
+
+		cluster := srv.ClusterConfig()
+		cluster.PropagateDeadline = true
+		session, err := cluster.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+
+		for i, timeout := range []time.Duration{10 * time.Second, 20 * time.Second} {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := session.Query(`DELETE FROM users WHERE id = ?`, i).WithContext(ctx).Exec()
+			cancel()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := session.Query(`DELETE FROM users WHERE id = ?`, 2).Exec(); err != nil {
+			t.Fatal(err)
+		}
+
+		reqs := srv.Requests()
+		if len(reqs) != 3 {
+			t.Fatalf("expected 3 requests, got %d", len(reqs))
+		}
+		for i, req := range reqs[:2] {
+			withTimeout := req.Statement == `DELETE FROM users USING TIMEOUT ? WHERE id = ?`
+			if withTimeout != scylla {
+				t.Errorf("scylla=%v: unexpected statement %q", scylla, req.Statement)
+			}
+			if max := time.Duration(i+1) * 10 * time.Second; scylla && (req.Timeout <= max-time.Second || req.Timeout > max) {
+				t.Errorf("expected a timeout of about %v, got %v", max, req.Timeout)
+			}
+			var got int
+			if err := req.Scan(&got); err != nil || got != i {
+				t.Errorf("expected the value %d to be bound, got %d: %v", i, got, err)
+			}
+		}
+		if reqs[2].Statement != `DELETE FROM users WHERE id = ?` || reqs[2].Timeout != 0 {
+			t.Errorf("expected statement without deadline to be unchanged, got %q with timeout %v", reqs[2].Statement, reqs[2].Timeout)
+		}
+
+		// the timeout is bound to a marker, the statement is prepared once
+		var prepares int
+		for _, stmt := range srv.Prepares() {
+			if strings.Contains(stmt, "TIMEOUT") {
+				prepares++
+			}
+		}
+		if scylla && prepares != 1 {
+			t.Errorf("expected the statement with a timeout to be prepared once, got %d prepares", prepares)
+		}
+	}
+}
+
+func TestPropagateDeadlineClientTimeout(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT name FROM users`).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"}).
+		Delay(200 * time.Millisecond)
+
+	cluster := srv.ClusterConfig()
+	cluster.Timeout = 50 * time.Millisecond
+	cluster.PropagateDeadline = true
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELECT name FROM users`).Exec(); err != gocql.ErrTimeoutNoResponse {
+		t.Fatalf("expected the timeout of the connection without a deadline, got %v", err)
+	}
+
+	// the response is waited for until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var name string
+	if err := session.Query(`SELECT name FROM users`).WithContext(ctx).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" {
+		t.Fatalf("expected alice, got %q", name)
+	}
+}
@@ -173,7 +173,9 @@
 // When using CQL protocol >= 4, it is possible to use gocql.UnsetValue as the bound value of a column.
 // This will cause the database to ignore writing the column.
 // The main advantage is the ability to keep the same prepared statement even when you don't
-// want to update some fields, where before you needed to make another prepared statement.
+// want to update some fields, where before you needed to make another prepared statement. Unlike a nil value,
+// an unset value doesn't write a tombstone. With older protocols queries binding UnsetValue fail with
+// ErrUnsetValueUnsupported before they are sent.
 //
 // # Executing multiple queries concurrently
 //
@@ -317,8 +319,9 @@
 // # Retries and speculative execution
 //
 // Queries can be marked as idempotent. Marking the query as idempotent tells the driver that the query can be executed
-// multiple times without affecting its result. Non-idempotent queries are not eligible for retrying nor speculative
-// execution.
+// multiple times without affecting its result. Non-idempotent queries are not eligible for speculative execution, nor
+// for retrying after errors which leave unknown whether they were applied, such as write timeouts or connections
+// closed before the response. Batches are idempotent if all their statements are, or if marked with Batch.Idempotent.
 //
 // Idempotent queries are retried in case of errors based on the configured RetryPolicy.
 //
@@ -328,6 +331,8 @@
 // is still executing. The two parallel executions of the query race to return a result, the first received result will
 // be returned.
 //
+// ClusterConfig.HedgedReads enables speculative executions for all the SELECT queries of a session, see HedgedReads.
+//
 // # User-defined types
 //
 // UDTs can be mapped (un)marshaled from/to map[string]interface{} a Go struct (or a type implementing
@@ -354,7 +359,8 @@
 // CQL protocol also supports tracing of queries. When enabled, the database will write information about
 // internal events that happened during execution of the query. You can use Query.Trace to request tracing and receive
 // the session ID that the database used to store the trace information in system_traces.sessions and
-// system_traces.events tables. NewTraceWriter returns an implementation of Tracer that writes the events to a writer.
+// system_traces.events tables. NewTraceWriter returns an implementation of Tracer that writes the events to a writer,
+// NewTraceCollector one that reads the traces in the background and hands them to a function.
 // Gathering trace information might be essential for debugging and optimizing queries, but writing traces has overhead,
 // so this feature should not be used on production systems with very high load unless you know what you are doing.
 package gocql // import "github.com/gocql/gocql"
@@ -0,0 +1,174 @@
+// Package encrypt encrypts the values of columns on the client with AES-GCM.
+//
+// A Column is a gocql.ColumnTransformer: the values bound to the column are
+// marshalled as the type of the Column, encrypted and stored as a blob, and
+// decrypted when rows are scanned, transparently to the code executing the
+// queries:
+//
+//	keys := &encrypt.StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": key}}
+//	cluster.ColumnTransformers = map[string]gocql.ColumnTransformer{
+//		"shop.customers.email": encrypt.NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), keys),
+//	}
+//
+// Every value is encrypted with a random nonce, so encrypted columns can't be
+// part of the primary key or of an index. The ID of the key is stored with the
+// ciphertext, so that values encrypted with previous keys can still be read
+// after the current key was rotated. The ciphertext is bound to its column,
+// copying it to another column makes it fail to decrypt.
+//
+// EnvelopeKeys keeps the keys out of the application: values are encrypted
+// with data keys which are themselves encrypted by a MasterKey held in a KMS
+// or Vault, and stored with the values. Clients of AWS KMS or Google Cloud KMS
+// implement MasterKey with a few lines, VaultTransit uses the transit engine of
+// Vault:
+//
+//	keys := &encrypt.EnvelopeKeys{
+//		Master: &encrypt.VaultTransit{Address: "https://vault:8200", Token: token, Key: "customers"},
+//	}
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gocql/gocql"
+)
+
+// version is the first byte of the values encrypted by this package.
+const version = 1
+
+// ErrInvalidCiphertext is returned when decrypting a value which wasn't
+// encrypted by this package, was modified or belongs to another column.
+var ErrInvalidCiphertext = errors.New("encrypt: invalid ciphertext")
+
+// KeyProvider provides the AES keys of columns, of 16, 24 or 32 bytes.
+type KeyProvider interface {
+	// EncryptionKey returns the key to encrypt new values of col with, and its
+	// ID.
+	EncryptionKey(col gocql.ColumnInfo) (id string, key []byte, err error)
+	// DecryptionKey returns the key with the given ID, with which a value of
+	// col was encrypted.
+	DecryptionKey(col gocql.ColumnInfo, id string) ([]byte, error)
+}
+
+// StaticKeys provides keys from memory. New values are encrypted with the key
+// Current, values are decrypted with the key they were encrypted with.
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k *StaticKeys) EncryptionKey(col gocql.ColumnInfo) (string, []byte, error) {
+	key, err := k.DecryptionKey(col, k.Current)
+	return k.Current, key, err
+}
+
+func (k *StaticKeys) DecryptionKey(col gocql.ColumnInfo, id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("encrypt: unknown key %q", id)
+	}
+	return key, nil
+}
+
+// Column encrypts the values of a column.
+type Column struct {
+	typ  gocql.TypeInfo
+	keys KeyProvider
+}
+
+// NewColumn returns a Column encrypting values of typ with the keys of keys.
+func NewColumn(typ gocql.TypeInfo, keys KeyProvider) *Column {
+	return &Column{typ: typ, keys: keys}
+}
+
+func (c *Column) Type() gocql.TypeInfo {
+	return c.typ
+}
+
+// additionalData binds the ciphertext to its column and key.
+func additionalData(col gocql.ColumnInfo, id string) []byte {
+	return []byte(col.Keyspace + "." + col.Table + "." + col.Name + "/" + id)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encode encrypts data. The ciphertext is the version, the length of the key
+// ID and the key ID, followed by the nonce and the sealed data.
+func (c *Column) Encode(col gocql.ColumnInfo, data []byte) ([]byte, error) {
+	id, key, err := c.keys.EncryptionKey(col)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("encrypt: key ID %q is longer than 255 bytes", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 2+len(id)+gcm.NonceSize()+len(data)+gcm.Overhead())
+	out = append(out, version, byte(len(id)))
+	out = append(out, id...)
+	nonce := out[len(out) : len(out)+gcm.NonceSize()]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("encrypt: generate nonce: %w", err)
+	}
+	out = out[:len(out)+len(nonce)]
+	return gcm.Seal(out, nonce, data, additionalData(col, id)), nil
+}
+
+// splitCiphertext returns the key ID of a value encrypted by Encode and the
+// rest of the value.
+func splitCiphertext(data []byte) (string, []byte, error) {
+	if len(data) < 2 || data[0] != version || len(data) < 2+int(data[1]) {
+		return "", nil, ErrInvalidCiphertext
+	}
+	n := 2 + int(data[1])
+	return string(data[2:n]), data[n:], nil
+}
+
+// KeyID returns the ID of the key a value was encrypted with, to find the
+// values to encrypt again once a key is retired.
+func KeyID(ciphertext []byte) (string, error) {
+	id, _, err := splitCiphertext(ciphertext)
+	return id, err
+}
+
+// Decode decrypts data encrypted by Encode.
+func (c *Column) Decode(col gocql.ColumnInfo, data []byte) ([]byte, error) {
+	id, data, err := splitCiphertext(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.keys.DecryptionKey(col, id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	// not nil for empty values, which would be read as null
+	plain := make([]byte, 0, len(data))
+	plain, err = gcm.Open(plain, data[:gcm.NonceSize()], data[gcm.NonceSize():], additionalData(col, id))
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plain, nil
+}
@@ -0,0 +1,163 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestColumnRoundTrip(t *testing.T) {
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	c := NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), keys)
+	col := gocql.ColumnInfo{Keyspace: "shop", Table: "customers", Name: "email"}
+
+	for _, plain := range [][]byte{[]byte("alice@example.com"), {}} {
+		encrypted, err := c.Encode(col, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(encrypted, plain) && len(plain) > 0 {
+			t.Fatal("the plaintext is part of the ciphertext")
+		}
+		decrypted, err := c.Decode(col, encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted == nil || !bytes.Equal(decrypted, plain) {
+			t.Fatalf("expected %q, got %q", plain, decrypted)
+		}
+	}
+
+	encrypted, err := c.Encode(col, []byte("alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.Encode(col, []byte("alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(encrypted, again) {
+		t.Fatal("expected a random nonce")
+	}
+
+	other := col
+	other.Name = "phone"
+	if _, err := c.Decode(other, encrypted); err != ErrInvalidCiphertext {
+		t.Fatalf("expected the ciphertext of another column to fail, got %v", err)
+	}
+	tampered := append([]byte(nil), encrypted...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := c.Decode(col, tampered); err != ErrInvalidCiphertext {
+		t.Fatalf("expected a modified ciphertext to fail, got %v", err)
+	}
+
+	// values encrypted with a previous key are still decrypted
+	keys.Keys["k2"] = bytes.Repeat([]byte{2}, 16)
+	keys.Current = "k2"
+	if decrypted, err := c.Decode(col, encrypted); err != nil || string(decrypted) != "alice@example.com" {
+		t.Fatalf("expected the value encrypted with k1, got %q %v", decrypted, err)
+	}
+	delete(keys.Keys, "k1")
+	if _, err := c.Decode(col, encrypted); err == nil {
+		t.Fatal("expected an unknown key to fail")
+	}
+}
+
+func TestColumnTransformer(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		stored = make(map[int][]byte)
+	)
+	id := gocqltest.Column{Name: "id", Type: gocqltest.Int}
+	email := gocqltest.Column{Name: "email", Type: gocqltest.Blob}
+	srv.On(`INSERT INTO customers (id, email) VALUES (?, ?)`).Table("customers").Params(id, email).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var (
+				id    int
+				value []byte
+			)
+			if err := req.Scan(&id, &value); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			mu.Lock()
+			stored[id] = value
+			mu.Unlock()
+			return gocqltest.Response{}
+		})
+	srv.On(`SELECT email FROM customers WHERE id = ?`).Table("customers").Params(id).
+		Handle([]gocqltest.Column{email}, func(req *gocqltest.Request) gocqltest.Response {
+			var id int
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return gocqltest.Response{Rows: [][]interface{}{{stored[id]}}}
+		})
+
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	cluster := srv.ClusterConfig()
+	cluster.Keyspace = "shop"
+	cluster.ColumnTransformers = map[string]gocql.ColumnTransformer{
+		"shop.customers.email": NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), keys),
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`INSERT INTO customers (id, email) VALUES (?, ?)`, 1, "alice@example.com").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	b := session.NewBatch(gocql.UnloggedBatch)
+	b.Query(`INSERT INTO customers (id, email) VALUES (?, ?)`, 2, "bob@example.com")
+	b.Query(`INSERT INTO customers (id, email) VALUES (?, ?)`, 3, nil)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	if bytes.Contains(stored[1], []byte("alice")) || bytes.Contains(stored[2], []byte("bob")) {
+		mu.Unlock()
+		t.Fatal("expected the values to be stored encrypted")
+	}
+	if stored[3] != nil {
+		mu.Unlock()
+		t.Fatal("expected null to be stored unencrypted")
+	}
+	mu.Unlock()
+
+	for id, want := range map[int]string{1: "alice@example.com", 2: "bob@example.com", 3: ""} {
+		var got string
+		if err := session.Query(`SELECT email FROM customers WHERE id = ?`, id).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("expected %q for %d, got %q", want, id, got)
+		}
+	}
+
+	row := make(map[string]interface{})
+	if err := session.Query(`SELECT email FROM customers WHERE id = ?`, 1).MapScan(row); err != nil {
+		t.Fatal(err)
+	}
+	if row["email"] != "alice@example.com" {
+		t.Fatalf("expected the decrypted value in MapScan, got %#v", row["email"])
+	}
+
+	mu.Lock()
+	stored[1] = []byte("not encrypted")
+	mu.Unlock()
+	var got string
+	if err := session.Query(`SELECT email FROM customers WHERE id = ?`, 1).Scan(&got); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("expected the invalid ciphertext to fail, got %v", err)
+	}
+}
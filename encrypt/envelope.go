@@ -0,0 +1,244 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/internal/lru"
+)
+
+// MasterKey encrypts the data keys of EnvelopeKeys, usually with a key which
+// never leaves a KMS or Vault. Wrapped data keys must be at most 255 bytes
+// long, as they are stored with every value.
+type MasterKey interface {
+	// Wrap encrypts a data key.
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap decrypts a data key encrypted by Wrap.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// EnvelopeKeys provides data keys encrypted by a master key. The current data
+// key is used for DataKeyLifetime before a new one is generated, unwrapped
+// data keys are cached so that the master key is only used once per data key.
+//
+// The wrapped data key is the ID of the key stored with every value, see
+// KeyID, so that values can be decrypted after the master key was rotated as
+// long as it can unwrap the data keys of its previous versions.
+type EnvelopeKeys struct {
+	Master MasterKey
+
+	// DataKeyLifetime is the time after which a new data key is generated.
+	// Default: 24h
+	DataKeyLifetime time.Duration
+
+	// CacheSize is the number of unwrapped data keys cached.
+	// Default: 1000
+	CacheSize int
+
+	// Timeout limits the calls to the master key.
+	// Default: 10s
+	Timeout time.Duration
+
+	// rotate is held while generating a data key.
+	rotate  sync.Mutex
+	mu      sync.Mutex
+	current []byte
+	wrapped string
+	created time.Time
+	cache   *lru.Cache
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+func (k *EnvelopeKeys) context() (context.Context, context.CancelFunc) {
+	timeout := k.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func (k *EnvelopeKeys) time() time.Time {
+	if k.now != nil {
+		return k.now()
+	}
+	return time.Now()
+}
+
+// cached returns the cache, k.mu must be held.
+func (k *EnvelopeKeys) cached() *lru.Cache {
+	if k.cache == nil {
+		size := k.CacheSize
+		if size <= 0 {
+			size = 1000
+		}
+		k.cache = lru.New(size)
+	}
+	return k.cache
+}
+
+func (k *EnvelopeKeys) EncryptionKey(col gocql.ColumnInfo) (string, []byte, error) {
+	if wrapped, key, ok := k.currentKey(); ok {
+		return wrapped, key, nil
+	}
+
+	// data keys are generated one at a time, without holding k.mu across the
+	// call to the master key so that DecryptionKey isn't blocked
+	k.rotate.Lock()
+	defer k.rotate.Unlock()
+	if wrapped, key, ok := k.currentKey(); ok {
+		// generated while waiting
+		return wrapped, key, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", nil, fmt.Errorf("encrypt: generate data key: %w", err)
+	}
+	ctx, cancel := k.context()
+	defer cancel()
+	wrapped, err := k.Master.Wrap(ctx, key)
+	if err != nil {
+		return "", nil, fmt.Errorf("encrypt: wrap data key: %w", err)
+	}
+	if len(wrapped) > 255 {
+		return "", nil, fmt.Errorf("encrypt: wrapped data key of %d bytes is longer than 255 bytes", len(wrapped))
+	}
+
+	k.mu.Lock()
+	k.current, k.wrapped, k.created = key, string(wrapped), k.time()
+	k.cached().Add(k.wrapped, key)
+	k.mu.Unlock()
+	return string(wrapped), key, nil
+}
+
+// currentKey returns the current data key, ok is false if it must be
+// generated.
+func (k *EnvelopeKeys) currentKey() (wrapped string, key []byte, ok bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	lifetime := k.DataKeyLifetime
+	if lifetime <= 0 {
+		lifetime = 24 * time.Hour
+	}
+	if k.current == nil || k.time().Sub(k.created) >= lifetime {
+		return "", nil, false
+	}
+	return k.wrapped, k.current, true
+}
+
+func (k *EnvelopeKeys) DecryptionKey(col gocql.ColumnInfo, id string) ([]byte, error) {
+	k.mu.Lock()
+	if key, ok := k.cached().Get(id); ok {
+		k.mu.Unlock()
+		return key.([]byte), nil
+	}
+	k.mu.Unlock()
+
+	ctx, cancel := k.context()
+	defer cancel()
+	key, err := k.Master.Unwrap(ctx, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: unwrap data key: %w", err)
+	}
+
+	k.mu.Lock()
+	k.cached().Add(id, key)
+	k.mu.Unlock()
+	return key, nil
+}
+
+// VaultTransit is a MasterKey encrypting data keys with the transit secrets
+// engine of HashiCorp Vault. The wrapped data keys are the ciphertexts of
+// Vault, which are prefixed with the version of the key, so the key can be
+// rotated in Vault.
+type VaultTransit struct {
+	// Address of Vault, such as https://vault.example.com:8200.
+	Address string
+	// Token authenticates the requests.
+	Token string
+	// Key is the name of the transit key.
+	Key string
+	// Mount is the path the transit engine is mounted at.
+	// Default: transit
+	Mount string
+
+	// Client sends the requests.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+func (v *VaultTransit) do(ctx context.Context, op string, req, resp interface{}) error {
+	mount := v.Mount
+	if mount == "" {
+		mount = "transit"
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + mount + "/" + op + "/" + v.Key
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("X-Vault-Token", v.Token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return fmt.Errorf("vault %s: %s: %s", op, httpResp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+func (v *VaultTransit) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	req := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	if err := v.do(ctx, "encrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Ciphertext == "" {
+		return nil, errors.New("vault encrypt: no ciphertext in response")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (v *VaultTransit) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	req := map[string]string{"ciphertext": string(wrapped)}
+	if err := v.do(ctx, "decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
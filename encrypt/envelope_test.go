@@ -0,0 +1,214 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// fakeVault implements the encrypt and decrypt endpoints of the transit
+// engine, "wrapping" keys by reversing them.
+func fakeVault(t *testing.T, unwraps *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		reverse := func(s string) string {
+			b := []byte(s)
+			for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+				b[i], b[j] = b[j], b[i]
+			}
+			return string(b)
+		}
+		var data map[string]string
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/orders":
+			data = map[string]string{"ciphertext": "vault:v1:" + reverse(req["plaintext"])}
+		case "/v1/transit/decrypt/orders":
+			atomic.AddInt32(unwraps, 1)
+			data = map[string]string{"plaintext": reverse(strings.TrimPrefix(req["ciphertext"], "vault:v1:"))}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+func TestEnvelopeKeys(t *testing.T) {
+	var unwraps int32
+	vault := fakeVault(t, &unwraps)
+	defer vault.Close()
+
+	now := time.Unix(1700000000, 0)
+	keys := &EnvelopeKeys{
+		Master: &VaultTransit{Address: vault.URL, Token: "token", Key: "orders"},
+		now:    func() time.Time { return now },
+	}
+	c := NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), keys)
+	col := gocql.ColumnInfo{Keyspace: "shop", Table: "orders", Name: "card"}
+
+	first, err := c.Encode(col, []byte("4111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := KeyID(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(id, "vault:v1:") {
+		t.Fatalf("expected the wrapped data key as the key ID, got %q", id)
+	}
+	second, err := c.Encode(col, []byte("4222"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2, _ := KeyID(second); id2 != id {
+		t.Fatalf("expected the data key to be reused, got %q and %q", id, id2)
+	}
+
+	// the data key is rotated once its lifetime passed
+	now = now.Add(25 * time.Hour)
+	third, err := c.Encode(col, []byte("4333"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id3, _ := KeyID(third); id3 == id {
+		t.Fatal("expected a new data key")
+	}
+
+	for _, v := range []struct {
+		encrypted []byte
+		plain     string
+	}{{first, "4111"}, {second, "4222"}, {third, "4333"}} {
+		plain, err := c.Decode(col, v.encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plain) != v.plain {
+			t.Fatalf("expected %q, got %q", v.plain, plain)
+		}
+	}
+	if atomic.LoadInt32(&unwraps) != 0 {
+		t.Fatalf("expected the generated data keys to be cached, unwrapped %d", unwraps)
+	}
+
+	// another client unwraps each data key once
+	reader := &EnvelopeKeys{Master: &VaultTransit{Address: vault.URL, Token: "token", Key: "orders"}}
+	r := NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), reader)
+	for i := 0; i < 2; i++ {
+		for _, encrypted := range [][]byte{first, second, third} {
+			if _, err := r.Decode(col, encrypted); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if atomic.LoadInt32(&unwraps) != 2 {
+		t.Fatalf("expected 2 data keys to be unwrapped, unwrapped %d", unwraps)
+	}
+}
+
+// blockingMaster wraps data keys once released.
+type blockingMaster struct {
+	wrapping chan struct{}
+	release  chan struct{}
+}
+
+func (m *blockingMaster) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	m.wrapping <- struct{}{}
+	<-m.release
+	return append([]byte("wrapped:"), dataKey...), nil
+}
+
+func (m *blockingMaster) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return bytes.TrimPrefix(wrapped, []byte("wrapped:")), nil
+}
+
+func TestEnvelopeKeysRotateUnlocked(t *testing.T) {
+	master := &blockingMaster{wrapping: make(chan struct{}), release: make(chan struct{})}
+	now := time.Unix(1700000000, 0)
+	keys := &EnvelopeKeys{Master: master, now: func() time.Time { return now }}
+	col := gocql.ColumnInfo{Keyspace: "shop", Table: "orders", Name: "card"}
+
+	encryptionKey := func() <-chan string {
+		ids := make(chan string, 1)
+		go func() {
+			id, _, err := keys.EncryptionKey(col)
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- id
+		}()
+		return ids
+	}
+
+	ids := encryptionKey()
+	<-master.wrapping
+	master.release <- struct{}{}
+	id := <-ids
+
+	// the data key is rotated, the master key wraps the new one while the
+	// previous one is decrypted
+	now = now.Add(25 * time.Hour)
+	ids = encryptionKey()
+	<-master.wrapping
+	decrypted := make(chan error, 1)
+	go func() {
+		_, err := keys.DecryptionKey(col, id)
+		decrypted <- err
+	}()
+	select {
+	case err := <-decrypted:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DecryptionKey blocked by the rotation of the data key")
+	}
+	master.release <- struct{}{}
+	if next := <-ids; next == id {
+		t.Fatal("expected a new data key")
+	}
+}
+
+func TestVaultTransitError(t *testing.T) {
+	var unwraps int32
+	vault := fakeVault(t, &unwraps)
+	defer vault.Close()
+
+	keys := &EnvelopeKeys{Master: &VaultTransit{Address: vault.URL, Token: "wrong", Key: "orders"}}
+	_, _, err := keys.EncryptionKey(gocql.ColumnInfo{})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected the error of Vault, got %v", err)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	c := NewColumn(gocql.NewNativeType(4, gocql.TypeText, ""), keys)
+	encrypted, err := c.Encode(gocql.ColumnInfo{}, []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := KeyID(encrypted); err != nil || id != "k1" {
+		t.Fatalf("expected k1, got %q, %v", id, err)
+	}
+	if _, err := KeyID([]byte(base64.StdEncoding.EncodeToString([]byte("plain")))); err != ErrInvalidCiphertext {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
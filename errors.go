@@ -187,6 +187,26 @@ type RequestErrFunctionFailure struct {
 	ArgTypes []string
 }
 
+// RequestErrOverloaded is distinct error for ErrCodeOverloaded, returned by a
+// coordinator shedding load. Queries are retried on the next host after a
+// backoff, see ClusterConfig.OverloadedBackoff.
+type RequestErrOverloaded struct {
+	errorFrame
+}
+
+// RequestErrBootstrapping is distinct error for ErrCodeBootstrapping, returned
+// by a coordinator which is still joining the cluster. Queries are retried on
+// the next host.
+type RequestErrBootstrapping struct {
+	errorFrame
+}
+
+// RequestErrTruncate is distinct error for ErrCodeTruncate, returned when a
+// truncation failed, for example because it timed out on a replica.
+type RequestErrTruncate struct {
+	errorFrame
+}
+
 // RequestErrCASWriteUnknown is distinct error for ErrCodeCasWriteUnknown.
 //
 // See https://github.com/apache/cassandra/blob/7337fc0/doc/native_protocol_v5.spec#L1387-L1397
@@ -1,14 +1,16 @@
 package gocql
 
 import (
+	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 )
 
 type eventDebouncer struct {
 	name   string
-	timer  *time.Timer
+	timer  Timer
 	mu     sync.Mutex
 	events []frame
 
@@ -18,11 +20,11 @@ type eventDebouncer struct {
 	logger StdLogger
 }
 
-func newEventDebouncer(name string, eventHandler func([]frame), logger StdLogger) *eventDebouncer {
+func newEventDebouncer(name string, eventHandler func([]frame), logger StdLogger, clock Clock) *eventDebouncer {
 	e := &eventDebouncer{
 		name:     name,
 		quit:     make(chan struct{}),
-		timer:    time.NewTimer(eventDebounceTime),
+		timer:    clock.NewTimer(eventDebounceTime),
 		callback: eventHandler,
 		logger:   logger,
 	}
@@ -40,7 +42,7 @@ func (e *eventDebouncer) stop() {
 func (e *eventDebouncer) flusher() {
 	for {
 		select {
-		case <-e.timer.C:
+		case <-e.timer.C():
 			e.mu.Lock()
 			e.flush()
 			e.mu.Unlock()
@@ -76,22 +78,29 @@ func (e *eventDebouncer) debounce(frame frame) {
 	if len(e.events) < eventBufferSize {
 		e.events = append(e.events, frame)
 	} else {
-		e.logger.Printf("%s: buffer full, dropping event frame: %s", e.name, frame)
+		logWarn(e.logger, e.name+": buffer full, dropping event frame", LogField{"frame", frame})
 	}
 
 	e.mu.Unlock()
 }
 
 func (s *Session) handleEvent(framer *framer) {
+	var body []byte
+	if s.eventSubscribers.active() {
+		body = append([]byte(nil), framer.buf...)
+	}
 	frame, err := framer.parseFrame()
 	if err != nil {
-		s.logger.Printf("gocql: unable to parse event frame: %v\n", err)
+		logWarn(s.logger, "unable to parse event frame", LogField{"err", err})
 		return
 	}
 
-	if gocqlDebug {
-		s.logger.Printf("gocql: handling frame: %v\n", frame)
+	logDebug(s.logger, "handling frame", LogField{"frame", frame})
+
+	if s.control != nil {
+		s.control.eventReceived()
 	}
+	s.publishEvent(frame, body)
 
 	switch f := frame.(type) {
 	case *schemaChangeKeyspace, *schemaChangeFunction,
@@ -101,7 +110,7 @@ func (s *Session) handleEvent(framer *framer) {
 	case *topologyChangeEventFrame, *statusChangeEventFrame:
 		s.nodeEvents.debounce(frame)
 	default:
-		s.logger.Printf("gocql: invalid event frame (%T): %v\n", f, f)
+		logWarn(s.logger, "invalid event frame", LogField{"type", fmt.Sprintf("%T", f)}, LogField{"frame", f})
 	}
 }
 
@@ -154,6 +163,9 @@ func (s *Session) handleNodeEvent(frames []frame) {
 		switch f := frame.(type) {
 		case *topologyChangeEventFrame:
 			topologyEventReceived = true
+			if f.change == "REMOVED_NODE" && !s.cfg.Events.DisableTopologyEvents {
+				s.handleNodeRemoved(f.host, f.port)
+			}
 		case *statusChangeEventFrame:
 			event, ok := sEvents[f.host.String()]
 			if !ok {
@@ -169,9 +181,7 @@ func (s *Session) handleNodeEvent(frames []frame) {
 	}
 
 	for _, f := range sEvents {
-		if gocqlDebug {
-			s.logger.Printf("gocql: dispatching status change event: %+v\n", f)
-		}
+		logDebug(s.logger, "dispatching status change event", LogField{"event", f})
 
 		// ignore events we received if they were disabled
 		// see https://github.com/gocql/gocql/issues/1591
@@ -189,9 +199,7 @@ func (s *Session) handleNodeEvent(frames []frame) {
 }
 
 func (s *Session) handleNodeUp(eventIp net.IP, eventPort int) {
-	if gocqlDebug {
-		s.logger.Printf("gocql: Session.handleNodeUp: %s:%d\n", eventIp.String(), eventPort)
-	}
+	logDebug(s.logger, "Session.handleNodeUp", LogField{"host", net.JoinHostPort(eventIp.String(), strconv.Itoa(eventPort))})
 
 	host, ok := s.ring.getHostByIP(eventIp.String())
 	if !ok {
@@ -203,12 +211,28 @@ func (s *Session) handleNodeUp(eventIp net.IP, eventPort int) {
 		return
 	}
 
-	if d := host.Version().nodeUpDelay(); d > 0 {
-		time.Sleep(d)
+	// the pool fill is delayed in the background so that the handling of
+	// the other events isn't delayed
+	if d := s.nodeUpDelay(host); d > 0 {
+		s.cfg.clock().AfterFunc(d, func() {
+			if !s.Closed() {
+				s.startPoolFill(host)
+			}
+		})
+		return
 	}
 	s.startPoolFill(host)
 }
 
+// nodeUpDelay returns the time to wait before connecting to host after it was
+// reported up, see ClusterConfig.NodeUpDelay.
+func (s *Session) nodeUpDelay(host *HostInfo) time.Duration {
+	if !host.Version().reportsUpEarly() {
+		return 0
+	}
+	return s.cfg.NodeUpDelay
+}
+
 func (s *Session) startPoolFill(host *HostInfo) {
 	// we let the pool call handleNodeConnected to change the host state
 	s.pool.addHost(host)
@@ -216,9 +240,7 @@ func (s *Session) startPoolFill(host *HostInfo) {
 }
 
 func (s *Session) handleNodeConnected(host *HostInfo) {
-	if gocqlDebug {
-		s.logger.Printf("gocql: Session.handleNodeConnected: %s:%d\n", host.ConnectAddress(), host.Port())
-	}
+	logDebug(s.logger, "Session.handleNodeConnected", LogField{"host", host.ConnectAddressAndPort()})
 
 	host.setState(NodeUp)
 
@@ -227,11 +249,24 @@ func (s *Session) handleNodeConnected(host *HostInfo) {
 	}
 }
 
-func (s *Session) handleNodeDown(ip net.IP, port int) {
-	if gocqlDebug {
-		s.logger.Printf("gocql: Session.handleNodeDown: %s:%d\n", ip.String(), port)
+// handleNodeRemoved stops routing requests to a host leaving the ring before
+// the ring refresh removes it, and drains its connections so that the requests
+// in flight on them are not reset.
+func (s *Session) handleNodeRemoved(ip net.IP, port int) {
+	logDebug(s.logger, "Session.handleNodeRemoved", LogField{"host", net.JoinHostPort(ip.String(), strconv.Itoa(port))})
+
+	host, ok := s.ring.getHostByIP(ip.String())
+	if !ok || s.cfg.filterHost(host) {
+		return
 	}
 
+	s.policy.RemoveHost(host)
+	s.pool.drainHost(host.HostID(), s.cfg.DrainTimeout)
+}
+
+func (s *Session) handleNodeDown(ip net.IP, port int) {
+	logDebug(s.logger, "Session.handleNodeDown", LogField{"host", net.JoinHostPort(ip.String(), strconv.Itoa(port))})
+
 	host, ok := s.ring.getHostByIP(ip.String())
 	if ok {
 		host.setState(NodeDown)
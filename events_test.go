@@ -4,6 +4,7 @@ import (
 	"net"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestEventDebounce(t *testing.T) {
@@ -15,7 +16,7 @@ func TestEventDebounce(t *testing.T) {
 	debouncer := newEventDebouncer("testDebouncer", func(events []frame) {
 		defer wg.Done()
 		eventsSeen += len(events)
-	}, &defaultLogger{})
+	}, &defaultLogger{}, systemClock{})
 	defer debouncer.stop()
 
 	for i := 0; i < eventCount; i++ {
@@ -31,3 +32,29 @@ func TestEventDebounce(t *testing.T) {
 		t.Fatalf("expected to see %d events but got %d", eventCount, eventsSeen)
 	}
 }
+
+func TestNodeUpDelay(t *testing.T) {
+	s := &Session{cfg: ClusterConfig{NodeUpDelay: 5 * time.Second}}
+
+	tests := []struct {
+		version cassVersion
+		delay   time.Duration
+	}{
+		{cassVersion{Major: 1, Minor: 2}, 5 * time.Second},
+		{cassVersion{Major: 2, Minor: 1, Patch: 9}, 5 * time.Second},
+		{cassVersion{Major: 2, Minor: 2}, 0},
+		{cassVersion{Major: 3, Minor: 0}, 0},
+		{cassVersion{Major: 4, Minor: 1}, 0},
+	}
+	for _, test := range tests {
+		host := &HostInfo{version: test.version}
+		if d := s.nodeUpDelay(host); d != test.delay {
+			t.Errorf("%v: expected a delay of %v, got %v", test.version, test.delay, d)
+		}
+	}
+
+	s.cfg.NodeUpDelay = 0
+	if d := s.nodeUpDelay(&HostInfo{version: cassVersion{Major: 2, Minor: 1}}); d != 0 {
+		t.Errorf("expected no delay once disabled, got %v", d)
+	}
+}
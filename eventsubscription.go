@@ -0,0 +1,150 @@
+package gocql
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// ClusterEvent is an event frame pushed by the server to the control
+// connection, as received, before the session debounces and handles it, see
+// Session.SubscribeEvents.
+type ClusterEvent struct {
+	// Seq numbers the events of the session from 1, a gap in the events of
+	// a subscription means that events were dropped.
+	Seq uint64
+	// Received is the time the event was handled by the session.
+	Received time.Time
+
+	// Type is TOPOLOGY_CHANGE, STATUS_CHANGE or SCHEMA_CHANGE.
+	Type string
+	// Change is the change reported by the event, such as NEW_NODE,
+	// REMOVED_NODE, MOVED_NODE, UP, DOWN, CREATED, UPDATED or DROPPED.
+	Change string
+
+	// Host and Port are the address of the node of topology and status
+	// events, as sent by the server, before address translation.
+	Host net.IP
+	Port int
+
+	// Target is the kind of the schema object of schema events, KEYSPACE,
+	// TABLE, TYPE, FUNCTION or AGGREGATE, Keyspace its keyspace and Name its
+	// name, empty for keyspaces. Args are the argument types of functions and
+	// aggregates.
+	Target   string
+	Keyspace string
+	Name     string
+	Args     []string
+
+	// Body is the body of the frame, decompressed, for consumers decoding it
+	// themselves.
+	Body []byte
+}
+
+// SubscribeEvents returns a channel receiving the events pushed by the server
+// to the session, before they are debounced, for tooling which mirrors the
+// events of the cluster into another system. Only the events the session
+// registered for are received, see ClusterConfig.Events.
+//
+// Events are dropped if the channel, of capacity buffer, is full, instead of
+// holding up the session; the gaps of ClusterEvent.Seq tell how many. The
+// channel is closed by cancel or when the session is closed.
+func (s *Session) SubscribeEvents(buffer int) (events <-chan ClusterEvent, cancel func()) {
+	return s.eventSubscribers.subscribe(buffer)
+}
+
+// eventSubscribers are the subscriptions to the events of a session.
+type eventSubscribers struct {
+	mu sync.Mutex
+	// seq is the sequence number of the last event, events are numbered
+	// even without subscribers.
+	seq    uint64
+	subs   map[chan ClusterEvent]struct{}
+	closed bool
+}
+
+func (e *eventSubscribers) subscribe(buffer int) (<-chan ClusterEvent, func()) {
+	ch := make(chan ClusterEvent, buffer)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if e.subs == nil {
+		e.subs = make(map[chan ClusterEvent]struct{})
+	}
+	e.subs[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if _, ok := e.subs[ch]; ok {
+				delete(e.subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+func (e *eventSubscribers) active() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.subs) > 0
+}
+
+// publish numbers ev and sends it to the subscriptions which have room for it.
+func (e *eventSubscribers) publish(ev ClusterEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seq++
+	ev.Seq = e.seq
+	for ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (e *eventSubscribers) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	for ch := range e.subs {
+		close(ch)
+	}
+	e.subs = nil
+}
+
+// publishEvent sends the event frame f with body to the subscriptions of the
+// session.
+func (s *Session) publishEvent(f frame, body []byte) {
+	ev := ClusterEvent{
+		Received: s.cfg.clock().Now(),
+		Body:     body,
+	}
+
+	switch f := f.(type) {
+	case *topologyChangeEventFrame:
+		ev.Type, ev.Change, ev.Host, ev.Port = "TOPOLOGY_CHANGE", f.change, f.host, f.port
+	case *statusChangeEventFrame:
+		ev.Type, ev.Change, ev.Host, ev.Port = "STATUS_CHANGE", f.change, f.host, f.port
+	case *schemaChangeKeyspace:
+		ev.Type, ev.Change, ev.Target, ev.Keyspace = "SCHEMA_CHANGE", f.change, "KEYSPACE", f.keyspace
+	case *schemaChangeTable:
+		ev.Type, ev.Change, ev.Target, ev.Keyspace, ev.Name = "SCHEMA_CHANGE", f.change, "TABLE", f.keyspace, f.object
+	case *schemaChangeType:
+		ev.Type, ev.Change, ev.Target, ev.Keyspace, ev.Name = "SCHEMA_CHANGE", f.change, "TYPE", f.keyspace, f.object
+	case *schemaChangeFunction:
+		ev.Type, ev.Change, ev.Target, ev.Keyspace, ev.Name, ev.Args = "SCHEMA_CHANGE", f.change, "FUNCTION", f.keyspace, f.name, f.args
+	case *schemaChangeAggregate:
+		ev.Type, ev.Change, ev.Target, ev.Keyspace, ev.Name, ev.Args = "SCHEMA_CHANGE", f.change, "AGGREGATE", f.keyspace, f.name, f.args
+	default:
+		return
+	}
+	s.eventSubscribers.publish(ev)
+}
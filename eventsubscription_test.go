@@ -0,0 +1,62 @@
+package gocql_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	events, cancel := session.SubscribeEvents(10)
+	defer cancel()
+
+	pushed := []gocqltest.Event{
+		{Type: "SCHEMA_CHANGE", Change: "CREATED", Target: "TABLE", Keyspace: "example", Name: "users"},
+		{Type: "SCHEMA_CHANGE", Change: "UPDATED", Target: "FUNCTION", Keyspace: "example", Name: "plus", Args: []string{"int", "int"}},
+		{Type: "STATUS_CHANGE", Change: "UP", Host: net.ParseIP("127.0.0.1"), Port: 9042},
+	}
+	for _, ev := range pushed {
+		if n := srv.PushEvent(ev); n != 1 {
+			t.Fatalf("expected the event to be sent to the control connection, sent to %d connections", n)
+		}
+		// events are handled concurrently, wait for each to keep their order
+		var got gocql.ClusterEvent
+		select {
+		case got = <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %+v wasn't received", ev)
+		}
+		if got.Type != ev.Type || got.Change != ev.Change || got.Target != ev.Target ||
+			got.Keyspace != ev.Keyspace || got.Name != ev.Name || len(got.Args) != len(ev.Args) {
+			t.Fatalf("expected event %+v, got %+v", ev, got)
+		}
+		if ev.Host != nil && (!got.Host.Equal(ev.Host) || got.Port != ev.Port) {
+			t.Fatalf("expected the address of the node %v:%d, got %v:%d", ev.Host, ev.Port, got.Host, got.Port)
+		}
+		if len(got.Body) == 0 || got.Seq == 0 || got.Received.IsZero() {
+			t.Fatalf("expected the body, sequence number and time of the event, got %+v", got)
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("expected cancel to close the channel")
+	}
+	closed, _ := session.SubscribeEvents(1)
+	session.Close()
+	if _, ok := <-closed; ok {
+		t.Fatal("expected Close to close the channel")
+	}
+}
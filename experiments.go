@@ -0,0 +1,53 @@
+package gocql
+
+import "sort"
+
+// Experiment names a new behavior of the driver which is off by default while
+// it is being rolled out, see ClusterConfig.Experiments. Experiments are
+// removed once their behavior becomes the default or is abandoned, the names
+// of experiments a version of the driver doesn't know are ignored so that a
+// configuration works across versions.
+type Experiment string
+
+const (
+	// ExperimentProtocolV5 makes the sessions which discover the protocol
+	// version, the ClusterConfig.ProtoVersion of which is 0, attempt version
+	// 5 first instead of version 4.
+	ExperimentProtocolV5 Experiment = "protocol_v5"
+)
+
+// knownExperiments are the experiments of this version of the driver.
+var knownExperiments = map[Experiment]bool{
+	ExperimentProtocolV5: true,
+}
+
+// experiments are the experiments enabled for a session.
+type experiments map[Experiment]bool
+
+func newExperiments(names []Experiment) experiments {
+	e := make(experiments, len(names))
+	for _, name := range names {
+		if knownExperiments[name] {
+			e[name] = true
+		}
+	}
+	return e
+}
+
+// Experiments returns the experiments enabled for the session, in the order of
+// their names. The experiments of ClusterConfig.Experiments which this
+// version of the driver doesn't know are not enabled.
+func (s *Session) Experiments() []Experiment {
+	names := make([]Experiment, 0, len(s.experiments))
+	for name := range s.experiments {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// ExperimentEnabled reports whether the experiment name is enabled for the
+// session.
+func (s *Session) ExperimentEnabled(name Experiment) bool {
+	return s.experiments[name]
+}
@@ -0,0 +1,62 @@
+package gocql_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type recordingConnectObserver struct {
+	mu       sync.Mutex
+	connects []gocql.ObservedConnect
+}
+
+func (o *recordingConnectObserver) ObserveConnect(c gocql.ObservedConnect) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.connects = append(o.connects, c)
+}
+
+func TestExperiments(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT * FROM experiments`).Rows(nil)
+
+	observer := &recordingConnectObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.NumConns = 1
+	cluster.ConnectObserver = observer
+	cluster.Experiments = []gocql.Experiment{gocql.ExperimentProtocolV5, "unknown"}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if got, want := session.Experiments(), []gocql.Experiment{gocql.ExperimentProtocolV5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected experiments %v, got %v", want, got)
+	}
+	if !session.ExperimentEnabled(gocql.ExperimentProtocolV5) || session.ExperimentEnabled("unknown") {
+		t.Fatal("expected only the known experiment to be enabled")
+	}
+
+	// the server doesn't support version 5, the session falls back to 4
+	observer.mu.Lock()
+	connects := observer.connects
+	observer.mu.Unlock()
+	if len(connects) == 0 || connects[0].Err == nil || !strings.Contains(connects[0].Err.Error(), "(5)") {
+		t.Fatalf("expected the first connection to be refused version 5, got %+v", connects)
+	}
+	if err := session.Query(`SELECT * FROM experiments`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range srv.Requests() {
+		if req.Statement == `SELECT * FROM experiments` && req.ProtocolVersion != 4 {
+			t.Fatalf("expected the query with version 4, got %d", req.ProtocolVersion)
+		}
+	}
+}
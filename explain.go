@@ -0,0 +1,92 @@
+package gocql
+
+// QueryExplanation describes how a query is routed, see Query.Explain.
+type QueryExplanation struct {
+	Statement string
+	// Keyspace and Table are the keyspace and table of the statement, known
+	// once it is prepared, or parsed from its text if its partition key is
+	// restricted by literals.
+	Keyspace string
+	Table    string
+	// Prepared is true if the statement is executed as a prepared statement.
+	Prepared bool
+
+	// RoutingKey is the serialized partition key of the query and Token its
+	// token, both empty if they are unknown, in which case the query isn't
+	// routed to the replicas of its partition.
+	RoutingKey []byte
+	Token      string
+
+	// Consistency is the consistency sent to the first host, after
+	// ClusterConfig.TableDefaults and DCConsistency are applied.
+	Consistency       Consistency
+	SerialConsistency SerialConsistency
+
+	// Hosts are the hosts the query is attempted on, in the order of the host
+	// selection policy. With a token aware policy the replicas of Token come
+	// first.
+	Hosts []*HostInfo
+}
+
+// Explain returns how the query would be routed if it were executed now,
+// without executing it, to debug hot partitions or requests sent to the wrong
+// datacenter. It may prepare the statement to compute its routing key. The
+// hosts are picked from the host selection policy like for an execution, which
+// advances round-robin policies.
+func (q *Query) Explain() (*QueryExplanation, error) {
+	s := q.session
+	if s.Closed() {
+		return nil, ErrSessionClosed
+	}
+
+	// the table defaults change the query, they are applied to a copy
+	qry := q.Clone()
+	defer qry.Release()
+	s.applyTableDefaults(qry)
+
+	e := &QueryExplanation{
+		Statement:         qry.stmt,
+		Prepared:          !qry.skipPrepare && qry.shouldPrepare(),
+		Consistency:       qry.cons,
+		SerialConsistency: qry.serialCons,
+	}
+
+	routingKey, err := qry.GetRoutingKey()
+	if err != nil {
+		return nil, err
+	}
+	e.RoutingKey = routingKey
+	e.Keyspace = qry.Keyspace()
+	e.Table = qry.Table()
+	if routingKey != nil {
+		if p, err := newPartitioner(s.Partitioner()); err == nil {
+			e.Token = p.Hash(routingKey).String()
+		}
+	}
+
+	if hostID := qry.targetHostID(); hostID != "" {
+		if host := s.ring.getHost(hostID); host != nil {
+			e.Hosts = []*HostInfo{host}
+		}
+	} else {
+		policy := s.policy
+		if p := qry.hostSelectionPolicy(); p != nil {
+			policy = p
+		}
+		next := policy.Pick(qry)
+		seen := make(map[*HostInfo]bool)
+		for selected := next(); selected != nil; selected = next() {
+			host := selected.Info()
+			if host == nil || seen[host] {
+				continue
+			}
+			seen[host] = true
+			e.Hosts = append(e.Hosts, host)
+		}
+	}
+
+	if len(e.Hosts) > 0 {
+		e.Consistency = s.dcConsistency.consistency(e.Consistency, e.Hosts[0])
+	}
+	return e, nil
+}
@@ -0,0 +1,58 @@
+package gocql_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+	"github.com/gocql/gocql/internal/murmur"
+)
+
+func TestQueryExplain(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	stmt := `SELECT total FROM orders WHERE id = ?`
+	srv.On(stmt).
+		Table("orders").
+		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+		PartitionKey(0).
+		Rows([]gocqltest.Column{{Name: "total", Type: gocqltest.Int}})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	e, err := session.Query(stmt, 42).Consistency(gocql.LocalQuorum).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Prepared || e.Table != "orders" || e.Consistency != gocql.LocalQuorum {
+		t.Fatalf("unexpected explanation %+v", e)
+	}
+	key := []byte{0, 0, 0, 42}
+	if !bytes.Equal(e.RoutingKey, key) {
+		t.Fatalf("expected routing key %x, got %x", key, e.RoutingKey)
+	}
+	if token := strconv.FormatInt(murmur.Murmur3H1(key), 10); e.Token != token {
+		t.Fatalf("expected token %s, got %s", token, e.Token)
+	}
+	if len(e.Hosts) != 1 || e.Hosts[0].HostID() != srv.HostID.String() {
+		t.Fatalf("expected the host of the server, got %v", e.Hosts)
+	}
+
+	e, err = session.Query(`TRUNCATE orders`).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Prepared || e.RoutingKey != nil || e.Token != "" {
+		t.Fatalf("expected an unrouted statement, got %+v", e)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Fatal("expected no query to be executed")
+	}
+}
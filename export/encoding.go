@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// NewCSVEncoder returns an Encoder writing a header line with the names of
+// the columns followed by a line per row. Blobs are written in hex prefixed
+// with 0x, timestamps in RFC 3339 and null values as empty fields.
+func NewCSVEncoder(w io.Writer) Encoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) WriteHeader(columns []string) error {
+	return e.w.Write(columns)
+}
+
+func (e *csvEncoder) WriteRow(values []interface{}) error {
+	e.record = e.record[:0]
+	for _, v := range values {
+		e.record = append(e.record, csvField(v))
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func csvField(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		if v == nil {
+			return ""
+		}
+		return "0x" + hex.EncodeToString(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		// collections and UDTs are written in JSON
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// NewJSONEncoder returns an Encoder writing every row as a JSON object on its
+// own line, with the columns in the order of the table. Values are encoded
+// with encoding/json, blobs in base64.
+func NewJSONEncoder(w io.Writer) Encoder {
+	return &jsonEncoder{w: bufio.NewWriter(w)}
+}
+
+type jsonEncoder struct {
+	w       *bufio.Writer
+	columns [][]byte
+	line    []byte
+}
+
+func (e *jsonEncoder) WriteHeader(columns []string) error {
+	e.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		name, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		e.columns[i] = name
+	}
+	return nil
+}
+
+func (e *jsonEncoder) WriteRow(values []interface{}) error {
+	if len(values) != len(e.columns) {
+		return fmt.Errorf("export: row has %d values, expected %d", len(values), len(e.columns))
+	}
+	e.line = append(e.line[:0], '{')
+	for i, v := range values {
+		if i > 0 {
+			e.line = append(e.line, ',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("export: encode column %s: %w", e.columns[i], err)
+		}
+		e.line = append(e.line, e.columns[i]...)
+		e.line = append(e.line, ':')
+		e.line = append(e.line, value...)
+	}
+	e.line = append(e.line, '}', '\n')
+	_, err := e.w.Write(e.line)
+	return err
+}
+
+func (e *jsonEncoder) Flush() error {
+	return e.w.Flush()
+}
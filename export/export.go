@@ -0,0 +1,293 @@
+// Package export dumps tables by scanning their token ranges in parallel.
+//
+// An Exporter splits the token ring of the Murmur3 partitioner into ranges,
+// reads them with a bounded number of concurrent queries, and writes the rows
+// with an Encoder:
+//
+//	f, err := os.Create("orders.csv")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	exporter := &export.Exporter{Session: session, Keyspace: "shop", Table: "orders"}
+//	if err := exporter.Export(ctx, export.NewCSVEncoder(f)); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Rows are written in the order they are read, so the rows of the ranges read
+// concurrently are interleaved. At most Concurrency pages and Buffer rows are
+// held in memory.
+package export
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gocql/gocql"
+)
+
+// Encoder writes the rows of an export.
+type Encoder interface {
+	// WriteHeader is called once with the names of the columns, before the
+	// first row. It isn't called if the table has no rows.
+	WriteHeader(columns []string) error
+	// WriteRow writes the values of a row, in the order of the columns.
+	WriteRow(values []interface{}) error
+	// Flush is called once all rows are written.
+	Flush() error
+}
+
+// Progress is the progress of an export.
+type Progress struct {
+	// Ranges is the number of token ranges, RangesDone the number of ranges
+	// which were read completely.
+	Ranges     int
+	RangesDone int
+	// Rows is the number of rows written.
+	Rows int64
+}
+
+// Exporter exports a table.
+type Exporter struct {
+	Session  *gocql.Session
+	Keyspace string
+	Table    string
+
+	// Columns are the columns exported.
+	// Default: all columns
+	Columns []string
+
+	// PartitionKey are the columns of the partition key of the table.
+	// Default: read from the metadata of the table
+	PartitionKey []string
+
+	// Ranges is the number of token ranges the ring is split into.
+	// Default: 256
+	Ranges int
+
+	// Concurrency is the number of ranges read concurrently.
+	// Default: 4
+	Concurrency int
+
+	// Buffer is the number of rows read ahead of the encoder.
+	// Default: 1000
+	Buffer int
+
+	// PageSize of the queries, if not zero.
+	PageSize int
+
+	// Progress is called after every range with the progress of the export,
+	// from the goroutine calling Export.
+	Progress func(Progress)
+}
+
+// tokenRange is the range of tokens (start, end].
+type tokenRange struct {
+	start, end int64
+}
+
+// splitRing splits the tokens of the Murmur3 partitioner into n ranges.
+func splitRing(n int) []tokenRange {
+	ranges := make([]tokenRange, n)
+	width := math.MaxUint64 / uint64(n)
+	base := int64(math.MinInt64)
+	start := base
+	for i := range ranges {
+		end := int64(math.MaxInt64)
+		if i < n-1 {
+			end = int64(uint64(base) + uint64(i+1)*width)
+		}
+		ranges[i] = tokenRange{start: start, end: end}
+		start = end
+	}
+	return ranges
+}
+
+func (e *Exporter) table() string {
+	if e.Keyspace != "" {
+		return e.Keyspace + "." + e.Table
+	}
+	return e.Table
+}
+
+func (e *Exporter) partitionKey() ([]string, error) {
+	if len(e.PartitionKey) > 0 {
+		return e.PartitionKey, nil
+	}
+	keyspace, err := e.Session.KeyspaceMetadata(e.Keyspace)
+	if err != nil {
+		return nil, fmt.Errorf("export: read metadata of %s: %w", e.table(), err)
+	}
+	table, ok := keyspace.Tables[e.Table]
+	if !ok {
+		return nil, fmt.Errorf("export: table %s not found", e.table())
+	}
+	key := make([]string, len(table.PartitionKey))
+	for i, column := range table.PartitionKey {
+		key[i] = column.Name
+	}
+	return key, nil
+}
+
+func (e *Exporter) statement(key []string) string {
+	columns := "*"
+	if len(e.Columns) > 0 {
+		columns = strings.Join(e.Columns, ", ")
+	}
+	token := "token(" + strings.Join(key, ", ") + ")"
+	return "SELECT " + columns + " FROM " + e.table() + " WHERE " + token + " > ? AND " + token + " <= ?"
+}
+
+// row is a row read by a worker, or the end of a range if values is nil.
+type row struct {
+	columns []string
+	values  []interface{}
+}
+
+// Export writes the rows of the table with enc. It stops at the first error,
+// which is returned.
+func (e *Exporter) Export(ctx context.Context, enc Encoder) error {
+	key, err := e.partitionKey()
+	if err != nil {
+		return err
+	}
+	stmt := e.statement(key)
+
+	numRanges := e.Ranges
+	if numRanges <= 0 {
+		numRanges = 256
+	}
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	buffer := e.Buffer
+	if buffer <= 0 {
+		buffer = 1000
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ranges := make(chan tokenRange)
+	rows := make(chan row, buffer)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		failed   int32
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			atomic.StoreInt32(&failed, 1)
+			cancel()
+		})
+	}
+
+	go func() {
+		defer close(ranges)
+		for _, r := range splitRing(numRanges) {
+			select {
+			case ranges <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range ranges {
+				if err := e.readRange(ctx, stmt, r, rows); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(rows)
+	}()
+
+	progress := Progress{Ranges: numRanges}
+	wroteHeader := false
+	for row := range rows {
+		if atomic.LoadInt32(&failed) != 0 {
+			continue
+		}
+		if row.values == nil {
+			progress.RangesDone++
+			if e.Progress != nil {
+				e.Progress(progress)
+			}
+			continue
+		}
+		if !wroteHeader {
+			if err := enc.WriteHeader(row.columns); err != nil {
+				fail(err)
+				continue
+			}
+			wroteHeader = true
+		}
+		if err := enc.WriteRow(row.values); err != nil {
+			fail(err)
+			continue
+		}
+		progress.Rows++
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// readRange sends the rows of the token range r to rows, followed by the end
+// of the range.
+func (e *Exporter) readRange(ctx context.Context, stmt string, r tokenRange, rows chan<- row) error {
+	send := func(row row) error {
+		select {
+		case rows <- row:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	q := e.Session.Query(stmt, r.start, r.end).WithContext(ctx)
+	if e.PageSize > 0 {
+		q = q.PageSize(e.PageSize)
+	}
+	iter := q.Iter()
+	for {
+		data, err := iter.RowData()
+		if err != nil {
+			iter.Close()
+			return fmt.Errorf("export: read token range (%d, %d]: %w", r.start, r.end, err)
+		}
+		if !iter.Scan(data.Values...) {
+			break
+		}
+		values := make([]interface{}, len(data.Values))
+		for i, v := range data.Values {
+			values[i] = reflect.ValueOf(v).Elem().Interface()
+		}
+		if err := send(row{columns: data.Columns, values: values}); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("export: read token range (%d, %d]: %w", r.start, r.end, err)
+	}
+	return send(row{})
+}
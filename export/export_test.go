@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestSplitRing(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 256} {
+		ranges := splitRing(n)
+		if len(ranges) != n {
+			t.Fatalf("expected %d ranges, got %d", n, len(ranges))
+		}
+		if ranges[0].start != math.MinInt64 || ranges[n-1].end != math.MaxInt64 {
+			t.Fatalf("%d ranges don't cover the ring: %v", n, ranges)
+		}
+		for i, r := range ranges {
+			if r.start >= r.end {
+				t.Fatalf("empty range %d of %d: %v", i, n, r)
+			}
+			if i > 0 && ranges[i-1].end != r.start {
+				t.Fatalf("ranges %d and %d of %d aren't contiguous: %v %v", i-1, i, n, ranges[i-1], r)
+			}
+		}
+	}
+}
+
+// orders are stubbed with their token, the range queries return the orders
+// of their range.
+type order struct {
+	token int64
+	id    int
+	name  string
+}
+
+func newFakeOrders(t *testing.T, orders []order) (*gocql.Session, *gocqltest.Server) {
+	t.Helper()
+
+	srv := gocqltest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.On(`SELECT id, name FROM shop.orders WHERE token(id) > ? AND token(id) <= ?`).
+		Params(
+			gocqltest.Column{Name: "start", Type: gocqltest.BigInt},
+			gocqltest.Column{Name: "end", Type: gocqltest.BigInt},
+		).
+		Handle([]gocqltest.Column{
+			{Name: "id", Type: gocqltest.Int},
+			{Name: "name", Type: gocqltest.Text},
+		}, func(req *gocqltest.Request) gocqltest.Response {
+			var start, end int64
+			if err := req.Scan(&start, &end); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			var rows [][]interface{}
+			for _, o := range orders {
+				if o.token > start && o.token <= end {
+					rows = append(rows, []interface{}{o.id, o.name})
+				}
+			}
+			return gocqltest.Response{Rows: rows}
+		})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return session, srv
+}
+
+var testOrders = []order{
+	{token: math.MinInt64 + 1, id: 1, name: "a"},
+	{token: -10, id: 2, name: "b,c"},
+	{token: 0, id: 3, name: "d"},
+	{token: math.MaxInt64, id: 4, name: "e"},
+}
+
+func TestExportCSV(t *testing.T) {
+	session, _ := newFakeOrders(t, testOrders)
+
+	var (
+		mu       sync.Mutex
+		progress []Progress
+	)
+	exporter := &Exporter{
+		Session:      session,
+		Keyspace:     "shop",
+		Table:        "orders",
+		Columns:      []string{"id", "name"},
+		PartitionKey: []string{"id"},
+		Ranges:       8,
+		Concurrency:  3,
+		Buffer:       1,
+		Progress: func(p Progress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		},
+	}
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), NewCSVEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id,name" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	got := make(map[string]bool)
+	for _, line := range lines[1:] {
+		got[line] = true
+	}
+	for _, want := range []string{"1,a", `2,"b,c"`, "3,d", "4,e"} {
+		if !got[want] {
+			t.Errorf("missing row %q in %q", want, buf.String())
+		}
+	}
+	if len(lines) != 5 {
+		t.Fatalf("expected 4 rows, got %q", buf.String())
+	}
+
+	if len(progress) != 8 {
+		t.Fatalf("expected progress after each of the 8 ranges, got %d", len(progress))
+	}
+	if last := progress[len(progress)-1]; last.Ranges != 8 || last.RangesDone != 8 || last.Rows != 4 {
+		t.Fatalf("unexpected final progress %+v", last)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	session, _ := newFakeOrders(t, testOrders[:1])
+
+	exporter := &Exporter{
+		Session:      session,
+		Keyspace:     "shop",
+		Table:        "orders",
+		Columns:      []string{"id", "name"},
+		PartitionKey: []string{"id"},
+		Ranges:       2,
+	}
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), NewJSONEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != `{"id":1,"name":"a"}`+"\n" {
+		t.Fatalf("unexpected output %q", s)
+	}
+}
+
+func TestCSVField(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{[]byte{0xca, 0xfe}, "0xcafe"},
+		{[]byte(nil), ""},
+		{int64(42), "42"},
+		{[]string{"a", "b"}, `["a","b"]`},
+		{gocql.UUID{}, "00000000-0000-0000-0000-000000000000"},
+	}
+	for _, test := range tests {
+		if got := csvField(test.value); got != test.want {
+			t.Errorf("csvField(%#v) = %q, expected %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestExportError(t *testing.T) {
+	session, srv := newFakeOrders(t, testOrders)
+	srv.On(`SELECT id, name FROM shop.orders WHERE token(id) > ? AND token(id) <= ?`).
+		Error(gocql.ErrCodeReadTimeout, "timed out")
+
+	exporter := &Exporter{
+		Session:      session,
+		Keyspace:     "shop",
+		Table:        "orders",
+		Columns:      []string{"id", "name"},
+		PartitionKey: []string{"id"},
+		Ranges:       4,
+	}
+	err := exporter.Export(context.Background(), NewCSVEncoder(&bytes.Buffer{}))
+	var reqErr gocql.RequestError
+	if !errors.As(err, &reqErr) || !strings.Contains(err.Error(), "token range") {
+		t.Fatalf("expected the read error of a token range, got %v", err)
+	}
+}
+
+type failingEncoder struct{ err error }
+
+func (e failingEncoder) WriteHeader([]string) error   { return nil }
+func (e failingEncoder) WriteRow([]interface{}) error { return e.err }
+func (e failingEncoder) Flush() error                 { return nil }
+
+func TestExportEncoderError(t *testing.T) {
+	session, _ := newFakeOrders(t, testOrders)
+
+	exporter := &Exporter{
+		Session:      session,
+		Keyspace:     "shop",
+		Table:        "orders",
+		Columns:      []string{"id", "name"},
+		PartitionKey: []string{"id"},
+	}
+	errEncode := errors.New("disk full")
+	if err := exporter.Export(context.Background(), failingEncoder{errEncode}); err != errEncode {
+		t.Fatalf("expected the encoder error, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package gocql
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy chooses the write dropped when the queue of fire and forget
+// writes is full, see FireAndForget.
+type DropPolicy int
+
+const (
+	// DropNewest drops the write being queued.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the write queued the longest, to make room for the
+	// new one.
+	DropOldest
+)
+
+// FireAndForget configures the writes of a session executed with
+// Query.FireAndForget.
+type FireAndForget struct {
+	// QueueSize is the number of writes which can be waiting to be executed.
+	// Default: 1024
+	QueueSize int
+
+	// Workers is the number of goroutines executing the writes.
+	// Default: 1
+	Workers int
+
+	// DropPolicy chooses the write dropped when the queue is full.
+	// Default: DropNewest
+	DropPolicy DropPolicy
+
+	// OnError, if set, is called from the workers with the statement and the
+	// error of every write which failed.
+	OnError func(stmt string, err error)
+}
+
+// FireAndForgetStats are the counters of the writes of a session executed
+// with Query.FireAndForget, see Session.FireAndForgetStats.
+type FireAndForgetStats struct {
+	// Queued is the number of writes queued, Pending the number waiting in
+	// the queue.
+	Queued  uint64
+	Pending int
+	// Sent is the number of writes which succeeded and Failed the number of
+	// writes which returned an error.
+	Sent   uint64
+	Failed uint64
+	// Dropped is the number of writes dropped because the queue was full or
+	// the session was closed.
+	Dropped uint64
+}
+
+// FireAndForget queues the query to be executed in the background and returns
+// immediately, for best-effort writes, such as telemetry, the caller must not
+// wait for. The query belongs to the session once queued and must not be used
+// by the caller anymore. Its results are discarded, its errors are counted in
+// Session.FireAndForgetStats and passed to FireAndForget.OnError.
+//
+// When the queue of the session is full a write is dropped, according to
+// ClusterConfig.FireAndForget.DropPolicy. The writes still queued when the
+// session is closed are dropped too.
+//
+// The query is executed with its own context, which should not be canceled
+// when the caller returns.
+func (q *Query) FireAndForget() {
+	q.session.fireAndForget.enqueue(q)
+}
+
+// FireAndForgetStats returns the counters of the writes executed with
+// Query.FireAndForget.
+func (s *Session) FireAndForgetStats() FireAndForgetStats {
+	return s.fireAndForget.stats()
+}
+
+// fireAndForgetQueue executes the writes of Query.FireAndForget. Its workers
+// are started by the first write.
+type fireAndForgetQueue struct {
+	// counters are first to be 64-bit aligned.
+	queued  uint64
+	sent    uint64
+	failed  uint64
+	dropped uint64
+
+	cfg   FireAndForget
+	queue chan *Query
+	start sync.Once
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newFireAndForgetQueue(cfg FireAndForget) *fireAndForgetQueue {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	return &fireAndForgetQueue{
+		cfg:   cfg,
+		queue: make(chan *Query, cfg.QueueSize),
+		stop:  make(chan struct{}),
+	}
+}
+
+func (f *fireAndForgetQueue) enqueue(q *Query) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		f.drop(q)
+		return
+	}
+	f.start.Do(func() {
+		f.wg.Add(f.cfg.Workers)
+		for i := 0; i < f.cfg.Workers; i++ {
+			go f.worker()
+		}
+	})
+
+	for {
+		select {
+		case f.queue <- q:
+			atomic.AddUint64(&f.queued, 1)
+			return
+		default:
+		}
+		if f.cfg.DropPolicy != DropOldest {
+			f.drop(q)
+			return
+		}
+		select {
+		case old := <-f.queue:
+			f.drop(old)
+		default:
+		}
+	}
+}
+
+func (f *fireAndForgetQueue) worker() {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case q := <-f.queue:
+			if err := q.Exec(); err != nil {
+				atomic.AddUint64(&f.failed, 1)
+				if f.cfg.OnError != nil {
+					f.cfg.OnError(q.stmt, err)
+				}
+			} else {
+				atomic.AddUint64(&f.sent, 1)
+			}
+			q.Release()
+		}
+	}
+}
+
+func (f *fireAndForgetQueue) drop(q *Query) {
+	atomic.AddUint64(&f.dropped, 1)
+	q.Release()
+}
+
+// close stops the workers once they executed their current write and drops
+// the queued writes.
+func (f *fireAndForgetQueue) close() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	close(f.stop)
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	for {
+		select {
+		case q := <-f.queue:
+			f.drop(q)
+		default:
+			return
+		}
+	}
+}
+
+func (f *fireAndForgetQueue) stats() FireAndForgetStats {
+	return FireAndForgetStats{
+		Queued:  atomic.LoadUint64(&f.queued),
+		Pending: len(f.queue),
+		Sent:    atomic.LoadUint64(&f.sent),
+		Failed:  atomic.LoadUint64(&f.failed),
+		Dropped: atomic.LoadUint64(&f.dropped),
+	}
+}
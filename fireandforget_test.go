@@ -0,0 +1,110 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// waitForStats polls the fire and forget stats of session until done returns
+// true.
+func waitForStats(t *testing.T, session *gocql.Session, done func(gocql.FireAndForgetStats) bool) gocql.FireAndForgetStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := session.FireAndForgetStats()
+		if done(stats) {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for fire and forget writes, stats %+v", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFireAndForget(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO ks.events (id) VALUES (1)`).Rows(nil)
+	srv.On(`INSERT INTO ks.missing (id) VALUES (1)`).Error(gocql.ErrCodeInvalid, "unconfigured table missing")
+
+	var (
+		mu     sync.Mutex
+		failed []string
+	)
+	cluster := srv.ClusterConfig()
+	cluster.FireAndForget.OnError = func(stmt string, err error) {
+		mu.Lock()
+		failed = append(failed, stmt)
+		mu.Unlock()
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 3; i++ {
+		session.Query(`INSERT INTO ks.events (id) VALUES (1)`).FireAndForget()
+	}
+	session.Query(`INSERT INTO ks.missing (id) VALUES (1)`).FireAndForget()
+
+	stats := waitForStats(t, session, func(s gocql.FireAndForgetStats) bool { return s.Sent+s.Failed == 4 })
+	if want := (gocql.FireAndForgetStats{Queued: 4, Sent: 3, Failed: 1}); stats != want {
+		t.Fatalf("expected stats %+v, got %+v", want, stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || failed[0] != `INSERT INTO ks.missing (id) VALUES (1)` {
+		t.Fatalf("expected OnError to be called for the failed write, got %q", failed)
+	}
+}
+
+func TestFireAndForgetDropPolicy(t *testing.T) {
+	tests := []struct {
+		policy gocql.DropPolicy
+		sent   string
+	}{
+		{gocql.DropNewest, `INSERT INTO ks.events (id) VALUES (2)`},
+		{gocql.DropOldest, `INSERT INTO ks.events (id) VALUES (3)`},
+	}
+	for _, test := range tests {
+		srv := gocqltest.NewServer()
+		started, unblock := blockOn(srv, `INSERT INTO ks.events (id) VALUES (1)`)
+		srv.On(`INSERT INTO ks.events (id) VALUES (2)`).Rows(nil)
+		srv.On(`INSERT INTO ks.events (id) VALUES (3)`).Rows(nil)
+
+		cluster := srv.ClusterConfig()
+		cluster.FireAndForget = gocql.FireAndForget{QueueSize: 1, DropPolicy: test.policy}
+		session, err := cluster.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		session.Query(`INSERT INTO ks.events (id) VALUES (1)`).FireAndForget()
+		<-started
+		session.Query(`INSERT INTO ks.events (id) VALUES (2)`).FireAndForget()
+		session.Query(`INSERT INTO ks.events (id) VALUES (3)`).FireAndForget()
+		if stats := session.FireAndForgetStats(); stats.Dropped != 1 || stats.Pending != 1 {
+			t.Fatalf("policy %v: expected a write dropped and a write pending, got %+v", test.policy, stats)
+		}
+
+		unblock()
+		waitForStats(t, session, func(s gocql.FireAndForgetStats) bool { return s.Sent == 2 })
+		var sent []string
+		for _, req := range srv.Requests() {
+			sent = append(sent, req.Statement)
+		}
+		if len(sent) != 2 || sent[1] != test.sent {
+			t.Fatalf("policy %v: expected %q to be sent after the first write, got %q", test.policy, test.sent, sent)
+		}
+
+		session.Close()
+		srv.Close()
+	}
+}
@@ -27,6 +27,25 @@ type unsetColumn struct{}
 // UnsetValue is only available when using the version 4 of the protocol.
 var UnsetValue = unsetColumn{}
 
+// ErrUnsetValueUnsupported is returned for queries and batches binding
+// UnsetValue on connections using a protocol older than version 4, which has
+// no unset marker. The request isn't sent.
+var ErrUnsetValueUnsupported = errors.New("gocql: UnsetValue requires protocol version 4 or later")
+
+// checkUnset returns ErrUnsetValueUnsupported if values hold an unset value
+// and the protocol version doesn't support them.
+func checkUnset(version byte, values []queryValues) error {
+	if version >= protoVersion4 {
+		return nil
+	}
+	for i := range values {
+		if values[i].isUnset {
+			return ErrUnsetValueUnsupported
+		}
+	}
+	return nil
+}
+
 type namedValue struct {
 	name  string
 	value interface{}
@@ -382,6 +401,11 @@ type framer struct {
 	buf []byte
 
 	customPayload map[string][]byte
+
+	// compression overrides whether the frame is compressed, frames smaller
+	// than compressThreshold are not by default.
+	compression       Compression
+	compressThreshold int
 }
 
 func newFramer(compressor Compressor, version byte) *framer {
@@ -682,8 +706,20 @@ func (f *framer) parseErrorFrame() frame {
 		res.Received = f.readInt()
 		res.BlockFor = f.readInt()
 		return res
-	case ErrCodeInvalid, ErrCodeBootstrapping, ErrCodeConfig, ErrCodeCredentials, ErrCodeOverloaded,
-		ErrCodeProtocol, ErrCodeServer, ErrCodeSyntax, ErrCodeTruncate, ErrCodeUnauthorized:
+	case ErrCodeOverloaded:
+		return &RequestErrOverloaded{
+			errorFrame: errD,
+		}
+	case ErrCodeBootstrapping:
+		return &RequestErrBootstrapping{
+			errorFrame: errD,
+		}
+	case ErrCodeTruncate:
+		return &RequestErrTruncate{
+			errorFrame: errD,
+		}
+	case ErrCodeInvalid, ErrCodeConfig, ErrCodeCredentials,
+		ErrCodeProtocol, ErrCodeServer, ErrCodeSyntax, ErrCodeUnauthorized:
 		// TODO(zariel): we should have some distinct types for these errors
 		return errD
 	default:
@@ -748,18 +784,34 @@ func (f *framer) finish() error {
 		return ErrFrameTooBig
 	}
 
+	if f.buf[1]&flagCompress == flagCompress && !f.shouldCompress() {
+		f.buf[1] &^= flagCompress
+	}
 	if f.buf[1]&flagCompress == flagCompress {
 		if f.compres == nil {
 			panic("compress flag set with no compressor")
 		}
 
-		// TODO: only compress frames which are big enough
-		compressed, err := f.compres.Encode(f.buf[f.headSize:])
-		if err != nil {
-			return err
-		}
+		if appender, ok := f.compres.(AppendEncoder); ok {
+			bufp := encodeBufPool.Get().(*[]byte)
+			compressed, err := appender.AppendEncode((*bufp)[:0], f.buf[f.headSize:])
+			if err != nil {
+				encodeBufPool.Put(bufp)
+				return err
+			}
+			f.buf = append(f.buf[:f.headSize], compressed...)
+			if cap(compressed) <= maxPooledEncodeBufSize {
+				*bufp = compressed
+			}
+			encodeBufPool.Put(bufp)
+		} else {
+			compressed, err := f.compres.Encode(f.buf[f.headSize:])
+			if err != nil {
+				return err
+			}
 
-		f.buf = append(f.buf[:f.headSize], compressed...)
+			f.buf = append(f.buf[:f.headSize], compressed...)
+		}
 	}
 	length := len(f.buf) - f.headSize
 	f.setLength(length)
@@ -767,6 +819,18 @@ func (f *framer) finish() error {
 	return nil
 }
 
+// shouldCompress reports whether the frame is compressed when the connection
+// negotiated compression.
+func (f *framer) shouldCompress() bool {
+	switch f.compression {
+	case CompressionDisabled:
+		return false
+	case CompressionForced:
+		return true
+	}
+	return len(f.buf)-f.headSize >= f.compressThreshold
+}
+
 func (f *framer) writeTo(w io.Writer) error {
 	_, err := w.Write(f.buf)
 	return err
@@ -1546,6 +1610,8 @@ type writeQueryFrame struct {
 
 	// v4+
 	customPayload map[string][]byte
+
+	compression Compression
 }
 
 func (w *writeQueryFrame) String() string {
@@ -1553,6 +1619,7 @@ func (w *writeQueryFrame) String() string {
 }
 
 func (w *writeQueryFrame) buildFrame(framer *framer, streamID int) error {
+	framer.compression = w.compression
 	return framer.writeQueryFrame(streamID, w.statement, &w.params, w.customPayload)
 }
 
@@ -1584,6 +1651,8 @@ type writeExecuteFrame struct {
 
 	// v4+
 	customPayload map[string][]byte
+
+	compression Compression
 }
 
 func (e *writeExecuteFrame) String() string {
@@ -1591,6 +1660,7 @@ func (e *writeExecuteFrame) String() string {
 }
 
 func (e *writeExecuteFrame) buildFrame(fr *framer, streamID int) error {
+	fr.compression = e.compression
 	return fr.writeExecuteFrame(streamID, e.preparedID, &e.params, &e.customPayload)
 }
 
@@ -1639,18 +1709,58 @@ type writeBatchFrame struct {
 
 	//v4+
 	customPayload map[string][]byte
+
+	compression Compression
 }
 
 func (w *writeBatchFrame) buildFrame(framer *framer, streamID int) error {
+	framer.compression = w.compression
 	return framer.writeBatchFrame(streamID, w, w.customPayload)
 }
 
+// bodySize returns the encoded size of the batch after the custom payload, so
+// that the frame buffer is allocated once.
+func (w *writeBatchFrame) bodySize(proto byte) int {
+	// type, number of statements and consistency
+	size := 1 + 2 + 2
+	for i := range w.statements {
+		b := &w.statements[i]
+		if len(b.preparedID) == 0 {
+			size += 1 + 4 + len(b.statement)
+		} else {
+			size += 1 + 2 + len(b.preparedID)
+		}
+		size += 2
+		for j := range b.values {
+			if b.values[j].name != "" {
+				size += 2 + len(b.values[j].name)
+			}
+			size += 4 + len(b.values[j].value)
+		}
+	}
+	if proto > protoVersion2 {
+		if proto > protoVersion4 {
+			size += 4
+		} else {
+			size++
+		}
+		if w.serialConsistency > 0 {
+			size += 2
+		}
+		if w.defaultTimestamp {
+			size += 8
+		}
+	}
+	return size
+}
+
 func (f *framer) writeBatchFrame(streamID int, w *writeBatchFrame, customPayload map[string][]byte) error {
 	if len(customPayload) > 0 {
 		f.payload()
 	}
 	f.writeHeader(f.flags, opBatch, streamID)
 	f.writeCustomPayload(&customPayload)
+	f.grow(w.bodySize(f.proto))
 	f.writeByte(byte(w.typ))
 
 	n := len(w.statements)
@@ -1916,6 +2026,16 @@ func (f *framer) readStringMultiMap() map[string][]string {
 	return m
 }
 
+// grow ensures that n more bytes can be written without reallocating.
+func (f *framer) grow(n int) {
+	if cap(f.buf)-len(f.buf) >= n {
+		return
+	}
+	buf := make([]byte, len(f.buf), len(f.buf)+n)
+	copy(buf, f.buf)
+	f.buf = buf
+}
+
 func (f *framer) writeByte(b byte) {
 	f.buf = append(f.buf, b)
 }
@@ -3,6 +3,7 @@ package gocql
 import (
 	"bytes"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -103,3 +104,78 @@ func TestFrameReadTooLong(t *testing.T) {
 		t.Fatalf("expected to get header %v got %v", opReady, head.op)
 	}
 }
+
+func TestBatchFrameBodySize(t *testing.T) {
+	w := &writeBatchFrame{
+		typ: LoggedBatch,
+		statements: []batchStatment{
+			{statement: "INSERT INTO t (a) VALUES (1)"},
+			{preparedID: []byte{1, 2, 3}, values: []queryValues{{value: []byte("a")}, {value: nil}, {isUnset: true}}},
+		},
+		consistency:       Quorum,
+		serialConsistency: LocalSerial,
+		defaultTimestamp:  true,
+	}
+
+	for _, proto := range []byte{protoVersion2, protoVersion4, protoVersion5} {
+		framer := newFramer(nil, proto)
+		if err := framer.writeBatchFrame(1, w, nil); err != nil {
+			t.Fatal(err)
+		}
+		if size, written := w.bodySize(proto), len(framer.buf)-framer.headSize; size != written {
+			t.Errorf("protocol %d: expected a body size of %d, got %d", proto, written, size)
+		}
+	}
+}
+
+func TestConsistencyWireValues(t *testing.T) {
+	// the values are written as is in frames
+	tests := []struct {
+		cons Consistency
+		wire uint16
+		name string
+	}{
+		{Any, 0x00, "ANY"},
+		{One, 0x01, "ONE"},
+		{Two, 0x02, "TWO"},
+		{Three, 0x03, "THREE"},
+		{Quorum, 0x04, "QUORUM"},
+		{All, 0x05, "ALL"},
+		{LocalQuorum, 0x06, "LOCAL_QUORUM"},
+		{EachQuorum, 0x07, "EACH_QUORUM"},
+		{LocalOne, 0x0A, "LOCAL_ONE"},
+	}
+	for _, test := range tests {
+		if uint16(test.cons) != test.wire {
+			t.Errorf("%s: expected wire value 0x%x, got 0x%x", test.name, test.wire, uint16(test.cons))
+		}
+		text, err := test.cons.MarshalText()
+		if err != nil || string(text) != test.name {
+			t.Errorf("expected %s to marshal to %q, got %q: %v", test.name, test.name, text, err)
+		}
+		if c, err := ParseConsistencyWrapper(strings.ToLower(test.name)); err != nil || c != test.cons {
+			t.Errorf("expected %q to parse to %v, got %v: %v", test.name, test.cons, c, err)
+		}
+	}
+	if _, err := ParseConsistencyWrapper("SERIAL"); err == nil {
+		t.Error("expected SERIAL not to be a consistency")
+	}
+
+	serials := []struct {
+		cons SerialConsistency
+		wire uint16
+		name string
+	}{
+		{Serial, 0x08, "SERIAL"},
+		{LocalSerial, 0x09, "LOCAL_SERIAL"},
+	}
+	for _, test := range serials {
+		if uint16(test.cons) != test.wire {
+			t.Errorf("%s: expected wire value 0x%x, got 0x%x", test.name, test.wire, uint16(test.cons))
+		}
+		var s SerialConsistency
+		if err := s.UnmarshalText([]byte(test.name)); err != nil || s != test.cons {
+			t.Errorf("expected %q to unmarshal to %v, got %v: %v", test.name, test.cons, s, err)
+		}
+	}
+}
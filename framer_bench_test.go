@@ -46,3 +46,28 @@ func BenchmarkParseRowsFrame(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkWriteBatchFrame(b *testing.B) {
+	values := make([]queryValues, 8)
+	for i := range values {
+		values[i].value = make([]byte, 16)
+	}
+	w := &writeBatchFrame{
+		typ:         UnloggedBatch,
+		statements:  make([]batchStatment, 100),
+		consistency: One,
+	}
+	for i := range w.statements {
+		w.statements[i] = batchStatment{preparedID: make([]byte, 16), values: values}
+	}
+
+	framer := newFramer(nil, protoVersion4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		framer.buf = framer.buf[:0:0]
+		if err := framer.writeBatchFrame(1, w, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package gocqlmock provides interfaces for the public surface of gocql.Session,
+// gocql.Query and gocql.Iter together with in-memory implementations, so that
+// code talking to Cassandra can be unit tested without a live cluster.
+//
+// Application code depends on the interfaces and is handed either a wrapped
+// session in production:
+//
+//	s, err := cluster.CreateSession()
+//	if err != nil {
+//		return err
+//	}
+//	repo := NewRepository(gocqlmock.NewSession(s))
+//
+// or a MockSession in tests:
+//
+//	repo := NewRepository(&gocqlmock.MockSession{
+//		QueryFunc: func(stmt string, values ...interface{}) gocqlmock.Query {
+//			return &gocqlmock.MockQuery{
+//				Columns: []string{"id", "name"},
+//				Rows:    [][]interface{}{{1, "alice"}},
+//			}
+//		},
+//	})
+package gocqlmock
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Session is the subset of *gocql.Session used to execute queries and batches.
+type Session interface {
+	Query(stmt string, values ...interface{}) Query
+	NewBatch(typ gocql.BatchType) *gocql.Batch
+	ExecuteBatch(batch *gocql.Batch) error
+	ExecuteBatchCAS(batch *gocql.Batch, dest ...interface{}) (applied bool, iter Iter, err error)
+	Close()
+	Closed() bool
+}
+
+// Query is the subset of *gocql.Query used to configure and execute a query.
+// Methods configuring the query return the query to allow chaining like
+// their gocql counterparts.
+type Query interface {
+	Bind(v ...interface{}) Query
+	Consistency(c gocql.Consistency) Query
+	SerialConsistency(cons gocql.SerialConsistency) Query
+	PageSize(n int) Query
+	PageState(state []byte) Query
+	MaxRows(n int) Query
+	Idempotent(value bool) Query
+	Cached(value bool) Query
+	Coalesce(value bool) Query
+	ScanPrefix(value bool) Query
+	SetHost(hostID string) Query
+	WithContext(ctx context.Context) Query
+	WithTimestamp(timestamp int64) Query
+	WithTTL(d time.Duration) Query
+	Tag(key, value string) Query
+	LogField(key string, value interface{}) Query
+	RetryPolicy(r gocql.RetryPolicy) Query
+
+	Exec() error
+	ExecCAS() (gocql.ExecResult, error)
+	Scan(dest ...interface{}) error
+	ScanCAS(dest ...interface{}) (applied bool, err error)
+	MapScan(m map[string]interface{}) error
+	MapScanCAS(dest map[string]interface{}) (applied bool, err error)
+	Iter() Iter
+	Clone() Query
+	Release()
+}
+
+// Iter is the subset of *gocql.Iter used to read the results of a query.
+// *gocql.Iter implements Iter.
+type Iter interface {
+	Scan(dest ...interface{}) bool
+	MapScan(m map[string]interface{}) bool
+	SliceMap() ([]map[string]interface{}, error)
+	Columns() []gocql.ColumnInfo
+	PageState() []byte
+	NumRows() int
+	Warnings() []string
+	Close() error
+}
+
+var (
+	_ Iter    = (*gocql.Iter)(nil)
+	_ Session = (*session)(nil)
+	_ Query   = (*query)(nil)
+)
+
+// NewSession wraps s so that it implements Session.
+func NewSession(s *gocql.Session) Session {
+	return &session{s: s}
+}
+
+type session struct {
+	s *gocql.Session
+}
+
+func (s *session) Query(stmt string, values ...interface{}) Query {
+	return &query{q: s.s.Query(stmt, values...)}
+}
+
+func (s *session) NewBatch(typ gocql.BatchType) *gocql.Batch {
+	return s.s.NewBatch(typ)
+}
+
+func (s *session) ExecuteBatch(batch *gocql.Batch) error {
+	return s.s.ExecuteBatch(batch)
+}
+
+func (s *session) ExecuteBatchCAS(batch *gocql.Batch, dest ...interface{}) (bool, Iter, error) {
+	applied, iter, err := s.s.ExecuteBatchCAS(batch, dest...)
+	if iter == nil {
+		return applied, nil, err
+	}
+	return applied, iter, err
+}
+
+func (s *session) Close() {
+	s.s.Close()
+}
+
+func (s *session) Closed() bool {
+	return s.s.Closed()
+}
+
+type query struct {
+	q *gocql.Query
+}
+
+func (q *query) Bind(v ...interface{}) Query {
+	q.q.Bind(v...)
+	return q
+}
+
+func (q *query) Consistency(c gocql.Consistency) Query {
+	q.q.Consistency(c)
+	return q
+}
+
+func (q *query) SerialConsistency(cons gocql.SerialConsistency) Query {
+	q.q.SerialConsistency(cons)
+	return q
+}
+
+func (q *query) PageSize(n int) Query {
+	q.q.PageSize(n)
+	return q
+}
+
+func (q *query) MaxRows(n int) Query {
+	q.q.MaxRows(n)
+	return q
+}
+
+func (q *query) PageState(state []byte) Query {
+	q.q.PageState(state)
+	return q
+}
+
+func (q *query) Idempotent(value bool) Query {
+	q.q.Idempotent(value)
+	return q
+}
+
+func (q *query) WithContext(ctx context.Context) Query {
+	q.q = q.q.WithContext(ctx)
+	return q
+}
+
+func (q *query) WithTimestamp(timestamp int64) Query {
+	q.q.WithTimestamp(timestamp)
+	return q
+}
+
+func (q *query) Cached(value bool) Query {
+	q.q.Cached(value)
+	return q
+}
+
+func (q *query) Coalesce(value bool) Query {
+	q.q.Coalesce(value)
+	return q
+}
+
+func (q *query) ScanPrefix(value bool) Query {
+	q.q.ScanPrefix(value)
+	return q
+}
+
+func (q *query) SetHost(hostID string) Query {
+	q.q.SetHost(hostID)
+	return q
+}
+
+func (q *query) WithTTL(d time.Duration) Query {
+	q.q.WithTTL(d)
+	return q
+}
+
+func (q *query) Tag(key, value string) Query {
+	q.q.Tag(key, value)
+	return q
+}
+
+func (q *query) LogField(key string, value interface{}) Query {
+	q.q.LogField(key, value)
+	return q
+}
+
+func (q *query) RetryPolicy(r gocql.RetryPolicy) Query {
+	q.q.RetryPolicy(r)
+	return q
+}
+
+func (q *query) Exec() error {
+	return q.q.Exec()
+}
+
+func (q *query) Scan(dest ...interface{}) error {
+	return q.q.Scan(dest...)
+}
+
+func (q *query) ExecCAS() (gocql.ExecResult, error) {
+	return q.q.ExecCAS()
+}
+
+func (q *query) ScanCAS(dest ...interface{}) (bool, error) {
+	return q.q.ScanCAS(dest...)
+}
+
+func (q *query) MapScan(m map[string]interface{}) error {
+	return q.q.MapScan(m)
+}
+
+func (q *query) MapScanCAS(dest map[string]interface{}) (bool, error) {
+	return q.q.MapScanCAS(dest)
+}
+
+func (q *query) Iter() Iter {
+	return q.q.Iter()
+}
+
+func (q *query) Clone() Query {
+	return &query{q: q.q.Clone()}
+}
+
+func (q *query) Release() {
+	q.q.Release()
+}
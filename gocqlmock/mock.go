@@ -0,0 +1,442 @@
+package gocqlmock
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	_ Session = (*MockSession)(nil)
+	_ Query   = (*MockQuery)(nil)
+	_ Iter    = (*MockIter)(nil)
+)
+
+// MockSession is a Session whose behaviour is defined by its function fields.
+// Unset functions fall back to returning empty results without an error.
+type MockSession struct {
+	// QueryFunc is called by Query. If nil, Query returns a MockQuery
+	// without rows.
+	QueryFunc func(stmt string, values ...interface{}) Query
+
+	// ExecuteBatchFunc is called by ExecuteBatch and ExecuteBatchCAS.
+	ExecuteBatchFunc func(batch *gocql.Batch) error
+
+	// ExecuteBatchCASFunc is called by ExecuteBatchCAS. If nil,
+	// ExecuteBatchCAS reports the batch as applied.
+	ExecuteBatchCASFunc func(batch *gocql.Batch, dest ...interface{}) (applied bool, iter Iter, err error)
+
+	mu      sync.Mutex
+	closed  bool
+	queries []*MockQuery
+}
+
+// Query returns the result of QueryFunc. Queries are recorded and can be
+// inspected with Queries.
+func (s *MockSession) Query(stmt string, values ...interface{}) Query {
+	var q Query
+	if s.QueryFunc != nil {
+		q = s.QueryFunc(stmt, values...)
+	} else {
+		q = &MockQuery{}
+	}
+
+	if mq, ok := q.(*MockQuery); ok {
+		if mq.Stmt == "" {
+			mq.Stmt = stmt
+		}
+		if mq.Values == nil {
+			mq.Values = values
+		}
+		s.mu.Lock()
+		s.queries = append(s.queries, mq)
+		s.mu.Unlock()
+	}
+	return q
+}
+
+// Queries returns the MockQuery values created by Query so far.
+func (s *MockSession) Queries() []*MockQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*MockQuery(nil), s.queries...)
+}
+
+// NewBatch returns a new batch which isn't bound to a session.
+func (s *MockSession) NewBatch(typ gocql.BatchType) *gocql.Batch {
+	return &gocql.Batch{Type: typ}
+}
+
+func (s *MockSession) ExecuteBatch(batch *gocql.Batch) error {
+	if s.ExecuteBatchFunc != nil {
+		return s.ExecuteBatchFunc(batch)
+	}
+	return nil
+}
+
+func (s *MockSession) ExecuteBatchCAS(batch *gocql.Batch, dest ...interface{}) (bool, Iter, error) {
+	if s.ExecuteBatchCASFunc != nil {
+		return s.ExecuteBatchCASFunc(batch, dest...)
+	}
+	if err := s.ExecuteBatch(batch); err != nil {
+		return false, nil, err
+	}
+	return true, &MockIter{}, nil
+}
+
+func (s *MockSession) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
+
+func (s *MockSession) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// MockQuery is a Query returning fixed results. The settings applied to the
+// query are recorded in its fields so that tests can assert on them.
+type MockQuery struct {
+	Stmt   string
+	Values []interface{}
+
+	Cons             gocql.Consistency
+	SerialCons       gocql.SerialConsistency
+	PageSizeValue    int
+	PageStateValue   []byte
+	MaxRowsValue     int
+	IdempotentValue  bool
+	CachedValue      bool
+	CoalesceValue    bool
+	ScanPrefixValue  bool
+	HostID           string
+	Ctx              context.Context
+	Timestamp        int64
+	TTL              time.Duration
+	Tags             map[string]string
+	LogFields        []gocql.LogField
+	RetryPolicyValue gocql.RetryPolicy
+	Released         bool
+	ExecCount        int
+
+	// Columns are the names of the columns in Rows.
+	Columns []string
+	// Rows returned by the query, each row holds one value per column.
+	Rows [][]interface{}
+	// Applied is returned by ExecCAS, ScanCAS and MapScanCAS.
+	Applied bool
+	// Err is returned when executing the query.
+	Err error
+}
+
+func (q *MockQuery) Bind(v ...interface{}) Query {
+	q.Values = v
+	return q
+}
+
+func (q *MockQuery) Consistency(c gocql.Consistency) Query {
+	q.Cons = c
+	return q
+}
+
+func (q *MockQuery) SerialConsistency(cons gocql.SerialConsistency) Query {
+	q.SerialCons = cons
+	return q
+}
+
+func (q *MockQuery) PageSize(n int) Query {
+	q.PageSizeValue = n
+	return q
+}
+
+func (q *MockQuery) MaxRows(n int) Query {
+	q.MaxRowsValue = n
+	return q
+}
+
+func (q *MockQuery) PageState(state []byte) Query {
+	q.PageStateValue = state
+	return q
+}
+
+func (q *MockQuery) Idempotent(value bool) Query {
+	q.IdempotentValue = value
+	return q
+}
+
+func (q *MockQuery) Cached(value bool) Query {
+	q.CachedValue = value
+	return q
+}
+
+func (q *MockQuery) Coalesce(value bool) Query {
+	q.CoalesceValue = value
+	return q
+}
+
+func (q *MockQuery) ScanPrefix(value bool) Query {
+	q.ScanPrefixValue = value
+	return q
+}
+
+func (q *MockQuery) SetHost(hostID string) Query {
+	q.HostID = hostID
+	return q
+}
+
+func (q *MockQuery) WithContext(ctx context.Context) Query {
+	q.Ctx = ctx
+	return q
+}
+
+func (q *MockQuery) WithTimestamp(timestamp int64) Query {
+	q.Timestamp = timestamp
+	return q
+}
+
+func (q *MockQuery) WithTTL(d time.Duration) Query {
+	q.TTL = d
+	return q
+}
+
+func (q *MockQuery) Tag(key, value string) Query {
+	if q.Tags == nil {
+		q.Tags = make(map[string]string)
+	}
+	q.Tags[key] = value
+	return q
+}
+
+func (q *MockQuery) LogField(key string, value interface{}) Query {
+	q.LogFields = append(q.LogFields, gocql.LogField{Key: key, Value: value})
+	return q
+}
+
+func (q *MockQuery) RetryPolicy(r gocql.RetryPolicy) Query {
+	q.RetryPolicyValue = r
+	return q
+}
+
+// Exec returns Err.
+func (q *MockQuery) Exec() error {
+	return q.Iter().Close()
+}
+
+// Scan copies the first row into dest. If there are no rows gocql.ErrNotFound
+// is returned.
+func (q *MockQuery) Scan(dest ...interface{}) error {
+	iter := q.Iter()
+	if err := q.checkErrAndNotFound(); err != nil {
+		return err
+	}
+	iter.Scan(dest...)
+	return iter.Close()
+}
+
+// ExecCAS returns Applied as the result of a conditional statement. If it is
+// not applied, the first row, if any, is returned as the previous values.
+func (q *MockQuery) ExecCAS() (gocql.ExecResult, error) {
+	iter := q.Iter()
+	if q.Err != nil {
+		return gocql.ExecResult{}, q.Err
+	}
+	res := gocql.ExecResult{Conditional: true, Applied: q.Applied}
+	if !q.Applied && len(q.Rows) > 0 {
+		res.Previous = make(map[string]interface{}, len(q.Columns))
+		iter.MapScan(res.Previous)
+		delete(res.Previous, "[applied]")
+	}
+	return res, iter.Close()
+}
+
+// ScanCAS returns Applied and copies the first row, if any, into dest.
+func (q *MockQuery) ScanCAS(dest ...interface{}) (bool, error) {
+	iter := q.Iter()
+	if q.Err != nil {
+		return false, q.Err
+	}
+	if len(q.Rows) > 0 {
+		iter.Scan(dest...)
+	}
+	return q.Applied, iter.Close()
+}
+
+// MapScan copies the first row into m. If there are no rows gocql.ErrNotFound
+// is returned.
+func (q *MockQuery) MapScan(m map[string]interface{}) error {
+	iter := q.Iter()
+	if err := q.checkErrAndNotFound(); err != nil {
+		return err
+	}
+	iter.MapScan(m)
+	return iter.Close()
+}
+
+// MapScanCAS returns Applied and copies the first row, if any, into dest.
+func (q *MockQuery) MapScanCAS(dest map[string]interface{}) (bool, error) {
+	iter := q.Iter()
+	if q.Err != nil {
+		return false, q.Err
+	}
+	if len(q.Rows) > 0 {
+		iter.MapScan(dest)
+	}
+	return q.Applied, iter.Close()
+}
+
+// Iter returns a MockIter over Rows.
+func (q *MockQuery) Iter() Iter {
+	q.ExecCount++
+	return &MockIter{ColumnNames: q.Columns, Rows: q.Rows, Err: q.Err, ScanPrefix: q.ScanPrefixValue}
+}
+
+// Clone returns a copy of the query with its own Values, Tags and LogFields.
+// The clone is not recorded by the MockSession.
+func (q *MockQuery) Clone() Query {
+	c := *q
+	c.Values = append([]interface{}(nil), q.Values...)
+	if q.Tags != nil {
+		c.Tags = make(map[string]string, len(q.Tags))
+		for k, v := range q.Tags {
+			c.Tags[k] = v
+		}
+	}
+	if q.LogFields != nil {
+		c.LogFields = append([]gocql.LogField(nil), q.LogFields...)
+	}
+	return &c
+}
+
+func (q *MockQuery) Release() {
+	q.Released = true
+}
+
+func (q *MockQuery) checkErrAndNotFound() error {
+	if q.Err != nil {
+		return q.Err
+	}
+	if len(q.Rows) == 0 {
+		return gocql.ErrNotFound
+	}
+	return nil
+}
+
+// MockIter is an Iter over a fixed set of rows. Values are assigned to the
+// scan destinations as is, so they must have the type pointed at by the
+// destination.
+type MockIter struct {
+	// ColumnNames are the names of the columns in Rows.
+	ColumnNames []string
+	// Rows returned by the iterator, each row holds one value per column.
+	Rows [][]interface{}
+	// Err is returned by Close.
+	Err error
+	// WarningsValue is returned by Warnings.
+	WarningsValue []string
+	// ScanPrefix allows Scan to be called with fewer destinations than
+	// columns, as with Query.ScanPrefix.
+	ScanPrefix bool
+
+	pos int
+}
+
+// Scan copies the next row into dest and reports whether there was a row.
+func (iter *MockIter) Scan(dest ...interface{}) bool {
+	if iter.Err != nil || iter.pos >= len(iter.Rows) {
+		return false
+	}
+	row := iter.Rows[iter.pos]
+	iter.pos++
+
+	if len(dest) != len(row) && !(iter.ScanPrefix && len(dest) < len(row)) {
+		iter.Err = &gocql.ScanCountError{Have: len(dest), Want: len(row)}
+		return false
+	}
+	for i, v := range row[:len(dest)] {
+		if err := assign(dest[i], v); err != nil {
+			iter.Err = err
+			return false
+		}
+	}
+	return true
+}
+
+// MapScan copies the next row into m and reports whether there was a row.
+func (iter *MockIter) MapScan(m map[string]interface{}) bool {
+	if iter.Err != nil || iter.pos >= len(iter.Rows) {
+		return false
+	}
+	row := iter.Rows[iter.pos]
+	iter.pos++
+
+	for i, v := range row {
+		if i < len(iter.ColumnNames) {
+			m[iter.ColumnNames[i]] = v
+		}
+	}
+	return true
+}
+
+// SliceMap returns the remaining rows as maps keyed by column name.
+func (iter *MockIter) SliceMap() ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	for {
+		m := make(map[string]interface{}, len(iter.ColumnNames))
+		if !iter.MapScan(m) {
+			break
+		}
+		rows = append(rows, m)
+	}
+	return rows, iter.Err
+}
+
+// Columns returns ColumnNames as column info without type information.
+func (iter *MockIter) Columns() []gocql.ColumnInfo {
+	columns := make([]gocql.ColumnInfo, len(iter.ColumnNames))
+	for i, name := range iter.ColumnNames {
+		columns[i] = gocql.ColumnInfo{Name: name}
+	}
+	return columns
+}
+
+// PageState always returns nil as all rows are returned in a single page.
+func (iter *MockIter) PageState() []byte {
+	return nil
+}
+
+func (iter *MockIter) NumRows() int {
+	return len(iter.Rows)
+}
+
+func (iter *MockIter) Warnings() []string {
+	return iter.WarningsValue
+}
+
+func (iter *MockIter) Close() error {
+	return iter.Err
+}
+
+func assign(dest, value interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("gocqlmock: can not scan into non-pointer or nil %T", dest)
+	}
+	elem := rv.Elem()
+	if value == nil {
+		elem.Set(reflect.Zero(elem.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(elem.Type()) {
+		return fmt.Errorf("gocqlmock: can not scan %T into %T", value, dest)
+	}
+	elem.Set(v)
+	return nil
+}
@@ -0,0 +1,131 @@
+package gocqlmock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+type user struct {
+	id   int
+	name string
+}
+
+func loadUser(s Session, id int) (user, error) {
+	u := user{}
+	err := s.Query(`SELECT id, name FROM users WHERE id = ?`, id).
+		Consistency(gocql.LocalQuorum).
+		Scan(&u.id, &u.name)
+	return u, err
+}
+
+func TestMockSessionQuery(t *testing.T) {
+	s := &MockSession{
+		QueryFunc: func(stmt string, values ...interface{}) Query {
+			return &MockQuery{
+				Columns: []string{"id", "name"},
+				Rows:    [][]interface{}{{values[0], "alice"}},
+			}
+		},
+	}
+
+	u, err := loadUser(s, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.id != 1 || u.name != "alice" {
+		t.Fatalf("unexpected user %+v", u)
+	}
+
+	queries := s.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 query, got %d", len(queries))
+	}
+	if queries[0].Stmt != `SELECT id, name FROM users WHERE id = ?` {
+		t.Errorf("unexpected statement %q", queries[0].Stmt)
+	}
+	if queries[0].Cons != gocql.LocalQuorum {
+		t.Errorf("expected consistency %v, got %v", gocql.LocalQuorum, queries[0].Cons)
+	}
+}
+
+func TestMockQueryNotFound(t *testing.T) {
+	s := &MockSession{}
+	if _, err := loadUser(s, 1); err != gocql.ErrNotFound {
+		t.Fatalf("expected %v, got %v", gocql.ErrNotFound, err)
+	}
+}
+
+func TestMockQueryError(t *testing.T) {
+	errQuery := errors.New("query failed")
+	q := &MockQuery{Err: errQuery}
+	if err := q.Exec(); err != errQuery {
+		t.Fatalf("expected %v, got %v", errQuery, err)
+	}
+	if q.ExecCount != 1 {
+		t.Fatalf("expected query to be executed once, got %d", q.ExecCount)
+	}
+}
+
+func TestMockQueryExecCAS(t *testing.T) {
+	q := &MockQuery{Columns: []string{"[applied]", "name"}, Rows: [][]interface{}{{false, "alice"}}}
+	res, err := q.ExecCAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Conditional || res.Applied || len(res.Previous) != 1 || res.Previous["name"] != "alice" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestMockIter(t *testing.T) {
+	iter := (&MockQuery{
+		Columns: []string{"id", "name"},
+		Rows:    [][]interface{}{{1, "alice"}, {2, nil}},
+	}).Iter()
+
+	var (
+		id   int
+		name string
+	)
+	if !iter.Scan(&id, &name) || id != 1 || name != "alice" {
+		t.Fatalf("unexpected first row: %d %q", id, name)
+	}
+	if !iter.Scan(&id, &name) || id != 2 || name != "" {
+		t.Fatalf("unexpected second row: %d %q", id, name)
+	}
+	if iter.Scan(&id, &name) {
+		t.Fatal("expected no more rows")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMockIterTypeMismatch(t *testing.T) {
+	iter := &MockIter{Rows: [][]interface{}{{"not an int"}}}
+
+	var id int
+	if iter.Scan(&id) {
+		t.Fatal("expected scan to fail")
+	}
+	if err := iter.Close(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestMockIterSliceMap(t *testing.T) {
+	iter := &MockIter{
+		ColumnNames: []string{"id"},
+		Rows:        [][]interface{}{{1}, {2}},
+	}
+
+	rows, err := iter.SliceMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != 1 || rows[1]["id"] != 2 {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
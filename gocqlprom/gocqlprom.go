@@ -0,0 +1,234 @@
+// Package gocqlprom exposes the metrics of gocql sessions to Prometheus.
+//
+// A Recorder is set as the gocql.MetricsRecorder of a cluster and serves the
+// metrics in the Prometheus text exposition format, to be scraped alongside
+// the other metrics of the application:
+//
+//	recorder := &gocqlprom.Recorder{}
+//	cluster.MetricsRecorder = recorder
+//	http.Handle("/metrics/gocql", recorder)
+//
+// The metrics are labelled with the address and the datacenter of the hosts:
+//
+//	gocql_attempts_total               attempts at executing queries and batches
+//	gocql_attempt_errors_total         attempts which failed
+//	gocql_attempt_duration_seconds     histogram of the latency of the attempts
+//	gocql_retries_total                queries and batches attempted again
+//	gocql_timeouts_total               attempts which timed out
+//	gocql_pool_connections             connections open to the host
+//	gocql_connection_errors_total      connections which failed to open or were closed by an error
+//
+// The package doesn't depend on the Prometheus client library, a Recorder can
+// be served by its own handler or its output appended to another.
+package gocqlprom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of the latency histogram,
+// in seconds, from 1ms to 10s.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Recorder is a gocql.MetricsRecorder keeping the metrics of the hosts in
+// memory and serving them over HTTP in the Prometheus text format. The zero
+// value is ready to use, a Recorder may be shared by several sessions.
+type Recorder struct {
+	// Namespace prefixes the names of the metrics. Default: gocql.
+	Namespace string
+	// Buckets are the upper bounds of the buckets of the latency histogram,
+	// in seconds and in increasing order. It must not be changed once the
+	// Recorder is used. Default: DefaultBuckets.
+	Buckets []float64
+
+	mu    sync.Mutex
+	hosts map[hostLabels]*hostMetrics
+}
+
+type hostLabels struct {
+	host       string
+	datacenter string
+}
+
+// hostMetrics are the metrics of a host, guarded by the mutex of the recorder.
+type hostMetrics struct {
+	attempts         uint64
+	errors           uint64
+	retries          uint64
+	timeouts         uint64
+	connectionErrors uint64
+	connections      int
+
+	// buckets are the number of attempts of each bucket of the histogram,
+	// the last one is +Inf.
+	buckets []uint64
+	seconds float64
+}
+
+var _ gocql.MetricsRecorder = (*Recorder)(nil)
+
+func (r *Recorder) buckets() []float64 {
+	if r.Buckets == nil {
+		return DefaultBuckets
+	}
+	return r.Buckets
+}
+
+// host returns the metrics of host, r.mu must be held.
+func (r *Recorder) host(host *gocql.HostInfo) *hostMetrics {
+	labels := hostLabels{host: host.ConnectAddressAndPort(), datacenter: host.DataCenter()}
+	if r.hosts == nil {
+		r.hosts = make(map[hostLabels]*hostMetrics)
+	}
+	m, ok := r.hosts[labels]
+	if !ok {
+		m = &hostMetrics{buckets: make([]uint64, len(r.buckets())+1)}
+		r.hosts[labels] = m
+	}
+	return m
+}
+
+func (r *Recorder) RecordAttempt(host *gocql.HostInfo, latency time.Duration, err error) {
+	seconds := latency.Seconds()
+	bucket := sort.SearchFloat64s(r.buckets(), seconds)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.host(host)
+	m.attempts++
+	if err != nil {
+		m.errors++
+	}
+	m.buckets[bucket]++
+	m.seconds += seconds
+}
+
+func (r *Recorder) RecordRetry(host *gocql.HostInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.host(host).retries++
+}
+
+func (r *Recorder) RecordTimeout(host *gocql.HostInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.host(host).timeouts++
+}
+
+func (r *Recorder) RecordPoolSize(host *gocql.HostInfo, conns int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.host(host).connections = conns
+}
+
+func (r *Recorder) RecordConnectionError(host *gocql.HostInfo, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.host(host).connectionErrors++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes the metrics to w in the Prometheus text format, the hosts of
+// every metric in the order of their addresses.
+func (r *Recorder) Write(w io.Writer) error {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = "gocql"
+	}
+	buckets := r.buckets()
+
+	r.mu.Lock()
+	labels := make([]hostLabels, 0, len(r.hosts))
+	hosts := make([]hostMetrics, 0, len(r.hosts))
+	for l := range r.hosts {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].host != labels[j].host {
+			return labels[i].host < labels[j].host
+		}
+		return labels[i].datacenter < labels[j].datacenter
+	})
+	for _, l := range labels {
+		m := *r.hosts[l]
+		m.buckets = append([]uint64(nil), m.buckets...)
+		hosts = append(hosts, m)
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	counter := func(name, help string, value func(m *hostMetrics) uint64) {
+		name = namespace + "_" + name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for i := range hosts {
+			fmt.Fprintf(bw, "%s{%s} %d\n", name, labels[i], value(&hosts[i]))
+		}
+	}
+
+	counter("attempts_total", "Attempts at executing queries and batches.",
+		func(m *hostMetrics) uint64 { return m.attempts })
+	counter("attempt_errors_total", "Attempts at executing queries and batches which failed.",
+		func(m *hostMetrics) uint64 { return m.errors })
+
+	name := namespace + "_attempt_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s Latency of the attempts at executing queries and batches.\n# TYPE %s histogram\n", name, name)
+	for i, m := range hosts {
+		var count uint64
+		for j, bound := range buckets {
+			count += m.buckets[j]
+			fmt.Fprintf(bw, "%s_bucket{%s,le=%q} %d\n", name, labels[i], formatFloat(bound), count)
+		}
+		count += m.buckets[len(buckets)]
+		fmt.Fprintf(bw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels[i], count)
+		fmt.Fprintf(bw, "%s_sum{%s} %s\n", name, labels[i], formatFloat(m.seconds))
+		fmt.Fprintf(bw, "%s_count{%s} %d\n", name, labels[i], count)
+	}
+
+	counter("retries_total", "Queries and batches attempted again after an attempt failed.",
+		func(m *hostMetrics) uint64 { return m.retries })
+	counter("timeouts_total", "Attempts at executing queries and batches which timed out.",
+		func(m *hostMetrics) uint64 { return m.timeouts })
+
+	name = namespace + "_pool_connections"
+	fmt.Fprintf(bw, "# HELP %s Connections open to the host.\n# TYPE %s gauge\n", name, name)
+	for i, m := range hosts {
+		fmt.Fprintf(bw, "%s{%s} %d\n", name, labels[i], m.connections)
+	}
+
+	counter("connection_errors_total", "Connections which failed to open or were closed by an error.",
+		func(m *hostMetrics) uint64 { return m.connectionErrors })
+
+	return bw.Flush()
+}
+
+// String formats the labels as in the Prometheus text format.
+func (l hostLabels) String() string {
+	return `host="` + escapeLabel(l.host) + `",datacenter="` + escapeLabel(l.datacenter) + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
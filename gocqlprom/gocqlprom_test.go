@@ -0,0 +1,80 @@
+package gocqlprom
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// retrySameHost retries queries once on the same host.
+type retrySameHost struct{}
+
+func (retrySameHost) Attempt(q gocql.RetryableQuery) bool { return q.Attempts() <= 1 }
+
+func (retrySameHost) GetRetryType(error) gocql.RetryType { return gocql.Retry }
+
+func TestRecorder(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT * FROM users`).FailFirst(1, gocql.ErrCodeReadTimeout, "timed out").Rows(nil)
+
+	recorder := &Recorder{Namespace: "test", Buckets: []float64{0.5, 1}}
+	cluster := srv.ClusterConfig()
+	cluster.NumConns = 1
+	cluster.MetricsRecorder = recorder
+	cluster.RetryPolicy = retrySameHost{}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELECT * FROM users`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := `host="` + srv.Addr + `",datacenter="` + srv.DataCenter + `"`
+	rec := httptest.NewRecorder()
+	recorder.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	for _, line := range []string{
+		"# TYPE test_attempts_total counter",
+		"test_attempts_total{" + labels + "} 2",
+		"test_attempt_errors_total{" + labels + "} 1",
+		"# TYPE test_attempt_duration_seconds histogram",
+		"test_attempt_duration_seconds_bucket{" + labels + `,le="+Inf"} 2`,
+		"test_attempt_duration_seconds_count{" + labels + "} 2",
+		"test_retries_total{" + labels + "} 1",
+		"test_timeouts_total{" + labels + "} 1",
+		"test_pool_connections{" + labels + "} 1",
+		"test_connection_errors_total{" + labels + "} 0",
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("expected the line %q in\n%s", line, rec.Body)
+		}
+	}
+
+	// the connection closed by the node is a connection error
+	srv.CloseConnections()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var buf bytes.Buffer
+		if err := recorder.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "test_connection_errors_total{"+labels+"} 0\n") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the connection error in\n%s", buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,487 @@
+// Package gocqlsql implements a database/sql driver backed by gocql, so that
+// tools and libraries built on database/sql can talk to Cassandra.
+//
+// The driver is registered as "cql":
+//
+//	db, err := sql.Open("cql", "cql://127.0.0.1:9042/example?consistency=local_quorum")
+//
+// See ParseDSN for the data source name format. To use a cluster config
+// directly, use NewConnector with sql.OpenDB.
+//
+// Statements use ? bind markers like CQL. PostgreSQL style $1 markers are
+// rewritten to ? markers, allowing arguments to be reused or reordered. Named
+// arguments are not supported. Arguments are passed to gocql as is, so any
+// type supported by gocql.Marshal can be used.
+//
+// Cassandra has no transactions, BeginTx fails with ErrTxNotSupported. The
+// number of affected rows and last insert ids are not reported by Cassandra
+// either.
+package gocqlsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	// ErrTxNotSupported is returned when starting a transaction.
+	ErrTxNotSupported = errors.New("gocqlsql: transactions are not supported")
+	// ErrNamedArgs is returned when executing a statement with named arguments.
+	ErrNamedArgs = errors.New("gocqlsql: named arguments are not supported")
+	// ErrNoRowsAffected is returned by Result.RowsAffected.
+	ErrNoRowsAffected = errors.New("gocqlsql: rows affected are not reported by cassandra")
+	// ErrNoLastInsertID is returned by Result.LastInsertId.
+	ErrNoLastInsertID = errors.New("gocqlsql: last insert id is not reported by cassandra")
+)
+
+func init() {
+	sql.Register("cql", &Driver{})
+}
+
+// Driver is the database/sql driver, registered as "cql".
+type Driver struct{}
+
+var (
+	_ driver.Driver        = (*Driver)(nil)
+	_ driver.DriverContext = (*Driver)(nil)
+)
+
+// Open returns a connection to the cluster described by dsn. Every call
+// creates a new session, use sql.Open to share a session between connections.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector returns a connector for the cluster described by dsn.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	cluster, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(cluster), nil
+}
+
+// Connector creates connections sharing a single session.
+type Connector struct {
+	cluster *gocql.ClusterConfig
+
+	mu      sync.Mutex
+	session *gocql.Session
+}
+
+var (
+	_ driver.Connector = (*Connector)(nil)
+	_ io.Closer        = (*Connector)(nil)
+)
+
+// NewConnector returns a connector for cluster, to be used with sql.OpenDB.
+// The session is created by the first connection and closed by sql.DB.Close.
+func NewConnector(cluster *gocql.ClusterConfig) *Connector {
+	return &Connector{cluster: cluster}
+}
+
+// Connect returns a connection using the session of the connector, creating
+// the session if needed.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil || c.session.Closed() {
+		session, err := c.cluster.CreateSession()
+		if err != nil {
+			return nil, err
+		}
+		c.session = session
+	}
+	return &conn{session: c.session}, nil
+}
+
+// Driver returns the driver of the connector.
+func (c *Connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// Close closes the session of the connector.
+func (c *Connector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
+	return nil
+}
+
+// conn is a connection of database/sql. It doesn't own any resources as the
+// session manages its own connection pool.
+type conn struct {
+	session *gocql.Session
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	cql, order := rewritePlaceholders(query)
+	return &stmt{conn: c, query: cql, order: order, numInput: countInputs(order)}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrTxNotSupported
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return nil, ErrTxNotSupported
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if c.session.Closed() {
+		return driver.ErrBadConn
+	}
+	return c.session.Query(`SELECT release_version FROM system.local`).WithContext(ctx).Exec()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	q, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Release()
+	if err := q.Exec(); err != nil {
+		return nil, err
+	}
+	return result{}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(q)
+}
+
+// CheckNamedValue accepts all values so that they are marshalled by gocql
+// instead of being converted by database/sql.
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	if v.Name != "" {
+		return ErrNamedArgs
+	}
+	if valuer, ok := v.Value.(driver.Valuer); ok {
+		if _, ok := v.Value.(gocql.Marshaler); !ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return err
+			}
+			v.Value = value
+		}
+	}
+	return nil
+}
+
+func (c *conn) query(ctx context.Context, query string, args []driver.NamedValue) (*gocql.Query, error) {
+	stmt, order := rewritePlaceholders(query)
+	values, err := bindValues(args, order)
+	if err != nil {
+		return nil, err
+	}
+	return c.session.Query(stmt, values...).WithContext(ctx), nil
+}
+
+type stmt struct {
+	conn     *conn
+	query    string
+	order    []int
+	numInput int
+}
+
+var (
+	_ driver.Stmt             = (*stmt)(nil)
+	_ driver.StmtExecContext  = (*stmt)(nil)
+	_ driver.StmtQueryContext = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	q, err := s.bind(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Release()
+	if err := q.Exec(); err != nil {
+		return nil, err
+	}
+	return result{}, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, err := s.bind(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(q)
+}
+
+func (s *stmt) bind(ctx context.Context, args []driver.NamedValue) (*gocql.Query, error) {
+	values, err := bindValues(args, s.order)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.session.Query(s.query, values...).WithContext(ctx), nil
+}
+
+type result struct{}
+
+func (result) LastInsertId() (int64, error) {
+	return 0, ErrNoLastInsertID
+}
+
+func (result) RowsAffected() (int64, error) {
+	return 0, ErrNoRowsAffected
+}
+
+type rows struct {
+	query   *gocql.Query
+	iter    *gocql.Iter
+	columns []string
+	types   []gocql.TypeInfo
+}
+
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+)
+
+// newRows executes q. Errors are returned by Next and Close as gocql.Iter
+// doesn't expose them before the first row is read.
+func newRows(q *gocql.Query) (*rows, error) {
+	iter := q.Iter()
+	r := &rows{query: q, iter: iter}
+	for _, col := range iter.Columns() {
+		if tuple, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			for i, elem := range tuple.Elems {
+				r.columns = append(r.columns, gocql.TupleColumnName(col.Name, i))
+				r.types = append(r.types, elem)
+			}
+			continue
+		}
+		r.columns = append(r.columns, col.Name)
+		r.types = append(r.types, col.TypeInfo)
+	}
+	return r, nil
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(r.types[index].Type().String())
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	row, err := r.iter.RowData()
+	if err != nil {
+		return err
+	}
+	// the values are scanned through pointers to them, which are set to nil
+	// for null values
+	ptrs := make([]interface{}, len(row.Values))
+	for i, v := range row.Values {
+		ptr := reflect.New(reflect.TypeOf(v))
+		ptr.Elem().Set(reflect.ValueOf(v))
+		ptrs[i] = ptr.Interface()
+	}
+	if !r.iter.Scan(ptrs...) {
+		if err := r.iter.Close(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	for i, ptr := range ptrs {
+		v := reflect.ValueOf(ptr).Elem()
+		if v.IsNil() {
+			dest[i] = nil
+			continue
+		}
+		dest[i] = driverValue(v.Interface())
+	}
+	return nil
+}
+
+func (r *rows) Close() error {
+	err := r.iter.Close()
+	r.query.Release()
+	return err
+}
+
+// driverValue dereferences a value created by gocql.RowData and converts it
+// to the types database/sql can convert from where possible.
+func driverValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *int:
+		return int64(*v)
+	case *int8:
+		return int64(*v)
+	case *int16:
+		return int64(*v)
+	case *int32:
+		return int64(*v)
+	case *int64:
+		return *v
+	case *float32:
+		return float64(*v)
+	case *float64:
+		return *v
+	case *bool:
+		return *v
+	case *string:
+		return *v
+	case *[]byte:
+		return *v
+	case *gocql.UUID:
+		return v.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		return rv.Elem().Interface()
+	}
+	return v
+}
+
+// rewritePlaceholders replaces $N bind markers outside of string literals and
+// quoted identifiers with ?. order holds the zero based argument index of
+// every bind marker, it is nil if the query doesn't use $N markers.
+func rewritePlaceholders(query string) (string, []int) {
+	if !strings.Contains(query, "$") {
+		return query, nil
+	}
+
+	var (
+		b     strings.Builder
+		order []int
+		quote byte
+	)
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '$':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, err := strconv.Atoi(query[i+1 : j])
+				if err == nil && n > 0 {
+					order = append(order, n-1)
+					b.WriteByte('?')
+					i = j - 1
+					continue
+				}
+			}
+		}
+		b.WriteByte(ch)
+	}
+	if order == nil {
+		return query, nil
+	}
+	return b.String(), order
+}
+
+// countInputs returns the number of arguments expected by a statement, or -1
+// if the number is unknown.
+func countInputs(order []int) int {
+	if order == nil {
+		return -1
+	}
+	max := 0
+	for _, i := range order {
+		if i+1 > max {
+			max = i + 1
+		}
+	}
+	return max
+}
+
+func bindValues(args []driver.NamedValue, order []int) ([]interface{}, error) {
+	for _, arg := range args {
+		if arg.Name != "" {
+			return nil, ErrNamedArgs
+		}
+	}
+
+	if order == nil {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return values, nil
+	}
+
+	values := make([]interface{}, len(order))
+	for i, n := range order {
+		if n >= len(args) {
+			return nil, fmt.Errorf("gocqlsql: statement uses $%d but only %d args are given", n+1, len(args))
+		}
+		values[i] = args[n].Value
+	}
+	return values, nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
@@ -0,0 +1,222 @@
+package gocqlsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func openDB(t *testing.T, srv *gocqltest.Server) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("cql", "cql://"+srv.Addr+"/example?consistency=one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDriverQuery(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id, name FROM users WHERE age > ?`).
+		Params(gocqltest.Column{Name: "age", Type: gocqltest.Int}).
+		Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}, {Name: "name", Type: gocqltest.Text}},
+			[]interface{}{1, "alice"},
+			[]interface{}{2, "bob"})
+
+	db := openDB(t, srv)
+
+	rows, err := db.QueryContext(context.Background(), `SELECT id, name FROM users WHERE age > ?`, 18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "name"}) {
+		t.Fatalf("unexpected columns %v", columns)
+	}
+
+	var names []string
+	for rows.Next() {
+		var (
+			id   int
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"alice", "bob"}) {
+		t.Fatalf("unexpected names %v", names)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	if reqs[0].Consistency != gocql.One {
+		t.Errorf("expected consistency %v, got %v", gocql.One, reqs[0].Consistency)
+	}
+}
+
+func TestDriverExecPlaceholders(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`UPDATE users SET name = ? WHERE id = ?`).
+		Params(gocqltest.Column{Name: "name", Type: gocqltest.Text}, gocqltest.Column{Name: "id", Type: gocqltest.Int})
+
+	db := openDB(t, srv)
+
+	stmt, err := db.Prepare(`UPDATE users SET name = $2 WHERE id = $1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(1, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.RowsAffected(); err != ErrNoRowsAffected {
+		t.Errorf("expected %v, got %v", ErrNoRowsAffected, err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	var (
+		name string
+		id   int
+	)
+	if err := reqs[0].Scan(&name, &id); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" || id != 1 {
+		t.Fatalf("unexpected values name=%q id=%d", name, id)
+	}
+}
+
+func TestDriverQueryError(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM users`).Error(gocql.ErrCodeUnavailable, "not enough replicas")
+
+	db := openDB(t, srv)
+
+	var id int
+	err := db.QueryRow(`SELECT id FROM users`).Scan(&id)
+	var unavailable *gocql.RequestErrUnavailable
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("expected unavailable error, got %v", err)
+	}
+}
+
+func TestDriverNoRows(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM users`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}})
+
+	db := openDB(t, srv)
+
+	var id int
+	if err := db.QueryRow(`SELECT id FROM users`).Scan(&id); err != sql.ErrNoRows {
+		t.Fatalf("expected %v, got %v", sql.ErrNoRows, err)
+	}
+}
+
+func TestDriverNulls(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT age, name, avatar FROM users`).
+		Rows([]gocqltest.Column{
+			{Name: "age", Type: gocqltest.Int},
+			{Name: "name", Type: gocqltest.Text},
+			{Name: "avatar", Type: gocqltest.Blob},
+		},
+			[]interface{}{nil, nil, nil},
+			[]interface{}{0, "", []byte{}})
+
+	db := openDB(t, srv)
+
+	rows, err := db.Query(`SELECT age, name, avatar FROM users`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got [][2]interface{}
+	for rows.Next() {
+		var (
+			age    sql.NullInt64
+			name   sql.NullString
+			avatar []byte
+		)
+		if err := rows.Scan(&age, &name, &avatar); err != nil {
+			t.Fatal(err)
+		}
+		if avatar != nil {
+			t.Errorf("expected a nil blob, got %q", avatar)
+		}
+		got = append(got, [2]interface{}{age, name})
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]interface{}{
+		{sql.NullInt64{}, sql.NullString{}},
+		{sql.NullInt64{Valid: true}, sql.NullString{Valid: true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDriverTx(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	db := openDB(t, srv)
+	if _, err := db.Begin(); err != ErrTxNotSupported {
+		t.Fatalf("expected %v, got %v", ErrTxNotSupported, err)
+	}
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		order    []int
+	}{
+		{`SELECT * FROM t WHERE a = ?`, `SELECT * FROM t WHERE a = ?`, nil},
+		{`SELECT * FROM t WHERE a = $1 AND b = $2`, `SELECT * FROM t WHERE a = ? AND b = ?`, []int{0, 1}},
+		{`UPDATE t SET a = $2 WHERE b = $1 AND c = $2`, `UPDATE t SET a = ? WHERE b = ? AND c = ?`, []int{1, 0, 1}},
+		{`SELECT * FROM t WHERE a = '$1' AND b = $1`, `SELECT * FROM t WHERE a = '$1' AND b = ?`, []int{0}},
+		{`SELECT "$1" FROM t`, `SELECT "$1" FROM t`, nil},
+	}
+	for _, test := range tests {
+		query, order := rewritePlaceholders(test.query)
+		if query != test.expected || !reflect.DeepEqual(order, test.order) {
+			t.Errorf("rewritePlaceholders(%q) = %q, %v; want %q, %v", test.query, query, order, test.expected, test.order)
+		}
+	}
+}
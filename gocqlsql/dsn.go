@@ -0,0 +1,120 @@
+package gocqlsql
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ParseDSN parses a data source name into a cluster config. The format is
+//
+//	cql://[username[:password]@]host1[:port][,host2[:port]...][/keyspace][?param=value&...]
+//
+// The supported parameters are:
+//
+//	consistency         consistency of queries, e.g. local_quorum
+//	serial_consistency  serial or local_serial
+//	timeout             request timeout, e.g. 5s
+//	connect_timeout     connect timeout, e.g. 5s
+//	proto               protocol version
+//	page_size           default page size
+//	num_conns           connections per host
+//	dc                  local data center, enables DC aware token aware routing
+func ParseDSN(dsn string) (*gocql.ClusterConfig, error) {
+	rest := strings.TrimPrefix(dsn, "cql://")
+	if rest == dsn {
+		return nil, fmt.Errorf("gocqlsql: dsn must start with cql://: %q", dsn)
+	}
+
+	var rawQuery string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, rawQuery = rest[:i], rest[i+1:]
+	}
+
+	var keyspace string
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest, keyspace = rest[:i], rest[i+1:]
+	}
+
+	var username, password string
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 {
+		var userinfo string
+		userinfo, rest = rest[:i], rest[i+1:]
+		username = userinfo
+		if j := strings.IndexByte(userinfo, ':'); j >= 0 {
+			username, password = userinfo[:j], userinfo[j+1:]
+		}
+
+		var err error
+		if username, err = url.PathUnescape(username); err != nil {
+			return nil, fmt.Errorf("gocqlsql: invalid username in dsn: %v", err)
+		}
+		if password, err = url.PathUnescape(password); err != nil {
+			return nil, fmt.Errorf("gocqlsql: invalid password in dsn: %v", err)
+		}
+	}
+
+	var hosts []string
+	for _, host := range strings.Split(rest, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("gocqlsql: no hosts in dsn")
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = keyspace
+	if username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{Username: username, Password: password}
+	}
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("gocqlsql: invalid parameters in dsn: %v", err)
+	}
+	for key, values := range params {
+		value := values[len(values)-1]
+		if err := setParam(cluster, key, value); err != nil {
+			return nil, fmt.Errorf("gocqlsql: invalid dsn parameter %s=%q: %v", key, value, err)
+		}
+	}
+	return cluster, nil
+}
+
+func setParam(cluster *gocql.ClusterConfig, key, value string) error {
+	var err error
+	switch key {
+	case "consistency":
+		cluster.Consistency, err = gocql.ParseConsistencyWrapper(value)
+	case "serial_consistency":
+		switch strings.ToLower(value) {
+		case "serial":
+			cluster.SerialConsistency = gocql.Serial
+		case "local_serial":
+			cluster.SerialConsistency = gocql.LocalSerial
+		default:
+			err = fmt.Errorf("unknown serial consistency")
+		}
+	case "timeout":
+		cluster.Timeout, err = time.ParseDuration(value)
+	case "connect_timeout":
+		cluster.ConnectTimeout, err = time.ParseDuration(value)
+	case "proto":
+		cluster.ProtoVersion, err = strconv.Atoi(value)
+	case "page_size":
+		cluster.PageSize, err = strconv.Atoi(value)
+	case "num_conns":
+		cluster.NumConns, err = strconv.Atoi(value)
+	case "dc":
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(value))
+	default:
+		err = fmt.Errorf("unknown parameter")
+	}
+	return err
+}
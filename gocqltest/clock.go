@@ -0,0 +1,192 @@
+package gocqltest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Clock is a fake gocql.Clock whose time only moves with Advance, to test
+// timeouts and retries instantly and deterministically:
+//
+//	clock := gocqltest.NewClock(time.Now())
+//	cluster := srv.ClusterConfig()
+//	cluster.Clock = clock
+//	...
+//	go func() { errc <- session.Query(stmt).Exec() }()
+//	clock.WaitForTimers(1) // the timeout of the request
+//	clock.Advance(cluster.Timeout)
+//
+// Timers and tickers fire in the order of their time when the clock is
+// advanced past it. Functions of AfterFunc are called in their own goroutine.
+type Clock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	pending []*clockTimer
+}
+
+// NewClock returns a fake clock set to now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer firing once the clock is advanced by d.
+func (c *Clock) NewTimer(d time.Duration) gocql.Timer {
+	t := &clockTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker returns a ticker firing every time the clock is advanced by d.
+func (c *Clock) NewTicker(d time.Duration) gocql.Ticker {
+	if d <= 0 {
+		panic("gocqltest: non-positive interval for NewTicker")
+	}
+	t := &clockTimer{clock: c, ch: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return clockTicker{t}
+}
+
+// AfterFunc calls f in its own goroutine once the clock is advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) gocql.Timer {
+	t := &clockTimer{clock: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the time of the clock forward by d, firing the timers and
+// tickers due meanwhile.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	target := c.now.Add(d)
+	for {
+		next := -1
+		for i, t := range c.pending {
+			if !t.when.After(target) && (next < 0 || t.when.Before(c.pending[next].when)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.pending[next]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.fire(t)
+	}
+	c.now = target
+}
+
+// Timers returns the number of timers and tickers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// WaitForTimers blocks until at least n timers and tickers are waiting to
+// fire, for example until the code under test waits for a timeout.
+func (c *Clock) WaitForTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.pending) < n {
+		c.cond.Wait()
+	}
+}
+
+// fire fires t, which must be pending, and schedules it again if it is a
+// ticker. It must be called with mu locked.
+func (c *Clock) fire(t *clockTimer) {
+	c.remove(t)
+	if t.fn != nil {
+		go t.fn()
+	} else {
+		// like the timers of the time package, a tick is dropped if the
+		// previous one wasn't received
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+		c.add(t)
+	}
+}
+
+func (c *Clock) add(t *clockTimer) {
+	t.active = true
+	c.pending = append(c.pending, t)
+	c.cond.Broadcast()
+}
+
+func (c *Clock) remove(t *clockTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, p := range c.pending {
+		if p == t {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type clockTimer struct {
+	clock  *Clock
+	ch     chan time.Time
+	fn     func()
+	period time.Duration
+
+	// when and active are guarded by the mutex of the clock.
+	when   time.Time
+	active bool
+}
+
+type clockTicker struct {
+	*clockTimer
+}
+
+func (t clockTicker) Stop() {
+	t.clockTimer.Stop()
+}
+
+func (t *clockTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *clockTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *clockTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := c.remove(t)
+	t.when = c.now.Add(d)
+	c.add(t)
+	if d <= 0 && t.period == 0 {
+		c.fire(t)
+	}
+	return active
+}
@@ -0,0 +1,58 @@
+package gocqltest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	timer := clock.NewTimer(time.Second)
+	ticker := clock.NewTicker(400 * time.Millisecond)
+	defer ticker.Stop()
+	called := make(chan time.Time, 1)
+	clock.AfterFunc(2*time.Second, func() { called <- clock.Now() })
+	if n := clock.Timers(); n != 3 {
+		t.Fatalf("expected 3 pending timers, got %d", n)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	if tick := <-ticker.C(); !tick.Equal(start.Add(400 * time.Millisecond)) {
+		t.Fatalf("unexpected tick %v", tick)
+	}
+
+	clock.Advance(time.Second)
+	if fired := <-timer.C(); !fired.Equal(start.Add(time.Second)) {
+		t.Fatalf("expected the timer to fire at its time, got %v", fired)
+	}
+	if timer.Stop() {
+		t.Fatal("expected Stop of a fired timer to return false")
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case at := <-called:
+		if !at.Equal(start.Add(2500 * time.Millisecond)) {
+			t.Fatalf("unexpected time in AfterFunc %v", at)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("AfterFunc was not called")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("expected Reset of a fired timer to return false")
+	}
+	if !timer.Stop() {
+		t.Fatal("expected Stop of a pending timer to return true")
+	}
+	if n := clock.Timers(); n != 1 {
+		t.Fatalf("expected only the ticker to be pending, got %d timers", n)
+	}
+}
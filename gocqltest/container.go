@@ -0,0 +1,251 @@
+package gocqltest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Images of databases that can be started with StartContainer.
+const (
+	CassandraImage = "cassandra:4.1"
+	ScyllaImage    = "scylladb/scylla:5.2"
+)
+
+// ErrDockerUnavailable is returned by StartContainer if the docker command
+// is not installed.
+var ErrDockerUnavailable = errors.New("gocqltest: docker is not available")
+
+// ContainerOptions configures a database container started by StartContainer.
+type ContainerOptions struct {
+	// Image of the container. Default: CassandraImage
+	Image string
+
+	// Args passed to the database in the container. If empty, Scylla images
+	// are started in developer mode with a single CPU and 512MB of memory.
+	Args []string
+
+	// Env sets environment variables of the container, formatted as KEY=VALUE.
+	Env []string
+
+	// Keyspace created once the database is ready and set on the cluster
+	// config returned by Container.ClusterConfig. It must be a valid
+	// unquoted identifier. If empty, no keyspace is created.
+	Keyspace string
+
+	// ReplicationFactor of Keyspace.
+	// Default: 1
+	ReplicationFactor int
+
+	// StartupTimeout limits the time to wait until the database accepts CQL
+	// connections.
+	// Default: 3 minutes
+	StartupTimeout time.Duration
+}
+
+// Container is a database running in a docker container.
+type Container struct {
+	// ID of the docker container.
+	ID string
+	// Addr is the host:port address of the CQL port of the container.
+	Addr string
+
+	keyspace string
+}
+
+// StartContainer starts a database in a docker container, waits until it
+// accepts CQL connections and creates the configured keyspace. The container
+// is removed when Close is called or if StartContainer fails.
+func StartContainer(ctx context.Context, opts ContainerOptions) (*Container, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, ErrDockerUnavailable
+	}
+	if opts.Image == "" {
+		opts.Image = CassandraImage
+	}
+	if opts.Args == nil && strings.HasPrefix(opts.Image, "scylladb/") {
+		opts.Args = []string{"--smp", "1", "--memory", "512M", "--overprovisioned", "1", "--developer-mode", "1"}
+	}
+	if opts.ReplicationFactor <= 0 {
+		opts.ReplicationFactor = 1
+	}
+	if opts.StartupTimeout <= 0 {
+		opts.StartupTimeout = 3 * time.Minute
+	}
+
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::9042"}
+	for _, env := range opts.Env {
+		args = append(args, "--env", env)
+	}
+	args = append(args, opts.Image)
+	args = append(args, opts.Args...)
+
+	out, err := docker(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gocqltest: unable to start container: %v", err)
+	}
+	c := &Container{ID: strings.TrimSpace(out), keyspace: opts.Keyspace}
+
+	if err := c.start(ctx, opts); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Container) start(ctx context.Context, opts ContainerOptions) error {
+	out, err := docker(ctx, "port", c.ID, "9042/tcp")
+	if err != nil {
+		return fmt.Errorf("gocqltest: unable to get port of container: %v", err)
+	}
+	c.Addr, err = parsePort(out)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.StartupTimeout)
+	defer cancel()
+	session, err := c.waitReady(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if opts.Keyspace == "" {
+		return nil
+	}
+	stmt := fmt.Sprintf(`CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': %d}`,
+		opts.Keyspace, opts.ReplicationFactor)
+	if err := session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf("gocqltest: unable to create keyspace %s: %v", opts.Keyspace, err)
+	}
+	return nil
+}
+
+// waitReady connects to the container until it succeeds, the container stops
+// or ctx is done.
+func (c *Container) waitReady(ctx context.Context) (*gocql.Session, error) {
+	var lastErr error
+	for {
+		cluster := gocql.NewCluster(c.Addr)
+		cluster.Logger = nopLogger{}
+		cluster.Timeout = 5 * time.Second
+		session, err := cluster.CreateSession()
+		if err == nil {
+			return session, nil
+		}
+		lastErr = err
+
+		if out, err := docker(ctx, "inspect", "--format", "{{.State.Running}}", c.ID); err != nil || strings.TrimSpace(out) != "true" {
+			return nil, fmt.Errorf("gocqltest: container %s stopped before accepting connections", c.ID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gocqltest: container not ready: %v", lastErr)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// ClusterConfig returns a cluster config connecting to the container, using
+// the keyspace created by StartContainer.
+//
+// The only contact point is the port published on the loopback interface, so
+// DisableInitialHostLookup is set to avoid connecting to the address of the
+// node within the container network.
+func (c *Container) ClusterConfig() *gocql.ClusterConfig {
+	cluster := gocql.NewCluster(c.Addr)
+	cluster.Keyspace = c.keyspace
+	cluster.DisableInitialHostLookup = true
+	cluster.Timeout = 5 * time.Second
+	return cluster
+}
+
+// Close removes the container.
+func (c *Container) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := docker(ctx, "rm", "--force", "--volumes", c.ID); err != nil {
+		return fmt.Errorf("gocqltest: unable to remove container %s: %v", c.ID, err)
+	}
+	return nil
+}
+
+// NewContainerSession starts a container with StartContainer and returns a
+// session connected to it. The session is closed and the container removed
+// when the test finishes. The test is skipped if docker is unavailable or
+// when running with -short.
+func NewContainerSession(t testing.TB, opts ContainerOptions) *gocql.Session {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("gocqltest: skipping container in short mode")
+	}
+
+	c, err := StartContainer(context.Background(), opts)
+	if errors.Is(err, ErrDockerUnavailable) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	session, err := c.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatalf("gocqltest: unable to create session: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// parsePort parses the output of docker port, which lists one address per
+// line, and returns the first IPv4 address.
+func parsePort(out string) (string, error) {
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(host); ip == nil || ip.To4() == nil {
+			continue
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			continue
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	return "", fmt.Errorf("gocqltest: unable to parse published port %q", out)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Print(v ...interface{})                 {}
+func (nopLogger) Printf(format string, v ...interface{}) {}
+func (nopLogger) Println(v ...interface{})               {}
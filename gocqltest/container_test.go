@@ -0,0 +1,37 @@
+package gocqltest
+
+import (
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		out  string
+		addr string
+	}{
+		{"127.0.0.1:49153\n", "127.0.0.1:49153"},
+		{"0.0.0.0:32768\n[::]:32768\n", "0.0.0.0:32768"},
+		{"[::]:32768\n127.0.0.1:32769\n", "127.0.0.1:32769"},
+	}
+	for _, test := range tests {
+		addr, err := parsePort(test.out)
+		if err != nil {
+			t.Errorf("parsePort(%q): %v", test.out, err)
+		} else if addr != test.addr {
+			t.Errorf("parsePort(%q) = %q, want %q", test.out, addr, test.addr)
+		}
+	}
+
+	if _, err := parsePort("Error: No public port '9042/tcp' published"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestContainerSession(t *testing.T) {
+	session := NewContainerSession(t, ContainerOptions{Keyspace: "gocqltest"})
+
+	var keyspace string
+	if err := session.Query(`SELECT keyspace_name FROM system_schema.keyspaces WHERE keyspace_name = 'gocqltest'`).Scan(&keyspace); err != nil {
+		t.Fatal(err)
+	}
+}
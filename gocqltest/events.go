@@ -0,0 +1,81 @@
+package gocqltest
+
+import (
+	"net"
+)
+
+// Event is an event pushed by the server to the clients registered for its
+// type, see Server.PushEvent.
+type Event struct {
+	// Type is TOPOLOGY_CHANGE, STATUS_CHANGE or SCHEMA_CHANGE.
+	Type string
+	// Change is the change, such as NEW_NODE, UP or CREATED.
+	Change string
+
+	// Host and Port are the address of the node of topology and status
+	// events.
+	Host net.IP
+	Port int
+
+	// Target is the kind of the schema object of schema events, KEYSPACE,
+	// TABLE, TYPE, FUNCTION or AGGREGATE, Name its name, empty for keyspaces,
+	// and Args the argument types of functions and aggregates.
+	Target   string
+	Keyspace string
+	Name     string
+	Args     []string
+}
+
+func (e Event) encode() []byte {
+	w := &writer{}
+	w.writeString(e.Type)
+	w.writeString(e.Change)
+	if e.Type != "SCHEMA_CHANGE" {
+		ip := e.Host.To4()
+		if ip == nil {
+			ip = e.Host.To16()
+		}
+		w.writeByte(byte(len(ip)))
+		w.buf = append(w.buf, ip...)
+		w.writeInt(int32(e.Port))
+		return w.buf
+	}
+
+	w.writeString(e.Target)
+	w.writeString(e.Keyspace)
+	switch e.Target {
+	case "TABLE", "TYPE":
+		w.writeString(e.Name)
+	case "FUNCTION", "AGGREGATE":
+		w.writeString(e.Name)
+		w.writeStringList(e.Args)
+	}
+	return w.buf
+}
+
+// PushEvent sends ev to the connections which registered for events of its
+// type, the control connections of the sessions. It returns the number of
+// connections it was sent to.
+func (s *Server) PushEvent(ev Event) int {
+	body := ev.encode()
+
+	s.mu.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	n := 0
+	for _, c := range conns {
+		c.mu.Lock()
+		registered, version := c.events[ev.Type], c.version
+		c.mu.Unlock()
+		if !registered {
+			continue
+		}
+		c.write(encodeFrame(header{version: version, stream: -1}, opEvent, nil, body))
+		n++
+	}
+	return n
+}
@@ -0,0 +1,385 @@
+package gocqltest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	minProtocol = 3
+	maxProtocol = 4
+
+	maxFrameSize = 256 << 20
+)
+
+const (
+	opError     byte = 0x00
+	opStartup   byte = 0x01
+	opReady     byte = 0x02
+	opOptions   byte = 0x05
+	opSupported byte = 0x06
+	opQuery     byte = 0x07
+	opResult    byte = 0x08
+	opPrepare   byte = 0x09
+	opExecute   byte = 0x0A
+	opRegister  byte = 0x0B
+	opEvent     byte = 0x0C
+	opBatch     byte = 0x0D
+)
+
+const (
+	headerFlagCompress      byte = 0x01
+	headerFlagTracing       byte = 0x02
+	headerFlagCustomPayload byte = 0x04
+	headerFlagWarning       byte = 0x08
+)
+
+const (
+	resultKindVoid     = 1
+	resultKindRows     = 2
+	resultKindKeyspace = 3
+	resultKindPrepared = 4
+)
+
+const (
+	flagValues            = 0x01
+	flagSkipMetadata      = 0x02
+	flagPageSize          = 0x04
+	flagPagingState       = 0x08
+	flagSerialConsistency = 0x10
+	flagTimestamp         = 0x20
+	flagNamedValues       = 0x40
+
+	flagGlobalTableSpec = 0x01
+	flagHasMorePages    = 0x02
+	flagNoMetadata      = 0x04
+)
+
+type header struct {
+	version byte
+	flags   byte
+	stream  int
+	op      byte
+	length  int
+}
+
+func readHeader(r io.Reader) (header, error) {
+	var buf [9]byte
+	if _, err := io.ReadFull(r, buf[:1]); err != nil {
+		return header{}, err
+	}
+
+	h := header{version: buf[0] & 0x7f}
+	if h.version < 3 {
+		if _, err := io.ReadFull(r, buf[1:8]); err != nil {
+			return header{}, err
+		}
+		h.flags = buf[1]
+		h.stream = int(int8(buf[2]))
+		h.op = buf[3]
+		h.length = int(int32(binary.BigEndian.Uint32(buf[4:8])))
+	} else {
+		if _, err := io.ReadFull(r, buf[1:9]); err != nil {
+			return header{}, err
+		}
+		h.flags = buf[1]
+		h.stream = int(int16(binary.BigEndian.Uint16(buf[2:4])))
+		h.op = buf[4]
+		h.length = int(int32(binary.BigEndian.Uint32(buf[5:9])))
+	}
+
+	if h.length < 0 || h.length > maxFrameSize {
+		return header{}, fmt.Errorf("gocqltest: invalid frame length %d", h.length)
+	}
+	return h, nil
+}
+
+// encodeFrame returns a response frame for the request with header req. The
+// warnings are only sent with protocol v4 and later, results of traced
+// requests have a random trace ID.
+func encodeFrame(req header, op byte, warnings []string, body []byte) []byte {
+	var flags byte
+	if len(warnings) > 0 && req.version >= 4 {
+		flags |= headerFlagWarning
+		w := &writer{}
+		w.writeStringList(warnings)
+		body = append(w.buf, body...)
+	}
+	if req.flags&headerFlagTracing != 0 && op == opResult {
+		flags |= headerFlagTracing
+		body = append(gocql.MustRandomUUID().Bytes(), body...)
+	}
+
+	var buf []byte
+	if req.version < 3 {
+		buf = make([]byte, 8, 8+len(body))
+		buf[0] = req.version | 0x80
+		buf[1] = flags
+		buf[2] = byte(req.stream)
+		buf[3] = op
+		binary.BigEndian.PutUint32(buf[4:8], uint32(len(body)))
+	} else {
+		buf = make([]byte, 9, 9+len(body))
+		buf[0] = req.version | 0x80
+		buf[1] = flags
+		binary.BigEndian.PutUint16(buf[2:4], uint16(req.stream))
+		buf[4] = op
+		binary.BigEndian.PutUint32(buf[5:9], uint32(len(body)))
+	}
+	return append(buf, body...)
+}
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) writeByte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *writer) writeShort(n uint16) {
+	w.buf = append(w.buf, byte(n>>8), byte(n))
+}
+
+func (w *writer) writeInt(n int32) {
+	w.buf = append(w.buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func (w *writer) writeString(s string) {
+	w.writeShort(uint16(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) writeBytes(b []byte) {
+	if b == nil {
+		w.writeInt(-1)
+		return
+	}
+	w.writeInt(int32(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) writeShortBytes(b []byte) {
+	w.writeShort(uint16(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) writeStringList(l []string) {
+	w.writeShort(uint16(len(l)))
+	for _, s := range l {
+		w.writeString(s)
+	}
+}
+
+func (w *writer) writeStringMultimap(m map[string][]string) {
+	w.writeShort(uint16(len(m)))
+	for k, v := range m {
+		w.writeString(k)
+		w.writeStringList(v)
+	}
+}
+
+func (w *writer) writeType(t gocql.TypeInfo) error {
+	w.writeShort(uint16(t.Type()))
+	switch t.Type() {
+	case gocql.TypeCustom:
+		w.writeString(t.Custom())
+	case gocql.TypeList, gocql.TypeSet:
+		c, ok := t.(gocql.CollectionType)
+		if !ok {
+			return fmt.Errorf("gocqltest: %s type must be a gocql.CollectionType, got %T", t.Type(), t)
+		}
+		return w.writeType(c.Elem)
+	case gocql.TypeMap:
+		c, ok := t.(gocql.CollectionType)
+		if !ok {
+			return fmt.Errorf("gocqltest: map type must be a gocql.CollectionType, got %T", t)
+		}
+		if err := w.writeType(c.Key); err != nil {
+			return err
+		}
+		return w.writeType(c.Elem)
+	case gocql.TypeTuple:
+		tuple, ok := t.(gocql.TupleTypeInfo)
+		if !ok {
+			return fmt.Errorf("gocqltest: tuple type must be a gocql.TupleTypeInfo, got %T", t)
+		}
+		w.writeShort(uint16(len(tuple.Elems)))
+		for _, elem := range tuple.Elems {
+			if err := w.writeType(elem); err != nil {
+				return err
+			}
+		}
+	case gocql.TypeUDT:
+		udt, ok := t.(gocql.UDTTypeInfo)
+		if !ok {
+			return fmt.Errorf("gocqltest: udt type must be a gocql.UDTTypeInfo, got %T", t)
+		}
+		w.writeString(udt.KeySpace)
+		w.writeString(udt.Name)
+		w.writeShort(uint16(len(udt.Elements)))
+		for _, field := range udt.Elements {
+			w.writeString(field.Name)
+			if err := w.writeType(field.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var errShortFrame = errors.New("gocqltest: frame too short")
+
+// reader decodes request bodies. Reads past the end of the body panic with
+// errShortFrame, which is recovered when handling the request.
+type reader struct {
+	buf []byte
+}
+
+func (r *reader) next(n int) []byte {
+	if n < 0 || len(r.buf) < n {
+		panic(errShortFrame)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) readByte() byte {
+	return r.next(1)[0]
+}
+
+func (r *reader) readShort() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *reader) readInt() int32 {
+	return int32(binary.BigEndian.Uint32(r.next(4)))
+}
+
+func (r *reader) readLong() int64 {
+	return int64(binary.BigEndian.Uint64(r.next(8)))
+}
+
+func (r *reader) readString() string {
+	return string(r.next(int(r.readShort())))
+}
+
+func (r *reader) readLongString() string {
+	return string(r.next(int(r.readInt())))
+}
+
+func (r *reader) readBytes() []byte {
+	n := r.readInt()
+	if n < 0 {
+		return nil
+	}
+	return append([]byte{}, r.next(int(n))...)
+}
+
+func (r *reader) readShortBytes() []byte {
+	return append([]byte{}, r.next(int(r.readShort()))...)
+}
+
+func (r *reader) readStringList() []string {
+	n := int(r.readShort())
+	l := make([]string, n)
+	for i := range l {
+		l[i] = r.readString()
+	}
+	return l
+}
+
+func (r *reader) readStringMap() map[string]string {
+	n := int(r.readShort())
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k := r.readString()
+		m[k] = r.readString()
+	}
+	return m
+}
+
+func (r *reader) readBytesMap() map[string][]byte {
+	n := int(r.readShort())
+	m := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		k := r.readString()
+		m[k] = r.readBytes()
+	}
+	return m
+}
+
+// queryParams are the parameters of QUERY and EXECUTE requests.
+type queryParams struct {
+	consistency       gocql.Consistency
+	serialConsistency gocql.SerialConsistency
+	values            [][]byte
+	skipMetadata      bool
+	pageSize          int
+	pagingState       []byte
+	timestamp         int64
+	// customPayload, traced and version are read from the frame header, not
+	// the parameters.
+	customPayload map[string][]byte
+	traced        bool
+	version       byte
+}
+
+func (r *reader) readQueryParams() queryParams {
+	var p queryParams
+	p.consistency = gocql.Consistency(r.readShort())
+	flags := r.readByte()
+
+	if flags&flagValues != 0 {
+		n := int(r.readShort())
+		p.values = make([][]byte, n)
+		for i := 0; i < n; i++ {
+			if flags&flagNamedValues != 0 {
+				r.readString()
+			}
+			p.values[i] = r.readBytes()
+		}
+	}
+	p.skipMetadata = flags&flagSkipMetadata != 0
+	if flags&flagPageSize != 0 {
+		p.pageSize = int(r.readInt())
+	}
+	if flags&flagPagingState != 0 {
+		p.pagingState = r.readBytes()
+	}
+	if flags&flagSerialConsistency != 0 {
+		p.serialConsistency = gocql.SerialConsistency(r.readShort())
+	}
+	if flags&flagTimestamp != 0 {
+		p.timestamp = r.readLong()
+	}
+	return p
+}
+
+// encodePagingState encodes the offset of the next row to return. The server
+// doesn't validate paging states beyond their length.
+func encodePagingState(offset int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(offset))
+	return b
+}
+
+func decodePagingState(state []byte) (int, error) {
+	if len(state) == 0 {
+		return 0, nil
+	}
+	if len(state) != 4 {
+		return 0, &Error{Code: gocql.ErrCodeProtocol, Message: "gocqltest: invalid paging state"}
+	}
+	offset := binary.BigEndian.Uint32(state)
+	if offset > math.MaxInt32 {
+		return 0, &Error{Code: gocql.ErrCodeProtocol, Message: "gocqltest: invalid paging state"}
+	}
+	return int(offset), nil
+}
@@ -0,0 +1,793 @@
+// Package gocqltest provides utilities for testing code using gocql.
+//
+// Server is an in-memory fake Cassandra node speaking native protocol
+// versions 3 and 4. It answers the system table queries the driver issues
+// when connecting and responds to other statements as programmed with
+// Server.On, so that code depending on a gocql.Session can be tested without
+// running Cassandra:
+//
+//	srv := gocqltest.NewServer()
+//	defer srv.Close()
+//
+//	srv.On(`SELECT name FROM users WHERE id = ?`).
+//		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+//		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}},
+//			[]interface{}{"alice"})
+//
+//	session, err := srv.ClusterConfig().CreateSession()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer session.Close()
+//
+//	var name string
+//	err = session.Query(`SELECT name FROM users WHERE id = ?`, 1).Scan(&name)
+//
+// Statements that are not stubbed succeed without returning rows, unless they
+// have bind markers, in which case preparing them fails as the types of the
+// values are unknown. Stubs can also delay their responses, fail their first
+// executions or split their rows into pages of their own size, to test
+// retries, speculative executions and timeouts, see Stub.Delay,
+// Stub.FailFirst and Stub.PageSize.
+//
+// For tests that need a real database, StartContainer and
+// NewContainerSession run Cassandra or Scylla in a docker container:
+//
+//	session := gocqltest.NewContainerSession(t, gocqltest.ContainerOptions{
+//		Image:    gocqltest.ScyllaImage,
+//		Keyspace: "example",
+//	})
+package gocqltest
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Server is an in-memory fake Cassandra node. It behaves like a single node
+// cluster and keeps no data, results are programmed per statement with On.
+type Server struct {
+	// Addr is the host:port address the server listens on, set by Start.
+	Addr string
+
+	// Values reported in system.local. They must not be changed after Start.
+	ClusterName    string
+	DataCenter     string
+	Rack           string
+	ReleaseVersion string
+	HostID         gocql.UUID
+	SchemaVersion  gocql.UUID
+
+	// Scylla makes the server advertise Scylla options in its SUPPORTED
+	// response, so that clients enable Scylla specific features. It must not
+	// be changed after Start.
+	Scylla bool
+
+	// Clock, if set, is the clock the delays of the stubs are waited on, see
+	// Stub.Delay, so that tests advance them like the clock of the client.
+	Clock *Clock
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	conns    map[*serverConn]struct{}
+	stubs    map[string]*Stub
+	prepared map[string]string
+	prepares []string
+	requests []Request
+}
+
+// NewServer starts and returns a new Server listening on a random port on the
+// loopback interface. The caller should call Close when finished.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new Server that is not yet listening, so that
+// its configuration can be changed before calling Start.
+func NewUnstartedServer() *Server {
+	return &Server{
+		ClusterName:    "gocqltest",
+		DataCenter:     "datacenter1",
+		Rack:           "rack1",
+		ReleaseVersion: "3.11.4",
+		HostID:         gocql.TimeUUID(),
+		SchemaVersion:  gocql.TimeUUID(),
+		conns:          make(map[*serverConn]struct{}),
+		stubs:          make(map[string]*Stub),
+		prepared:       make(map[string]string),
+	}
+}
+
+// Start starts the server. It panics if the server can't listen, like
+// net/http/httptest does.
+func (s *Server) Start() {
+	if s.listener != nil {
+		panic("gocqltest: server already started")
+	}
+	addr := s.Addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		panic(fmt.Sprintf("gocqltest: failed to listen on %s: %v", addr, err))
+	}
+	s.listener = l
+	s.Addr = l.Addr().String()
+
+	s.wg.Add(1)
+	go s.serve()
+}
+
+// Close stops the server and closes all client connections.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// CloseConnections closes all client connections while the server keeps
+// accepting new ones, as if the node was restarted.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+}
+
+// ClusterConfig returns a cluster config connecting to the server.
+func (s *Server) ClusterConfig() *gocql.ClusterConfig {
+	return gocql.NewCluster(s.Addr)
+}
+
+// On returns the stub programming the response to stmt, replacing any
+// previous stub of the statement. Statements are matched exactly, except for
+// whitespace. Stubs of queries of system tables take precedence over the
+// system tables of the server, and stubs of USE statements over the switch of
+// the keyspace, for example to make it fail.
+//
+// Clients cache the metadata of prepared statements, so stubs should be set
+// up before the statement is executed for the first time.
+func (s *Server) On(stmt string) *Stub {
+	stub := &Stub{}
+	s.mu.Lock()
+	s.stubs[normalizeStatement(stmt)] = stub
+	s.mu.Unlock()
+	return stub
+}
+
+// Requests returns the statements executed against the server, in the order
+// in which they were received. Queries of system tables are not included,
+// unless they are stubbed.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Prepares returns the statements prepared by clients, in the order in which
+// they were received, including the statements prepared more than once.
+// Statements of system tables are not included, unless they are stubbed.
+func (s *Server) Prepares() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.prepares...)
+}
+
+// sleep waits for d on the clock of the server.
+func (s *Server) sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if s.Clock == nil {
+		time.Sleep(d)
+		return
+	}
+	timer := s.Clock.NewTimer(d)
+	<-timer.C()
+}
+
+func (s *Server) stub(stmt string) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stubs[normalizeStatement(stmt)]
+}
+
+func (s *Server) record(req Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &serverConn{srv: s, conn: conn}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go c.serve()
+	}
+}
+
+type serverConn struct {
+	srv  *Server
+	conn net.Conn
+	wg   sync.WaitGroup
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	keyspace string
+	// events are the types of the events the client registered for, with
+	// the protocol version of the REGISTER request.
+	events  map[string]bool
+	version byte
+}
+
+func (c *serverConn) serve() {
+	defer func() {
+		c.conn.Close()
+		c.wg.Wait()
+
+		c.srv.mu.Lock()
+		delete(c.srv.conns, c)
+		c.srv.mu.Unlock()
+		c.srv.wg.Done()
+	}()
+
+	for {
+		h, err := readHeader(c.conn)
+		if err != nil {
+			return
+		}
+		body := make([]byte, h.length)
+		if _, err := io.ReadFull(c.conn, body); err != nil {
+			return
+		}
+
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			var warnings []string
+			op, resp := c.process(h, body, &warnings)
+			c.write(encodeFrame(h, op, warnings, resp))
+		}()
+	}
+}
+
+func (c *serverConn) write(frame []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.Write(frame)
+}
+
+func (c *serverConn) localAddress() string {
+	if addr, ok := c.conn.LocalAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return "127.0.0.1"
+}
+
+func (c *serverConn) currentKeyspace() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keyspace
+}
+
+// process handles a request frame and returns the opcode and body of the
+// response, adding the warnings of the response to warnings.
+func (c *serverConn) process(h header, body []byte, warnings *[]string) (op byte, resp []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errShortFrame {
+				panic(r)
+			}
+			op, resp = encodeError(&Error{Code: gocql.ErrCodeProtocol, Message: "gocqltest: malformed frame"})
+		}
+	}()
+
+	if h.version < minProtocol || h.version > maxProtocol {
+		return encodeError(&Error{
+			Code: gocql.ErrCodeProtocol,
+			Message: fmt.Sprintf("Invalid or unsupported protocol version (%d); the lowest supported version is %d and the greatest is %d",
+				h.version, minProtocol, maxProtocol),
+		})
+	}
+	if h.flags&headerFlagCompress != 0 {
+		return encodeError(&Error{Code: gocql.ErrCodeProtocol, Message: "gocqltest: compression is not supported"})
+	}
+
+	r := &reader{buf: body}
+	var payload map[string][]byte
+	if h.flags&headerFlagCustomPayload != 0 {
+		payload = r.readBytesMap()
+	}
+
+	switch h.op {
+	case opStartup:
+		if options := r.readStringMap(); options["COMPRESSION"] != "" {
+			return encodeError(&Error{Code: gocql.ErrCodeProtocol, Message: "gocqltest: compression is not supported"})
+		}
+		return opReady, nil
+	case opOptions:
+		supported := map[string][]string{
+			"CQL_VERSION": {"3.4.4"},
+			"COMPRESSION": {},
+		}
+		if c.srv.Scylla {
+			supported["SCYLLA_NR_SHARDS"] = []string{"1"}
+			supported["SCYLLA_SHARD"] = []string{"0"}
+		}
+		w := &writer{}
+		w.writeStringMultimap(supported)
+		return opSupported, w.buf
+	case opRegister:
+		types := r.readStringList()
+		c.mu.Lock()
+		if c.events == nil {
+			c.events = make(map[string]bool)
+		}
+		for _, typ := range types {
+			c.events[typ] = true
+		}
+		c.version = h.version
+		c.mu.Unlock()
+		return opReady, nil
+	case opQuery:
+		stmt := r.readLongString()
+		params := r.readQueryParams()
+		params.customPayload = payload
+		params.traced = h.flags&headerFlagTracing != 0
+		params.version = h.version
+		return c.execute(stmt, params, false, warnings)
+	case opPrepare:
+		return c.prepare(r.readLongString(), h.version)
+	case opExecute:
+		id := r.readShortBytes()
+		params := r.readQueryParams()
+		params.customPayload = payload
+		params.traced = h.flags&headerFlagTracing != 0
+		params.version = h.version
+		stmt, ok := c.srv.preparedStatement(id)
+		if !ok {
+			return encodeError(&unpreparedError{id: id})
+		}
+		return c.execute(stmt, params, true, warnings)
+	case opBatch:
+		return c.batch(r, payload, h.flags&headerFlagTracing != 0, h.version, warnings)
+	default:
+		return encodeError(&Error{Code: gocql.ErrCodeProtocol, Message: fmt.Sprintf("gocqltest: unsupported opcode 0x%x", h.op)})
+	}
+}
+
+var useStmtRe = regexp.MustCompile(`(?is)^USE\s+("([^"]+)"|(\w+))\s*;?$`)
+
+func (c *serverConn) execute(stmt string, params queryParams, prepared bool, warnings *[]string) (byte, []byte) {
+	stmt = strings.TrimSpace(stmt)
+
+	if m := useStmtRe.FindStringSubmatch(stmt); m != nil && c.srv.stub(stmt) == nil {
+		keyspace := m[2]
+		if keyspace == "" {
+			keyspace = strings.ToLower(m[3])
+		}
+		c.mu.Lock()
+		c.keyspace = keyspace
+		c.mu.Unlock()
+
+		w := &writer{}
+		w.writeInt(resultKindKeyspace)
+		w.writeString(keyspace)
+		return opResult, w.buf
+	}
+
+	if columns, rows, ok, err := c.unstubbedSystemQuery(stmt); ok {
+		if err != nil {
+			return encodeError(err)
+		}
+		return c.rows(columns, rows, params)
+	}
+
+	values := params.values
+	var timeout time.Duration
+	if i := timeoutMarker(stmt); prepared && c.srv.Scylla && i >= 0 && i < len(values) {
+		var d gocql.Duration
+		if err := gocql.Unmarshal(Duration, values[i], &d); err != nil {
+			return encodeError(&Error{Code: gocql.ErrCodeInvalid, Message: fmt.Sprintf("gocqltest: invalid timeout: %v", err)})
+		}
+		timeout = time.Duration(d.Nanoseconds)
+		values = append(values[:i:i], values[i+1:]...)
+	}
+
+	req := &Request{
+		Statement:         stmt,
+		Values:            values,
+		Keyspace:          c.currentKeyspace(),
+		Consistency:       params.consistency,
+		SerialConsistency: params.serialConsistency,
+		PageSize:          params.pageSize,
+		PagingState:       params.pagingState,
+		Timestamp:         params.timestamp,
+		CustomPayload:     params.customPayload,
+		Prepared:          prepared,
+		Traced:            params.traced,
+		ProtocolVersion:   int(params.version),
+		Timeout:           timeout,
+	}
+
+	var (
+		columns []Column
+		resp    Response
+	)
+	if stub := c.srv.stub(stmt); stub != nil {
+		columns, resp = stub.respond(req)
+		delay, pageSize := stub.behavior(req)
+		if pageSize > 0 {
+			params.pageSize = pageSize
+		}
+		c.srv.sleep(delay)
+	}
+	c.srv.record(*req)
+	*warnings = append(*warnings, resp.Warnings...)
+
+	if resp.Err != nil {
+		return encodeError(resp.Err)
+	}
+	if columns == nil {
+		w := &writer{}
+		w.writeInt(resultKindVoid)
+		return opResult, w.buf
+	}
+	return c.rows(columns, resp.Rows, params)
+}
+
+// rows returns a rows result with the page of rows selected by params.
+func (c *serverConn) rows(columns []Column, rows [][]interface{}, params queryParams) (byte, []byte) {
+	offset, err := decodePagingState(params.pagingState)
+	if err != nil {
+		return encodeError(err)
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	page := rows[offset:]
+
+	var pagingState []byte
+	if params.pageSize > 0 && len(page) > params.pageSize {
+		page = page[:params.pageSize]
+		pagingState = encodePagingState(offset + params.pageSize)
+	}
+
+	w := &writer{}
+	w.writeInt(resultKindRows)
+	if err := c.writeMetadata(w, "", columns, pagingState, params.skipMetadata); err != nil {
+		return encodeError(err)
+	}
+
+	w.writeInt(int32(len(page)))
+	for _, row := range page {
+		if len(row) != len(columns) {
+			return encodeError(fmt.Errorf("gocqltest: row has %d values, expected %d", len(row), len(columns)))
+		}
+		for i, v := range row {
+			b, err := gocql.Marshal(columns[i].Type, v)
+			if err != nil {
+				return encodeError(fmt.Errorf("gocqltest: unable to marshal column %s: %v", columns[i].Name, err))
+			}
+			w.writeBytes(b)
+		}
+	}
+	return opResult, w.buf
+}
+
+func (c *serverConn) writeMetadata(w *writer, table string, columns []Column, pagingState []byte, skipMetadata bool) error {
+	flags := int32(0)
+	if pagingState != nil {
+		flags |= flagHasMorePages
+	}
+	if skipMetadata || len(columns) == 0 {
+		flags |= flagNoMetadata
+	} else {
+		flags |= flagGlobalTableSpec
+	}
+
+	w.writeInt(flags)
+	w.writeInt(int32(len(columns)))
+	if pagingState != nil {
+		w.writeBytes(pagingState)
+	}
+	if flags&flagNoMetadata != 0 {
+		return nil
+	}
+	return c.writeColumns(w, table, columns)
+}
+
+func (c *serverConn) writeColumns(w *writer, table string, columns []Column) error {
+	w.writeString(c.currentKeyspace())
+	w.writeString(table)
+	for _, col := range columns {
+		w.writeString(col.Name)
+		if err := w.writeType(col.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTimeoutParam returns params with the bind marker of the USING TIMEOUT
+// of a Scylla statement at index, and the partition key columns pkey shifted
+// accordingly.
+func withTimeoutParam(params []Column, pkey []int, index int) ([]Column, []int) {
+	withTimeout := make([]Column, 0, len(params)+1)
+	withTimeout = append(withTimeout, params[:index]...)
+	withTimeout = append(withTimeout, Column{Name: "[timeout]", Type: Duration})
+	withTimeout = append(withTimeout, params[index:]...)
+
+	shifted := make([]int, len(pkey))
+	for j, i := range pkey {
+		if i >= index {
+			i++
+		}
+		shifted[j] = i
+	}
+	return withTimeout, shifted
+}
+
+func (c *serverConn) prepare(stmt string, version byte) (byte, []byte) {
+	stmt = strings.TrimSpace(stmt)
+
+	var (
+		params, columns []Column
+		pkey            []int
+		table           string
+	)
+	if cols, _, ok, err := c.unstubbedSystemQuery(stmt); ok {
+		if err != nil {
+			return encodeError(err)
+		}
+		columns = cols
+	} else {
+		if stub := c.srv.stub(stmt); stub != nil {
+			table, params, pkey, columns = stub.metadata()
+		}
+		if i := timeoutMarker(stmt); c.srv.Scylla && i >= 0 && i <= len(params) {
+			params, pkey = withTimeoutParam(params, pkey, i)
+		}
+		c.srv.mu.Lock()
+		c.srv.prepares = append(c.srv.prepares, stmt)
+		c.srv.mu.Unlock()
+	}
+	if n := countBindMarkers(stmt); n != len(params) {
+		return encodeError(&Error{
+			Code:    gocql.ErrCodeInvalid,
+			Message: fmt.Sprintf("gocqltest: statement has %d bind markers but %d params are stubbed: %q", n, len(params), stmt),
+		})
+	}
+
+	id := c.srv.prepareStatement(stmt)
+
+	w := &writer{}
+	w.writeInt(resultKindPrepared)
+	w.writeShortBytes(id)
+
+	// metadata of the bind markers
+	if len(params) > 0 {
+		w.writeInt(flagGlobalTableSpec)
+	} else {
+		w.writeInt(0)
+	}
+	w.writeInt(int32(len(params)))
+	if version >= 4 {
+		w.writeInt(int32(len(pkey)))
+		for _, i := range pkey {
+			w.writeShort(uint16(i))
+		}
+	}
+	if len(params) > 0 {
+		if err := c.writeColumns(w, table, params); err != nil {
+			return encodeError(err)
+		}
+	}
+
+	// metadata of the result
+	if err := c.writeMetadata(w, table, columns, nil, false); err != nil {
+		return encodeError(err)
+	}
+	return opResult, w.buf
+}
+
+func (s *Server) prepareStatement(stmt string) []byte {
+	sum := md5.Sum([]byte(normalizeStatement(stmt)))
+	s.mu.Lock()
+	s.prepared[string(sum[:])] = stmt
+	s.mu.Unlock()
+	return sum[:]
+}
+
+func (s *Server) preparedStatement(id []byte) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stmt, ok := s.prepared[string(id)]
+	return stmt, ok
+}
+
+func (c *serverConn) batch(r *reader, payload map[string][]byte, traced bool, version byte, warnings *[]string) (byte, []byte) {
+	r.readByte() // batch type
+	n := int(r.readShort())
+	reqs := make([]*Request, n)
+	for i := range reqs {
+		req := &Request{Batch: true}
+		switch kind := r.readByte(); kind {
+		case 0:
+			req.Statement = r.readLongString()
+		case 1:
+			id := r.readShortBytes()
+			stmt, ok := c.srv.preparedStatement(id)
+			if !ok {
+				return encodeError(&unpreparedError{id: id})
+			}
+			req.Statement = stmt
+			req.Prepared = true
+		default:
+			return encodeError(&Error{Code: gocql.ErrCodeProtocol, Message: fmt.Sprintf("gocqltest: invalid batch statement kind %d", kind)})
+		}
+
+		req.Values = make([][]byte, r.readShort())
+		for j := range req.Values {
+			req.Values[j] = r.readBytes()
+		}
+		reqs[i] = req
+	}
+
+	consistency := gocql.Consistency(r.readShort())
+	flags := r.readByte()
+	var (
+		serialConsistency gocql.SerialConsistency
+		timestamp         int64
+	)
+	if flags&flagSerialConsistency != 0 {
+		serialConsistency = gocql.SerialConsistency(r.readShort())
+	}
+	if flags&flagTimestamp != 0 {
+		timestamp = r.readLong()
+	}
+
+	keyspace := c.currentKeyspace()
+	var err error
+	for _, req := range reqs {
+		req.Keyspace = keyspace
+		req.Consistency = consistency
+		req.SerialConsistency = serialConsistency
+		req.Timestamp = timestamp
+		req.CustomPayload = payload
+		req.Traced = traced
+		req.ProtocolVersion = int(version)
+
+		if stub := c.srv.stub(req.Statement); stub != nil {
+			_, resp := stub.respond(req)
+			if resp.Err != nil && err == nil {
+				err = resp.Err
+			}
+			*warnings = append(*warnings, resp.Warnings...)
+			delay, _ := stub.behavior(req)
+			c.srv.sleep(delay)
+		}
+		c.srv.record(*req)
+	}
+	if err != nil {
+		return encodeError(err)
+	}
+
+	w := &writer{}
+	w.writeInt(resultKindVoid)
+	return opResult, w.buf
+}
+
+type unpreparedError struct {
+	id []byte
+}
+
+func (e *unpreparedError) Error() string {
+	return "gocqltest: unprepared statement"
+}
+
+// encodeError returns an error response for err, with zero values for the
+// additional information carried by some error codes.
+func encodeError(err error) (byte, []byte) {
+	w := &writer{}
+
+	if e, ok := err.(*unpreparedError); ok {
+		w.writeInt(gocql.ErrCodeUnprepared)
+		w.writeString("Prepared query with ID not found")
+		w.writeShortBytes(e.id)
+		return opError, w.buf
+	}
+
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Code: gocql.ErrCodeServer, Message: err.Error()}
+	}
+
+	w.writeInt(int32(e.Code))
+	w.writeString(e.Message)
+	switch e.Code {
+	case gocql.ErrCodeUnavailable:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+	case gocql.ErrCodeWriteTimeout:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeString("SIMPLE")
+	case gocql.ErrCodeReadTimeout:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeByte(0)
+	case gocql.ErrCodeReadFailure:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeByte(0)
+	case gocql.ErrCodeWriteFailure:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeInt(0)
+		w.writeString("SIMPLE")
+	case gocql.ErrCodeFunctionFailure:
+		w.writeString("")
+		w.writeString("")
+		w.writeStringList(nil)
+	case gocql.ErrCodeAlreadyExists:
+		w.writeString("")
+		w.writeString("")
+	case gocql.ErrCodeUnprepared:
+		w.writeShortBytes(nil)
+	case gocql.ErrCodeCASWriteUnknown:
+		w.writeShort(0)
+		w.writeInt(0)
+		w.writeInt(0)
+	}
+	return opError, w.buf
+}
@@ -0,0 +1,302 @@
+package gocqltest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+func newSession(t *testing.T, srv *Server) *gocql.Session {
+	t.Helper()
+	cluster := srv.ClusterConfig()
+	cluster.Timeout = time.Second
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("unable to create session: %v", err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+func TestServerQuery(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name, age FROM users WHERE id = ?`).
+		Params(Column{Name: "id", Type: Int}).
+		Rows([]Column{{Name: "name", Type: Text}, {Name: "age", Type: Int}},
+			[]interface{}{"alice", 42})
+
+	session := newSession(t, srv)
+
+	var (
+		name string
+		age  int
+	)
+	if err := session.Query(`SELECT name, age
+		FROM users WHERE id = ?`, 1).Consistency(gocql.LocalQuorum).Scan(&name, &age); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" || age != 42 {
+		t.Fatalf("got name=%q age=%d", name, age)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	if !reqs[0].Prepared {
+		t.Error("expected statement to be prepared")
+	}
+	if reqs[0].Consistency != gocql.LocalQuorum {
+		t.Errorf("expected consistency %v, got %v", gocql.LocalQuorum, reqs[0].Consistency)
+	}
+	var id int
+	if err := reqs[0].Scan(&id); err != nil {
+		t.Fatal(err)
+	} else if id != 1 {
+		t.Errorf("expected id 1, got %d", id)
+	}
+}
+
+func TestServerPaging(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	var rows [][]interface{}
+	for i := 0; i < 25; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	srv.On(`SELECT id FROM events`).Rows([]Column{{Name: "id", Type: Int}}, rows...)
+
+	session := newSession(t, srv)
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(10).Iter()
+	var id, n int
+	for iter.Scan(&id) {
+		if id != n {
+			t.Fatalf("expected id %d, got %d", n, id)
+		}
+		n++
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(rows) {
+		t.Fatalf("expected %d rows, got %d", len(rows), n)
+	}
+
+	if reqs := srv.Requests(); len(reqs) != 3 {
+		t.Fatalf("expected 3 page requests, got %d", len(reqs))
+	}
+}
+
+func TestServerPageSize(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM events`).
+		Rows([]Column{{Name: "id", Type: Int}}, []interface{}{1}, []interface{}{2}, []interface{}{3}).
+		PageSize(2)
+
+	session := newSession(t, srv)
+
+	rows, err := session.Query(`SELECT id FROM events`).PageSize(100).Iter().SliceMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if reqs := srv.Requests(); len(reqs) != 2 {
+		t.Fatalf("expected 2 page requests, got %d", len(reqs))
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	srv := NewUnstartedServer()
+	srv.Clock = NewClock(time.Now())
+	srv.Start()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM events`).DelayFunc(func(req *Request) time.Duration {
+		if req.Consistency == gocql.All {
+			return time.Minute
+		}
+		return 0
+	})
+
+	session := newSession(t, srv)
+
+	if err := session.Query(`SELECT id FROM events`).Consistency(gocql.One).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- session.Query(`SELECT id FROM events`).Consistency(gocql.All).Exec() }()
+	srv.Clock.WaitForTimers(1)
+	select {
+	case err := <-errc:
+		t.Fatalf("expected the response to be delayed, got %v", err)
+	default:
+	}
+	srv.Clock.Advance(time.Minute)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerFailFirst(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO users (id) VALUES (1)`).FailFirst(2, gocql.ErrCodeOverloaded, "overloaded")
+
+	session := newSession(t, srv)
+
+	for i := 0; i < 2; i++ {
+		var overloaded *gocql.RequestErrOverloaded
+		if err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec(); !errors.As(err, &overloaded) {
+			t.Fatalf("execution %d: expected overloaded error, got %v", i, err)
+		}
+	}
+	if err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO users (id) VALUES (1)`).Error(gocql.ErrCodeWriteTimeout, "timed out")
+
+	session := newSession(t, srv)
+
+	err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec()
+	var timeout *gocql.RequestErrWriteTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected write timeout, got %v", err)
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users WHERE id = ?`).
+		Params(Column{Name: "id", Type: Int}).
+		Handle([]Column{{Name: "name", Type: Text}}, func(req *Request) Response {
+			var id int
+			if err := req.Scan(&id); err != nil {
+				return Response{Err: err}
+			}
+			if id != 1 {
+				return Response{}
+			}
+			return Response{Rows: [][]interface{}{{"alice"}}}
+		})
+
+	session := newSession(t, srv)
+
+	var name string
+	if err := session.Query(`SELECT name FROM users WHERE id = ?`, 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	} else if name != "alice" {
+		t.Fatalf("expected alice, got %q", name)
+	}
+	if err := session.Query(`SELECT name FROM users WHERE id = ?`, 2).Scan(&name); err != gocql.ErrNotFound {
+		t.Fatalf("expected %v, got %v", gocql.ErrNotFound, err)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO users (id) VALUES (?)`).Params(Column{Name: "id", Type: Int})
+
+	session := newSession(t, srv)
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO users (id) VALUES (?)`, 1)
+	b.Query(`INSERT INTO users (id) VALUES (?)`, 2)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	for i, req := range reqs {
+		var id int
+		if !req.Batch {
+			t.Errorf("expected request %d to be part of a batch", i)
+		}
+		if err := req.Scan(&id); err != nil {
+			t.Fatal(err)
+		} else if id != i+1 {
+			t.Errorf("expected id %d, got %d", i+1, id)
+		}
+	}
+}
+
+func TestServerUnstubbedBindMarkers(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	session := newSession(t, srv)
+
+	if err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatalf("unexpected error for statement without bind markers: %v", err)
+	}
+	if err := session.Query(`INSERT INTO users (id) VALUES (?)`, 1).Exec(); err == nil {
+		t.Fatal("expected error for statement with unstubbed bind markers")
+	}
+}
+
+func TestServerKeyspace(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	cluster := srv.ClusterConfig()
+	cluster.Keyspace = "example"
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`TRUNCATE users`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Keyspace != "example" {
+		t.Fatalf("expected request in keyspace example, got %+v", reqs)
+	}
+}
+
+func TestServerStubbedSystemQuery(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT duration FROM system_traces.sessions WHERE session_id = ?`).
+		Params(Column{Name: "session_id", Type: UUID}).
+		Rows([]Column{{Name: "duration", Type: Int}}, []interface{}{1500})
+
+	session := newSession(t, srv)
+
+	var duration int
+	if err := session.Query(`SELECT duration FROM system_traces.sessions WHERE session_id = ?`, gocql.TimeUUID()).Scan(&duration); err != nil {
+		t.Fatal(err)
+	}
+	if duration != 1500 {
+		t.Fatalf("expected the stubbed duration, got %d", duration)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("expected the stubbed system query to be recorded, got %d requests", n)
+	}
+}
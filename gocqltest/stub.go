@@ -0,0 +1,337 @@
+package gocqltest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gocql/gocql"
+)
+
+// Column is a bind marker of a statement or a column of its result.
+type Column struct {
+	Name string
+	Type gocql.TypeInfo
+}
+
+func nativeType(typ gocql.Type) gocql.NativeType {
+	return gocql.NewNativeType(maxProtocol, typ, "")
+}
+
+// Types of columns, usable with both protocol versions supported by the
+// server.
+var (
+	Ascii     = nativeType(gocql.TypeAscii)
+	BigInt    = nativeType(gocql.TypeBigInt)
+	Blob      = nativeType(gocql.TypeBlob)
+	Boolean   = nativeType(gocql.TypeBoolean)
+	Counter   = nativeType(gocql.TypeCounter)
+	Date      = nativeType(gocql.TypeDate)
+	Decimal   = nativeType(gocql.TypeDecimal)
+	Double    = nativeType(gocql.TypeDouble)
+	Duration  = nativeType(gocql.TypeDuration)
+	Float     = nativeType(gocql.TypeFloat)
+	Inet      = nativeType(gocql.TypeInet)
+	Int       = nativeType(gocql.TypeInt)
+	SmallInt  = nativeType(gocql.TypeSmallInt)
+	Text      = nativeType(gocql.TypeText)
+	Time      = nativeType(gocql.TypeTime)
+	Timestamp = nativeType(gocql.TypeTimestamp)
+	TimeUUID  = nativeType(gocql.TypeTimeUUID)
+	TinyInt   = nativeType(gocql.TypeTinyInt)
+	UUID      = nativeType(gocql.TypeUUID)
+	Varchar   = nativeType(gocql.TypeVarchar)
+	Varint    = nativeType(gocql.TypeVarint)
+)
+
+// List returns the type of a list with elements of type elem.
+func List(elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.CollectionType{NativeType: nativeType(gocql.TypeList), Elem: elem}
+}
+
+// Set returns the type of a set with elements of type elem.
+func Set(elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.CollectionType{NativeType: nativeType(gocql.TypeSet), Elem: elem}
+}
+
+// Map returns the type of a map from key to elem.
+func Map(key, elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.CollectionType{NativeType: nativeType(gocql.TypeMap), Key: key, Elem: elem}
+}
+
+// Tuple returns the type of a tuple with the given element types.
+func Tuple(elems ...gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.TupleTypeInfo{NativeType: nativeType(gocql.TypeTuple), Elems: elems}
+}
+
+// Error is an error response with a native protocol error code, for example
+// gocql.ErrCodeUnavailable. Additional information carried by some error
+// codes, like the number of replicas that responded, is sent as zero values.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gocqltest: error 0x%x: %s", e.Code, e.Message)
+}
+
+// Request is a statement executed against the server, either on its own or
+// as part of a batch.
+type Request struct {
+	// Statement is the CQL statement as sent by the client.
+	Statement string
+	// Values are the serialized values bound to the statement.
+	Values [][]byte
+	// Keyspace is the keyspace of the connection executing the request.
+	Keyspace string
+
+	Consistency       gocql.Consistency
+	SerialConsistency gocql.SerialConsistency
+	PageSize          int
+	PagingState       []byte
+	// Timestamp is the client side timestamp or 0 if none was sent.
+	Timestamp int64
+	// CustomPayload is the custom payload sent with the request.
+	CustomPayload map[string][]byte
+
+	// Prepared is true if the statement was executed as a prepared statement.
+	Prepared bool
+	// Batch is true if the statement was executed as part of a batch.
+	Batch bool
+	// Traced is true if the client requested the tracing of the statement.
+	Traced bool
+	// ProtocolVersion is the version of the native protocol of the request.
+	ProtocolVersion int
+	// Timeout is the USING TIMEOUT bound to a marker of a prepared statement
+	// executed on a Scylla server, 0 if none. Its value is not in Values.
+	Timeout time.Duration
+
+	params []Column
+}
+
+// Scan unmarshals Values into dest using the types of the bind markers
+// declared with Stub.Params. Values without a declared type are unmarshalled
+// as text.
+func (r *Request) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.Values) {
+		return fmt.Errorf("gocqltest: request has %d values, got %d destinations", len(r.Values), len(dest))
+	}
+	for i, v := range r.Values {
+		var typ gocql.TypeInfo = Text
+		if i < len(r.params) {
+			typ = r.params[i].Type
+		}
+		if err := gocql.Unmarshal(typ, v, dest[i]); err != nil {
+			return fmt.Errorf("gocqltest: unable to unmarshal value %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Response is the result of executing a stubbed statement.
+type Response struct {
+	// Rows returned by the statement. Each row holds a value for every
+	// column of the stub, values are marshalled with gocql.Marshal.
+	Rows [][]interface{}
+	// Err is returned to the client instead of rows. Errors other than
+	// *Error are sent as server errors.
+	Err error
+	// Warnings are sent to the client with the response, with protocol v4
+	// and later.
+	Warnings []string
+}
+
+// Stub programs the response of the server to a statement, see Server.On.
+type Stub struct {
+	mu      sync.Mutex
+	table   string
+	params  []Column
+	pkey    []int
+	columns []Column
+	handler func(*Request) Response
+
+	delay    func(*Request) time.Duration
+	failures []Response
+	pageSize int
+}
+
+// Table sets the table reported in the metadata of the prepared statement,
+// which is empty by default.
+func (s *Stub) Table(name string) *Stub {
+	s.mu.Lock()
+	s.table = name
+	s.mu.Unlock()
+	return s
+}
+
+// PartitionKey declares the indexes of the bind markers which are the
+// partition key of the statement, reported to clients preparing it with
+// protocol v4 so that they compute its routing key. No bind marker is part of
+// the partition key by default.
+func (s *Stub) PartitionKey(indexes ...int) *Stub {
+	s.mu.Lock()
+	s.pkey = indexes
+	s.mu.Unlock()
+	return s
+}
+
+// Params declares the bind markers of the statement. They are sent to the
+// client when it prepares the statement and are required to bind values.
+func (s *Stub) Params(params ...Column) *Stub {
+	s.mu.Lock()
+	s.params = params
+	s.mu.Unlock()
+	return s
+}
+
+// Rows makes the statement return rows with the given columns. Rows are
+// split into pages according to the page size requested by the client.
+func (s *Stub) Rows(columns []Column, rows ...[]interface{}) *Stub {
+	return s.Handle(columns, func(*Request) Response {
+		return Response{Rows: rows}
+	})
+}
+
+// Error makes the statement fail with the given error code and message.
+func (s *Stub) Error(code int, message string) *Stub {
+	return s.Handle(nil, func(*Request) Response {
+		return Response{Err: &Error{Code: code, Message: message}}
+	})
+}
+
+// Handle calls fn to respond to every execution of the statement. The rows of
+// all responses must match columns. Statements without columns return a void
+// result when fn doesn't return an error.
+//
+// fn is called concurrently for concurrent requests.
+func (s *Stub) Handle(columns []Column, fn func(req *Request) Response) *Stub {
+	s.mu.Lock()
+	s.columns = columns
+	s.handler = fn
+	s.mu.Unlock()
+	return s
+}
+
+// Delay delays every response to the statement by d, so that clients time out
+// or speculate. The requests are handled concurrently, a delayed response
+// doesn't delay the responses to the other requests of the connection. The
+// delay is waited on Server.Clock if it is set.
+func (s *Stub) Delay(d time.Duration) *Stub {
+	return s.DelayFunc(func(*Request) time.Duration { return d })
+}
+
+// DelayFunc delays every response to the statement by the duration returned
+// by fn for the request, for example to make some consistency levels slow,
+// see Delay.
+func (s *Stub) DelayFunc(fn func(req *Request) time.Duration) *Stub {
+	s.mu.Lock()
+	s.delay = fn
+	s.mu.Unlock()
+	return s
+}
+
+// FailFirst makes the next n executions of the statement fail with the given
+// error code and message, before it is handled as programmed again, for
+// example to test that the error is retried.
+func (s *Stub) FailFirst(n int, code int, message string) *Stub {
+	s.mu.Lock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, Response{Err: &Error{Code: code, Message: message}})
+	}
+	s.mu.Unlock()
+	return s
+}
+
+// PageSize makes the server split the rows of the statement into pages of n
+// rows, whatever the page size requested by the client, like the server does
+// when pages reach its size limits. 0 uses the page size of the client.
+func (s *Stub) PageSize(n int) *Stub {
+	s.mu.Lock()
+	s.pageSize = n
+	s.mu.Unlock()
+	return s
+}
+
+func (s *Stub) metadata() (table string, params []Column, pkey []int, columns []Column) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table, s.params, s.pkey, s.columns
+}
+
+func (s *Stub) respond(req *Request) ([]Column, Response) {
+	s.mu.Lock()
+	req.params = s.params
+	columns, handler := s.columns, s.handler
+	if len(s.failures) > 0 {
+		resp := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		return columns, resp
+	}
+	s.mu.Unlock()
+
+	if handler == nil {
+		return nil, Response{}
+	}
+	return columns, handler(req)
+}
+
+// behavior returns the delay of the response to req and the page size of the
+// statement.
+func (s *Stub) behavior(req *Request) (delay time.Duration, pageSize int) {
+	s.mu.Lock()
+	fn, pageSize := s.delay, s.pageSize
+	s.mu.Unlock()
+	if fn != nil {
+		delay = fn(req)
+	}
+	return delay, pageSize
+}
+
+// normalizeStatement collapses whitespace so that stubs match statements
+// regardless of their formatting.
+func normalizeStatement(stmt string) string {
+	return strings.Join(strings.Fields(stmt), " ")
+}
+
+// countBindMarkers returns the number of positional and named bind markers
+// outside of string literals and quoted identifiers.
+func countBindMarkers(stmt string) int {
+	var (
+		n     int
+		quote rune
+		colon bool
+	)
+	for _, r := range stmt {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+		case colon && (unicode.IsLetter(r) || r == '_'):
+			n++
+		}
+		colon = quote == 0 && r == ':'
+	}
+	return n
+}
+
+var timeoutMarkerRe = regexp.MustCompile(`(?i)\bTIMEOUT\s+\?`)
+
+// timeoutMarker returns the index of the bind marker of the USING TIMEOUT of
+// stmt among its bind markers, or -1 if the timeout is not bound to a marker.
+func timeoutMarker(stmt string) int {
+	loc := timeoutMarkerRe.FindStringIndex(stmt)
+	if loc == nil {
+		return -1
+	}
+	return countBindMarkers(stmt[:loc[0]])
+}
@@ -0,0 +1,140 @@
+package gocqltest
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+var systemQueryRe = regexp.MustCompile(`(?is)^SELECT\s+(.+?)\s+FROM\s+(system\w*)\.(\w+)`)
+
+var localColumns = []Column{
+	{Name: "key", Type: Text},
+	{Name: "bootstrapped", Type: Text},
+	{Name: "broadcast_address", Type: Inet},
+	{Name: "cluster_name", Type: Text},
+	{Name: "cql_version", Type: Text},
+	{Name: "data_center", Type: Text},
+	{Name: "host_id", Type: UUID},
+	{Name: "listen_address", Type: Inet},
+	{Name: "native_protocol_version", Type: Text},
+	{Name: "partitioner", Type: Text},
+	{Name: "rack", Type: Text},
+	{Name: "release_version", Type: Text},
+	{Name: "rpc_address", Type: Inet},
+	{Name: "schema_version", Type: UUID},
+	{Name: "tokens", Type: Set(Text)},
+}
+
+var peerColumns = []Column{
+	{Name: "peer", Type: Inet},
+	{Name: "data_center", Type: Text},
+	{Name: "host_id", Type: UUID},
+	{Name: "preferred_ip", Type: Inet},
+	{Name: "rack", Type: Text},
+	{Name: "release_version", Type: Text},
+	{Name: "rpc_address", Type: Inet},
+	{Name: "schema_version", Type: UUID},
+	{Name: "tokens", Type: Set(Text)},
+}
+
+func (s *Server) localRow(address string) []interface{} {
+	return []interface{}{
+		"local",
+		"COMPLETED",
+		address,
+		s.ClusterName,
+		"3.4.4",
+		s.DataCenter,
+		s.HostID,
+		address,
+		"4",
+		"org.apache.cassandra.dht.Murmur3Partitioner",
+		s.Rack,
+		s.ReleaseVersion,
+		address,
+		s.SchemaVersion,
+		[]string{"0"},
+	}
+}
+
+// systemQuery responds to queries of system tables the driver relies on.
+// system.local describes the server itself and system.peers is empty as the
+// server is a single node cluster. Other system tables are empty. ok is false
+// if stmt doesn't query a system table.
+// unstubbedSystemQuery is systemQuery for statements which have no stub.
+func (c *serverConn) unstubbedSystemQuery(stmt string) (columns []Column, rows [][]interface{}, ok bool, err error) {
+	if c.srv.stub(stmt) != nil {
+		return nil, nil, false, nil
+	}
+	return c.systemQuery(stmt)
+}
+
+func (c *serverConn) systemQuery(stmt string) (columns []Column, rows [][]interface{}, ok bool, err error) {
+	m := systemQueryRe.FindStringSubmatch(stmt)
+	if m == nil {
+		return nil, nil, false, nil
+	}
+	selection, keyspace, table := m[1], strings.ToLower(m[2]), strings.ToLower(m[3])
+
+	switch {
+	case keyspace == "system" && table == "local":
+		columns, rows = localColumns, [][]interface{}{c.srv.localRow(c.localAddress())}
+	case keyspace == "system" && table == "peers":
+		columns = peerColumns
+	case keyspace == "system" && table == "peers_v2":
+		return nil, nil, true, &Error{Code: gocql.ErrCodeInvalid, Message: "unconfigured table peers_v2"}
+	default:
+		// other tables are empty, their columns are reported as text.
+		if strings.TrimSpace(selection) == "*" {
+			return nil, nil, true, nil
+		}
+		for _, name := range strings.Split(selection, ",") {
+			columns = append(columns, Column{Name: strings.TrimSpace(name), Type: Text})
+		}
+		return columns, nil, true, nil
+	}
+
+	columns, rows, err = project(columns, rows, selection)
+	return columns, rows, true, err
+}
+
+// project returns the selected columns of rows.
+func project(columns []Column, rows [][]interface{}, selection string) ([]Column, [][]interface{}, error) {
+	if strings.TrimSpace(selection) == "*" {
+		return columns, rows, nil
+	}
+
+	var (
+		projected []Column
+		indexes   []int
+	)
+	for _, name := range strings.Split(selection, ",") {
+		name = strings.TrimSpace(name)
+		i := columnIndex(columns, name)
+		if i < 0 {
+			return nil, nil, &Error{Code: gocql.ErrCodeInvalid, Message: "Undefined column name " + name}
+		}
+		projected = append(projected, columns[i])
+		indexes = append(indexes, i)
+	}
+
+	projectedRows := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		projectedRows[i] = make([]interface{}, len(indexes))
+		for j, index := range indexes {
+			projectedRows[i][j] = row[index]
+		}
+	}
+	return projected, projectedRows, nil
+}
+
+func columnIndex(columns []Column, name string) int {
+	for i, col := range columns {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
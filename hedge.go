@@ -0,0 +1,43 @@
+package gocql
+
+import "time"
+
+// HedgedReads sends the reads of a session to further hosts when the first
+// host is slow to respond, see ClusterConfig.HedgedReads. The first response,
+// of the primary request or of a hedge, is returned. Hedges are independent of
+// the retry policy: every hedge is retried on its own on errors, while hedging
+// only cuts the latency of slow hosts.
+//
+//	cluster.HedgedReads = &gocql.HedgedReads{
+//		Delay:     20 * time.Millisecond,
+//		MaxHedges: 1,
+//	}
+//
+// Session.Stats counts the hedges sent and whether the primary request or a
+// hedge answered.
+type HedgedReads struct {
+	// Delay is how long to wait for a response before sending the next hedge.
+	Delay time.Duration
+
+	// MaxHedges is the number of hedges sent at most in addition to the
+	// primary request.
+	MaxHedges int
+
+	// OnlyIdempotent restricts hedging to the queries marked idempotent.
+	// Otherwise every SELECT is hedged, as reads can be sent again safely.
+	OnlyIdempotent bool
+}
+
+// speculation returns the speculative execution policy hedging qry, or nil if
+// qry isn't hedged. Batches and writes are never hedged, nor are queries which
+// set their own SpeculativeExecutionPolicy.
+func (h *HedgedReads) speculation(qry ExecutableQuery) SpeculativeExecutionPolicy {
+	if h == nil || h.MaxHedges <= 0 || h.Delay <= 0 {
+		return nil
+	}
+	q, ok := qry.(*Query)
+	if !ok || !isReadStatement(q.stmt) || (h.OnlyIdempotent && !q.IsIdempotent()) {
+		return nil
+	}
+	return &SimpleSpeculativeExecution{NumAttempts: h.MaxHedges, TimeoutDelay: h.Delay}
+}
@@ -0,0 +1,108 @@
+package gocql_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestHedgedReads(t *testing.T) {
+	// the hosts of a session need distinct addresses
+	l, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("127.0.0.2 isn't available: %v", err)
+	}
+	l.Close()
+
+	slow := gocqltest.NewServer()
+	defer slow.Close()
+	fast := gocqltest.NewUnstartedServer()
+	fast.Addr = "127.0.0.2:0"
+	fast.Start()
+	defer fast.Close()
+
+	columns := []gocqltest.Column{{Name: "name", Type: gocqltest.Text}}
+	slow.On(`SELECT name FROM users`).Handle(columns, func(*gocqltest.Request) gocqltest.Response {
+		time.Sleep(200 * time.Millisecond)
+		return gocqltest.Response{Rows: [][]interface{}{{"slow"}}}
+	})
+	fast.On(`SELECT name FROM users`).Rows(columns, []interface{}{"fast"})
+	slow.On(`INSERT INTO users (name) VALUES ('x')`)
+	fast.On(`INSERT INTO users (name) VALUES ('x')`)
+
+	cluster := gocql.NewCluster(slow.Addr, fast.Addr)
+	cluster.DisableInitialHostLookup = true
+	cluster.HedgedReads = &gocql.HedgedReads{Delay: 20 * time.Millisecond, MaxHedges: 1}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	// the round-robin policy starts with either host, the slow host is the
+	// first one at least once
+	var names []string
+	for i := 0; i < 4; i++ {
+		var name string
+		if err := session.Query(`SELECT name FROM users`).Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if name != "fast" {
+			t.Fatalf("expected the fast host to answer every read, got %v", names)
+		}
+	}
+
+	stats := session.Stats()
+	if stats.Hedges == 0 || stats.HedgeWins == 0 {
+		t.Fatalf("expected hedges winning over the slow host, got %+v", stats)
+	}
+	if stats.HedgeWins+stats.PrimaryWins > 4 {
+		t.Fatalf("expected at most 4 raced queries, got %+v", stats)
+	}
+
+	// writes are never hedged
+	hedges := stats.Hedges
+	if err := session.Query(`INSERT INTO users (name) VALUES ('x')`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if n := session.Stats().Hedges; n != hedges {
+		t.Fatalf("expected no hedge of writes, got %d", n-hedges)
+	}
+}
+
+func TestHedgedReadsNoHostLeft(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{{Name: "name", Type: gocqltest.Text}}
+	srv.On(`SELECT name FROM users`).Handle(columns, func(*gocqltest.Request) gocqltest.Response {
+		time.Sleep(50 * time.Millisecond)
+		return gocqltest.Response{Rows: [][]interface{}{{"alice"}}}
+	})
+
+	cluster := srv.ClusterConfig()
+	cluster.HedgedReads = &gocql.HedgedReads{Delay: 5 * time.Millisecond, MaxHedges: 2}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	// the hedges find no other host, the primary request answers
+	var name string
+	if err := session.Query(`SELECT name FROM users`).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" {
+		t.Fatalf("expected alice, got %q", name)
+	}
+	if stats := session.Stats(); stats.PrimaryWins != 1 || stats.HedgeWins != 0 {
+		t.Fatalf("expected the primary request to win, got %+v", stats)
+	}
+}
@@ -71,6 +71,11 @@ func goType(t TypeInfo) (reflect.Type, error) {
 		tuple := t.(TupleTypeInfo)
 		return reflect.TypeOf(make([]interface{}, len(tuple.Elems))), nil
 	case TypeUDT:
+		if udt, ok := t.(UDTTypeInfo); ok {
+			if typ := registeredUDT(udt); typ != nil {
+				return typ, nil
+			}
+		}
 		return reflect.TypeOf(make(map[string]interface{})), nil
 	case TypeDate:
 		return reflect.TypeOf(*new(time.Time)), nil
@@ -158,7 +163,7 @@ func getCassandraType(name string, logger StdLogger) TypeInfo {
 	} else if strings.HasPrefix(name, "map<") {
 		names := splitCompositeTypes(strings.TrimPrefix(name[:len(name)-1], "map<"))
 		if len(names) != 2 {
-			logger.Printf("Error parsing map type, it has %d subelements, expecting 2\n", len(names))
+			logWarn(logger, "error parsing map type, expecting 2 subelements", LogField{"subelements", len(names)})
 			return NativeType{
 				typ: TypeCustom,
 			}
@@ -360,7 +365,7 @@ func (iter *Iter) SliceMap() ([]map[string]interface{}, error) {
 
 	// Not checking for the error because we just did
 	rowData, _ := iter.RowData()
-	dataToReturn := make([]map[string]interface{}, 0)
+	dataToReturn := make([]map[string]interface{}, 0, iter.NumRows())
 	for iter.Scan(rowData.Values...) {
 		m := make(map[string]interface{}, len(rowData.Columns))
 		rowData.rowMap(m)
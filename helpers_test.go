@@ -212,3 +212,31 @@ func TestGetCassandraType(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckScanDest(t *testing.T) {
+	columns := []ColumnInfo{
+		{Name: "id", TypeInfo: NativeType{typ: TypeInt}},
+		{Name: "point", TypeInfo: TupleTypeInfo{Elems: []TypeInfo{NativeType{typ: TypeInt}, NativeType{typ: TypeInt}}}},
+		{Name: "name", TypeInfo: NativeType{typ: TypeText}},
+	}
+
+	if err := checkScanDest(columns, 4, 4, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkScanDest(columns, 4, 3, true); err != nil {
+		t.Fatalf("expected a prefix ending with the tuple to be accepted, got %v", err)
+	}
+
+	err, ok := checkScanDest(columns, 4, 2, true).(*ScanCountError)
+	if !ok || !err.SplitTuple {
+		t.Fatalf("expected a prefix splitting the tuple to be rejected, got %v", err)
+	}
+	if len(err.Unmatched) != 1 || err.Unmatched[0].Name != "name" {
+		t.Fatalf("expected name to be unmatched, got %v", err.Unmatched)
+	}
+
+	err, ok = checkScanDest(columns, 4, 5, true).(*ScanCountError)
+	if !ok || err.Unmatched != nil {
+		t.Fatalf("expected too many destinations to be rejected, got %v", err)
+	}
+}
@@ -100,13 +100,10 @@ func (c cassVersion) String() string {
 	return fmt.Sprintf("v%d.%d.%d", c.Major, c.Minor, c.Patch)
 }
 
-func (c cassVersion) nodeUpDelay() time.Duration {
-	if c.Major >= 2 && c.Minor >= 2 {
-		// CASSANDRA-8236
-		return 0
-	}
-
-	return 10 * time.Second
+// reportsUpEarly reports whether nodes of the version are reported up before
+// they accept connections, which is fixed by CASSANDRA-8236 in 2.2.
+func (c cassVersion) reportsUpEarly() bool {
+	return c.Major < 2 || (c.Major == 2 && c.Minor < 2)
 }
 
 type HostInfo struct {
@@ -562,6 +559,11 @@ func (s *Session) hostInfoFromMap(row map[string]interface{}, host *HostInfo) (*
 	ip, port := s.cfg.translateAddressPort(host.ConnectAddress(), host.port)
 	host.connectAddress = ip
 	host.port = port
+	if port, ok := s.contactPorts[ip.String()]; ok {
+		// the port the host was given as a contact point with, which may not be
+		// the port it reports, for example behind port forwarding
+		host.port = port
+	}
 
 	return host, nil
 }
@@ -633,8 +635,8 @@ func (r *ringDescriber) getClusterPeerInfo(localHost *HostInfo) ([]*HostInfo, er
 			return nil, err
 		} else if !isValidPeer(host) {
 			// If it's not a valid peer
-			r.session.logger.Printf("Found invalid peer '%s' "+
-				"Likely due to a gossip or snitch issue, this host will be ignored", host)
+			logWarn(r.session.logger, "found invalid peer, likely due to a gossip or snitch issue, this host will be ignored",
+				LogField{"peer", host})
 			continue
 		}
 
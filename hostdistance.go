@@ -0,0 +1,51 @@
+package gocql
+
+import "fmt"
+
+// HostDistance is how far a host is from the client, as assigned by the host
+// selection policy, which sizes the connection pool of the host after it.
+type HostDistance int
+
+const (
+	// HostDistanceLocal hosts get ClusterConfig.NumConns connections.
+	HostDistanceLocal HostDistance = iota
+	// HostDistanceRemote hosts get ClusterConfig.NumRemoteConns connections,
+	// or NumConns when it is not set.
+	HostDistanceRemote
+	// HostDistanceIgnored hosts get no connections, queries are never sent
+	// to them.
+	HostDistanceIgnored
+)
+
+func (d HostDistance) String() string {
+	switch d {
+	case HostDistanceLocal:
+		return "local"
+	case HostDistanceRemote:
+		return "remote"
+	case HostDistanceIgnored:
+		return "ignored"
+	}
+	return fmt.Sprintf("HostDistance(%d)", int(d))
+}
+
+// HostDistancer is implemented by the host selection policies assigning
+// distances to hosts. The hosts of the other policies are local if the policy
+// reports them local with IsLocal and remote otherwise.
+//
+// The distance of a host is taken when its connection pool is created, when
+// the host is added or comes back up.
+type HostDistancer interface {
+	Distance(host *HostInfo) HostDistance
+}
+
+// hostDistance returns the distance of host assigned by policy.
+func hostDistance(policy HostSelectionPolicy, host *HostInfo) HostDistance {
+	if d, ok := policy.(HostDistancer); ok {
+		return d.Distance(host)
+	}
+	if policy.IsLocal(host) {
+		return HostDistanceLocal
+	}
+	return HostDistanceRemote
+}
@@ -0,0 +1,53 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestQuerySetHost(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT address FROM system_views.clients`).Rows(
+		[]gocqltest.Column{{Name: "address", Type: gocqltest.Text}},
+		[]interface{}{"127.0.0.1"},
+	)
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeReadTimeout, "read timeout")
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &gocql.SimpleRetryPolicy{NumRetries: 2}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	hostID := srv.HostID.String()
+	var address string
+	q := session.Query(`SELECT address FROM system_views.clients`).SetHost(hostID)
+	if err := q.Scan(&address); err != nil {
+		t.Fatal(err)
+	}
+	if address != "127.0.0.1" {
+		t.Fatalf("unexpected address %q", address)
+	}
+	if q.HostID() != hostID {
+		t.Fatalf("expected host ID %s, got %s", hostID, q.HostID())
+	}
+
+	// a pinned query is never retried on another host
+	q = session.Query(`SELECT name FROM users`).SetHost(hostID)
+	if err := q.Exec(); err == nil {
+		t.Fatal("expected a read timeout")
+	}
+	if hosts := q.AttemptedHosts(); len(hosts) != 1 || hosts[0].HostID() != hostID {
+		t.Fatalf("expected 1 attempt on the pinned host, got %v", hosts)
+	}
+
+	if err := session.Query(`SELECT address FROM system_views.clients`).SetHost(gocql.TimeUUID().String()).Exec(); err == nil {
+		t.Fatal("expected an error for an unknown host")
+	}
+}
@@ -0,0 +1,99 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InitialConnectRetry configures the retries of the connection to the contact
+// points when a session is created, see ClusterConfig.InitialConnectRetry.
+type InitialConnectRetry struct {
+	// Timeout bounds the time spent connecting to the contact points, from the
+	// first attempt. The error of the last attempt is returned once it elapsed.
+	Timeout time.Duration
+
+	// Interval is the delay before the first retry, doubled on every retry up
+	// to MaxInterval.
+	//
+	// (default: 1 second and 30 seconds)
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// connectInitial discovers the protocol version if discovered is set and
+// connects the control connection to the contact points, retrying as
+// configured by ClusterConfig.InitialConnectRetry.
+func (s *Session) connectInitial(hosts []*HostInfo, discovered bool) error {
+	retry := s.cfg.InitialConnectRetry
+	if retry == nil {
+		return s.connectContactPoints(hosts, discovered)
+	}
+
+	interval, maxInterval := retry.Interval, retry.MaxInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+
+	clock := s.cfg.clock()
+	deadline := clock.Now().Add(retry.Timeout)
+	for attempt := 1; ; attempt++ {
+		err := s.connectContactPoints(hosts, discovered)
+		if err == nil || !isRetryableInitialError(err) {
+			return err
+		}
+
+		wait := getExponentialTime(interval, maxInterval, attempt)
+		if remaining := deadline.Sub(clock.Now()); remaining <= 0 {
+			return err
+		} else if wait > remaining {
+			wait = remaining
+		}
+		logWarn(s.logger, "unable to connect to the contact points, retrying", LogField{"wait", wait}, LogField{"err", err})
+
+		timer := clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-s.ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (s *Session) connectContactPoints(hosts []*HostInfo, discovered bool) error {
+	if discovered {
+		proto, err := s.control.discoverProtocol(hosts)
+		if err != nil {
+			return fmt.Errorf("unable to discover protocol version: %w", err)
+		} else if proto == 0 {
+			return errors.New("unable to discovery protocol version")
+		}
+
+		// TODO(zariel): we really only need this in 1 place
+		s.cfg.ProtoVersion = proto
+		s.connCfg.ProtoVersion = proto
+	}
+
+	return s.control.connect(hosts)
+}
+
+// isRetryableInitialError reports whether connecting to the contact points
+// may succeed later after failing with err, unlike when the cluster or the
+// credentials are wrong.
+func isRetryableInitialError(err error) bool {
+	var (
+		mismatch *ClusterMismatchError
+		reqErr   RequestError
+	)
+	if errors.As(err, &mismatch) {
+		return false
+	}
+	if errors.As(err, &reqErr) && reqErr.Code() == ErrCodeCredentials {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package gocql_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// freeAddr returns an address nothing listens on, for a server started later.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestInitialConnectRetry(t *testing.T) {
+	srv := gocqltest.NewUnstartedServer()
+	srv.Addr = freeAddr(t)
+	defer srv.Close()
+
+	cluster := srv.ClusterConfig()
+	cluster.InitialConnectRetry = &gocql.InitialConnectRetry{
+		Timeout:     10 * time.Second,
+		Interval:    50 * time.Millisecond,
+		MaxInterval: 100 * time.Millisecond,
+	}
+
+	time.AfterFunc(300*time.Millisecond, srv.Start)
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("expected the session to connect once the server started, got %v", err)
+	}
+	session.Close()
+}
+
+func TestInitialConnectRetryTimeout(t *testing.T) {
+	cluster := gocql.NewCluster(freeAddr(t))
+	cluster.InitialConnectRetry = &gocql.InitialConnectRetry{
+		Timeout:  200 * time.Millisecond,
+		Interval: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if _, err := cluster.CreateSession(); err == nil {
+		t.Fatal("expected the session to fail without a server")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 5*time.Second {
+		t.Fatalf("expected the session to fail once the retry timeout elapsed, took %v", elapsed)
+	}
+}
+
+func TestInitialConnectRetryClusterMismatch(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	cluster := srv.ClusterConfig()
+	cluster.ClusterName = "other"
+	cluster.InitialConnectRetry = &gocql.InitialConnectRetry{Timeout: time.Minute}
+
+	start := time.Now()
+	if _, err := cluster.CreateSession(); err == nil {
+		t.Fatal("expected the session to fail with another cluster name")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected a cluster mismatch not to be retried, took %v", elapsed)
+	}
+}
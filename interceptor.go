@@ -0,0 +1,131 @@
+package gocql
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// FrameInterceptor intercepts the frames exchanged on a connection, to test
+// how applications behave when frames are delayed, altered or lost, without a
+// proxy in front of the nodes, see ClusterConfig.UnsafeFrameInterceptor.
+//
+// The frames are passed as on the wire, header included and body compressed
+// if the connection compresses frames. A frame returned instead is sent or
+// received as is and must be well formed, or the connection is closed by the
+// node or the driver. Dropping a frame leaves its request waiting for a
+// response until it times out.
+//
+// The methods are called by the goroutines writing and reading the frames of
+// the connection, so that delaying a frame, by sleeping before returning,
+// delays the frames after it too, like a slow network does.
+//
+// Intercepting frames is unsafe and meant for tests only.
+type FrameInterceptor interface {
+	// Outgoing is called with every frame before it is written to the node and
+	// returns the frames to write instead, nil to drop it.
+	Outgoing(frame []byte) []byte
+
+	// Incoming is called with every frame read from the node and returns the
+	// frames to read instead, nil to drop it.
+	Incoming(frame []byte) []byte
+}
+
+// interceptedConn passes the frames written to and read from a connection
+// through a FrameInterceptor.
+type interceptedConn struct {
+	net.Conn
+	interceptor FrameInterceptor
+
+	// wmu guards written, the bytes written of a frame not complete yet.
+	wmu     sync.Mutex
+	written []byte
+
+	// read are the intercepted bytes which were not read yet.
+	read []byte
+}
+
+func newInterceptedConn(conn net.Conn, interceptor FrameInterceptor) *interceptedConn {
+	return &interceptedConn{Conn: conn, interceptor: interceptor}
+}
+
+// frameLength returns the length of the frame starting in p, header included,
+// or 0 if p doesn't contain the whole header yet.
+func frameLength(p []byte) int {
+	if len(p) == 0 {
+		return 0
+	}
+	headSize := 9
+	if p[0]&protoVersionMask < protoVersion3 {
+		headSize = 8
+	}
+	if len(p) < headSize {
+		return 0
+	}
+	return headSize + int(binary.BigEndian.Uint32(p[headSize-4:headSize]))
+}
+
+func (c *interceptedConn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.written = append(c.written, p...)
+	var out []byte
+	for {
+		n := frameLength(c.written)
+		if n == 0 || len(c.written) < n {
+			break
+		}
+		frame := append([]byte(nil), c.written[:n]...)
+		c.written = c.written[n:]
+		out = append(out, c.interceptor.Outgoing(frame)...)
+	}
+	if len(c.written) == 0 {
+		c.written = nil
+	}
+
+	if len(out) > 0 {
+		if _, err := c.Conn.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *interceptedConn) Read(p []byte) (int, error) {
+	for len(c.read) == 0 {
+		frame, err := c.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		c.read = c.interceptor.Incoming(frame)
+	}
+	n := copy(p, c.read)
+	c.read = c.read[n:]
+	return n, nil
+}
+
+// readFrame reads the next frame of the connection, header included.
+func (c *interceptedConn) readFrame() ([]byte, error) {
+	frame := make([]byte, 9)
+	if _, err := io.ReadFull(c.Conn, frame[:1]); err != nil {
+		return nil, err
+	}
+	headSize := 9
+	if frame[0]&protoVersionMask < protoVersion3 {
+		headSize = 8
+	}
+	if _, err := io.ReadFull(c.Conn, frame[1:headSize]); err != nil {
+		return nil, err
+	}
+	n := frameLength(frame[:headSize])
+	if n < headSize || n-headSize > maxFrameSize {
+		return nil, ErrFrameTooBig
+	}
+	frame = append(frame[:headSize], make([]byte, n-headSize)...)
+	if _, err := io.ReadFull(c.Conn, frame[headSize:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
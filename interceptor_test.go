@@ -0,0 +1,81 @@
+package gocql_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// lossyNetwork drops the requests of one statement and the responses to
+// another.
+type lossyNetwork struct {
+	mu sync.Mutex
+	// unanswered are the streams of the requests whose responses are dropped.
+	unanswered map[uint16]bool
+}
+
+func stream(frame []byte) uint16 {
+	return uint16(frame[2])<<8 | uint16(frame[3])
+}
+
+func (n *lossyNetwork) Outgoing(frame []byte) []byte {
+	switch {
+	case bytes.Contains(frame, []byte(`FROM lost`)):
+		return nil
+	case bytes.Contains(frame, []byte(`FROM unanswered`)):
+		n.mu.Lock()
+		n.unanswered[stream(frame)] = true
+		n.mu.Unlock()
+	}
+	return frame
+}
+
+func (n *lossyNetwork) Incoming(frame []byte) []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.unanswered[stream(frame)] {
+		delete(n.unanswered, stream(frame))
+		return nil
+	}
+	return frame
+}
+
+func TestFrameInterceptor(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT * FROM lost`).Rows(nil)
+	srv.On(`SELECT * FROM unanswered`).Rows(nil)
+	srv.On(`SELECT * FROM users`).Rows(nil)
+
+	cluster := srv.ClusterConfig()
+	cluster.Timeout = 100 * time.Millisecond
+	cluster.UnsafeFrameInterceptor = func(*gocql.HostInfo) gocql.FrameInterceptor {
+		return &lossyNetwork{unanswered: make(map[uint16]bool)}
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELECT * FROM users`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{`SELECT * FROM lost`, `SELECT * FROM unanswered`} {
+		if err := session.Query(stmt).Exec(); err != gocql.ErrTimeoutNoResponse {
+			t.Fatalf("%s: expected %v, got %v", stmt, gocql.ErrTimeoutNoResponse, err)
+		}
+	}
+
+	// the statements are prepared, the lost and unanswered requests are the
+	// preparations of their statements
+	prepared := srv.Prepares()
+	if len(prepared) != 2 || prepared[1] != `SELECT * FROM unanswered` {
+		t.Fatalf("expected the lost request not to reach the server, got %q", prepared)
+	}
+}
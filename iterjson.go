@@ -0,0 +1,151 @@
+package gocql
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"gopkg.in/inf.v0"
+)
+
+// EncodeJSON writes the rows of the iterator to w as a JSON array of objects,
+// streaming them as they are read so that only one page is held in memory.
+// The fields of the objects are the columns, in the order of the result, the
+// elements of tuples being named as by TupleColumnName. Nulls are written as
+// null, blobs in base64, timestamps in RFC 3339 and decimals as numbers.
+//
+// EncodeJSON closes the iterator and returns its error, or the first error
+// writing to w.
+func (iter *Iter) EncodeJSON(w io.Writer) error {
+	return iter.encodeJSON(w, false)
+}
+
+// EncodeJSONLines is like EncodeJSON but writes every row as a JSON object on
+// its own line, without an enclosing array.
+func (iter *Iter) EncodeJSONLines(w io.Writer) error {
+	return iter.encodeJSON(w, true)
+}
+
+// jsonColumn unmarshals a column into value, recording whether it was null.
+type jsonColumn struct {
+	value interface{}
+	null  bool
+}
+
+func (c *jsonColumn) UnmarshalCQL(info TypeInfo, data []byte) error {
+	c.null = data == nil
+	if c.null {
+		return nil
+	}
+	return Unmarshal(info, data, c.value)
+}
+
+func (iter *Iter) encodeJSON(w io.Writer, lines bool) error {
+	var (
+		names [][]byte
+		dest  []interface{}
+	)
+	addColumn := func(name string, info TypeInfo) error {
+		value, err := info.NewWithError()
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		names = append(names, encoded)
+		dest = append(dest, &jsonColumn{value: value})
+		return nil
+	}
+	for _, column := range iter.Columns() {
+		var err error
+		if tuple, ok := column.TypeInfo.(TupleTypeInfo); ok {
+			for i, elem := range tuple.Elems {
+				if err = addColumn(TupleColumnName(column.Name, i), elem); err != nil {
+					break
+				}
+			}
+		} else {
+			err = addColumn(column.Name, column.TypeInfo)
+		}
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if !lines {
+		bw.WriteByte('[')
+	}
+	var (
+		row   []byte
+		first = true
+	)
+	for iter.Scan(dest...) {
+		row = row[:0]
+		if !lines && !first {
+			row = append(row, ',')
+		}
+		first = false
+
+		row = append(row, '{')
+		for i, d := range dest {
+			if i > 0 {
+				row = append(row, ',')
+			}
+			row = append(row, names[i]...)
+			row = append(row, ':')
+
+			value, err := jsonValue(d.(*jsonColumn))
+			if err != nil {
+				iter.Close()
+				return fmt.Errorf("gocql: encode column %s: %w", names[i], err)
+			}
+			row = append(row, value...)
+		}
+		row = append(row, '}')
+		if lines {
+			row = append(row, '\n')
+		}
+		if _, err := bw.Write(row); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	if !lines {
+		bw.WriteString("]\n")
+	}
+	return bw.Flush()
+}
+
+func jsonValue(c *jsonColumn) ([]byte, error) {
+	if c.null {
+		return []byte("null"), nil
+	}
+	v := reflect.ValueOf(c.value).Elem().Interface()
+	switch v := v.(type) {
+	case *inf.Dec:
+		if v == nil {
+			return []byte("null"), nil
+		}
+		return []byte(v.String()), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// not representable in JSON
+			return json.Marshal(fmt.Sprint(v))
+		}
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return json.Marshal(fmt.Sprint(v))
+		}
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,75 @@
+package gocql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestIterEncodeJSON(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	srv.On(`SELECT * FROM orders`).Rows([]gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "name", Type: gocqltest.Text},
+		{Name: "payload", Type: gocqltest.Blob},
+		{Name: "created", Type: gocqltest.Timestamp},
+		{Name: "paid", Type: gocqltest.Boolean},
+		{Name: "tags", Type: gocqltest.List(gocqltest.Text)},
+	},
+		[]interface{}{1, "a", []byte{1, 2}, ts, true, []string{"x", "y"}},
+		[]interface{}{2, nil, nil, ts, false, nil},
+	)
+	srv.On(`SELECT * FROM missing`).Error(gocql.ErrCodeInvalid, "unconfigured table missing")
+	srv.On(`SELECT * FROM empty`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var buf bytes.Buffer
+	// a page per row
+	if err := session.Query(`SELECT * FROM orders`).PageSize(1).Iter().EncodeJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	const want = `[{"id":1,"name":"a","payload":"AQI=","created":"2024-03-01T12:00:00Z","paid":true,"tags":["x","y"]},` +
+		`{"id":2,"name":null,"payload":null,"created":"2024-03-01T12:00:00Z","paid":false,"tags":null}]` + "\n"
+	if s := buf.String(); s != want {
+		t.Fatalf("unexpected JSON\n%s\nexpected\n%s", s, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Fatal("invalid JSON")
+	}
+
+	buf.Reset()
+	if err := session.Query(`SELECT * FROM orders`).Iter().EncodeJSONLines(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(lines[1], &row); err != nil || row["id"] != 2.0 || row["name"] != nil {
+		t.Fatalf("unexpected line %s: %v", lines[1], err)
+	}
+
+	buf.Reset()
+	if err := session.Query(`SELECT * FROM empty`).Iter().EncodeJSON(&buf); err != nil || buf.String() != "[]\n" {
+		t.Fatalf("expected an empty array, got %q %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	err = session.Query(`SELECT * FROM missing`).Iter().EncodeJSON(&buf)
+	if _, ok := err.(gocql.RequestError); !ok {
+		t.Fatalf("expected the error of the query, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package gocql
+
+import "context"
+
+// Keyspace returns the keyspace of the session, ClusterConfig.Keyspace or the
+// keyspace set with SetKeyspace.
+func (s *Session) Keyspace() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keyspace
+}
+
+// SetKeyspace switches the keyspace of the session, on all its connections
+// and on the connections opened afterwards, so that unqualified table names
+// refer to keyspace. A USE statement executed with Session.Query only switches
+// the connection it is sent to, use SetKeyspace instead.
+//
+// The keyspace is checked first on one connection, within ctx. If it doesn't
+// exist SetKeyspace returns the error of the server and the session keeps its
+// keyspace. The other connections failing to switch are closed and replaced.
+// Queries executing while the keyspace is switched may use either keyspace.
+func (s *Session) SetKeyspace(ctx context.Context, keyspace string) error {
+	if s.Closed() {
+		return ErrSessionClosed
+	} else if keyspace == "" {
+		return ErrNoKeyspace
+	}
+
+	conn := s.getConn()
+	if conn == nil {
+		return ErrNoConnections
+	}
+	if err := conn.useKeyspace(ctx, keyspace); err != nil {
+		return err
+	}
+
+	// once the pools switched, all the connections must follow, the switch is
+	// limited by the timeout of the connections rather than ctx
+	for _, conn := range s.pool.setKeyspace(keyspace) {
+		if conn.keyspace() == keyspace {
+			continue
+		}
+		if err := conn.UseKeyspace(keyspace); err != nil {
+			// the pool replaces the connection with one using the keyspace
+			conn.closeWithError(err)
+		}
+	}
+
+	s.mu.Lock()
+	s.keyspace = keyspace
+	s.mu.Unlock()
+
+	if !s.cfg.disableControlConn {
+		s.policy.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: keyspace})
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package gocql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestKeyspaceReappliedOnReconnect(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	cluster := srv.ClusterConfig()
+	cluster.Keyspace = "example"
+	cluster.NumConns = 2
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.CloseConnections()
+
+	// wait for the pool to reconnect
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not reconnect: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) < 2 {
+		t.Fatalf("expected at least 2 requests, got %d", len(reqs))
+	}
+	for _, req := range reqs {
+		if req.Keyspace != "example" {
+			t.Fatalf("expected request in keyspace example, got %q", req.Keyspace)
+		}
+	}
+}
+
+func TestSessionSetKeyspace(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`USE "missing"`).Error(gocql.ErrCodeInvalid, "Keyspace 'missing' does not exist")
+
+	cluster := srv.ClusterConfig()
+	cluster.Keyspace = "example"
+	cluster.NumConns = 3
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.SetKeyspace(context.Background(), "other"); err != nil {
+		t.Fatal(err)
+	}
+	if ks := session.Keyspace(); ks != "other" {
+		t.Fatalf("expected keyspace other, got %q", ks)
+	}
+
+	// the queries on every connection use the keyspace
+	for i := 0; i < 10; i++ {
+		if err := session.Query(`INSERT INTO users (id) VALUES (1)`).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, req := range srv.Requests() {
+		if req.Statement == `INSERT INTO users (id) VALUES (1)` && req.Keyspace != "other" {
+			t.Fatalf("expected request in keyspace other, got %q", req.Keyspace)
+		}
+	}
+
+	// a keyspace which doesn't exist leaves the session unchanged
+	if err := session.SetKeyspace(context.Background(), "missing"); err == nil {
+		t.Fatal("expected an error for a missing keyspace")
+	}
+	if ks := session.Keyspace(); ks != "other" {
+		t.Fatalf("expected keyspace other, got %q", ks)
+	}
+
+	// the new connections use the keyspace too
+	srv.CloseConnections()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := session.Query(`SELECT id FROM users`).Exec()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session did not reconnect: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	reqs := srv.Requests()
+	if req := reqs[len(reqs)-1]; req.Keyspace != "other" {
+		t.Fatalf("expected request in keyspace other after reconnecting, got %q", req.Keyspace)
+	}
+}
@@ -0,0 +1,228 @@
+package gocql
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyAwarePolicy wraps a policy, deferring the hosts much slower than the
+// fastest to after the others, see LatencyAwarePolicy.
+type latencyAwarePolicy struct {
+	fallback HostSelectionPolicy
+
+	exclusion       float64
+	retryPeriod     time.Duration
+	minMeasurements int
+	smoothing       float64
+
+	clock Clock
+
+	mu        sync.RWMutex
+	latencies map[string]*hostLatency
+}
+
+// hostLatency is the moving average of the latencies of a host, guarded by the
+// mutex of the policy.
+type hostLatency struct {
+	average      float64 // nanoseconds
+	measurements int
+	updated      time.Time
+}
+
+// LatencyExclusion sets how many times slower than the fastest host a host
+// is considered slow, see LatencyAwarePolicy. Default: 2.
+func LatencyExclusion(multiple float64) func(*latencyAwarePolicy) {
+	return func(p *latencyAwarePolicy) {
+		if multiple > 1 {
+			p.exclusion = multiple
+		}
+	}
+}
+
+// LatencyRetryPeriod sets how long a slow host is not measured before it is
+// tried again as if it was fast, since it only gets traffic once the other
+// hosts failed. Default: 10s.
+func LatencyRetryPeriod(d time.Duration) func(*latencyAwarePolicy) {
+	return func(p *latencyAwarePolicy) {
+		if d > 0 {
+			p.retryPeriod = d
+		}
+	}
+}
+
+// LatencyMinMeasurements sets the number of attempts a host is measured for
+// before its latency is compared with the others. Default: 50.
+func LatencyMinMeasurements(n int) func(*latencyAwarePolicy) {
+	return func(p *latencyAwarePolicy) {
+		if n > 0 {
+			p.minMeasurements = n
+		}
+	}
+}
+
+// LatencyAwarePolicy is a host selection policy which measures the latency of
+// the attempts at executing queries on every host, as an exponentially
+// weighted moving average, and picks the hosts whose latency exceeds
+// LatencyExclusion times the latency of the fastest host after the others, so
+// that a slow or pausing node stops receiving traffic without being marked
+// down:
+//
+//	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(
+//		gocql.LatencyAwarePolicy(gocql.DCAwareRoundRobinPolicy("dc1")))
+//
+// The hosts are picked by fallback, in its order otherwise. Wrapped by
+// TokenAwareHostPolicy, only the order of the non replica hosts is changed.
+func LatencyAwarePolicy(fallback HostSelectionPolicy, opts ...func(*latencyAwarePolicy)) HostSelectionPolicy {
+	p := &latencyAwarePolicy{
+		fallback:        fallback,
+		exclusion:       2,
+		retryPeriod:     10 * time.Second,
+		minMeasurements: 50,
+		smoothing:       0.1,
+		clock:           systemClock{},
+		latencies:       make(map[string]*hostLatency),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *latencyAwarePolicy) Init(s *Session) {
+	p.clock = s.cfg.clock()
+	p.fallback.Init(s)
+}
+
+func (p *latencyAwarePolicy) IsLocal(host *HostInfo) bool {
+	return p.fallback.IsLocal(host)
+}
+
+func (p *latencyAwarePolicy) Distance(host *HostInfo) HostDistance {
+	return hostDistance(p.fallback, host)
+}
+
+func (p *latencyAwarePolicy) KeyspaceChanged(update KeyspaceUpdateEvent) {
+	p.fallback.KeyspaceChanged(update)
+}
+
+func (p *latencyAwarePolicy) SetPartitioner(partitioner string) {
+	p.fallback.SetPartitioner(partitioner)
+}
+
+func (p *latencyAwarePolicy) AddHost(host *HostInfo) {
+	p.fallback.AddHost(host)
+}
+
+func (p *latencyAwarePolicy) RemoveHost(host *HostInfo) {
+	p.mu.Lock()
+	delete(p.latencies, host.HostID())
+	p.mu.Unlock()
+	p.fallback.RemoveHost(host)
+}
+
+func (p *latencyAwarePolicy) HostUp(host *HostInfo) {
+	p.fallback.HostUp(host)
+}
+
+func (p *latencyAwarePolicy) HostDown(host *HostInfo) {
+	// the latency of a host coming back up is measured again
+	p.mu.Lock()
+	delete(p.latencies, host.HostID())
+	p.mu.Unlock()
+	p.fallback.HostDown(host)
+}
+
+func (p *latencyAwarePolicy) Pick(qry ExecutableQuery) NextHost {
+	next := p.fallback.Pick(qry)
+	now := p.clock.Now()
+	best := p.bestLatency(now)
+
+	var (
+		slow     []SelectedHost
+		drained  bool
+		returned int
+	)
+	return func() SelectedHost {
+		for !drained {
+			host := next()
+			if host == nil {
+				drained = true
+				break
+			}
+			if best > 0 && p.isSlow(host.Info(), best, now) {
+				slow = append(slow, host)
+				continue
+			}
+			return p.measured(host)
+		}
+		if returned < len(slow) {
+			returned++
+			return p.measured(slow[returned-1])
+		}
+		return nil
+	}
+}
+
+// bestLatency returns the lowest average latency of the hosts measured
+// enough, or 0.
+func (p *latencyAwarePolicy) bestLatency(now time.Time) float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var best float64
+	for _, l := range p.latencies {
+		if p.comparable(l, now) && (best == 0 || l.average < best) {
+			best = l.average
+		}
+	}
+	return best
+}
+
+func (p *latencyAwarePolicy) isSlow(host *HostInfo, best float64, now time.Time) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	l, ok := p.latencies[host.HostID()]
+	return ok && p.comparable(l, now) && l.average > p.exclusion*best
+}
+
+// comparable reports whether the latency l was measured enough, and recently
+// enough, to be compared.
+func (p *latencyAwarePolicy) comparable(l *hostLatency, now time.Time) bool {
+	return l.measurements >= p.minMeasurements && now.Sub(l.updated) <= p.retryPeriod
+}
+
+func (p *latencyAwarePolicy) measured(host SelectedHost) SelectedHost {
+	return &latencyMeasuredHost{SelectedHost: host, policy: p, start: p.clock.Now()}
+}
+
+func (p *latencyAwarePolicy) record(host *HostInfo, latency time.Duration) {
+	now := p.clock.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l, ok := p.latencies[host.HostID()]
+	if !ok {
+		l = &hostLatency{average: float64(latency)}
+		p.latencies[host.HostID()] = l
+	} else {
+		l.average += p.smoothing * (float64(latency) - l.average)
+	}
+	l.measurements++
+	l.updated = now
+}
+
+// latencyMeasuredHost measures the latency of an attempt from the pick of the
+// host until it is marked. Retries on the same host mark it again but are not
+// measured, the start of their attempt is not known.
+type latencyMeasuredHost struct {
+	SelectedHost
+	policy *latencyAwarePolicy
+	start  time.Time
+	marked bool
+}
+
+func (h *latencyMeasuredHost) Mark(err error) {
+	if !h.marked {
+		h.marked = true
+		h.policy.record(h.Info(), h.policy.clock.Now().Sub(h.start))
+	}
+	h.SelectedHost.Mark(err)
+}
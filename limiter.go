@@ -0,0 +1,200 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrConcurrencyLimit is returned by the queries rejected by an
+// AdaptiveLimiter because its limit of queries in flight was reached.
+var ErrConcurrencyLimit = errors.New("gocql: concurrency limit reached")
+
+// AdaptiveLimit configures an AdaptiveLimiter.
+type AdaptiveLimit struct {
+	// InitialLimit is the number of queries allowed in flight at first.
+	// Default: 20.
+	InitialLimit int
+	// MinLimit and MaxLimit bound the limit. Default: 1 and 1000.
+	MinLimit int
+	MaxLimit int
+
+	// Backoff is the factor the limit is multiplied with when a query shows
+	// that the cluster is overloaded. Default: 0.9.
+	Backoff float64
+	// LatencyThreshold is the latency above which a query counts as a sign
+	// of overload, like a timeout. Default: 0, only errors are counted.
+	LatencyThreshold time.Duration
+
+	// MaxWait is how long a query waits for another one to complete when the
+	// limit is reached, before failing with ErrConcurrencyLimit. Default: 0,
+	// the query fails at once.
+	MaxWait time.Duration
+
+	// Clock measures the latency of the queries and the waits. Default: the
+	// system clock.
+	Clock Clock
+}
+
+// AdaptiveLimiter limits the number of queries and batches of a session in
+// flight, adjusting the limit to the feedback of their results with additive
+// increase and multiplicative decrease (AIMD): every query completing in time
+// while the limit is used raises the limit, by one per limit queries, and every
+// query which times out, is rejected as overloaded or is slower than
+// LatencyThreshold lowers it by Backoff. The session so backs off by itself
+// when the cluster degrades, and recovers once it is healthy:
+//
+//	limiter := gocql.NewAdaptiveLimiter(gocql.AdaptiveLimit{
+//		MaxLimit:         200,
+//		LatencyThreshold: 100 * time.Millisecond,
+//	})
+//	cluster.Middleware = append(cluster.Middleware, limiter.Middleware())
+//
+// A limiter can be shared by the sessions of an application connected to the
+// same cluster.
+type AdaptiveLimiter struct {
+	cfg AdaptiveLimit
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// released is closed and replaced when a query completes, waking up the
+	// queries waiting for the limit.
+	released chan struct{}
+}
+
+// NewAdaptiveLimiter returns a limiter configured by cfg.
+func NewAdaptiveLimiter(cfg AdaptiveLimit) *AdaptiveLimiter {
+	if cfg.MinLimit <= 0 {
+		cfg.MinLimit = 1
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 1000
+	}
+	if cfg.MaxLimit < cfg.MinLimit {
+		cfg.MaxLimit = cfg.MinLimit
+	}
+	if cfg.InitialLimit <= 0 {
+		cfg.InitialLimit = 20
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
+
+	l := &AdaptiveLimiter{cfg: cfg, released: make(chan struct{})}
+	l.limit = l.bound(float64(cfg.InitialLimit))
+	return l
+}
+
+// Limit returns the number of queries currently allowed in flight.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of queries in flight.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Middleware returns the middleware limiting the queries of a session, see
+// ClusterConfig.Middleware.
+func (l *AdaptiveLimiter) Middleware() Middleware {
+	return func(next QueryExecutor) QueryExecutor {
+		return QueryExecutorFunc(func(qry ExecutableQuery) (*Iter, error) {
+			if err := l.acquire(qry.Context()); err != nil {
+				return nil, err
+			}
+			start := l.cfg.Clock.Now()
+			iter, err := next.Execute(qry)
+			if err == nil && iter != nil {
+				err = iter.err
+			}
+			l.release(l.cfg.Clock.Now().Sub(start), err)
+			return iter, err
+		})
+	}
+}
+
+func (l *AdaptiveLimiter) acquire(ctx context.Context) error {
+	var timeout <-chan time.Time
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		if l.cfg.MaxWait <= 0 {
+			return ErrConcurrencyLimit
+		}
+		if timeout == nil {
+			timer := l.cfg.Clock.NewTimer(l.cfg.MaxWait)
+			defer timer.Stop()
+			timeout = timer.C()
+		}
+		select {
+		case <-released:
+		case <-timeout:
+			return ErrConcurrencyLimit
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release ends a query in flight, adjusting the limit to its latency and
+// error.
+func (l *AdaptiveLimiter) release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case isOverloadSignal(err), l.cfg.LatencyThreshold > 0 && latency > l.cfg.LatencyThreshold:
+		l.limit = l.bound(l.limit * l.cfg.Backoff)
+	case err == nil && l.inFlight*2 >= int(l.limit):
+		// only raise the limit while it is used, or it would grow without
+		// bounds under a light load and protect nothing once the load rises
+		l.limit = l.bound(l.limit + 1/l.limit)
+	}
+
+	l.inFlight--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *AdaptiveLimiter) bound(limit float64) float64 {
+	if limit < float64(l.cfg.MinLimit) {
+		return float64(l.cfg.MinLimit)
+	}
+	if limit > float64(l.cfg.MaxLimit) {
+		return float64(l.cfg.MaxLimit)
+	}
+	return limit
+}
+
+// isOverloadSignal reports whether err shows that the cluster is overloaded.
+func isOverloadSignal(err error) bool {
+	if err == nil {
+		return false
+	}
+	var reqErr RequestError
+	if errors.As(err, &reqErr) {
+		switch reqErr.Code() {
+		case ErrCodeReadTimeout, ErrCodeWriteTimeout, ErrCodeOverloaded:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, ErrTimeoutNoResponse) || errors.Is(err, context.DeadlineExceeded)
+}
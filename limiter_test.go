@@ -0,0 +1,124 @@
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func limitedSession(t *testing.T, srv *gocqltest.Server, limiter *gocql.AdaptiveLimiter) *gocql.Session {
+	t.Helper()
+	cluster := srv.ClusterConfig()
+	cluster.Middleware = []gocql.Middleware{limiter.Middleware()}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestAdaptiveLimiterFeedback(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	clock := gocqltest.NewClock(time.Now())
+	srv.On(`SELECT * FROM fast`).Rows(nil)
+	srv.On(`SELECT * FROM slow`).Handle(nil, func(*gocqltest.Request) gocqltest.Response {
+		clock.Advance(time.Second)
+		return gocqltest.Response{}
+	})
+	srv.On(`SELECT * FROM overloaded`).Error(gocql.ErrCodeOverloaded, "overloaded")
+
+	limiter := gocql.NewAdaptiveLimiter(gocql.AdaptiveLimit{
+		InitialLimit:     1,
+		MaxLimit:         10,
+		Backoff:          0.5,
+		LatencyThreshold: 100 * time.Millisecond,
+		Clock:            clock,
+	})
+	session := limitedSession(t, srv, limiter)
+	defer session.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := session.Query(`SELECT * FROM fast`).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if limit := limiter.Limit(); limit != 3 {
+		// the limit only rises while half of it is in flight
+		t.Fatalf("expected the limit to rise to 3 with sequential queries, got %d", limit)
+	}
+
+	if err := session.Query(`SELECT * FROM slow`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if limit := limiter.Limit(); limit != 1 {
+		t.Fatalf("expected a slow query to lower the limit to 1, got %d", limit)
+	}
+
+	if err := session.Query(`SELECT * FROM fast`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if limit := limiter.Limit(); limit != 2 {
+		t.Fatalf("expected the limit to rise to 2, got %d", limit)
+	}
+	if err := session.Query(`SELECT * FROM overloaded`).Exec(); err == nil {
+		t.Fatal("expected an overloaded error")
+	}
+	if limit := limiter.Limit(); limit != 1 {
+		t.Fatalf("expected an overloaded error to lower the limit to 1, got %d", limit)
+	}
+	if n := limiter.InFlight(); n != 0 {
+		t.Fatalf("expected no query in flight, got %d", n)
+	}
+}
+
+// blockOn stubs stmt on srv to block until unblock is called, started is
+// closed when the stub is executed.
+func blockOn(srv *gocqltest.Server, stmt string) (started <-chan struct{}, unblock func()) {
+	start, release := make(chan struct{}), make(chan struct{})
+	srv.On(stmt).Handle(nil, func(*gocqltest.Request) gocqltest.Response {
+		close(start)
+		<-release
+		return gocqltest.Response{}
+	})
+	return start, func() { close(release) }
+}
+
+func TestAdaptiveLimiterWait(t *testing.T) {
+	for _, maxWait := range []time.Duration{0, 10 * time.Second} {
+		srv := gocqltest.NewServer()
+		srv.On(`SELECT * FROM fast`).Rows(nil)
+		started, unblock := blockOn(srv, `SELECT * FROM blocked`)
+
+		limiter := gocql.NewAdaptiveLimiter(gocql.AdaptiveLimit{InitialLimit: 1, MaxLimit: 1, MaxWait: maxWait})
+		session := limitedSession(t, srv, limiter)
+
+		blocked := make(chan error, 1)
+		go func() { blocked <- session.Query(`SELECT * FROM blocked`).Exec() }()
+		<-started
+
+		if maxWait == 0 {
+			if err := session.Query(`SELECT * FROM fast`).Exec(); !errors.Is(err, gocql.ErrConcurrencyLimit) {
+				t.Fatalf("expected %v without MaxWait, got %v", gocql.ErrConcurrencyLimit, err)
+			}
+			unblock()
+		} else {
+			waiting := make(chan error, 1)
+			go func() { waiting <- session.Query(`SELECT * FROM fast`).Exec() }()
+			unblock()
+			if err := <-waiting; err != nil {
+				t.Fatalf("expected the waiting query to run once the first completed, got %v", err)
+			}
+		}
+		if err := <-blocked; err != nil {
+			t.Fatal(err)
+		}
+
+		session.Close()
+		srv.Close()
+	}
+}
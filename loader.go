@@ -0,0 +1,294 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// LoaderConfig configures a Loader.
+type LoaderConfig struct {
+	// MinInFlight and MaxInFlight bound the number of rows written
+	// concurrently. The loader starts at InitialInFlight, raises the number
+	// by one every time as many rows were written without being throttled,
+	// and halves it when a write times out or a coordinator is overloaded.
+	// Default: 1, 256 and 32
+	MinInFlight     int
+	MaxInFlight     int
+	InitialInFlight int
+
+	// Attempts is the number of times a throttled row is written before it
+	// fails, waiting with an exponential backoff of at least Backoff between
+	// attempts. Other errors are not retried by the loader, but by the retry
+	// policy of the query.
+	// Default: 5 and 100ms
+	Attempts int
+	Backoff  time.Duration
+
+	// OnError, if set, is called with the values and the error of every row
+	// which failed, and the loading goes on. By default Load stops at the
+	// first failed row.
+	OnError func(values []interface{}, err error)
+
+	// OnProgress, if set, is called every ProgressInterval and once Load
+	// returns, from the goroutine calling Load.
+	// Default: 10s
+	OnProgress       func(LoaderStats)
+	ProgressInterval time.Duration
+}
+
+// LoaderStats is the progress of a Loader.
+type LoaderStats struct {
+	// Rows is the number of rows written, Failed the number of rows which
+	// failed.
+	Rows   uint64
+	Failed uint64
+	// Throttled is the number of writes which timed out or were rejected by an
+	// overloaded coordinator.
+	Throttled uint64
+	// InFlight is the current target of rows written concurrently.
+	InFlight int
+	// Elapsed is the time since Load was called and RowsPerSecond the
+	// throughput since then.
+	Elapsed       time.Duration
+	RowsPerSecond float64
+}
+
+// Loader writes rows with a prepared statement, keeping as many writes in
+// flight as the cluster absorbs. It adapts to write timeouts and overloaded
+// coordinators rather than queueing more requests than the cluster can
+// handle, which makes it a safe default for loading large data sets:
+//
+//	loader, err := gocql.NewLoader(session, `INSERT INTO orders (id, total) VALUES (?, ?)`, gocql.LoaderConfig{})
+//	if err != nil {
+//		return err
+//	}
+//	rows := make(chan []interface{})
+//	go func() {
+//		defer close(rows)
+//		for _, o := range orders {
+//			rows <- []interface{}{o.ID, o.Total}
+//		}
+//	}()
+//	stats, err := loader.Load(ctx, rows)
+type Loader struct {
+	// updated atomically, first to be 64-bit aligned
+	rows      uint64
+	failed    uint64
+	throttled uint64
+	target    int64
+	start     int64
+
+	session *Session
+	stmt    string
+	cfg     LoaderConfig
+}
+
+// NewLoader returns a Loader writing rows with stmt, which is prepared so that
+// an invalid statement fails here rather than with the first row.
+func NewLoader(session *Session, stmt string, cfg LoaderConfig) (*Loader, error) {
+	if session == nil {
+		return nil, errors.New("gocql: loader requires a session")
+	}
+	if cfg.MinInFlight <= 0 {
+		cfg.MinInFlight = 1
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 256
+	}
+	if cfg.MaxInFlight < cfg.MinInFlight {
+		cfg.MaxInFlight = cfg.MinInFlight
+	}
+	if cfg.InitialInFlight <= 0 {
+		cfg.InitialInFlight = 32
+	}
+	if cfg.InitialInFlight < cfg.MinInFlight {
+		cfg.InitialInFlight = cfg.MinInFlight
+	} else if cfg.InitialInFlight > cfg.MaxInFlight {
+		cfg.InitialInFlight = cfg.MaxInFlight
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = 10 * time.Second
+	}
+
+	conn := session.getConn()
+	if conn == nil {
+		return nil, ErrNoConnections
+	}
+	if _, err := conn.prepareStatement(context.Background(), stmt, nil); err != nil {
+		return nil, err
+	}
+
+	return &Loader{
+		session: session,
+		stmt:    stmt,
+		cfg:     cfg,
+		target:  int64(cfg.InitialInFlight),
+	}, nil
+}
+
+// loaderResult is sent by the writes to the goroutine running Load, after a
+// throttled attempt and once a row is done.
+type loaderResult struct {
+	values    []interface{}
+	err       error
+	throttled bool
+	done      bool
+}
+
+// isThrottled reports whether err shows that the cluster doesn't keep up with
+// the writes.
+func isThrottled(err error) bool {
+	var (
+		writeTimeout *RequestErrWriteTimeout
+		overloaded   *RequestErrOverloaded
+	)
+	return errors.As(err, &writeTimeout) || errors.As(err, &overloaded) || errors.Is(err, ErrTimeoutNoResponse)
+}
+
+// Load writes the rows received until rows is closed, and returns once they
+// were all written. It stops early when ctx is done, or when a row fails and
+// OnError isn't set, returning the error; the rows left in the channel are
+// not read. Load must not be called concurrently.
+func (l *Loader) Load(ctx context.Context, rows <-chan []interface{}) (LoaderStats, error) {
+	atomic.StoreUint64(&l.rows, 0)
+	atomic.StoreUint64(&l.failed, 0)
+	atomic.StoreUint64(&l.throttled, 0)
+	atomic.StoreInt64(&l.start, time.Now().UnixNano())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan loaderResult)
+	ticker := time.NewTicker(l.cfg.ProgressInterval)
+	defer ticker.Stop()
+
+	var (
+		inflight int
+		// written is the number of rows written since the target was last
+		// changed, finished the number of attempts since it was last lowered
+		written  int
+		finished = l.cfg.MaxInFlight
+		firstErr error
+	)
+	handle := func(r loaderResult) {
+		target := int(atomic.LoadInt64(&l.target))
+		finished++
+		switch {
+		case r.throttled:
+			atomic.AddUint64(&l.throttled, 1)
+			// the writes in flight when the target was lowered are likely to
+			// be throttled as well, lower it once per round of writes
+			if finished >= target {
+				target /= 2
+				if target < l.cfg.MinInFlight {
+					target = l.cfg.MinInFlight
+				}
+				atomic.StoreInt64(&l.target, int64(target))
+				written, finished = 0, 0
+			}
+		case r.err != nil:
+			inflight--
+			atomic.AddUint64(&l.failed, 1)
+			if l.cfg.OnError != nil {
+				l.cfg.OnError(r.values, r.err)
+			} else if firstErr == nil {
+				firstErr = r.err
+				rows = nil
+			}
+		case r.done:
+			inflight--
+			atomic.AddUint64(&l.rows, 1)
+			written++
+			if written >= target && target < l.cfg.MaxInFlight {
+				atomic.StoreInt64(&l.target, int64(target+1))
+				written = 0
+			}
+		}
+	}
+	progress := func() {
+		if l.cfg.OnProgress != nil {
+			l.cfg.OnProgress(l.Stats())
+		}
+	}
+
+	for rows != nil || inflight > 0 {
+		var next <-chan []interface{}
+		if inflight < int(atomic.LoadInt64(&l.target)) {
+			next = rows
+		}
+		select {
+		case values, ok := <-next:
+			if !ok {
+				rows = nil
+				continue
+			}
+			inflight++
+			go l.write(ctx, values, results)
+		case r := <-results:
+			handle(r)
+		case <-ticker.C:
+			progress()
+		case <-ctx.Done():
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			rows = nil
+			// the writes in flight return promptly with the context
+			for inflight > 0 {
+				handle(<-results)
+			}
+		}
+	}
+
+	progress()
+	return l.Stats(), firstErr
+}
+
+// write writes a row, retrying throttled attempts.
+func (l *Loader) write(ctx context.Context, values []interface{}, results chan<- loaderResult) {
+	var err error
+	for attempt := 0; attempt < l.cfg.Attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(getExponentialTime(l.cfg.Backoff, 10*time.Second, attempt))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				results <- loaderResult{values: values, err: ctx.Err(), done: true}
+				return
+			}
+		}
+
+		err = l.session.Query(l.stmt, values...).WithContext(ctx).Exec()
+		if err == nil || !isThrottled(err) || ctx.Err() != nil {
+			break
+		}
+		results <- loaderResult{throttled: true}
+	}
+	results <- loaderResult{values: values, err: err, done: true}
+}
+
+// Stats returns the progress of the running or last call to Load.
+func (l *Loader) Stats() LoaderStats {
+	stats := LoaderStats{
+		Rows:      atomic.LoadUint64(&l.rows),
+		Failed:    atomic.LoadUint64(&l.failed),
+		Throttled: atomic.LoadUint64(&l.throttled),
+		InFlight:  int(atomic.LoadInt64(&l.target)),
+	}
+	if start := atomic.LoadInt64(&l.start); start != 0 {
+		stats.Elapsed = time.Since(time.Unix(0, start))
+		if seconds := stats.Elapsed.Seconds(); seconds > 0 {
+			stats.RowsPerSecond = float64(stats.Rows) / seconds
+		}
+	}
+	return stats
+}
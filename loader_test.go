@@ -0,0 +1,142 @@
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestLoader(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var (
+		mu         sync.Mutex
+		written    = make(map[int]int)
+		overloaded = 3
+	)
+	srv.On(`INSERT INTO orders (id) VALUES (?)`).
+		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var id int
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if overloaded > 0 {
+				overloaded--
+				return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeOverloaded, Message: "overloaded"}}
+			}
+			written[id]++
+			return gocqltest.Response{}
+		})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var progress []gocql.LoaderStats
+	loader, err := gocql.NewLoader(session, `INSERT INTO orders (id) VALUES (?)`, gocql.LoaderConfig{
+		InitialInFlight: 8,
+		Backoff:         1,
+		OnProgress:      func(s gocql.LoaderStats) { progress = append(progress, s) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := make(chan []interface{})
+	go func() {
+		defer close(rows)
+		for id := 0; id < 100; id++ {
+			rows <- []interface{}{id}
+		}
+	}()
+	stats, err := loader.Load(context.Background(), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(written) != 100 {
+		t.Fatalf("expected 100 rows to be written, got %d", len(written))
+	}
+	for id, n := range written {
+		if n != 1 {
+			t.Fatalf("row %d was written %d times", id, n)
+		}
+	}
+	if stats.Rows != 100 || stats.Failed != 0 || stats.Throttled != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if stats.InFlight < 1 || stats.InFlight > 256 {
+		t.Fatalf("unexpected in-flight target %d", stats.InFlight)
+	}
+	if len(progress) != 1 || progress[0].Rows != 100 || progress[0].RowsPerSecond <= 0 {
+		t.Fatalf("expected the final progress, got %+v", progress)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`INSERT INTO orders (id) VALUES (?)`).
+		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var id int
+			if err := req.Scan(&id); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			if id%2 == 1 {
+				return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeInvalid, Message: "odd"}}
+			}
+			return gocqltest.Response{}
+		})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if _, err := gocql.NewLoader(session, `INSERT INTO customers (id) VALUES (?)`, gocql.LoaderConfig{}); err == nil {
+		t.Fatal("expected a statement which can't be prepared to fail")
+	}
+
+	load := func(cfg gocql.LoaderConfig) (gocql.LoaderStats, error) {
+		loader, err := gocql.NewLoader(session, `INSERT INTO orders (id) VALUES (?)`, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := make(chan []interface{}, 10)
+		for id := 0; id < 10; id++ {
+			rows <- []interface{}{id}
+		}
+		close(rows)
+		return loader.Load(context.Background(), rows)
+	}
+
+	var failed []interface{}
+	stats, err := load(gocql.LoaderConfig{
+		OnError: func(values []interface{}, err error) { failed = append(failed, values[0]) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != 5 || stats.Failed != 5 || len(failed) != 5 {
+		t.Fatalf("expected 5 rows to be written and 5 to fail, got %+v", stats)
+	}
+
+	_, err = load(gocql.LoaderConfig{MaxInFlight: 1})
+	var reqErr gocql.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code() != gocql.ErrCodeInvalid {
+		t.Fatalf("expected the error of the failed row, got %v", err)
+	}
+}
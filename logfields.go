@@ -0,0 +1,62 @@
+package gocql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LogField is a structured field of the messages logged about a query, for
+// example the ID of the application request the query is executed for.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+type logFieldsKey struct{}
+
+// ContextWithLogFields returns a copy of ctx carrying fields after the fields
+// already in ctx. Queries and batches executed with the context include them
+// in their log fields, see Query.LogFields.
+func ContextWithLogFields(ctx context.Context, fields ...LogField) context.Context {
+	parent := LogFieldsFromContext(ctx)
+	merged := make([]LogField, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFieldsFromContext returns the log fields carried by ctx.
+func LogFieldsFromContext(ctx context.Context) []LogField {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(logFieldsKey{}).([]LogField)
+	return fields
+}
+
+// logFields returns the fields of ctx followed by fields.
+func logFields(ctx context.Context, fields []LogField) []LogField {
+	parent := LogFieldsFromContext(ctx)
+	if len(parent) == 0 {
+		return fields
+	} else if len(fields) == 0 {
+		return parent
+	}
+	merged := make([]LogField, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	return append(merged, fields...)
+}
+
+// formatLogFields formats fields as key=value pairs to be appended to a log
+// message, each preceded by a space.
+func formatLogFields(fields []LogField) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package gocql_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type logFieldsObserver struct {
+	mu      sync.Mutex
+	queries [][]gocql.LogField
+	batches [][]gocql.LogField
+}
+
+func (o *logFieldsObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.mu.Lock()
+	o.queries = append(o.queries, q.LogFields)
+	o.mu.Unlock()
+}
+
+func (o *logFieldsObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.mu.Lock()
+	o.batches = append(o.batches, b.LogFields)
+	o.mu.Unlock()
+}
+
+func TestLogFields(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"})
+	srv.On(`INSERT INTO users (name) VALUES ('bob')`).Rows(nil)
+
+	observer := &logFieldsObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.QueryObserver = observer
+	cluster.BatchObserver = observer
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	ctx := gocql.ContextWithLogFields(context.Background(), gocql.LogField{Key: "request_id", Value: "r1"})
+	ctx = gocql.ContextWithLogFields(ctx, gocql.LogField{Key: "tenant", Value: 7})
+	want := []gocql.LogField{
+		{Key: "request_id", Value: "r1"},
+		{Key: "tenant", Value: 7},
+		{Key: "feature", Value: "profile"},
+	}
+
+	q := session.Query(`SELECT name FROM users`).WithContext(ctx).LogField("feature", "profile")
+	if fields := q.LogFields(); !reflect.DeepEqual(fields, want) {
+		t.Fatalf("expected the fields of the context followed by the query, got %v", fields)
+	}
+	var name string
+	if err := q.Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	b := session.NewBatch(gocql.LoggedBatch).WithContext(ctx).LogField("feature", "profile")
+	b.Query(`INSERT INTO users (name) VALUES ('bob')`)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.queries) != 1 || !reflect.DeepEqual(observer.queries[0], want) {
+		t.Fatalf("unexpected log fields observed for the query: %v", observer.queries)
+	}
+	if len(observer.batches) != 1 || !reflect.DeepEqual(observer.batches[0], want) {
+		t.Fatalf("unexpected log fields observed for the batch: %v", observer.batches)
+	}
+
+	if fields := session.Query(`SELECT name FROM users`).LogFields(); fields != nil {
+		t.Fatalf("expected no log fields, got %v", fields)
+	}
+}
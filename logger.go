@@ -12,6 +12,88 @@ type StdLogger interface {
 	Println(v ...interface{})
 }
 
+// LogLevel is the severity of a message logged by the driver.
+type LogLevel int
+
+const (
+	// LogLevelDebug messages trace the inner workings of the driver, such as
+	// the events received from the cluster.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo messages report expected changes, such as the protocol
+	// version negotiated with the cluster.
+	LogLevelInfo
+	// LogLevelWarn messages report failures the driver recovers from, such as
+	// a connection which failed to open and is retried.
+	LogLevelWarn
+	// LogLevelError messages report failures the driver doesn't recover from
+	// on its own, such as a control connection which can't be reconnected.
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// StructuredLogger is a logger of leveled messages with structured fields,
+// such as the address of a host or an error. A ClusterConfig.Logger which
+// implements it receives the messages of the driver at their level, the
+// messages of the other loggers are formatted with their fields and the
+// debug messages are only logged by builds with the gocql_debug tag. See
+// NewSlogLogger for an adapter of log/slog.
+type StructuredLogger interface {
+	Debug(msg string, fields ...LogField)
+	Info(msg string, fields ...LogField)
+	Warn(msg string, fields ...LogField)
+	Error(msg string, fields ...LogField)
+}
+
+// logAt logs msg with fields at level to logger, see StructuredLogger.
+func logAt(logger StdLogger, level LogLevel, msg string, fields []LogField) {
+	if structured, ok := logger.(StructuredLogger); ok {
+		switch level {
+		case LogLevelDebug:
+			structured.Debug(msg, fields...)
+		case LogLevelInfo:
+			structured.Info(msg, fields...)
+		case LogLevelWarn:
+			structured.Warn(msg, fields...)
+		default:
+			structured.Error(msg, fields...)
+		}
+		return
+	}
+	if level == LogLevelDebug && !gocqlDebug {
+		return
+	}
+	logger.Printf("gocql: %s%s\n", msg, formatLogFields(fields))
+}
+
+func logDebug(logger StdLogger, msg string, fields ...LogField) {
+	logAt(logger, LogLevelDebug, msg, fields)
+}
+
+func logInfo(logger StdLogger, msg string, fields ...LogField) {
+	logAt(logger, LogLevelInfo, msg, fields)
+}
+
+func logWarn(logger StdLogger, msg string, fields ...LogField) {
+	logAt(logger, LogLevelWarn, msg, fields)
+}
+
+func logError(logger StdLogger, msg string, fields ...LogField) {
+	logAt(logger, LogLevelError, msg, fields)
+}
+
 type nopLogger struct{}
 
 func (n nopLogger) Print(_ ...interface{}) {}
@@ -3,6 +3,7 @@ package lz4
 import (
 	"encoding/binary"
 	"fmt"
+	"sync"
 
 	"github.com/pierrec/lz4/v4"
 )
@@ -23,10 +24,32 @@ func (s LZ4Compressor) Name() string {
 	return "lz4"
 }
 
+// compressors holds the hash tables of the LZ4 compressor, which are too big
+// to allocate for every frame.
+var compressors = sync.Pool{
+	New: func() interface{} {
+		return new(lz4.Compressor)
+	},
+}
+
 func (s LZ4Compressor) Encode(data []byte) ([]byte, error) {
-	buf := make([]byte, lz4.CompressBlockBound(len(data)+4))
-	var compressor lz4.Compressor
+	return s.AppendEncode(nil, data)
+}
+
+// AppendEncode appends the encoded data to dst, growing it if needed. It
+// implements gocql.AppendEncoder.
+func (s LZ4Compressor) AppendEncode(dst, data []byte) ([]byte, error) {
+	bound := lz4.CompressBlockBound(len(data)) + 4
+	if cap(dst)-len(dst) < bound {
+		grown := make([]byte, len(dst), len(dst)+bound)
+		copy(grown, dst)
+		dst = grown
+	}
+	buf := dst[len(dst) : len(dst)+bound]
+
+	compressor := compressors.Get().(*lz4.Compressor)
 	n, err := compressor.CompressBlock(data, buf[4:])
+	compressors.Put(compressor)
 	// According to lz4.CompressBlock doc, it doesn't fail as long as the dst
 	// buffer length is at least lz4.CompressBlockBound(len(data))) bytes, but
 	// we check for error anyway just to be thorough.
@@ -34,7 +57,7 @@ func (s LZ4Compressor) Encode(data []byte) ([]byte, error) {
 		return nil, err
 	}
 	binary.BigEndian.PutUint32(buf, uint32(len(data)))
-	return buf[:n+4], nil
+	return dst[:len(dst)+n+4], nil
 }
 
 func (s LZ4Compressor) Decode(data []byte) ([]byte, error) {
@@ -28,3 +28,43 @@ func TestLZ4Compressor(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, original, decoded)
 }
+
+func TestLZ4AppendEncode(t *testing.T) {
+	var c LZ4Compressor
+	original := []byte("My Test String My Test String My Test String")
+
+	prefix := []byte("prefix")
+	encoded, err := c.AppendEncode(prefix, original)
+	require.NoError(t, err)
+	require.Equal(t, prefix, encoded[:len(prefix)])
+
+	expected, err := c.Encode(original)
+	require.NoError(t, err)
+	require.Equal(t, expected, encoded[len(prefix):])
+
+	// the reused compressor state doesn't leak between encodings
+	for i := 0; i < 3; i++ {
+		again, err := c.AppendEncode(nil, original)
+		require.NoError(t, err)
+		require.Equal(t, expected, again)
+		decoded, err := c.Decode(again)
+		require.NoError(t, err)
+		require.Equal(t, original, decoded)
+	}
+}
+
+func BenchmarkLZ4Encode(b *testing.B) {
+	var c LZ4Compressor
+	data := make([]byte, 16<<10)
+	for i := range data {
+		data[i] = byte(i % 31)
+	}
+	buf := make([]byte, 0, len(data)*2)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := c.AppendEncode(buf[:0], data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -22,8 +22,7 @@ import (
 )
 
 var (
-	bigOne     = big.NewInt(1)
-	emptyValue reflect.Value
+	bigOne = big.NewInt(1)
 )
 
 var (
@@ -161,6 +160,68 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 	return nil, fmt.Errorf("can not marshal %T into %s", value, info)
 }
 
+// marshalAppend appends the encoding of the most common combinations of CQL
+// types and values to dst without using reflection or allocating. It reports
+// whether value was encoded, otherwise Marshal must be used.
+func marshalAppend(info TypeInfo, dst []byte, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		switch info.Type() {
+		case TypeVarchar, TypeAscii, TypeBlob, TypeText:
+			return append(dst, v...), true
+		}
+	case int64:
+		switch info.Type() {
+		case TypeBigInt, TypeCounter, TypeTimestamp:
+			return appendLong(dst, v), true
+		case TypeInt:
+			if v <= math.MaxInt32 && v >= math.MinInt32 {
+				return appendInt(dst, int32(v)), true
+			}
+		}
+	case int:
+		switch info.Type() {
+		case TypeBigInt, TypeCounter:
+			return appendLong(dst, int64(v)), true
+		case TypeInt:
+			if v <= math.MaxInt32 && v >= math.MinInt32 {
+				return appendInt(dst, int32(v)), true
+			}
+		}
+	case int32:
+		switch info.Type() {
+		case TypeInt:
+			return appendInt(dst, v), true
+		case TypeBigInt, TypeCounter:
+			return appendLong(dst, int64(v)), true
+		}
+	case bool:
+		if info.Type() == TypeBoolean {
+			if v {
+				return append(dst, 1), true
+			}
+			return append(dst, 0), true
+		}
+	case float64:
+		if info.Type() == TypeDouble {
+			return appendLong(dst, int64(math.Float64bits(v))), true
+		}
+	case time.Time:
+		if info.Type() == TypeTimestamp {
+			if v.IsZero() {
+				return dst, true
+			}
+			return appendLong(dst, int64(v.UTC().Unix()*1e3)+int64(v.UTC().Nanosecond()/1e6)), true
+		}
+	case UUID:
+		switch info.Type() {
+		case TypeUUID, TypeTimeUUID:
+			return append(dst, v[:]...), true
+		}
+	}
+	return dst, false
+}
+
 // Unmarshal parses the CQL encoded data based on the info parameter that
 // describes the Cassandra internal data type and stores the result in the
 // value pointed by value.
@@ -207,6 +268,10 @@ func Unmarshal(info TypeInfo, data []byte, value interface{}) error {
 		return v.UnmarshalCQL(info, data)
 	}
 
+	if unmarshalFast(info, data, value) {
+		return nil
+	}
+
 	if isNullableValue(value) {
 		return unmarshalNullable(info, data, value)
 	}
@@ -265,6 +330,87 @@ func Unmarshal(info TypeInfo, data []byte, value interface{}) error {
 	return fmt.Errorf("can not unmarshal %s into %T", info, value)
 }
 
+// unmarshalFast decodes the most common combinations of CQL types and
+// destinations without using reflection. It reports whether value was set,
+// otherwise the regular unmarshal functions must be used.
+func unmarshalFast(info TypeInfo, data []byte, value interface{}) bool {
+	switch v := value.(type) {
+	case *string:
+		switch info.Type() {
+		case TypeVarchar, TypeAscii, TypeBlob, TypeText:
+			*v = string(data)
+			return true
+		}
+	case *[]byte:
+		switch info.Type() {
+		case TypeVarchar, TypeAscii, TypeBlob, TypeText:
+			if data != nil {
+				*v = append((*v)[:0], data...)
+			} else {
+				*v = nil
+			}
+			return true
+		}
+	case *int64:
+		switch info.Type() {
+		case TypeBigInt, TypeCounter, TypeTimestamp:
+			*v = decBigInt(data)
+			return true
+		case TypeInt:
+			*v = int64(decInt(data))
+			return true
+		}
+	case *int:
+		switch info.Type() {
+		case TypeInt:
+			*v = int(decInt(data))
+			return true
+		case TypeBigInt, TypeCounter:
+			// on 32 bit platforms the range check of unmarshalIntlike is needed
+			if ^uint(0) == math.MaxUint32 {
+				return false
+			}
+			*v = int(decBigInt(data))
+			return true
+		}
+	case *bool:
+		if info.Type() == TypeBoolean {
+			*v = decBool(data)
+			return true
+		}
+	case *float64:
+		if info.Type() == TypeDouble {
+			*v = math.Float64frombits(uint64(decBigInt(data)))
+			return true
+		}
+	case *time.Time:
+		if info.Type() == TypeTimestamp {
+			if len(data) == 0 {
+				*v = time.Time{}
+				return true
+			}
+			x := decBigInt(data)
+			sec := x / 1000
+			nsec := (x - sec*1000) * 1000000
+			*v = time.Unix(sec, nsec).In(time.UTC)
+			return true
+		}
+	case *UUID:
+		switch info.Type() {
+		case TypeUUID, TypeTimeUUID:
+			switch len(data) {
+			case 0:
+				*v = UUID{}
+				return true
+			case 16:
+				copy(v[:], data)
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isNullableValue(value interface{}) bool {
 	v := reflect.ValueOf(value)
 	return v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Ptr
@@ -2263,21 +2409,13 @@ func marshalUDT(info TypeInfo, value interface{}) ([]byte, error) {
 		return nil, marshalErrorf("cannot marshal %T into %s", value, info)
 	}
 
-	fields := make(map[string]reflect.Value)
-	t := reflect.TypeOf(value)
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
-
-		if tag := sf.Tag.Get("cql"); tag != "" {
-			fields[tag] = k.Field(i)
-		}
-	}
+	fields := cachedUDTFields(k.Type(), udt)
 
 	var buf []byte
-	for _, e := range udt.Elements {
-		f, ok := fields[e.Name]
-		if !ok {
-			f = k.FieldByName(e.Name)
+	for i, e := range udt.Elements {
+		var f reflect.Value
+		if index := fields.fields[i]; index != nil {
+			f = k.FieldByIndex(index)
 		}
 
 		var data []byte
@@ -2383,17 +2521,8 @@ func unmarshalUDT(info TypeInfo, data []byte, value interface{}) error {
 		return nil
 	}
 
-	t := k.Type()
-	fields := make(map[string]reflect.Value, t.NumField())
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
-
-		if tag := sf.Tag.Get("cql"); tag != "" {
-			fields[tag] = k.Field(i)
-		}
-	}
-
 	udt := info.(UDTTypeInfo)
+	fields := cachedUDTFields(k.Type(), udt)
 	for id, e := range udt.Elements {
 		if len(data) == 0 {
 			return nil
@@ -2406,15 +2535,13 @@ func unmarshalUDT(info TypeInfo, data []byte, value interface{}) error {
 		var p []byte
 		p, data = readBytes(data)
 
-		f, ok := fields[e.Name]
-		if !ok {
-			f = k.FieldByName(e.Name)
-			if f == emptyValue {
-				// skip fields which exist in the UDT but not in
-				// the struct passed in
-				continue
-			}
+		index := fields.fields[id]
+		if index == nil {
+			// skip fields which exist in the UDT but not in
+			// the struct passed in
+			continue
 		}
+		f := k.FieldByIndex(index)
 
 		if !f.IsValid() || !f.CanAddr() {
 			return unmarshalErrorf("cannot unmarshal %s into %T: field %v is not valid", info, value, e.Name)
@@ -0,0 +1,138 @@
+package gocql
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalFastMatchesReflection(t *testing.T) {
+	// the slow paths are reached by unmarshalling into a named type
+	type (
+		str     string
+		blob    []byte
+		bigint  int64
+		integer int
+		flag    bool
+		double  float64
+	)
+
+	uuid := TimeUUID()
+	tests := []struct {
+		typ  Type
+		data []byte
+		fast interface{}
+		slow interface{}
+	}{
+		{TypeVarchar, []byte("hello"), new(string), new(str)},
+		{TypeBlob, []byte{1, 2, 3}, new([]byte), new(blob)},
+		{TypeBlob, nil, new([]byte), new(blob)},
+		{TypeBigInt, []byte{0, 0, 0, 0, 0, 0, 1, 0}, new(int64), new(bigint)},
+		{TypeInt, []byte{0xff, 0xff, 0xff, 0xfe}, new(int64), new(bigint)},
+		{TypeBigInt, nil, new(int), new(integer)},
+		{TypeInt, []byte{0, 0, 0, 42}, new(int), new(integer)},
+		{TypeBoolean, []byte{1}, new(bool), new(flag)},
+		{TypeDouble, []byte{0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}, new(float64), new(double)},
+	}
+	for _, test := range tests {
+		info := NativeType{proto: 4, typ: test.typ}
+		if !unmarshalFast(info, test.data, test.fast) {
+			t.Errorf("%s into %T: fast path not taken", test.typ, test.fast)
+			continue
+		}
+		if err := Unmarshal(info, test.data, test.slow); err != nil {
+			t.Errorf("%s into %T: %v", test.typ, test.slow, err)
+			continue
+		}
+		fast := reflect.ValueOf(test.fast).Elem()
+		slow := reflect.ValueOf(test.slow).Elem().Convert(fast.Type())
+		if !reflect.DeepEqual(fast.Interface(), slow.Interface()) {
+			t.Errorf("%s: fast path decoded %v, reflection decoded %v", test.typ, fast, slow)
+		}
+	}
+
+	var ts time.Time
+	if !unmarshalFast(NativeType{proto: 4, typ: TypeTimestamp}, encBigInt(1511543039519), &ts) {
+		t.Fatal("timestamp: fast path not taken")
+	}
+	if expected := time.Unix(0, 1511543039519*int64(time.Millisecond)).UTC(); !ts.Equal(expected) || ts.Location() != time.UTC {
+		t.Errorf("expected timestamp %v, got %v", expected, ts)
+	}
+
+	var id UUID
+	if !unmarshalFast(NativeType{proto: 4, typ: TypeTimeUUID}, uuid.Bytes(), &id) || id != uuid {
+		t.Errorf("expected uuid %v, got %v", uuid, id)
+	}
+	if unmarshalFast(NativeType{proto: 4, typ: TypeUUID}, []byte{1, 2, 3}, &id) {
+		t.Error("expected an invalid uuid to fall back to the regular unmarshal")
+	}
+	if unmarshalFast(NativeType{proto: 4, typ: TypeInt}, []byte("1"), new(string)) {
+		t.Error("expected an int into a string to fall back to the regular unmarshal")
+	}
+}
+
+func TestUnmarshalFastReusesBytes(t *testing.T) {
+	buf := make([]byte, 0, 16)
+	dest := buf
+	if err := Unmarshal(NativeType{proto: 4, typ: TypeBlob}, []byte("abc"), &dest); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dest, []byte("abc")) || &dest[0] != &buf[:1][0] {
+		t.Fatalf("expected the destination buffer to be reused, got %q", dest)
+	}
+}
+
+func TestMarshalAppendMatchesMarshal(t *testing.T) {
+	tests := []struct {
+		typ   Type
+		value interface{}
+	}{
+		{TypeVarchar, "hello"},
+		{TypeText, ""},
+		{TypeBigInt, int64(-3)},
+		{TypeTimestamp, int64(1511543039519)},
+		{TypeInt, int64(42)},
+		{TypeCounter, 7},
+		{TypeInt, -7},
+		{TypeInt, int32(1 << 20)},
+		{TypeBigInt, int32(-1)},
+		{TypeBoolean, true},
+		{TypeBoolean, false},
+		{TypeDouble, 3.25},
+		{TypeTimestamp, time.Date(2017, 11, 24, 17, 3, 59, 519000000, time.UTC)},
+		{TypeTimestamp, time.Time{}},
+		{TypeUUID, TimeUUID()},
+	}
+	for _, test := range tests {
+		info := NativeType{proto: 4, typ: test.typ}
+		expected, err := Marshal(info, test.value)
+		if err != nil {
+			t.Fatalf("%s: %v", test.typ, err)
+		}
+		buf, ok := marshalAppend(info, []byte{0xff}, test.value)
+		if !ok {
+			t.Errorf("%T into %s: append path not taken", test.value, test.typ)
+			continue
+		}
+		if !bytes.Equal(buf[1:], expected) || buf[0] != 0xff {
+			t.Errorf("%T into %s: expected % x, got % x", test.value, test.typ, expected, buf[1:])
+		}
+	}
+
+	if _, ok := marshalAppend(NativeType{proto: 4, typ: TypeInt}, nil, int64(1)<<40); ok {
+		t.Error("expected an out of range int to fall back to Marshal")
+	}
+}
+
+func BenchmarkUnmarshalFast(b *testing.B) {
+	info := NativeType{proto: 4, typ: TypeBigInt}
+	data := []byte{0, 0, 0, 0, 0, 0, 1, 0}
+	var v int64
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Unmarshal(info, data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1234,12 +1234,8 @@ func (t *typeParser) parse() typeParserResult {
 				var name string
 				decoded, err := hex.DecodeString(*param.name)
 				if err != nil {
-					t.logger.Printf(
-						"Error parsing type '%s', contains collection name '%s' with an invalid format: %v",
-						t.input,
-						*param.name,
-						err,
-					)
+					logWarn(t.logger, "error parsing type, contains collection name with an invalid format",
+						LogField{"type", t.input}, LogField{"name", *param.name}, LogField{"err", err})
 					// just use the provided name
 					name = *param.name
 				} else {
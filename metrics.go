@@ -0,0 +1,59 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MetricsRecorder records the metrics of the requests and connections of a
+// session per host, to build dashboards of the driver, see
+// ClusterConfig.MetricsRecorder. The gocqlprom package has a recorder
+// exposing them to Prometheus.
+//
+// The methods are called by the goroutines executing the queries and managing
+// the connections, they must be safe for concurrent use and return quickly.
+type MetricsRecorder interface {
+	// RecordAttempt is called after every attempt at executing a query or a
+	// batch on host, including retries and speculative executions, with its
+	// latency and error.
+	RecordAttempt(host *HostInfo, latency time.Duration, err error)
+
+	// RecordRetry is called when a query or a batch is attempted again after
+	// its attempt on host failed.
+	RecordRetry(host *HostInfo)
+
+	// RecordTimeout is called when an attempt on host timed out, waiting for
+	// the replicas on the coordinator or waiting for the response on the
+	// client.
+	RecordTimeout(host *HostInfo)
+
+	// RecordPoolSize is called with the number of connections open to host
+	// whenever it changes.
+	RecordPoolSize(host *HostInfo, conns int)
+
+	// RecordConnectionError is called when a connection to host couldn't be
+	// opened or was closed by an error.
+	RecordConnectionError(host *HostInfo, err error)
+}
+
+// isTimeoutError reports whether err is a timeout of the coordinator or of
+// the client, as counted in SessionStats.TimeoutErrors.
+func isTimeoutError(err error) bool {
+	var reqErr RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Code() == ErrCodeReadTimeout || reqErr.Code() == ErrCodeWriteTimeout
+	}
+	return errors.Is(err, ErrTimeoutNoResponse) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// recordAttempt records an attempt on host with metrics, if it is set.
+func recordAttempt(metrics MetricsRecorder, host *HostInfo, latency time.Duration, err error) {
+	if metrics == nil {
+		return
+	}
+	metrics.RecordAttempt(host, latency, err)
+	if isTimeoutError(err) {
+		metrics.RecordTimeout(host)
+	}
+}
@@ -0,0 +1,60 @@
+package gocql
+
+import "errors"
+
+// QueryExecutor executes a query or a batch, qry is either a *Query or a
+// *Batch. The context of the execution is qry.Context().
+type QueryExecutor interface {
+	Execute(qry ExecutableQuery) (*Iter, error)
+}
+
+// QueryExecutorFunc is a function implementing QueryExecutor.
+type QueryExecutorFunc func(qry ExecutableQuery) (*Iter, error)
+
+// Execute calls f(qry).
+func (f QueryExecutorFunc) Execute(qry ExecutableQuery) (*Iter, error) {
+	return f(qry)
+}
+
+// Middleware wraps the QueryExecutor of a session, see
+// ClusterConfig.Middleware. A middleware can modify the query before calling
+// next, for example with Query.SetStatement, inspect or measure the result,
+// or return an error without calling next at all.
+//
+// Middleware is called for every execution of a query, including the
+// fetching of further pages, but not for every retry or speculative
+// execution.
+type Middleware func(next QueryExecutor) QueryExecutor
+
+var errNoMiddlewareResult = errors.New("gocql: middleware returned neither an iterator nor an error")
+
+// chainMiddleware wraps exec with middleware, the first middleware being the
+// outermost.
+func chainMiddleware(exec QueryExecutor, middleware []Middleware) QueryExecutor {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		exec = middleware[i](exec)
+	}
+	return exec
+}
+
+// execute executes qry through the middleware of the session.
+func (s *Session) execute(qry ExecutableQuery) (iter *Iter, err error) {
+	s.stats.query()
+	defer func() {
+		if err != nil {
+			s.stats.failed(err)
+		} else {
+			s.stats.failed(iter.err)
+		}
+	}()
+
+	if s.middleware == nil {
+		return s.executor.executeQuery(qry)
+	}
+
+	iter, err = s.middleware.Execute(qry)
+	if err == nil && iter == nil {
+		err = errNoMiddlewareResult
+	}
+	return iter, err
+}
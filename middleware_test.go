@@ -0,0 +1,83 @@
+package gocql_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestMiddleware(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users_v2`).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"})
+
+	errBlocked := errors.New("blocked")
+	var calls []string
+	trace := func(name string) gocql.Middleware {
+		return func(next gocql.QueryExecutor) gocql.QueryExecutor {
+			return gocql.QueryExecutorFunc(func(qry gocql.ExecutableQuery) (*gocql.Iter, error) {
+				calls = append(calls, name)
+				return next.Execute(qry)
+			})
+		}
+	}
+	rewrite := func(next gocql.QueryExecutor) gocql.QueryExecutor {
+		return gocql.QueryExecutorFunc(func(qry gocql.ExecutableQuery) (*gocql.Iter, error) {
+			q, ok := qry.(*gocql.Query)
+			if !ok {
+				return next.Execute(qry)
+			}
+			switch q.Statement() {
+			case `SELECT name FROM users`:
+				q.SetStatement(`SELECT name FROM users_v2`)
+			case `DELETE FROM users`:
+				return nil, errBlocked
+			}
+			return next.Execute(qry)
+		})
+	}
+
+	cluster := srv.ClusterConfig()
+	cluster.Middleware = []gocql.Middleware{trace("outer"), trace("inner"), rewrite}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var name string
+	if err := session.Query(`SELECT name FROM users`).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" {
+		t.Fatalf("expected rewritten query to return alice, got %q", name)
+	}
+	if !reflect.DeepEqual(calls, []string{"outer", "inner"}) {
+		t.Fatalf("unexpected middleware calls %v", calls)
+	}
+
+	if err := session.Query(`DELETE FROM users`).Exec(); err != errBlocked {
+		t.Fatalf("expected %v, got %v", errBlocked, err)
+	}
+	for _, req := range srv.Requests() {
+		if req.Statement == `DELETE FROM users` {
+			t.Fatal("expected blocked query not to be sent")
+		}
+	}
+
+	calls = nil
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO users (name) VALUES ('bob')`)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, []string{"outer", "inner"}) {
+		t.Fatalf("unexpected middleware calls for batch %v", calls)
+	}
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(.+)\.cql$`)
+
+// ReadDir reads the migrations of a directory. Files must be named
+// <version>_<name>.cql, other files are ignored.
+func ReadDir(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: unable to read directory: %w", err)
+	}
+
+	var migrations []Migration
+	for _, file := range files {
+		m := fileNameRe.FindStringSubmatch(file.Name())
+		if file.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %s: %w", file.Name(), err)
+		}
+
+		src, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: unable to read migration: %w", err)
+		}
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       m[2],
+			Statements: SplitStatements(string(src)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// SplitStatements splits CQL source into its semicolon separated statements.
+// Comments are removed, semicolons in string literals, quoted identifiers and
+// $$ quoted function bodies do not end a statement.
+func SplitStatements(src string) []string {
+	var (
+		stmts []string
+		stmt  strings.Builder
+	)
+	flush := func() {
+		if s := strings.TrimSpace(stmt.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		stmt.Reset()
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\'' || c == '"':
+			// a doubled quote is an escaped quote and is copied as part of
+			// the literal
+			end := i + 1
+			for end < len(src) {
+				if src[end] == c {
+					if end+1 < len(src) && src[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			stmt.WriteString(src[i:min(end+1, len(src))])
+			i = end
+		case strings.HasPrefix(src[i:], "$$"):
+			end := strings.Index(src[i+2:], "$$")
+			if end < 0 {
+				stmt.WriteString(src[i:])
+				i = len(src)
+				break
+			}
+			stmt.WriteString(src[i : i+2+end+2])
+			i += 2 + end + 1
+		case strings.HasPrefix(src[i:], "--") || strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				i = len(src)
+				break
+			}
+			i += end - 1
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+				break
+			}
+			i += 2 + end + 1
+		case c == ';':
+			flush()
+		default:
+			stmt.WriteByte(c)
+		}
+	}
+	flush()
+
+	return stmts
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	src := `
+-- users
+CREATE TABLE users (id int PRIMARY KEY, name text);
+INSERT INTO users (id, name) VALUES (1, 'semi;colon''s'); // comment; here
+/* block; comment */
+CREATE FUNCTION f (a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java AS $$ return a; $$;
+SELECT "quoted;identifier" FROM users
+`
+	expected := []string{
+		`CREATE TABLE users (id int PRIMARY KEY, name text)`,
+		`INSERT INTO users (id, name) VALUES (1, 'semi;colon''s')`,
+		`CREATE FUNCTION f (a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE java AS $$ return a; $$`,
+		`SELECT "quoted;identifier" FROM users`,
+	}
+	if stmts := SplitStatements(src); !reflect.DeepEqual(stmts, expected) {
+		t.Fatalf("expected %q, got %q", expected, stmts)
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"0002_create_posts.cql": "CREATE TABLE posts (id int PRIMARY KEY);",
+		"0001_create_users.cql": "CREATE TABLE users (id int PRIMARY KEY);\nCREATE INDEX ON users (id);",
+		"README.md":             "not a migration",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Migration{
+		{Version: 1, Name: "create_users", Statements: []string{
+			"CREATE TABLE users (id int PRIMARY KEY)",
+			"CREATE INDEX ON users (id)",
+		}},
+		{Version: 2, Name: "create_posts", Statements: []string{"CREATE TABLE posts (id int PRIMARY KEY)"}},
+	}
+	if !reflect.DeepEqual(migrations, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, migrations)
+	}
+}
@@ -0,0 +1,221 @@
+// Package migrate applies versioned CQL schema migrations.
+//
+// Migrations are usually read from a directory of files named
+// <version>_<name>.cql, each holding one or more statements separated by
+// semicolons:
+//
+//	migrations, err := migrate.ReadDir("migrations")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	m := &migrate.Migrator{Session: session}
+//	if err := m.Up(ctx, migrations); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Applied versions are recorded in a table, schema_migrations by default, so
+// that every migration is applied once. A lightweight transaction on a lock
+// table prevents concurrent migrators from applying migrations at the same
+// time, and the migrator waits for schema agreement after every statement.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ErrLocked is returned by Migrator.Up when another migrator holds the lock.
+var ErrLocked = errors.New("migrate: migrations are locked by another migrator")
+
+// Migration is a versioned list of statements.
+type Migration struct {
+	Version    uint64
+	Name       string
+	Statements []string
+}
+
+// Migrator applies migrations with a session.
+type Migrator struct {
+	// Session is used to apply migrations.
+	Session *gocql.Session
+
+	// Keyspace of the migrations table and its lock table. If empty the
+	// tables are created in the keyspace of the session.
+	Keyspace string
+
+	// Table records the applied migrations. The lock is held in a table with
+	// the same name suffixed with _lock.
+	// Default: schema_migrations
+	Table string
+
+	// LockTTL is how long the lock is held without progress before it
+	// expires, so that a crashed migrator does not block migrations forever.
+	// The lock is renewed after every migration.
+	// Default: 10m
+	LockTTL time.Duration
+
+	// Owner identifies this migrator in the lock table.
+	// Default: a random UUID
+	Owner string
+}
+
+func (m *Migrator) table() string {
+	table := m.Table
+	if table == "" {
+		table = "schema_migrations"
+	}
+	if m.Keyspace != "" {
+		return m.Keyspace + "." + table
+	}
+	return table
+}
+
+func (m *Migrator) lockTTL() int {
+	if m.LockTTL <= 0 {
+		return int((10 * time.Minute).Seconds())
+	}
+	return int(m.LockTTL.Seconds())
+}
+
+// Applied returns the versions of the migrations that have been applied.
+func (m *Migrator) Applied(ctx context.Context) (map[uint64]bool, error) {
+	iter := m.Session.Query(fmt.Sprintf(`SELECT version FROM %s`, m.table())).WithContext(ctx).Iter()
+
+	applied := make(map[uint64]bool)
+	var version int64
+	for iter.Scan(&version) {
+		applied[uint64(version)] = true
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("migrate: unable to read applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// Up applies the migrations that have not been applied yet in order of their
+// version. It returns ErrLocked if another migrator is applying migrations.
+func (m *Migrator) Up(ctx context.Context, migrations []Migration) error {
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return fmt.Errorf("migrate: duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	if err := m.createTables(ctx); err != nil {
+		return err
+	}
+
+	owner := m.Owner
+	if owner == "" {
+		uuid, err := gocql.RandomUUID()
+		if err != nil {
+			return err
+		}
+		owner = uuid.String()
+	}
+
+	if err := m.lock(ctx, owner); err != nil {
+		return err
+	}
+	defer m.unlock(owner)
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+		if err := m.apply(ctx, migration); err != nil {
+			return err
+		}
+		if err := m.renew(ctx, owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) exec(ctx context.Context, stmt string, values ...interface{}) error {
+	return m.Session.Query(stmt, values...).WithContext(ctx).Exec()
+}
+
+func (m *Migrator) createTables(ctx context.Context) error {
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version bigint PRIMARY KEY, name text, applied_at timestamp)`, m.table()),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_lock (name text PRIMARY KEY, owner text)`, m.table()),
+	}
+	for _, stmt := range stmts {
+		if err := m.exec(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate: unable to create migrations table: %w", err)
+		}
+	}
+	if err := m.Session.AwaitSchemaAgreement(ctx); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) lock(ctx context.Context, owner string) error {
+	existing := make(map[string]interface{})
+	applied, err := m.Session.Query(fmt.Sprintf(`INSERT INTO %s_lock (name, owner) VALUES ('lock', ?) IF NOT EXISTS USING TTL ?`, m.table()),
+		owner, m.lockTTL()).WithContext(ctx).MapScanCAS(existing)
+	if err != nil {
+		return fmt.Errorf("migrate: unable to acquire lock: %w", err)
+	}
+	if !applied {
+		return fmt.Errorf("%w: held by %v", ErrLocked, existing["owner"])
+	}
+	return nil
+}
+
+func (m *Migrator) renew(ctx context.Context, owner string) error {
+	var holder string
+	applied, err := m.Session.Query(fmt.Sprintf(`UPDATE %s_lock USING TTL ? SET owner = ? WHERE name = 'lock' IF owner = ?`, m.table()),
+		m.lockTTL(), owner, owner).WithContext(ctx).ScanCAS(&holder)
+	if err != nil {
+		return fmt.Errorf("migrate: unable to renew lock: %w", err)
+	}
+	if !applied {
+		return fmt.Errorf("%w: lock expired and is held by %s", ErrLocked, holder)
+	}
+	return nil
+}
+
+// unlock releases the lock even if the context of Up was cancelled.
+func (m *Migrator) unlock(owner string) {
+	var holder string
+	// Failing to release the lock is not an error of the migration, the lock
+	// expires after LockTTL.
+	_, _ = m.Session.Query(fmt.Sprintf(`DELETE FROM %s_lock WHERE name = 'lock' IF owner = ?`, m.table()),
+		owner).ScanCAS(&holder)
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	for i, stmt := range migration.Statements {
+		if err := m.exec(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate: migration %d (%s) statement %d failed: %w", migration.Version, migration.Name, i+1, err)
+		}
+		if err := m.Session.AwaitSchemaAgreement(ctx); err != nil {
+			return fmt.Errorf("migrate: migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	err := m.exec(ctx, fmt.Sprintf(`INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)`, m.table()),
+		int64(migration.Version), migration.Name, time.Now())
+	if err != nil {
+		return fmt.Errorf("migrate: unable to record migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
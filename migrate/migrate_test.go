@@ -0,0 +1,235 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// fakeCluster stubs the statements of a Migrator on a fake server and keeps
+// the state of the migrations and lock tables.
+type fakeCluster struct {
+	srv *gocqltest.Server
+
+	mu      sync.Mutex
+	applied []int64
+	owner   string
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	t.Helper()
+
+	c := &fakeCluster{srv: gocqltest.NewServer()}
+	t.Cleanup(c.srv.Close)
+
+	applied := []gocqltest.Column{{Name: "[applied]", Type: gocqltest.Boolean}}
+	lockColumns := append(applied,
+		gocqltest.Column{Name: "name", Type: gocqltest.Text},
+		gocqltest.Column{Name: "owner", Type: gocqltest.Text})
+	ownerColumns := append(applied, gocqltest.Column{Name: "owner", Type: gocqltest.Text})
+
+	c.srv.On(`SELECT version FROM schema_migrations`).
+		Handle([]gocqltest.Column{{Name: "version", Type: gocqltest.BigInt}}, func(*gocqltest.Request) gocqltest.Response {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			var rows [][]interface{}
+			for _, v := range c.applied {
+				rows = append(rows, []interface{}{v})
+			}
+			return gocqltest.Response{Rows: rows}
+		})
+
+	c.srv.On(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`).
+		Params(
+			gocqltest.Column{Name: "version", Type: gocqltest.BigInt},
+			gocqltest.Column{Name: "name", Type: gocqltest.Text},
+			gocqltest.Column{Name: "applied_at", Type: gocqltest.Timestamp}).
+		Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+			var version int64
+			if err := gocql.Unmarshal(gocqltest.BigInt, req.Values[0], &version); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			c.mu.Lock()
+			c.applied = append(c.applied, version)
+			c.mu.Unlock()
+			return gocqltest.Response{}
+		})
+
+	c.srv.On(`INSERT INTO schema_migrations_lock (name, owner) VALUES ('lock', ?) IF NOT EXISTS USING TTL ?`).
+		Params(
+			gocqltest.Column{Name: "owner", Type: gocqltest.Text},
+			gocqltest.Column{Name: "[ttl]", Type: gocqltest.Int}).
+		Handle(lockColumns, func(req *gocqltest.Request) gocqltest.Response {
+			var owner string
+			var ttl int
+			if err := req.Scan(&owner, &ttl); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.owner != "" {
+				return gocqltest.Response{Rows: [][]interface{}{{false, "lock", c.owner}}}
+			}
+			c.owner = owner
+			return gocqltest.Response{Rows: [][]interface{}{{true, nil, nil}}}
+		})
+
+	c.srv.On(`UPDATE schema_migrations_lock USING TTL ? SET owner = ? WHERE name = 'lock' IF owner = ?`).
+		Params(
+			gocqltest.Column{Name: "[ttl]", Type: gocqltest.Int},
+			gocqltest.Column{Name: "owner", Type: gocqltest.Text},
+			gocqltest.Column{Name: "owner", Type: gocqltest.Text}).
+		Handle(ownerColumns, func(req *gocqltest.Request) gocqltest.Response {
+			var ttl int
+			var owner, expected string
+			if err := req.Scan(&ttl, &owner, &expected); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.owner != expected {
+				return gocqltest.Response{Rows: [][]interface{}{{false, c.owner}}}
+			}
+			return gocqltest.Response{Rows: [][]interface{}{{true, nil}}}
+		})
+
+	c.srv.On(`DELETE FROM schema_migrations_lock WHERE name = 'lock' IF owner = ?`).
+		Params(gocqltest.Column{Name: "owner", Type: gocqltest.Text}).
+		Handle(ownerColumns, func(req *gocqltest.Request) gocqltest.Response {
+			var owner string
+			if err := req.Scan(&owner); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.owner != owner {
+				return gocqltest.Response{Rows: [][]interface{}{{false, c.owner}}}
+			}
+			c.owner = ""
+			return gocqltest.Response{Rows: [][]interface{}{{true, nil}}}
+		})
+
+	return c
+}
+
+func (c *fakeCluster) session(t *testing.T) *gocql.Session {
+	t.Helper()
+	session, err := c.srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+// executed returns the migration statements executed against the server.
+func (c *fakeCluster) executed(migrations []Migration) []string {
+	known := make(map[string]bool)
+	for _, m := range migrations {
+		for _, stmt := range m.Statements {
+			known[stmt] = true
+		}
+	}
+	var stmts []string
+	for _, req := range c.srv.Requests() {
+		if known[req.Statement] {
+			stmts = append(stmts, req.Statement)
+		}
+	}
+	return stmts
+}
+
+func TestMigratorUp(t *testing.T) {
+	c := newFakeCluster(t)
+	c.applied = []int64{1}
+
+	migrations := []Migration{
+		{Version: 3, Name: "add_email", Statements: []string{`ALTER TABLE users ADD email text`}},
+		{Version: 1, Name: "create_users", Statements: []string{`CREATE TABLE users (id int PRIMARY KEY)`}},
+		{Version: 2, Name: "create_posts", Statements: []string{
+			`CREATE TABLE posts (id int PRIMARY KEY)`,
+			`CREATE INDEX ON posts (id)`,
+		}},
+	}
+
+	m := &Migrator{Session: c.session(t), Owner: "test"}
+	if err := m.Up(context.Background(), migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`CREATE TABLE posts (id int PRIMARY KEY)`,
+		`CREATE INDEX ON posts (id)`,
+		`ALTER TABLE users ADD email text`,
+	}
+	if stmts := c.executed(migrations); !reflect.DeepEqual(stmts, expected) {
+		t.Fatalf("expected statements %v, got %v", expected, stmts)
+	}
+	if !reflect.DeepEqual(c.applied, []int64{1, 2, 3}) {
+		t.Fatalf("unexpected applied versions %v", c.applied)
+	}
+	if c.owner != "" {
+		t.Fatalf("expected lock to be released, held by %q", c.owner)
+	}
+
+	if err := m.Up(context.Background(), migrations); err != nil {
+		t.Fatal(err)
+	}
+	if stmts := c.executed(migrations); len(stmts) != len(expected) {
+		t.Fatalf("expected no more statements, got %v", stmts)
+	}
+}
+
+func TestMigratorLocked(t *testing.T) {
+	c := newFakeCluster(t)
+	c.owner = "other"
+
+	migrations := []Migration{{Version: 1, Name: "create_users", Statements: []string{`CREATE TABLE users (id int PRIMARY KEY)`}}}
+
+	m := &Migrator{Session: c.session(t)}
+	if err := m.Up(context.Background(), migrations); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected %v, got %v", ErrLocked, err)
+	}
+	if stmts := c.executed(migrations); len(stmts) != 0 {
+		t.Fatalf("expected no statements, got %v", stmts)
+	}
+	if c.owner != "other" {
+		t.Fatalf("expected lock to be held by other, got %q", c.owner)
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	c := newFakeCluster(t)
+	c.srv.On(`ALTER TABLE users ADD email text`).Error(gocql.ErrCodeInvalid, "unconfigured table users")
+
+	migrations := []Migration{
+		{Version: 1, Name: "add_email", Statements: []string{`ALTER TABLE users ADD email text`}},
+		{Version: 2, Name: "create_posts", Statements: []string{`CREATE TABLE posts (id int PRIMARY KEY)`}},
+	}
+
+	m := &Migrator{Session: c.session(t)}
+	err := m.Up(context.Background(), migrations)
+	var reqErr gocql.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code() != gocql.ErrCodeInvalid {
+		t.Fatalf("expected invalid request error, got %v", err)
+	}
+	if len(c.applied) != 0 {
+		t.Fatalf("expected no applied versions, got %v", c.applied)
+	}
+	if c.owner != "" {
+		t.Fatalf("expected lock to be released, held by %q", c.owner)
+	}
+}
+
+func TestMigratorDuplicateVersion(t *testing.T) {
+	m := &Migrator{}
+	err := m.Up(context.Background(), []Migration{{Version: 1}, {Version: 1}})
+	if err == nil {
+		t.Fatal("expected error for duplicate versions")
+	}
+}
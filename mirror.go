@@ -0,0 +1,295 @@
+package gocql
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrMirrorClosed is returned by a MirroredSession after it was closed.
+var ErrMirrorClosed = errors.New("gocql: mirrored session closed")
+
+// MirrorConfig configures a MirroredSession.
+type MirrorConfig struct {
+	// Session is the session to the cluster or keyspace writes are mirrored
+	// to. Unqualified statements use its keyspace.
+	Session *Session
+
+	// Rewrite, if set, is applied to every statement before it is sent to the
+	// mirror, for example to replace the keyspace of qualified table names.
+	Rewrite func(stmt string) string
+
+	// CompareReads also executes reads made with MirroredSession.Select
+	// against the mirror and compares the results with those of the primary.
+	// Default: false
+	CompareReads bool
+
+	// QueueSize is the number of mirrored operations that can be waiting to
+	// be executed. Operations are dropped when the queue is full so that the
+	// mirror never slows down the primary.
+	// Default: 1024
+	QueueSize int
+
+	// Workers is the number of goroutines executing mirrored operations.
+	// Default: 1
+	Workers int
+
+	// OnError, if set, is called when a mirrored operation fails.
+	OnError func(stmt string, err error)
+
+	// OnDivergence, if set, is called when a compared read returned different
+	// rows from the primary and the mirror.
+	OnDivergence func(stmt string, primary, mirror []map[string]interface{})
+}
+
+// MirrorStats are counters of the operations of a MirroredSession.
+type MirrorStats struct {
+	// Writes is the number of writes executed against the mirror.
+	Writes uint64
+	// WriteErrors is the number of writes that failed on the mirror.
+	WriteErrors uint64
+	// Dropped is the number of operations dropped because the queue was full.
+	Dropped uint64
+	// Reads is the number of reads compared against the mirror.
+	Reads uint64
+	// ReadErrors is the number of compared reads that failed on the mirror.
+	ReadErrors uint64
+	// Divergences is the number of compared reads with different results.
+	Divergences uint64
+}
+
+// MirroredSession executes queries against a primary session and duplicates
+// successful writes to a mirror asynchronously, for migrating data to another
+// cluster or keyspace without downtime. Results and errors returned to the
+// caller are always those of the primary.
+type MirroredSession struct {
+	primary *Session
+	cfg     MirrorConfig
+	queue   chan mirrorOp
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	stats MirrorStats
+}
+
+type mirrorOp struct {
+	query *Query
+	batch *Batch
+	// compare is set for reads, rows are the results of the primary.
+	compare bool
+	rows    []map[string]interface{}
+}
+
+// NewMirroredSession mirrors writes made through the returned session from
+// primary to cfg.Session.
+func NewMirroredSession(primary *Session, cfg MirrorConfig) (*MirroredSession, error) {
+	if primary == nil || cfg.Session == nil {
+		return nil, errors.New("gocql: mirrored session requires a primary and a mirror session")
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+
+	m := &MirroredSession{
+		primary: primary,
+		cfg:     cfg,
+		queue:   make(chan mirrorOp, cfg.QueueSize),
+	}
+	m.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go m.worker()
+	}
+	return m, nil
+}
+
+// Query generates a new query object on the primary session, to be passed to
+// Exec or Select.
+func (m *MirroredSession) Query(stmt string, values ...interface{}) *Query {
+	return m.primary.Query(stmt, values...)
+}
+
+// NewBatch creates a new batch on the primary session, to be passed to
+// ExecuteBatch.
+func (m *MirroredSession) NewBatch(typ BatchType) *Batch {
+	return m.primary.NewBatch(typ)
+}
+
+// Exec executes a write on the primary and, if it succeeded, queues it for
+// the mirror. Unless the query has a timestamp one is assigned so that both
+// clusters store the write with the same timestamp.
+func (m *MirroredSession) Exec(q *Query) error {
+	if !q.defaultTimestamp || q.defaultTimestampValue == 0 {
+		q.WithTimestamp(m.primary.cfg.clock().Now().UnixNano() / 1000)
+	}
+	if err := q.Exec(); err != nil {
+		return err
+	}
+
+	mq := m.cfg.Session.Query(m.rewrite(q.stmt), q.values...)
+	mq.binding = q.binding
+	mq.namedValues = q.namedValues
+	mq.cons = q.cons
+	mq.serialCons = q.serialCons
+	mq.idempotent = q.idempotent
+	mq.WithTimestamp(q.defaultTimestampValue)
+	m.enqueue(mirrorOp{query: mq})
+	return nil
+}
+
+// ExecuteBatch executes a batch on the primary and, if it succeeded, queues
+// it for the mirror. Timestamps are assigned as in Exec.
+func (m *MirroredSession) ExecuteBatch(b *Batch) error {
+	if !b.defaultTimestamp || b.defaultTimestampValue == 0 {
+		b.WithTimestamp(m.primary.cfg.clock().Now().UnixNano() / 1000)
+	}
+	if err := m.primary.ExecuteBatch(b); err != nil {
+		return err
+	}
+
+	mb := m.cfg.Session.NewBatch(b.Type)
+	mb.Cons = b.Cons
+	mb.serialCons = b.serialCons
+	for _, entry := range b.Entries {
+		entry.Stmt = m.rewrite(entry.Stmt)
+		mb.Entries = append(mb.Entries, entry)
+	}
+	mb.WithTimestamp(b.defaultTimestampValue)
+	m.enqueue(mirrorOp{batch: mb})
+	return nil
+}
+
+// Select executes a read on the primary and returns all of its rows. If
+// CompareReads is enabled the same read is executed against the mirror in the
+// background and divergent results are counted in the stats.
+func (m *MirroredSession) Select(q *Query) ([]map[string]interface{}, error) {
+	rows, err := q.Iter().SliceMap()
+	if err != nil {
+		return nil, err
+	}
+
+	if m.cfg.CompareReads {
+		mq := m.cfg.Session.Query(m.rewrite(q.stmt), q.values...)
+		mq.binding = q.binding
+		mq.namedValues = q.namedValues
+		mq.cons = q.cons
+		m.enqueue(mirrorOp{query: mq, compare: true, rows: rows})
+	}
+	return rows, nil
+}
+
+// Stats returns a snapshot of the counters of the session.
+func (m *MirroredSession) Stats() MirrorStats {
+	return MirrorStats{
+		Writes:      atomic.LoadUint64(&m.stats.Writes),
+		WriteErrors: atomic.LoadUint64(&m.stats.WriteErrors),
+		Dropped:     atomic.LoadUint64(&m.stats.Dropped),
+		Reads:       atomic.LoadUint64(&m.stats.Reads),
+		ReadErrors:  atomic.LoadUint64(&m.stats.ReadErrors),
+		Divergences: atomic.LoadUint64(&m.stats.Divergences),
+	}
+}
+
+// Close waits for the queued operations to be executed against the mirror.
+// It does not close the primary or the mirror sessions.
+func (m *MirroredSession) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.queue)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+func (m *MirroredSession) rewrite(stmt string) string {
+	if m.cfg.Rewrite == nil {
+		return stmt
+	}
+	return m.cfg.Rewrite(stmt)
+}
+
+func (m *MirroredSession) enqueue(op mirrorOp) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		m.fail(op, ErrMirrorClosed)
+		return
+	}
+
+	select {
+	case m.queue <- op:
+	default:
+		atomic.AddUint64(&m.stats.Dropped, 1)
+	}
+}
+
+func (m *MirroredSession) worker() {
+	defer m.wg.Done()
+	for op := range m.queue {
+		m.execute(op)
+	}
+}
+
+func (m *MirroredSession) execute(op mirrorOp) {
+	switch {
+	case op.batch != nil:
+		atomic.AddUint64(&m.stats.Writes, 1)
+		if err := m.cfg.Session.ExecuteBatch(op.batch); err != nil {
+			m.fail(op, err)
+		}
+	case op.compare:
+		atomic.AddUint64(&m.stats.Reads, 1)
+		rows, err := op.query.Iter().SliceMap()
+		if err != nil {
+			m.fail(op, err)
+			return
+		}
+		if !rowsEqual(op.rows, rows) {
+			atomic.AddUint64(&m.stats.Divergences, 1)
+			if m.cfg.OnDivergence != nil {
+				m.cfg.OnDivergence(op.query.stmt, op.rows, rows)
+			}
+		}
+	default:
+		atomic.AddUint64(&m.stats.Writes, 1)
+		if err := op.query.Exec(); err != nil {
+			m.fail(op, err)
+		}
+		op.query.Release()
+	}
+}
+
+func (m *MirroredSession) fail(op mirrorOp, err error) {
+	var stmt string
+	if op.compare {
+		atomic.AddUint64(&m.stats.ReadErrors, 1)
+		stmt = op.query.stmt
+	} else {
+		atomic.AddUint64(&m.stats.WriteErrors, 1)
+		if op.batch != nil && len(op.batch.Entries) > 0 {
+			stmt = op.batch.Entries[0].Stmt
+		} else if op.query != nil {
+			stmt = op.query.stmt
+		}
+	}
+
+	if m.cfg.OnError != nil {
+		m.cfg.OnError(stmt, err)
+	}
+}
+
+func rowsEqual(a, b []map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,161 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func newMirroredSession(t *testing.T, primary, mirror *gocqltest.Server, cfg gocql.MirrorConfig) *gocql.MirroredSession {
+	t.Helper()
+
+	p, err := primary.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+
+	s, err := mirror.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	cfg.Session = s
+	m, err := gocql.NewMirroredSession(p, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMirroredSessionWrites(t *testing.T) {
+	primary := gocqltest.NewServer()
+	defer primary.Close()
+	mirror := gocqltest.NewServer()
+	defer mirror.Close()
+
+	params := []gocqltest.Column{{Name: "id", Type: gocqltest.Int}}
+	primary.On(`INSERT INTO ks1.users (id) VALUES (?)`).Params(params...)
+	mirror.On(`INSERT INTO ks2.users (id) VALUES (?)`).Params(params...)
+
+	m := newMirroredSession(t, primary, mirror, gocql.MirrorConfig{
+		Rewrite: func(stmt string) string {
+			if stmt == `INSERT INTO ks1.users (id) VALUES (?)` {
+				return `INSERT INTO ks2.users (id) VALUES (?)`
+			}
+			return stmt
+		},
+	})
+
+	if err := m.Exec(m.Query(`INSERT INTO ks1.users (id) VALUES (?)`, 1)); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	if stats := m.Stats(); stats.Writes != 1 || stats.WriteErrors != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	var primaryReq, mirrorReq *gocqltest.Request
+	for _, req := range primary.Requests() {
+		if req.Statement == `INSERT INTO ks1.users (id) VALUES (?)` {
+			req := req
+			primaryReq = &req
+		}
+	}
+	for _, req := range mirror.Requests() {
+		if req.Statement == `INSERT INTO ks2.users (id) VALUES (?)` {
+			req := req
+			mirrorReq = &req
+		}
+	}
+	if primaryReq == nil || mirrorReq == nil {
+		t.Fatalf("expected write on both clusters, primary=%v mirror=%v", primaryReq, mirrorReq)
+	}
+	if primaryReq.Timestamp == 0 || primaryReq.Timestamp != mirrorReq.Timestamp {
+		t.Fatalf("expected equal timestamps, got %d and %d", primaryReq.Timestamp, mirrorReq.Timestamp)
+	}
+
+	var id int
+	if err := mirrorReq.Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Fatalf("expected id 1 on mirror, got %d", id)
+	}
+}
+
+func TestMirroredSessionWriteError(t *testing.T) {
+	primary := gocqltest.NewServer()
+	defer primary.Close()
+	mirror := gocqltest.NewServer()
+	defer mirror.Close()
+
+	mirror.On(`INSERT INTO users (id) VALUES (1)`).Error(gocql.ErrCodeInvalid, "unconfigured table users")
+
+	var failed []string
+	m := newMirroredSession(t, primary, mirror, gocql.MirrorConfig{
+		OnError: func(stmt string, err error) { failed = append(failed, stmt) },
+	})
+
+	if err := m.Exec(m.Query(`INSERT INTO users (id) VALUES (1)`)); err != nil {
+		t.Fatalf("mirror errors must not be returned, got %v", err)
+	}
+	m.Close()
+
+	if stats := m.Stats(); stats.Writes != 1 || stats.WriteErrors != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if len(failed) != 1 || failed[0] != `INSERT INTO users (id) VALUES (1)` {
+		t.Fatalf("unexpected failed statements %v", failed)
+	}
+
+	if err := m.Exec(m.Query(`INSERT INTO users (id) VALUES (1)`)); err != nil {
+		t.Fatal(err)
+	}
+	if stats := m.Stats(); stats.WriteErrors != 2 {
+		t.Fatalf("expected writes after close to fail, got %+v", stats)
+	}
+}
+
+func TestMirroredSessionCompareReads(t *testing.T) {
+	primary := gocqltest.NewServer()
+	defer primary.Close()
+	mirror := gocqltest.NewServer()
+	defer mirror.Close()
+
+	columns := []gocqltest.Column{{Name: "name", Type: gocqltest.Text}}
+	primary.On(`SELECT name FROM users`).Rows(columns, []interface{}{"alice"}, []interface{}{"bob"})
+	mirror.On(`SELECT name FROM users`).Rows(columns, []interface{}{"alice"})
+	primary.On(`SELECT name FROM users LIMIT 1`).Rows(columns, []interface{}{"alice"})
+	mirror.On(`SELECT name FROM users LIMIT 1`).Rows(columns, []interface{}{"alice"})
+
+	var diverged []string
+	m := newMirroredSession(t, primary, mirror, gocql.MirrorConfig{
+		CompareReads: true,
+		OnDivergence: func(stmt string, primary, mirror []map[string]interface{}) {
+			diverged = append(diverged, stmt)
+		},
+	})
+
+	rows, err := m.Select(m.Query(`SELECT name FROM users`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected rows of the primary, got %v", rows)
+	}
+	if _, err := m.Select(m.Query(`SELECT name FROM users LIMIT 1`)); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	if stats := m.Stats(); stats.Reads != 2 || stats.Divergences != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if len(diverged) != 1 || diverged[0] != `SELECT name FROM users` {
+		t.Fatalf("unexpected divergent statements %v", diverged)
+	}
+}
@@ -0,0 +1,260 @@
+package gocql
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ClusterRole identifies one of the clusters of a MultiClusterSession.
+type ClusterRole int
+
+const (
+	PrimaryCluster ClusterRole = iota
+	StandbyCluster
+)
+
+func (r ClusterRole) String() string {
+	switch r {
+	case PrimaryCluster:
+		return "primary"
+	case StandbyCluster:
+		return "standby"
+	default:
+		return "unknown"
+	}
+}
+
+// MultiClusterConfig configures a MultiClusterSession for an active/passive
+// disaster recovery topology.
+type MultiClusterConfig struct {
+	// Primary is the cluster queries are sent to while it is available.
+	Primary *ClusterConfig
+
+	// Standby is the cluster queries are sent to after a failover.
+	Standby *ClusterConfig
+
+	// ShouldFailover is called once the primary cluster has been entirely
+	// unavailable, that is no connection to any of its hosts is open, for
+	// FailoverDelay. Returning false keeps the primary active and the
+	// decision is asked for again on the next check. If nil the session
+	// fails over automatically.
+	ShouldFailover func() bool
+
+	// OnSwitch, if set, is called after the active cluster changed.
+	OnSwitch func(active ClusterRole)
+
+	// AutoFailback switches back to the primary cluster as soon as it is
+	// available again. When false failing back requires a call to Failback.
+	// Default: false
+	AutoFailback bool
+
+	// FailoverDelay is how long the primary cluster has to be unavailable
+	// before failing over.
+	// Default: 5s
+	FailoverDelay time.Duration
+
+	// CheckInterval is how often the availability of the primary cluster is
+	// checked.
+	// Default: 1s
+	CheckInterval time.Duration
+}
+
+// ErrPrimaryUnavailable is returned by MultiClusterSession.Failback when the
+// primary cluster has no open connections.
+var ErrPrimaryUnavailable = errors.New("gocql: primary cluster is unavailable")
+
+// MultiClusterSession wraps sessions to a primary and a standby cluster and
+// routes queries to whichever one is active. It fails over to the standby
+// when the primary becomes entirely unavailable. It is safe for concurrent
+// use by multiple goroutines.
+type MultiClusterSession struct {
+	cfg     MultiClusterConfig
+	standby *Session
+
+	mu               sync.RWMutex
+	primary          *Session
+	active           ClusterRole
+	unavailableSince time.Time
+
+	quit      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewMultiClusterSession connects to both clusters of cfg. If the primary
+// cluster cannot be reached the session starts out on the standby and keeps
+// trying to connect to the primary in the background.
+func NewMultiClusterSession(cfg MultiClusterConfig) (*MultiClusterSession, error) {
+	if cfg.Primary == nil || cfg.Standby == nil {
+		return nil, errors.New("gocql: multi cluster session requires a primary and a standby cluster")
+	}
+	if cfg.FailoverDelay <= 0 {
+		cfg.FailoverDelay = 5 * time.Second
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = time.Second
+	}
+
+	standby, err := cfg.Standby.CreateSession()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MultiClusterSession{
+		cfg:     cfg,
+		standby: standby,
+		quit:    make(chan struct{}),
+	}
+
+	if primary, err := cfg.Primary.CreateSession(); err != nil {
+		logWarn(cfg.Primary.logger(), "unable to connect to primary cluster, starting on standby", LogField{"err", err})
+		m.active = StandbyCluster
+	} else {
+		m.primary = primary
+	}
+
+	m.wg.Add(1)
+	go m.monitor()
+
+	return m, nil
+}
+
+// Session returns the session of the active cluster.
+func (m *MultiClusterSession) Session() *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.active == PrimaryCluster {
+		return m.primary
+	}
+	return m.standby
+}
+
+// Active returns which cluster queries are currently sent to.
+func (m *MultiClusterSession) Active() ClusterRole {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// Query generates a new query object on the active cluster's session.
+func (m *MultiClusterSession) Query(stmt string, values ...interface{}) *Query {
+	return m.Session().Query(stmt, values...)
+}
+
+// Failover switches to the standby cluster regardless of the state of the
+// primary.
+func (m *MultiClusterSession) Failover() {
+	m.switchTo(StandbyCluster)
+}
+
+// Failback switches to the primary cluster. It returns ErrPrimaryUnavailable
+// if the primary has no open connections.
+func (m *MultiClusterSession) Failback() error {
+	m.mu.RLock()
+	available := sessionAvailable(m.primary)
+	m.mu.RUnlock()
+	if !available {
+		return ErrPrimaryUnavailable
+	}
+	m.switchTo(PrimaryCluster)
+	return nil
+}
+
+// Close closes the sessions to both clusters.
+func (m *MultiClusterSession) Close() {
+	m.closeOnce.Do(func() {
+		close(m.quit)
+		m.wg.Wait()
+
+		m.mu.Lock()
+		primary := m.primary
+		m.mu.Unlock()
+
+		if primary != nil {
+			primary.Close()
+		}
+		m.standby.Close()
+	})
+}
+
+func (m *MultiClusterSession) monitor() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.quit:
+			return
+		case now := <-ticker.C:
+			m.check(now)
+		}
+	}
+}
+
+func (m *MultiClusterSession) check(now time.Time) {
+	m.mu.RLock()
+	primary := m.primary
+	m.mu.RUnlock()
+
+	if primary == nil {
+		s, err := m.cfg.Primary.CreateSession()
+		if err != nil {
+			return
+		}
+		m.mu.Lock()
+		m.primary = s
+		m.unavailableSince = time.Time{}
+		m.mu.Unlock()
+		primary = s
+	}
+
+	available := sessionAvailable(primary)
+
+	m.mu.Lock()
+	active := m.active
+	switch {
+	case available:
+		m.unavailableSince = time.Time{}
+	case m.unavailableSince.IsZero():
+		m.unavailableSince = now
+	}
+	unavailableFor := now.Sub(m.unavailableSince)
+	m.mu.Unlock()
+
+	switch active {
+	case PrimaryCluster:
+		if available || unavailableFor < m.cfg.FailoverDelay {
+			return
+		}
+		if m.cfg.ShouldFailover != nil && !m.cfg.ShouldFailover() {
+			return
+		}
+		m.switchTo(StandbyCluster)
+	case StandbyCluster:
+		if available && m.cfg.AutoFailback {
+			m.switchTo(PrimaryCluster)
+		}
+	}
+}
+
+func (m *MultiClusterSession) switchTo(role ClusterRole) {
+	m.mu.Lock()
+	if m.active == role {
+		m.mu.Unlock()
+		return
+	}
+	m.active = role
+	m.mu.Unlock()
+
+	if m.cfg.OnSwitch != nil {
+		m.cfg.OnSwitch(role)
+	}
+}
+
+// sessionAvailable reports whether s has at least one open connection.
+func sessionAvailable(s *Session) bool {
+	return s != nil && !s.Closed() && s.pool != nil && s.pool.Size() > 0
+}
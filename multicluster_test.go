@@ -0,0 +1,149 @@
+package gocql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func newMultiClusterConfig(primary, standby *gocqltest.Server) gocql.MultiClusterConfig {
+	return gocql.MultiClusterConfig{
+		Primary:       primary.ClusterConfig(),
+		Standby:       standby.ClusterConfig(),
+		FailoverDelay: 50 * time.Millisecond,
+		CheckInterval: 10 * time.Millisecond,
+	}
+}
+
+func waitForRole(t *testing.T, m *gocql.MultiClusterSession, role gocql.ClusterRole) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Active() != role {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected active cluster to be %v, got %v", role, m.Active())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func queryCount(srv *gocqltest.Server, stmt string) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Statement == stmt {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMultiClusterSessionFailover(t *testing.T) {
+	primary := gocqltest.NewServer()
+	defer primary.Close()
+	standby := gocqltest.NewServer()
+	defer standby.Close()
+
+	const stmt = "INSERT INTO example.tweet (id) VALUES (1)"
+
+	switched := make(chan gocql.ClusterRole, 1)
+	cfg := newMultiClusterConfig(primary, standby)
+	cfg.OnSwitch = func(role gocql.ClusterRole) { switched <- role }
+
+	m, err := gocql.NewMultiClusterSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Active() != gocql.PrimaryCluster {
+		t.Fatalf("expected primary to be active, got %v", m.Active())
+	}
+	if err := m.Query(stmt).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if n := queryCount(primary, stmt); n != 1 {
+		t.Fatalf("expected 1 query on primary, got %d", n)
+	}
+
+	primary.Close()
+	waitForRole(t, m, gocql.StandbyCluster)
+	if role := <-switched; role != gocql.StandbyCluster {
+		t.Fatalf("expected switch to standby, got %v", role)
+	}
+
+	if err := m.Query(stmt).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if n := queryCount(standby, stmt); n != 1 {
+		t.Fatalf("expected 1 query on standby, got %d", n)
+	}
+
+	if err := m.Failback(); err != gocql.ErrPrimaryUnavailable {
+		t.Fatalf("expected %v, got %v", gocql.ErrPrimaryUnavailable, err)
+	}
+}
+
+func TestMultiClusterSessionShouldFailover(t *testing.T) {
+	primary := gocqltest.NewServer()
+	standby := gocqltest.NewServer()
+	defer standby.Close()
+
+	asked := make(chan struct{}, 1)
+	cfg := newMultiClusterConfig(primary, standby)
+	cfg.ShouldFailover = func() bool {
+		select {
+		case asked <- struct{}{}:
+		default:
+		}
+		return false
+	}
+
+	m, err := gocql.NewMultiClusterSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	primary.Close()
+	select {
+	case <-asked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ShouldFailover was not called")
+	}
+	if m.Active() != gocql.PrimaryCluster {
+		t.Fatalf("expected primary to stay active, got %v", m.Active())
+	}
+
+	m.Failover()
+	if m.Active() != gocql.StandbyCluster {
+		t.Fatalf("expected standby to be active, got %v", m.Active())
+	}
+}
+
+func TestMultiClusterSessionPrimaryDownAtStartup(t *testing.T) {
+	standby := gocqltest.NewServer()
+	defer standby.Close()
+
+	cfg := newMultiClusterConfig(standby, standby)
+	cfg.Primary = gocql.NewCluster("127.0.0.1:1")
+	cfg.Primary.ConnectTimeout = 50 * time.Millisecond
+	cfg.Primary.DisableInitialHostLookup = true
+	cfg.Primary.Logger = nopLogger{}
+
+	m, err := gocql.NewMultiClusterSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Active() != gocql.StandbyCluster {
+		t.Fatalf("expected standby to be active, got %v", m.Active())
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Print(v ...interface{})                 {}
+func (nopLogger) Printf(format string, v ...interface{}) {}
+func (nopLogger) Println(v ...interface{})               {}
@@ -0,0 +1,55 @@
+package gocql
+
+// Null is a value of type T which may be null, distinguishing null from the
+// zero value of T in both directions: a Null which isn't Valid is marshalled
+// as null, and only a null value unmarshals into a Null which isn't Valid.
+//
+//	var discount gocql.Null[int64]
+//	if err := session.Query(`SELECT discount FROM orders WHERE id = ?`, id).Scan(&discount); err != nil {
+//		return err
+//	}
+//	if discount.Valid {
+//		total -= discount.Value
+//	}
+//
+// Null complements pointers, which unmarshal null into a nil pointer, for
+// values which are kept in structs or slices without being allocated.
+type Null[T any] struct {
+	Value T
+	// Valid is false if the value is null.
+	Valid bool
+}
+
+// NotNull returns a valid Null holding v.
+func NotNull[T any](v T) Null[T] {
+	return Null[T]{Value: v, Valid: true}
+}
+
+// Ptr returns a pointer to a copy of the value, or nil if it is null.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Value
+	return &v
+}
+
+func (n Null[T]) MarshalCQL(info TypeInfo) ([]byte, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return Marshal(info, n.Value)
+}
+
+func (n *Null[T]) UnmarshalCQL(info TypeInfo, data []byte) error {
+	if data == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	var v T
+	if err := Unmarshal(info, data, &v); err != nil {
+		return err
+	}
+	*n = Null[T]{Value: v, Valid: true}
+	return nil
+}
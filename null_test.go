@@ -0,0 +1,66 @@
+package gocql
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNullMarshal(t *testing.T) {
+	bigint := NativeType{proto: 4, typ: TypeBigInt}
+	text := NativeType{proto: 4, typ: TypeVarchar}
+
+	data, err := Marshal(bigint, Null[int64]{})
+	if err != nil || data != nil {
+		t.Fatalf("expected a null value, got %v %v", data, err)
+	}
+	data, err = Marshal(bigint, NotNull(int64(0)))
+	if err != nil || !bytes.Equal(data, make([]byte, 8)) {
+		t.Fatalf("expected the zero value, got %v %v", data, err)
+	}
+	data, err = Marshal(text, NotNull(""))
+	if err != nil || data == nil || len(data) != 0 {
+		t.Fatalf("expected an empty value, got %v %v", data, err)
+	}
+	if _, err := Marshal(bigint, NotNull("a")); err == nil {
+		t.Fatal("expected an error marshalling a string into a bigint")
+	}
+}
+
+func TestNullUnmarshal(t *testing.T) {
+	bigint := NativeType{proto: 4, typ: TypeBigInt}
+	timestamp := NativeType{proto: 4, typ: TypeTimestamp}
+
+	n := NotNull(int64(42))
+	if err := Unmarshal(bigint, nil, &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Valid || n.Value != 0 || n.Ptr() != nil {
+		t.Fatalf("expected null, got %+v", n)
+	}
+
+	if err := Unmarshal(bigint, make([]byte, 8), &n); err != nil {
+		t.Fatal(err)
+	}
+	if !n.Valid || n.Value != 0 || n.Ptr() == nil || *n.Ptr() != 0 {
+		t.Fatalf("expected a valid zero value, got %+v", n)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	data, err := Marshal(timestamp, NotNull(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ts Null[time.Time]
+	if err := Unmarshal(timestamp, data, &ts); err != nil {
+		t.Fatal(err)
+	}
+	if !ts.Valid || !ts.Value.Equal(now) {
+		t.Fatalf("expected %v, got %+v", now, ts)
+	}
+
+	var b Null[bool]
+	if err := Unmarshal(bigint, make([]byte, 8), &b); err == nil || b.Valid {
+		t.Fatalf("expected an error unmarshalling a bigint into a bool, got %v %+v", err, b)
+	}
+}
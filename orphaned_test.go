@@ -0,0 +1,68 @@
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type countingConnectObserver struct {
+	connects int64
+}
+
+func (o *countingConnectObserver) ObserveConnect(gocql.ObservedConnect) {
+	atomic.AddInt64(&o.connects, 1)
+}
+
+func TestMaxOrphanedStreams(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	release := make(chan struct{})
+	defer close(release)
+	srv.On(`SELECT * FROM slow`).Handle(nil, func(*gocqltest.Request) gocqltest.Response {
+		<-release
+		return gocqltest.Response{}
+	})
+	srv.On(`SELECT * FROM fast`).Rows(nil)
+
+	observer := &countingConnectObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.NumConns = 1
+	cluster.MaxOrphanedStreams = 1
+	cluster.ConnectObserver = observer
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	connects := atomic.LoadInt64(&observer.connects)
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := session.Query(`SELECT * FROM slow`).WithContext(ctx).Exec()
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	}
+	if orphaned := session.Stats().OrphanedStreams; orphaned != 2 {
+		t.Fatalf("expected 2 orphaned streams, got %d", orphaned)
+	}
+
+	// the connection with too many orphaned streams is replaced
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(&observer.connects) == connects {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the connection to be reopened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := session.Query(`SELECT * FROM fast`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
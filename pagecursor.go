@@ -0,0 +1,111 @@
+package gocql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidCursor is returned by CursorSigner.Unwrap for cursors which
+	// weren't wrapped with its key and scope, or were modified.
+	ErrInvalidCursor = errors.New("gocql: invalid page cursor")
+	// ErrCursorExpired is returned by CursorSigner.Unwrap for cursors older
+	// than its TTL.
+	ErrCursorExpired = errors.New("gocql: page cursor expired")
+)
+
+// cursorVersion is the first byte of the cursors of CursorSigner.
+const cursorVersion = 1
+
+// CursorSigner wraps page states in cursors which can be handed to external
+// clients, such as the next page token of an API, and unwraps them back for
+// Query.PageState. Cursors are signed with HMAC-SHA256 so that modified or
+// forged page states are rejected before they reach Cassandra, and expire
+// after TTL:
+//
+//	signer := &gocql.CursorSigner{Key: key}
+//	pageState, err := signer.Unwrap(req.PageToken, userID)
+//	if err != nil {
+//		return err
+//	}
+//	iter := session.Query(stmt, userID).PageState(pageState).PageSize(50).Iter()
+//	...
+//	resp.NextPageToken = signer.Wrap(iter.PageState(), userID)
+//
+// The page state is signed, not encrypted, clients can read it.
+type CursorSigner struct {
+	// Key is the HMAC key, it should be at least 32 random bytes.
+	Key []byte
+
+	// TTL is how long cursors can be unwrapped after they were wrapped.
+	// Default: 1h
+	TTL time.Duration
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+func (s *CursorSigner) time() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *CursorSigner) mac(data []byte, scope string) []byte {
+	h := hmac.New(sha256.New, s.Key)
+	// the length of the scope first, so that the bytes of the page state can't
+	// be moved to the scope
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(scope)))
+	h.Write(n[:])
+	h.Write([]byte(scope))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Wrap returns a cursor of pageState, valid for the same scope only. The scope
+// binds the cursor to a query or a user, such as the statement and its values,
+// so that a cursor of another query can't be replayed. Wrap returns an empty
+// string for an empty page state, which ends the paging.
+func (s *CursorSigner) Wrap(pageState []byte, scope string) string {
+	if len(pageState) == 0 {
+		return ""
+	}
+	data := make([]byte, 9, 9+len(pageState)+sha256.Size)
+	data[0] = cursorVersion
+	binary.BigEndian.PutUint64(data[1:], uint64(s.time().Unix()))
+	data = append(data, pageState...)
+	data = append(data, s.mac(data, scope)...)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Unwrap returns the page state of a cursor wrapped with the same scope. It
+// returns nil for an empty cursor, which starts the paging.
+func (s *CursorSigner) Unwrap(cursor, scope string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) <= 9+sha256.Size || data[0] != cursorVersion {
+		return nil, ErrInvalidCursor
+	}
+	signed, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(mac, s.mac(signed, scope)) {
+		return nil, ErrInvalidCursor
+	}
+
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	created := time.Unix(int64(binary.BigEndian.Uint64(signed[1:9])), 0)
+	if s.time().Sub(created) > ttl {
+		return nil, ErrCursorExpired
+	}
+	return signed[9:], nil
+}
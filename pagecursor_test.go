@@ -0,0 +1,48 @@
+package gocql
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCursorSigner(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := &CursorSigner{Key: []byte("0123456789abcdef0123456789abcdef"), now: func() time.Time { return now }}
+	pageState := []byte{0x00, 0x10, 0xff, 0x42}
+
+	cursor := signer.Wrap(pageState, "user-1")
+	got, err := signer.Unwrap(cursor, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pageState) {
+		t.Fatalf("expected %x, got %x", pageState, got)
+	}
+
+	if _, err := signer.Unwrap(cursor, "user-2"); err != ErrInvalidCursor {
+		t.Fatalf("expected the cursor of another scope to be invalid, got %v", err)
+	}
+	other := &CursorSigner{Key: []byte("another key of at least 32 bytes")}
+	if _, err := other.Unwrap(cursor, "user-1"); err != ErrInvalidCursor {
+		t.Fatalf("expected the cursor of another key to be invalid, got %v", err)
+	}
+	for _, c := range []string{cursor[:len(cursor)-1], "x" + cursor[1:], "not base64!"} {
+		if _, err := signer.Unwrap(c, "user-1"); err != ErrInvalidCursor {
+			t.Fatalf("expected %q to be invalid, got %v", c, err)
+		}
+	}
+
+	// the first and last pages have no page state
+	if c := signer.Wrap(nil, "user-1"); c != "" {
+		t.Fatalf("expected an empty cursor, got %q", c)
+	}
+	if p, err := signer.Unwrap("", "user-1"); p != nil || err != nil {
+		t.Fatalf("expected no page state, got %x, %v", p, err)
+	}
+
+	now = now.Add(time.Hour + time.Second)
+	if _, err := signer.Unwrap(cursor, "user-1"); err != ErrCursorExpired {
+		t.Fatalf("expected the cursor to expire, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+//go:build all || unit
+// +build all unit
+
+package gocql
+
+import (
+	"context"
+	"time"
+)
+
+// execPipelined writes reqs on the connection from the calling goroutine,
+// keeping up to depth requests in flight, and returns the response framers in
+// the order of reqs.
+//
+// Responses of all requests are delivered on one channel so that they can be
+// handled in the order they arrive, without a goroutine per request. When no
+// stream is available the next request is written once a response released
+// one. The frames of all requests that fit are written at once through the
+// writer of the connection, so that they are serialized with its other
+// writes. The connection timeout applies to the time without any response.
+//
+// If a request can't be written or a response fails, the requests still in
+// flight are abandoned and the error is returned.
+func (c *Conn) execPipelined(ctx context.Context, reqs []frameBuilder, depth int) ([]*framer, error) {
+	if depth < 1 {
+		depth = 1
+	}
+
+	type pipelinedCall struct {
+		call  *callReq
+		index int
+	}
+
+	resp := make(chan callResp)
+	inflight := make(map[int]pipelinedCall, depth)
+	framers := make([]*framer, len(reqs))
+
+	// abandon stops waiting for the written requests
+	abandon := func() {
+		for _, p := range inflight {
+			close(p.call.timeout)
+		}
+		c.orphan(len(inflight))
+	}
+	// cancel frees the requests of a burst which was not written
+	cancel := func(calls []*callReq) {
+		for _, call := range calls {
+			c.cancelCall(call)
+		}
+	}
+
+	var (
+		timer     Timer
+		timeoutCh <-chan time.Time
+	)
+	if c.timeout > 0 {
+		timer = c.clock().NewTimer(c.timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C()
+	}
+
+	var ctxDone <-chan struct{}
+	if ctx != nil {
+		ctxDone = ctx.Done()
+	}
+
+	var (
+		next  int
+		burst []*callReq
+		buf   []byte
+	)
+	for next < len(reqs) || len(inflight) > 0 {
+		burst, buf = burst[:0], buf[:0]
+		for next < len(reqs) && len(inflight)+len(burst) < depth {
+			if err := ctx.Err(); err != nil {
+				cancel(burst)
+				abandon()
+				return nil, err
+			}
+			call, framer, err := c.newCall(ctx, reqs[next], nil, resp)
+			if err == ErrNoStreams && len(inflight)+len(burst) > 0 {
+				// streams are shared with other users of the connection, wait
+				// for one of ours to be released
+				break
+			} else if err != nil {
+				cancel(burst)
+				abandon()
+				return nil, err
+			}
+			burst = append(burst, call)
+			buf = append(buf, framer.buf...)
+			next++
+		}
+		if len(burst) > 0 {
+			if err := c.writeCalls(ctx, c.w, buf, burst...); err != nil {
+				abandon()
+				return nil, err
+			}
+			for i, call := range burst {
+				inflight[call.streamID] = pipelinedCall{call: call, index: next - len(burst) + i}
+			}
+		}
+
+		select {
+		case r := <-resp:
+			p := inflight[r.streamID]
+			delete(inflight, r.streamID)
+			close(p.call.timeout)
+			if r.err != nil {
+				if !c.Closed() {
+					c.releaseStream(p.call)
+				}
+				abandon()
+				return nil, r.err
+			}
+			c.releaseStream(p.call)
+
+			if v := r.framer.header.version.version(); v != c.version {
+				abandon()
+				return nil, NewErrProtocol("unexpected protocol version in response: got %d expected %d", v, c.version)
+			}
+			framers[p.index] = r.framer
+
+			if timer != nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C():
+					default:
+					}
+				}
+				timer.Reset(c.timeout)
+			}
+		case <-timeoutCh:
+			abandon()
+			c.handleTimeout()
+			return nil, ErrTimeoutNoResponse
+		case <-ctxDone:
+			abandon()
+			return nil, ctx.Err()
+		case <-c.ctx.Done():
+			abandon()
+			return nil, ErrConnectionClosed
+		}
+	}
+
+	return framers, nil
+}
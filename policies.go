@@ -152,18 +152,38 @@ func (s *SimpleRetryPolicy) GetRetryType(err error) RetryType {
 	return RetryNextHost
 }
 
-// ExponentialBackoffRetryPolicy sleeps between attempts
+// RetryBackoff is implemented by the retry policies waiting between the
+// attempts of a query. Once Attempt allowed another attempt, the query waits
+// for the duration returned by Backoff, or until its context is done, in which
+// case its error is returned.
+type RetryBackoff interface {
+	Backoff(q RetryableQuery) time.Duration
+}
+
+// ExponentialBackoffRetryPolicy retries queries up to NumRetries times, waiting
+// between attempts for Min, doubled after every attempt up to Max:
+//
+//	cluster.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+//		NumRetries: 3, Min: 100 * time.Millisecond, Max: 2 * time.Second, Jitter: 0.2}
 type ExponentialBackoffRetryPolicy struct {
 	NumRetries int
-	Min, Max   time.Duration
+	// Min and Max bound the wait between attempts.
+	// Default: 100ms and 10s
+	Min, Max time.Duration
+	// Jitter is the fraction of the wait randomized, between 0 and 1, so
+	// that the clients retrying together spread their attempts, for example
+	// 0.2 for waits 20% shorter or longer.
+	// Default: 0, waits randomized by Min/2
+	Jitter float64
 }
 
 func (e *ExponentialBackoffRetryPolicy) Attempt(q RetryableQuery) bool {
-	if q.Attempts() > e.NumRetries {
-		return false
-	}
-	time.Sleep(e.napTime(q.Attempts()))
-	return true
+	return q.Attempts() <= e.NumRetries
+}
+
+// Backoff returns the wait before the next attempt of q.
+func (e *ExponentialBackoffRetryPolicy) Backoff(q RetryableQuery) time.Duration {
+	return e.napTime(q.Attempts())
 }
 
 // used to calculate exponentially growing time
@@ -244,7 +264,20 @@ func (d *DowngradingConsistencyRetryPolicy) GetRetryType(err error) RetryType {
 }
 
 func (e *ExponentialBackoffRetryPolicy) napTime(attempts int) time.Duration {
-	return getExponentialTime(e.Min, e.Max, attempts)
+	if e.Jitter <= 0 {
+		return getExponentialTime(e.Min, e.Max, attempts)
+	}
+	min, max := e.Min, e.Max
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	jitter := math.Min(e.Jitter, 1)
+	nap := math.Min(float64(min)*math.Pow(2, float64(attempts-1)), float64(max))
+	nap += (rand.Float64()*2 - 1) * jitter * nap
+	return time.Duration(math.Min(nap, float64(max)))
 }
 
 type HostStateNotifier interface {
@@ -410,7 +443,7 @@ func (t *tokenAwareHostPolicy) Init(s *Session) {
 		panic("sharing token aware host selection policy between sessions is not supported")
 	}
 	t.getKeyspaceMetadata = s.KeyspaceMetadata
-	t.getKeyspaceName = func() string { return s.cfg.Keyspace }
+	t.getKeyspaceName = s.Keyspace
 	t.logger = s.logger
 }
 
@@ -418,6 +451,10 @@ func (t *tokenAwareHostPolicy) IsLocal(host *HostInfo) bool {
 	return t.fallback.IsLocal(host)
 }
 
+func (t *tokenAwareHostPolicy) Distance(host *HostInfo) HostDistance {
+	return hostDistance(t.fallback, host)
+}
+
 func (t *tokenAwareHostPolicy) KeyspaceChanged(update KeyspaceUpdateEvent) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -550,7 +587,7 @@ func (m *clusterMeta) resetTokenRing(partitioner string, hosts []*HostInfo, logg
 	// create a new token ring
 	tokenRing, err := newTokenRing(partitioner, hosts)
 	if err != nil {
-		logger.Printf("Unable to update the token ring due to error: %s", err)
+		logError(logger, "unable to update the token ring", LogField{"err", err})
 		return
 	}
 
@@ -816,13 +853,62 @@ type dcAwareRR struct {
 	localHosts      cowHostList
 	remoteHosts     cowHostList
 	lastUsedHostIdx uint64
+
+	// maxRemoteHosts is the number of remote hosts returned for a query, -1
+	// if they are not limited.
+	maxRemoteHosts int
+	// remoteOnlyIfLocalDown is set if remote hosts are only returned when no
+	// local host is up.
+	remoteOnlyIfLocalDown bool
 }
 
 // DCAwareRoundRobinPolicy is a host selection policies which will prioritize and
 // return hosts which are in the local datacentre before returning hosts in all
 // other datercentres
-func DCAwareRoundRobinPolicy(localDC string) HostSelectionPolicy {
-	return &dcAwareRR{local: localDC}
+//
+// By default all the hosts of the other datacenters are returned after the
+// local ones, which may send queries across datacenters silently. Use
+// NoRemoteDCs, RemoteDCsOnlyIfLocalDown or MaxRemoteHosts to restrict them,
+// and SessionStats.RemoteAttempts to monitor them:
+//
+//	cluster.PoolConfig.HostSelectionPolicy = gocql.DCAwareRoundRobinPolicy("dc1", gocql.MaxRemoteHosts(2))
+func DCAwareRoundRobinPolicy(localDC string, opts ...DCAwareRoundRobinOption) HostSelectionPolicy {
+	p := &dcAwareRR{local: localDC, maxRemoteHosts: -1}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// DCAwareRoundRobinOption is an option of DCAwareRoundRobinPolicy.
+type DCAwareRoundRobinOption func(*dcAwareRR)
+
+// NoRemoteDCs makes DCAwareRoundRobinPolicy return only the hosts of the local
+// datacenter, queries fail when none of them is up.
+func NoRemoteDCs() DCAwareRoundRobinOption {
+	return func(d *dcAwareRR) {
+		d.maxRemoteHosts = 0
+	}
+}
+
+// RemoteDCsOnlyIfLocalDown makes DCAwareRoundRobinPolicy return the hosts of
+// the other datacenters only if none of the hosts of the local datacenter is
+// up, rather than after the local hosts failed the query.
+func RemoteDCsOnlyIfLocalDown() DCAwareRoundRobinOption {
+	return func(d *dcAwareRR) {
+		d.remoteOnlyIfLocalDown = true
+	}
+}
+
+// MaxRemoteHosts limits the number of hosts of the other datacenters which
+// DCAwareRoundRobinPolicy returns for a query to n.
+func MaxRemoteHosts(n int) DCAwareRoundRobinOption {
+	return func(d *dcAwareRR) {
+		if n < 0 {
+			n = 0
+		}
+		d.maxRemoteHosts = n
+	}
 }
 
 func (d *dcAwareRR) Init(*Session)                       {}
@@ -833,6 +919,18 @@ func (d *dcAwareRR) IsLocal(host *HostInfo) bool {
 	return host.DataCenter() == d.local
 }
 
+// Distance ignores the hosts of the other datacenters with NoRemoteDCs, as
+// they are never returned.
+func (d *dcAwareRR) Distance(host *HostInfo) HostDistance {
+	if d.IsLocal(host) {
+		return HostDistanceLocal
+	}
+	if d.maxRemoteHosts == 0 {
+		return HostDistanceIgnored
+	}
+	return HostDistanceRemote
+}
+
 func (d *dcAwareRR) AddHost(host *HostInfo) {
 	if d.IsLocal(host) {
 		d.localHosts.add(host)
@@ -896,7 +994,36 @@ func roundRobbin(shift int, hosts ...[]*HostInfo) NextHost {
 
 func (d *dcAwareRR) Pick(q ExecutableQuery) NextHost {
 	nextStartOffset := atomic.AddUint64(&d.lastUsedHostIdx, 1)
-	return roundRobbin(int(nextStartOffset), d.localHosts.get(), d.remoteHosts.get())
+	local := d.localHosts.get()
+	if d.maxRemoteHosts == 0 || (d.remoteOnlyIfLocalDown && anyHostUp(local)) {
+		return roundRobbin(int(nextStartOffset), local)
+	}
+
+	next := roundRobbin(int(nextStartOffset), local, d.remoteHosts.get())
+	if d.maxRemoteHosts < 0 {
+		return next
+	}
+	remotes := 0
+	return func() SelectedHost {
+		h := next()
+		if h == nil || d.IsLocal(h.Info()) {
+			return h
+		}
+		if remotes == d.maxRemoteHosts {
+			return nil
+		}
+		remotes++
+		return h
+	}
+}
+
+func anyHostUp(hosts []*HostInfo) bool {
+	for _, h := range hosts {
+		if h.IsUp() {
+			return true
+		}
+	}
+	return false
 }
 
 // RackAwareRoundRobinPolicy is a host selection policies which will prioritize and
@@ -943,6 +1070,15 @@ func (d *rackAwareRR) IsLocal(host *HostInfo) bool {
 	return d.HostTier(host) == 0
 }
 
+// Distance is local for the hosts of every rack of the local datacenter, the
+// other racks are returned right after the local one.
+func (d *rackAwareRR) Distance(host *HostInfo) HostDistance {
+	if d.HostTier(host) < 2 {
+		return HostDistanceLocal
+	}
+	return HostDistanceRemote
+}
+
 func (d *rackAwareRR) AddHost(host *HostInfo) {
 	dist := d.HostTier(host)
 	d.hosts[dist].add(host)
@@ -991,6 +1127,10 @@ func (s *singleHostReadyPolicy) HostUp(host *HostInfo) {
 	s.readyMux.Unlock()
 }
 
+func (s *singleHostReadyPolicy) Distance(host *HostInfo) HostDistance {
+	return hostDistance(s.HostSelectionPolicy, host)
+}
+
 func (s *singleHostReadyPolicy) Ready() bool {
 	s.readyMux.Lock()
 	ready := s.ready
@@ -313,6 +313,17 @@ func TestExponentialBackoffPolicy(t *testing.T) {
 			}
 		}
 	}
+
+	// the jitter is a fraction of the delay up to the maximum
+	sut = &ExponentialBackoffRetryPolicy{NumRetries: 2, Min: time.Second, Max: 3 * time.Second, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if d := sut.napTime(2); d < 1600*time.Millisecond || d > 2400*time.Millisecond {
+			t.Fatalf("Delay %v out of the jitter of 20%% of 2s", d)
+		}
+		if d := sut.napTime(3); d < 2400*time.Millisecond || d > 3*time.Second {
+			t.Fatalf("Delay %v out of the jitter of 20%% of the maximum of 3s", d)
+		}
+	}
 }
 
 func TestDowngradingConsistencyRetryPolicy(t *testing.T) {
@@ -466,6 +477,58 @@ func TestHostPolicy_DCAwareRR(t *testing.T) {
 
 }
 
+func TestHostPolicy_DCAwareRRRemoteDCs(t *testing.T) {
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.ParseIP("10.0.0.1"), dataCenter: "local"},
+		{hostId: "1", connectAddress: net.ParseIP("10.0.0.2"), dataCenter: "remote"},
+		{hostId: "2", connectAddress: net.ParseIP("10.0.0.3"), dataCenter: "remote"},
+		{hostId: "3", connectAddress: net.ParseIP("10.0.0.4"), dataCenter: "remote"},
+	}
+
+	pick := func(p HostSelectionPolicy) (local, remote int) {
+		it := p.Pick(nil)
+		for h := it(); h != nil; h = it() {
+			if h.Info().dataCenter == "local" {
+				local++
+			} else {
+				remote++
+			}
+		}
+		return local, remote
+	}
+
+	tests := []struct {
+		name      string
+		opts      []DCAwareRoundRobinOption
+		localDown bool
+		local     int
+		remote    int
+	}{
+		{name: "default", local: 1, remote: 3},
+		{name: "never", opts: []DCAwareRoundRobinOption{NoRemoteDCs()}, local: 1},
+		{name: "never local down", opts: []DCAwareRoundRobinOption{NoRemoteDCs()}, localDown: true},
+		{name: "local down only", opts: []DCAwareRoundRobinOption{RemoteDCsOnlyIfLocalDown()}, local: 1},
+		{name: "local down only local down", opts: []DCAwareRoundRobinOption{RemoteDCsOnlyIfLocalDown()}, localDown: true, remote: 3},
+		{name: "max remote", opts: []DCAwareRoundRobinOption{MaxRemoteHosts(2)}, local: 1, remote: 2},
+		{name: "max remote local down", opts: []DCAwareRoundRobinOption{RemoteDCsOnlyIfLocalDown(), MaxRemoteHosts(1)}, localDown: true, remote: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := DCAwareRoundRobinPolicy("local", test.opts...)
+			for _, host := range hosts {
+				host.setState(NodeUp)
+				p.AddHost(host)
+			}
+			if test.localDown {
+				hosts[0].setState(NodeDown)
+			}
+			if local, remote := pick(p); local != test.local || remote != test.remote {
+				t.Fatalf("expected %d local and %d remote hosts, got %d and %d", test.local, test.remote, local, remote)
+			}
+		})
+	}
+}
+
 // Tests of the token-aware host selection policy implementation with a
 // DC aware round-robin host selection policy fallback
 // with {"class": "NetworkTopologyStrategy", "a": 1, "b": 1, "c": 1} replication.
@@ -823,3 +886,165 @@ func TestHostPolicy_TokenAware_RackAware(t *testing.T) {
 	expectHosts(t, "non-local DC", iter, "0", "1", "4", "5", "8", "9")
 	expectNoMoreHosts(t, iter)
 }
+
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		err       error
+		permanent bool
+	}{
+		{errorFrame{code: ErrCodeSyntax}, true},
+		{errorFrame{code: ErrCodeUnauthorized}, true},
+		{errorFrame{code: ErrCodeInvalid}, true},
+		{&RequestErrAlreadyExists{errorFrame: errorFrame{code: ErrCodeAlreadyExists}}, true},
+		{&RequestErrReadTimeout{errorFrame: errorFrame{code: ErrCodeReadTimeout}}, false},
+		{&RequestErrUnavailable{errorFrame: errorFrame{code: ErrCodeUnavailable}}, false},
+		{&RequestErrOverloaded{errorFrame: errorFrame{code: ErrCodeOverloaded}}, false},
+		{&RequestErrBootstrapping{errorFrame: errorFrame{code: ErrCodeBootstrapping}}, false},
+		{ErrNoConnections, false},
+	}
+	for _, test := range tests {
+		if got := isPermanentError(test.err); got != test.permanent {
+			t.Errorf("isPermanentError(%v) = %v, expected %v", test.err, got, test.permanent)
+		}
+	}
+}
+
+func TestHostPolicy_LatencyAware(t *testing.T) {
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.ParseIP("10.0.0.1")},
+		{hostId: "1", connectAddress: net.ParseIP("10.0.0.2")},
+		{hostId: "2", connectAddress: net.ParseIP("10.0.0.3")},
+	}
+	p := LatencyAwarePolicy(RoundRobinHostPolicy(), LatencyMinMeasurements(2), LatencyExclusion(3)).(*latencyAwarePolicy)
+	for _, host := range hosts {
+		p.AddHost(host)
+	}
+
+	// lastPicked counts how often every host is picked last
+	lastPicked := func() map[string]int {
+		last := make(map[string]int)
+		for i := 0; i < 6; i++ {
+			it := p.Pick(nil)
+			var picked []string
+			for h := it(); h != nil; h = it() {
+				picked = append(picked, h.Info().HostID())
+			}
+			if len(picked) != len(hosts) {
+				t.Fatalf("expected every host to be picked, got %v", picked)
+			}
+			last[picked[len(picked)-1]]++
+		}
+		return last
+	}
+
+	for i := 0; i < 2; i++ {
+		p.record(hosts[0], time.Millisecond)
+		p.record(hosts[1], 2*time.Millisecond)
+		p.record(hosts[2], 10*time.Millisecond)
+	}
+	if last := lastPicked(); last["2"] != 6 {
+		t.Fatalf("expected the slow host to always be picked last, got %v", last)
+	}
+
+	// a host 2 times slower than the best is below the exclusion threshold
+	p.record(hosts[2], 0)
+	for i := 0; i < 40; i++ {
+		p.record(hosts[2], 2*time.Millisecond)
+	}
+	if last := lastPicked(); last["2"] == 6 {
+		t.Fatalf("expected the host to be picked in turn once it is fast, got %v", last)
+	}
+
+	// latencies not measured for the retry period are not compared
+	for i := 0; i < 40; i++ {
+		p.record(hosts[2], 10*time.Millisecond)
+	}
+	p.latencies["2"].updated = time.Now().Add(-time.Minute)
+	if last := lastPicked(); last["2"] == 6 {
+		t.Fatalf("expected the slow host to be tried again after the retry period, got %v", last)
+	}
+
+	// attempts are measured when the host is marked, once when it is marked
+	// again by retries on the same host
+	p.HostDown(hosts[2])
+	p.HostUp(hosts[2])
+	it := p.Pick(nil)
+	for h := it(); h != nil; h = it() {
+		h.Mark(nil)
+		h.Mark(nil)
+	}
+	if l := p.latencies["2"]; l == nil || l.measurements != 1 {
+		t.Fatalf("expected the host to be measured again after it was down, got %+v", l)
+	}
+}
+
+func TestHostPolicy_Distance(t *testing.T) {
+	local := &HostInfo{hostId: "0", connectAddress: net.ParseIP("10.0.0.1"), dataCenter: "dc1", rack: "r1"}
+	rack := &HostInfo{hostId: "1", connectAddress: net.ParseIP("10.0.0.2"), dataCenter: "dc1", rack: "r2"}
+	remote := &HostInfo{hostId: "2", connectAddress: net.ParseIP("10.0.0.3"), dataCenter: "dc2", rack: "r1"}
+
+	tests := []struct {
+		name   string
+		policy HostSelectionPolicy
+		want   [3]HostDistance
+		conns  [3]int
+	}{
+		{
+			name:   "round robin",
+			policy: RoundRobinHostPolicy(),
+			want:   [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceLocal},
+			conns:  [3]int{2, 2, 2},
+		},
+		{
+			name:   "dc aware",
+			policy: TokenAwareHostPolicy(DCAwareRoundRobinPolicy("dc1")),
+			want:   [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceRemote},
+			conns:  [3]int{2, 2, 1},
+		},
+		{
+			name:   "no remote dcs",
+			policy: DCAwareRoundRobinPolicy("dc1", NoRemoteDCs()),
+			want:   [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceIgnored},
+			conns:  [3]int{2, 2, 0},
+		},
+		{
+			name:   "single host ready",
+			policy: SingleHostReadyPolicy(DCAwareRoundRobinPolicy("dc1", NoRemoteDCs())),
+			want:   [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceIgnored},
+			conns:  [3]int{2, 2, 0},
+		},
+		{
+			name:   "rack aware",
+			policy: LatencyAwarePolicy(RackAwareRoundRobinPolicy("dc1", "r1")),
+			want:   [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceRemote},
+			conns:  [3]int{2, 2, 1},
+		},
+		{
+			name: "profiles",
+			policy: wrapProfilePolicies(DCAwareRoundRobinPolicy("dc1", NoRemoteDCs()), map[string]*ExecutionProfile{
+				"analytics": {HostSelectionPolicy: DCAwareRoundRobinPolicy("dc2")},
+			}),
+			want:  [3]HostDistance{HostDistanceLocal, HostDistanceLocal, HostDistanceLocal},
+			conns: [3]int{2, 2, 2},
+		},
+	}
+	for _, test := range tests {
+		pool := &policyConnPool{session: &Session{policy: test.policy}, numConns: 2, remoteConns: 1}
+		for i, host := range []*HostInfo{local, rack, remote} {
+			if got := hostDistance(test.policy, host); got != test.want[i] {
+				t.Errorf("%s: expected host %d at distance %v, got %v", test.name, i, test.want[i], got)
+			}
+			if got := pool.size(host); got != test.conns[i] {
+				t.Errorf("%s: expected %d connections to host %d, got %d", test.name, test.conns[i], i, got)
+			}
+		}
+	}
+
+	// without NumRemoteConns the remote hosts get as many connections as the local ones
+	policy := DCAwareRoundRobinPolicy("dc1")
+	cfg := NewCluster()
+	pool := &policyConnPool{session: &Session{policy: policy}, numConns: cfg.NumConns, remoteConns: cfg.NumRemoteConns}
+	if got := pool.size(remote); got != cfg.NumConns {
+		t.Errorf("expected %d connections to the remote host by default, got %d", cfg.NumConns, got)
+	}
+}
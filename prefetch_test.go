@@ -0,0 +1,163 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestQueryPrefetchPages(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var rows [][]interface{}
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, rows...)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(2).PrefetchPages(3).Iter()
+	var id int
+	for i := 0; i < 2; i++ {
+		if !iter.Scan(&id) || id != i {
+			t.Fatalf("expected row %d, got %d: %v", i, id, iter.Close())
+		}
+	}
+
+	// the first page and the three prefetched ones
+	deadline := time.Now().Add(time.Second)
+	for len(srv.Requests()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Fatalf("expected 4 pages to be requested, got %d", n)
+	}
+
+	for i := 2; i < len(rows); i++ {
+		if !iter.Scan(&id) || id != i {
+			t.Fatalf("expected row %d, got %d: %v", i, id, iter.Close())
+		}
+	}
+	if iter.Scan(&id) {
+		t.Fatal("expected no more rows")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrefetchError(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		second []byte
+	)
+	var rows [][]interface{}
+	for i := 0; i < 6; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	srv.On(`SELECT id FROM events`).Handle([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, func(req *gocqltest.Request) gocqltest.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		// the requests of the pages after the second one fail
+		if len(req.PagingState) > 0 {
+			if second == nil {
+				second = req.PagingState
+			} else if string(second) != string(req.PagingState) {
+				return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeReadTimeout, Message: "read timeout"}}
+			}
+		}
+		return gocqltest.Response{Rows: rows}
+	})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(2).Iter()
+	var id, n int
+	for iter.Scan(&id) {
+		if id != n {
+			t.Fatalf("expected row %d, got %d", n, id)
+		}
+		n++
+	}
+	// the rows of the first two pages are returned before the error
+	if n != 4 {
+		t.Fatalf("expected 4 rows before the failed page, got %d", n)
+	}
+	if len(iter.Columns()) != 1 {
+		t.Fatalf("expected the columns to be kept, got %v", iter.Columns())
+	}
+	if err := iter.Close(); err == nil {
+		t.Fatal("expected the error of the failed page")
+	}
+}
+
+func TestCloseWaitsForPrefetch(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	rows := [][]interface{}{{0}, {1}, {2}, {3}}
+	srv.On(`SELECT id FROM events`).Handle([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, func(req *gocqltest.Request) gocqltest.Response {
+		if len(req.PagingState) > 0 {
+			started <- struct{}{}
+			<-release
+		}
+		return gocqltest.Response{Rows: rows}
+	})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(2).Iter()
+	var id int
+	for i := 0; i < 2; i++ {
+		if !iter.Scan(&id) {
+			t.Fatal(iter.Close())
+		}
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second page to be prefetched")
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- iter.Close()
+	}()
+	select {
+	case err := <-closed:
+		t.Fatalf("expected Close to wait for the prefetch, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to return once the prefetch is done")
+	}
+}
@@ -0,0 +1,195 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoProfile is returned when executing a query or batch that selected an
+// execution profile which is not configured in ClusterConfig.ExecutionProfiles.
+var ErrNoProfile = errors.New("gocql: execution profile not found")
+
+// ExecutionProfile is a named group of execution settings that can be selected
+// per query with Query.Profile or per batch with Batch.Profile, so that
+// workloads with different requirements (for example OLTP and analytics) can
+// share a session.
+//
+// Zero values leave the corresponding setting of the query untouched.
+//
+// Example:
+//
+//	cluster.ExecutionProfiles = map[string]*gocql.ExecutionProfile{
+//		"analytics": {
+//			Consistency: gocql.LocalOne,
+//			Timeout:     time.Minute,
+//			PageSize:    10000,
+//		},
+//	}
+//
+//	iter := session.Query(`SELECT * FROM events`).Profile("analytics").Iter()
+type ExecutionProfile struct {
+	// Consistency level used by queries selecting this profile.
+	// As Any is the zero value of Consistency it can't be selected through a
+	// profile, use Query.Consistency instead.
+	Consistency Consistency
+
+	// SerialConsistency used for the serial phase of conditional updates.
+	SerialConsistency SerialConsistency
+
+	// RetryPolicy used by queries selecting this profile.
+	RetryPolicy RetryPolicy
+
+	// Timeout limits the total time spent executing a query, including
+	// retries and speculative executions. Each page of a paged query is
+	// limited separately.
+	// Timeout only applies if it is lower than the connection level
+	// ClusterConfig.Timeout, which still limits every single request.
+	// If zero the timeout set with Session.SetQueryTimeout is kept.
+	Timeout time.Duration
+
+	// PageSize used by queries selecting this profile. It is ignored by batches.
+	PageSize int
+
+	// HostSelectionPolicy used to route queries selecting this profile.
+	// The policy is notified about the same host and schema changes as the
+	// session-wide policy. Like ClusterConfig.PoolConfig.HostSelectionPolicy
+	// the policy can't be shared with other profiles or sessions.
+	HostSelectionPolicy HostSelectionPolicy
+}
+
+// newProfiles validates profiles and returns a copy of them so that changes to
+// the cluster config don't affect a session that is already running.
+func newProfiles(profiles map[string]*ExecutionProfile) (map[string]*ExecutionProfile, error) {
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+
+	copied := make(map[string]*ExecutionProfile, len(profiles))
+	for name, profile := range profiles {
+		if profile == nil {
+			return nil, fmt.Errorf("gocql: execution profile %q is nil", name)
+		}
+		p := *profile
+		copied[name] = &p
+	}
+	return copied, nil
+}
+
+func (s *Session) profile(name string) (*ExecutionProfile, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoProfile, name)
+	}
+	profile, ok := s.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoProfile, name)
+	}
+	return profile, nil
+}
+
+// profileHostSelectionPolicy picks hosts with the session-wide policy and
+// forwards host and schema changes to the policies of all execution profiles.
+type profileHostSelectionPolicy struct {
+	HostSelectionPolicy
+	profiles []HostSelectionPolicy
+}
+
+// wrapProfilePolicies returns policy unchanged if no execution profile
+// configures its own host selection policy.
+func wrapProfilePolicies(policy HostSelectionPolicy, profiles map[string]*ExecutionProfile) HostSelectionPolicy {
+	var policies []HostSelectionPolicy
+	for _, profile := range profiles {
+		if profile.HostSelectionPolicy != nil {
+			policies = append(policies, profile.HostSelectionPolicy)
+		}
+	}
+	if len(policies) == 0 {
+		return policy
+	}
+	return &profileHostSelectionPolicy{HostSelectionPolicy: policy, profiles: policies}
+}
+
+func (p *profileHostSelectionPolicy) Init(s *Session) {
+	p.HostSelectionPolicy.Init(s)
+	for _, policy := range p.profiles {
+		policy.Init(s)
+	}
+}
+
+func (p *profileHostSelectionPolicy) KeyspaceChanged(update KeyspaceUpdateEvent) {
+	p.HostSelectionPolicy.KeyspaceChanged(update)
+	for _, policy := range p.profiles {
+		policy.KeyspaceChanged(update)
+	}
+}
+
+func (p *profileHostSelectionPolicy) SetPartitioner(partitioner string) {
+	p.HostSelectionPolicy.SetPartitioner(partitioner)
+	for _, policy := range p.profiles {
+		policy.SetPartitioner(partitioner)
+	}
+}
+
+func (p *profileHostSelectionPolicy) AddHost(host *HostInfo) {
+	p.HostSelectionPolicy.AddHost(host)
+	for _, policy := range p.profiles {
+		policy.AddHost(host)
+	}
+}
+
+func (p *profileHostSelectionPolicy) AddHosts(hosts []*HostInfo) {
+	type bulkAddHosts interface {
+		AddHosts([]*HostInfo)
+	}
+	for _, policy := range append([]HostSelectionPolicy{p.HostSelectionPolicy}, p.profiles...) {
+		if v, ok := policy.(bulkAddHosts); ok {
+			v.AddHosts(hosts)
+		} else {
+			for _, host := range hosts {
+				policy.AddHost(host)
+			}
+		}
+	}
+}
+
+func (p *profileHostSelectionPolicy) RemoveHost(host *HostInfo) {
+	p.HostSelectionPolicy.RemoveHost(host)
+	for _, policy := range p.profiles {
+		policy.RemoveHost(host)
+	}
+}
+
+func (p *profileHostSelectionPolicy) HostUp(host *HostInfo) {
+	p.HostSelectionPolicy.HostUp(host)
+	for _, policy := range p.profiles {
+		policy.HostUp(host)
+	}
+}
+
+func (p *profileHostSelectionPolicy) HostDown(host *HostInfo) {
+	p.HostSelectionPolicy.HostDown(host)
+	for _, policy := range p.profiles {
+		policy.HostDown(host)
+	}
+}
+
+// Distance is the closest distance of host assigned by the policies, so that
+// the host has the connections every profile needs.
+func (p *profileHostSelectionPolicy) Distance(host *HostInfo) HostDistance {
+	distance := hostDistance(p.HostSelectionPolicy, host)
+	for _, policy := range p.profiles {
+		if d := hostDistance(policy, host); d < distance {
+			distance = d
+		}
+	}
+	return distance
+}
+
+// Ready defers to the session-wide policy. If it is not a ReadyPolicy, the
+// session waits for all hosts to connect like it does without profiles.
+func (p *profileHostSelectionPolicy) Ready() bool {
+	if rdy, ok := p.HostSelectionPolicy.(ReadyPolicy); ok {
+		return rdy.Ready()
+	}
+	return false
+}
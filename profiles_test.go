@@ -0,0 +1,119 @@
+package gocql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryProfile(t *testing.T) {
+	rt := &SimpleRetryPolicy{NumRetries: 5}
+	policy := RoundRobinHostPolicy()
+	profiles, err := newProfiles(map[string]*ExecutionProfile{
+		"analytics": {
+			Consistency:         LocalOne,
+			SerialConsistency:   LocalSerial,
+			RetryPolicy:         rt,
+			Timeout:             time.Minute,
+			PageSize:            10000,
+			HostSelectionPolicy: policy,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Session{cons: Quorum, pageSize: 100, profiles: profiles}
+
+	qry := s.Query("SELECT * FROM events").Profile("analytics")
+	if qry.profileErr != nil {
+		t.Fatalf("unexpected error: %v", qry.profileErr)
+	}
+	if qry.cons != LocalOne {
+		t.Errorf("expected consistency %v, got %v", LocalOne, qry.cons)
+	}
+	if qry.serialCons != LocalSerial {
+		t.Errorf("expected serial consistency %v, got %v", LocalSerial, qry.serialCons)
+	}
+	if qry.rt != rt {
+		t.Errorf("expected retry policy %v, got %v", rt, qry.rt)
+	}
+	if qry.pageSize != 10000 {
+		t.Errorf("expected page size 10000, got %d", qry.pageSize)
+	}
+	if qry.queryTimeout() != time.Minute {
+		t.Errorf("expected timeout %v, got %v", time.Minute, qry.queryTimeout())
+	}
+	if qry.hostSelectionPolicy() != policy {
+		t.Errorf("expected host selection policy %v, got %v", policy, qry.hostSelectionPolicy())
+	}
+
+	// settings applied after the profile take precedence
+	qry = s.Query("SELECT * FROM events").Profile("analytics").Consistency(All)
+	if qry.cons != All {
+		t.Errorf("expected consistency %v, got %v", All, qry.cons)
+	}
+
+	b := s.NewBatch(LoggedBatch).Profile("analytics")
+	if b.profileErr != nil {
+		t.Fatalf("unexpected error: %v", b.profileErr)
+	}
+	if b.Cons != LocalOne {
+		t.Errorf("expected batch consistency %v, got %v", LocalOne, b.Cons)
+	}
+	if b.queryTimeout() != time.Minute {
+		t.Errorf("expected batch timeout %v, got %v", time.Minute, b.queryTimeout())
+	}
+}
+
+func TestQueryProfileNotFound(t *testing.T) {
+	s := &Session{cons: Quorum}
+
+	qry := s.Query("SELECT * FROM events").Profile("missing")
+	if !errors.Is(qry.profileErr, ErrNoProfile) {
+		t.Fatalf("expected %v, got %v", ErrNoProfile, qry.profileErr)
+	}
+	if err := qry.Iter().Close(); !errors.Is(err, ErrNoProfile) {
+		t.Fatalf("expected %v, got %v", ErrNoProfile, err)
+	}
+	if qry.cons != Quorum {
+		t.Errorf("expected consistency %v to be unchanged, got %v", Quorum, qry.cons)
+	}
+
+	b := s.NewBatch(LoggedBatch).Profile("missing")
+	if !errors.Is(b.profileErr, ErrNoProfile) {
+		t.Fatalf("expected %v, got %v", ErrNoProfile, b.profileErr)
+	}
+}
+
+func TestNewProfilesNil(t *testing.T) {
+	if _, err := newProfiles(map[string]*ExecutionProfile{"nil": nil}); err == nil {
+		t.Fatal("expected error for nil profile")
+	}
+}
+
+func TestWrapProfilePolicies(t *testing.T) {
+	primary := RoundRobinHostPolicy()
+	if got := wrapProfilePolicies(primary, map[string]*ExecutionProfile{"a": {PageSize: 10}}); got != primary {
+		t.Fatalf("expected policy to be returned unchanged, got %T", got)
+	}
+
+	secondary := RoundRobinHostPolicy()
+	policy := wrapProfilePolicies(primary, map[string]*ExecutionProfile{"a": {HostSelectionPolicy: secondary}})
+
+	host := &HostInfo{hostId: "host-1", connectAddress: []byte{127, 0, 0, 1}, state: NodeUp}
+	policy.AddHost(host)
+
+	for _, p := range []HostSelectionPolicy{primary, secondary} {
+		next := p.Pick(nil)
+		if h := next(); h == nil || h.Info() != host {
+			t.Fatalf("expected host to be added to %T", p)
+		}
+	}
+
+	policy.RemoveHost(host)
+	for _, p := range []HostSelectionPolicy{primary, secondary} {
+		if h := p.Pick(nil)(); h != nil {
+			t.Fatalf("expected host to be removed from %T", p)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestProtocolDiscovery(t *testing.T) {
+	for _, test := range []struct {
+		release string
+		proto   int
+	}{
+		{"3.11.4", 4},
+		// the server accepts the protocol v4, but nodes of its version don't
+		{"2.1.9", 3},
+	} {
+		t.Run(test.release, func(t *testing.T) {
+			srv := gocqltest.NewUnstartedServer()
+			srv.ReleaseVersion = test.release
+			srv.Start()
+			defer srv.Close()
+
+			session, err := srv.ClusterConfig().CreateSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer session.Close()
+
+			if err := session.Query(`INSERT INTO orders (id) VALUES (1)`).Exec(); err != nil {
+				t.Fatal(err)
+			}
+			for _, req := range srv.Requests() {
+				if req.ProtocolVersion != test.proto {
+					t.Fatalf("expected protocol version %d, got %d for %q", test.proto, req.ProtocolVersion, req.Statement)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package gocql
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -13,6 +15,10 @@ type ExecutableQuery interface {
 	attempt(keyspace string, end, start time.Time, iter *Iter, host *HostInfo)
 	retryPolicy() RetryPolicy
 	speculativeExecutionPolicy() SpeculativeExecutionPolicy
+	queryTimeout() time.Duration
+	hostSelectionPolicy() HostSelectionPolicy
+	// targetHostID returns the ID of the host the query is pinned to, if any.
+	targetHostID() string
 	GetRoutingKey() ([]byte, error)
 	Keyspace() string
 	Table() string
@@ -24,48 +30,107 @@ type ExecutableQuery interface {
 }
 
 type queryExecutor struct {
-	pool   *policyConnPool
-	policy HostSelectionPolicy
+	pool    *policyConnPool
+	policy  HostSelectionPolicy
+	stats   *sessionCounters
+	metrics MetricsRecorder
+	clock   Clock
+
+	tableWarnings     *tableWarnings
+	overloadedBackoff time.Duration
+	hedgedReads       *HedgedReads
+}
+
+// execution is the result of the primary execution of a query or of one of
+// its speculative executions, the hedges.
+type execution struct {
+	iter  *Iter
+	hedge bool
 }
 
 func (q *queryExecutor) attemptQuery(ctx context.Context, qry ExecutableQuery, conn *Conn) *Iter {
-	start := time.Now()
+	start := q.clock.Now()
 	iter := qry.execute(ctx, conn)
-	end := time.Now()
+	end := q.clock.Now()
 
-	qry.attempt(q.pool.keyspace, end, start, iter, conn.host)
+	q.tableWarnings.record(iter.Warnings())
+	qry.attempt(q.pool.getKeyspace(), end, start, iter, conn.host)
+	recordAttempt(q.metrics, conn.host, end.Sub(start), iter.err)
 
 	return iter
 }
 
 func (q *queryExecutor) speculate(ctx context.Context, qry ExecutableQuery, sp SpeculativeExecutionPolicy,
-	hostIter NextHost, results chan *Iter) *Iter {
-	ticker := time.NewTicker(sp.Delay())
+	hostIter NextHost, results chan execution) *Iter {
+	ticker := q.clock.NewTicker(sp.Delay())
 	defer ticker.Stop()
 
-	for i := 0; i < sp.Attempts(); i++ {
+	hedges := 0
+	for hedges < sp.Attempts() {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			qry.borrowForExecution() // ensure liveness in case of executing Query to prevent races with Query.Release().
-			go q.run(ctx, qry, hostIter, results)
+			q.stats.hedge()
+			hedges++
+			go q.run(ctx, qry, hostIter, results, true)
 		case <-ctx.Done():
 			return &Iter{err: ctx.Err()}
-		case iter := <-results:
-			return iter
+		case result := <-results:
+			return q.won(result, hedges)
 		}
 	}
 
-	return nil
+	select {
+	case result := <-results:
+		return q.won(result, hedges)
+	case <-ctx.Done():
+		return &Iter{err: ctx.Err()}
+	}
+}
+
+// won returns the iterator of the first execution to complete, counting
+// whether the primary execution or a hedge won the race if hedges were sent.
+func (q *queryExecutor) won(result execution, hedges int) *Iter {
+	if hedges > 0 {
+		q.stats.hedgeWon(result.hedge)
+	}
+	return result.iter
 }
 
 func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
-	hostIter := q.policy.Pick(qry)
+	policy := q.policy
+	if p := qry.hostSelectionPolicy(); p != nil {
+		policy = p
+	}
+	hostIter := policy.Pick(qry)
+	pinned := false
+	if hostID := qry.targetHostID(); hostID != "" {
+		host := q.pool.session.ring.getHost(hostID)
+		if host == nil {
+			return &Iter{err: fmt.Errorf("gocql: unknown host %q", hostID)}, nil
+		}
+		hostIter = pinnedHost(host)
+		pinned = true
+	}
+
+	ctx := qry.Context()
+	if timeout := qry.queryTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	// check if the query is not marked as idempotent, if
 	// it is, we force the policy to NonSpeculative
 	sp := qry.speculativeExecutionPolicy()
-	if !qry.IsIdempotent() || sp.Attempts() == 0 {
-		return q.do(qry.Context(), qry, hostIter), nil
+	idempotent := qry.IsIdempotent()
+	if sp.Attempts() == 0 {
+		if hedging := q.hedgedReads.speculation(qry); hedging != nil {
+			sp, idempotent = hedging, true
+		}
+	}
+	if !idempotent || sp.Attempts() == 0 || pinned {
+		return q.do(ctx, qry, hostIter), nil
 	}
 
 	// When speculative execution is enabled, we could be accessing the host iterator from multiple goroutines below.
@@ -78,28 +143,19 @@ func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
 		return origHostIter()
 	}
 
-	ctx, cancel := context.WithCancel(qry.Context())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	results := make(chan *Iter, 1)
+	results := make(chan execution, 1)
 
 	// Launch the main execution
 	qry.borrowForExecution() // ensure liveness in case of executing Query to prevent races with Query.Release().
-	go q.run(ctx, qry, hostIter, results)
+	go q.run(ctx, qry, hostIter, results, false)
 
 	// The speculative executions are launched _in addition_ to the main
 	// execution, on a timer. So Speculation{2} would make 3 executions running
 	// in total.
-	if iter := q.speculate(ctx, qry, sp, hostIter, results); iter != nil {
-		return iter, nil
-	}
-
-	select {
-	case iter := <-results:
-		return iter, nil
-	case <-ctx.Done():
-		return &Iter{err: ctx.Err()}, nil
-	}
+	return q.speculate(ctx, qry, sp, hostIter, results), nil
 }
 
 func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter NextHost) *Iter {
@@ -127,8 +183,12 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 			continue
 		}
 
+		if q.policy != nil && !q.policy.IsLocal(host) {
+			q.stats.remoteAttempt()
+		}
 		iter = q.attemptQuery(ctx, qry, conn)
 		iter.host = selectedHost.Info()
+		q.stats.attempted(iter.err)
 		// Update host
 		switch iter.err {
 		case context.Canceled, context.DeadlineExceeded, ErrNotFound:
@@ -137,9 +197,21 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 			selectedHost.Mark(nil)
 			return iter
 		default:
+			if isPermanentError(iter.err) {
+				// the request itself is at fault, the host is fine and
+				// retrying it would fail the same way
+				selectedHost.Mark(nil)
+				return iter
+			}
 			selectedHost.Mark(iter.err)
 		}
 
+		// a statement which may have been applied is only executed again if
+		// it is idempotent
+		if iter.err != nil && !qry.IsIdempotent() && isAmbiguousError(iter.err) {
+			return iter
+		}
+
 		// Exit if the query was successful
 		// or no retry policy defined or retry attempts were reached
 		if iter.err == nil || rt == nil || !rt.Attempt(qry) {
@@ -148,14 +220,22 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 		lastErr = iter.err
 
 		// If query is unsuccessful, check the error with RetryPolicy to retry
-		switch rt.GetRetryType(iter.err) {
+		retryType := rt.GetRetryType(iter.err)
+		if retryType == Retry || retryType == RetryNextHost {
+			if err := q.backoff(ctx, qry, rt, iter.err); err != nil {
+				return &Iter{err: err}
+			}
+		}
+		switch retryType {
 		case Retry:
 			// retry on the same host
+			q.retried(host)
 			continue
 		case Rethrow, Ignore:
 			return iter
 		case RetryNextHost:
 			// retry on the next host
+			q.retried(host)
 			selectedHost = hostIter()
 			continue
 		default:
@@ -171,10 +251,103 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 	return &Iter{err: ErrNoConnections}
 }
 
-func (q *queryExecutor) run(ctx context.Context, qry ExecutableQuery, hostIter NextHost, results chan<- *Iter) {
+// retried counts a query attempted again after its attempt on host failed.
+func (q *queryExecutor) retried(host *HostInfo) {
+	q.stats.retry()
+	if q.metrics != nil {
+		q.metrics.RecordRetry(host)
+	}
+}
+
+// pinnedHost returns a NextHost picking only host.
+func pinnedHost(host *HostInfo) NextHost {
+	picked := false
+	return func() SelectedHost {
+		if picked {
+			return nil
+		}
+		picked = true
+		return (*selectedHost)(host)
+	}
+}
+
+// backoff waits before retrying a query which failed with err, if err or the
+// retry policy rt asks for it. It returns the error of ctx if it is done first.
+func (q *queryExecutor) backoff(ctx context.Context, qry ExecutableQuery, rt RetryPolicy, err error) error {
+	var wait time.Duration
+	if b, ok := rt.(RetryBackoff); ok {
+		wait = b.Backoff(qry)
+	}
+	var overloaded *RequestErrOverloaded
+	if errors.As(err, &overloaded) && q.overloadedBackoff > 0 {
+		if d := getExponentialTime(q.overloadedBackoff, 10*time.Second, qry.Attempts()); d > wait {
+			wait = d
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := q.clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isAmbiguousError reports whether err leaves unknown whether the request was
+// applied, such as a write timeout or the connection closed before the
+// response, so that executing it again could apply it twice.
+func isAmbiguousError(err error) bool {
+	var (
+		writeTimeout *RequestErrWriteTimeout
+		writeFailure *RequestErrWriteFailure
+	)
+	if errors.As(err, &writeTimeout) || errors.As(err, &writeFailure) {
+		return true
+	}
+	return errors.Is(err, ErrTimeoutNoResponse) || errors.Is(err, ErrConnectionClosed) ||
+		errors.Is(err, ErrTooManyOrphanedStreams) || isNetError(err)
+}
+
+// isPermanentError reports whether err is caused by the request, such as a
+// syntax error or a request or response too large, which fails the same way on
+// every attempt.
+func isPermanentError(err error) bool {
+	var (
+		tooLarge         *RequestTooLargeError
+		responseTooLarge *ResponseTooLargeError
+		tooManyRows      *TooManyRowsError
+	)
+	if errors.As(err, &tooLarge) || errors.As(err, &responseTooLarge) || errors.As(err, &tooManyRows) ||
+		errors.Is(err, ErrUnsetValueUnsupported) {
+		return true
+	}
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+	switch reqErr.Code() {
+	case ErrCodeSyntax, ErrCodeUnauthorized, ErrCodeInvalid, ErrCodeConfig,
+		ErrCodeAlreadyExists, ErrCodeFunctionFailure, ErrCodeCredentials:
+		return true
+	}
+	return false
+}
+
+func (q *queryExecutor) run(ctx context.Context, qry ExecutableQuery, hostIter NextHost, results chan<- execution, hedge bool) {
+	defer qry.releaseAfterExecution()
+
+	iter := q.do(ctx, qry, hostIter)
+	if hedge && iter.err == ErrNoConnections {
+		// no host was left for the hedge, the other executions answer
+		return
+	}
 	select {
-	case results <- q.do(ctx, qry, hostIter):
+	case results <- execution{iter: iter, hedge: hedge}:
 	case <-ctx.Done():
 	}
-	qry.releaseAfterExecution()
 }
@@ -0,0 +1,59 @@
+package gocql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxNameLength is the longest keyspace or table name accepted by Cassandra.
+const maxNameLength = 48
+
+// QuoteIdentifier returns name as a quoted CQL identifier, such as a keyspace,
+// table or column name, that can be safely used in a statement. Quoted
+// identifiers are case sensitive.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// EscapeString escapes s to be used in a CQL string literal. The result must
+// be enclosed in single quotes:
+//
+//	stmt := "INSERT INTO users (name) VALUES ('" + gocql.EscapeString(name) + "')"
+//
+// Bind markers should be preferred where they can be used.
+func EscapeString(s string) string {
+	return strings.Replace(s, `'`, `''`, -1)
+}
+
+// ValidateKeyspaceName returns an error if name is not a valid keyspace name,
+// that is not between 1 and 48 alphanumeric characters or underscores.
+func ValidateKeyspaceName(name string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("gocql: invalid keyspace name %q: %v", name, err)
+	}
+	return nil
+}
+
+// ValidateTableName returns an error if name is not a valid table name, that is
+// not between 1 and 48 alphanumeric characters or underscores.
+func ValidateTableName(name string) error {
+	if err := validateName(name); err != nil {
+		return fmt.Errorf("gocql: invalid table name %q: %v", name, err)
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name is longer than %d characters", maxNameLength)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return fmt.Errorf("name contains %q", r)
+		}
+	}
+	return nil
+}
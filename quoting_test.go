@@ -0,0 +1,45 @@
+package gocql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"users":      `"users"`,
+		"MixedCase":  `"MixedCase"`,
+		`weird"name`: `"weird""name"`,
+		"":           `""`,
+	}
+	for name, expected := range tests {
+		if quoted := QuoteIdentifier(name); quoted != expected {
+			t.Errorf("QuoteIdentifier(%q) = %s, want %s", name, quoted, expected)
+		}
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	if escaped := EscapeString(`it's '' here`); escaped != `it''s '''' here` {
+		t.Fatalf("unexpected escaped string %s", escaped)
+	}
+}
+
+func TestValidateNames(t *testing.T) {
+	for _, name := range []string{"users", "Users_2", strings.Repeat("a", 48)} {
+		if err := ValidateKeyspaceName(name); err != nil {
+			t.Errorf("expected %q to be a valid keyspace name: %v", name, err)
+		}
+		if err := ValidateTableName(name); err != nil {
+			t.Errorf("expected %q to be a valid table name: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "my-keyspace", "users;DROP", `"users"`, "ключ", strings.Repeat("a", 49)} {
+		if err := ValidateKeyspaceName(name); err == nil {
+			t.Errorf("expected %q to be an invalid keyspace name", name)
+		}
+		if err := ValidateTableName(name); err == nil {
+			t.Errorf("expected %q to be an invalid table name", name)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql/internal/lru"
+)
+
+// ReadCacheConfig configures the client side read cache of a session, see
+// ClusterConfig.ReadCache.
+type ReadCacheConfig struct {
+	// TTL is how long the rows of a query are served from the cache.
+	// Default: 1m
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of cached results.
+	// Default: 1000
+	MaxEntries int
+
+	// MaxBytes is the maximum total size of the cached rows. Results larger
+	// than MaxBytes are not cached. Zero means no limit.
+	MaxBytes int
+}
+
+// ReadCache is a read-through cache of the rows of queries marked with
+// Query.Cached, keyed by keyspace, consistency, statement and values. Only
+// results fitting a single
+// page are cached. Cached results can be stale by up to the TTL of the cache
+// unless they are invalidated when the data is changed.
+type ReadCache struct {
+	ttl      time.Duration
+	maxBytes int
+	clock    Clock
+
+	mu    sync.Mutex
+	lru   *lru.Cache
+	bytes int
+	// keys of the cached results per statement, to the keys of their values,
+	// see readCacheValuesKey.
+	stmts map[string]map[string]string
+}
+
+type readCacheEntry struct {
+	stmt     string
+	meta     resultMetadata
+	numRows  int
+	rows     []byte
+	host     *HostInfo
+	expires  time.Time
+	warnings []string
+	// transformers decode the transformed columns of meta.
+	transformers []ColumnTransformer
+}
+
+// newReadCacheEntry copies the rows of iter if they fit a single page, it
+// returns nil otherwise. It must be called before iter is read.
+func newReadCacheEntry(stmt string, iter *Iter) *readCacheEntry {
+	if iter.err != nil || iter.framer == nil || iter.next != nil || iter.meta.morePages() {
+		return nil
+	}
+	entry := &readCacheEntry{
+		stmt:         stmt,
+		meta:         iter.meta,
+		numRows:      iter.numRows,
+		rows:         copyBytes(iter.framer.buf),
+		host:         iter.host,
+		transformers: iter.transformers,
+	}
+	if iter.framer.header != nil {
+		entry.warnings = iter.framer.header.warnings
+	}
+	return entry
+}
+
+// iter returns a new iterator over the rows of the entry.
+func (e *readCacheEntry) iter() *Iter {
+	// the rows are only read through the framer, it is safe to share them
+	// between iterators.
+	framer := &framer{
+		header: &frameHeader{warnings: e.warnings},
+		buf:    e.rows,
+	}
+	return &Iter{
+		meta:         e.meta,
+		numRows:      e.numRows,
+		host:         e.host,
+		framer:       framer,
+		transformers: e.transformers,
+	}
+}
+
+func newReadCache(cfg *ReadCacheConfig, clock Clock) *ReadCache {
+	c := &ReadCache{
+		ttl:      cfg.TTL,
+		maxBytes: cfg.MaxBytes,
+		clock:    clock,
+		stmts:    make(map[string]map[string]string),
+	}
+	if c.ttl <= 0 {
+		c.ttl = time.Minute
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	c.lru = lru.New(maxEntries)
+	c.lru.OnEvicted = c.evicted
+	return c
+}
+
+// evicted is called by the LRU with c.mu held.
+func (c *ReadCache) evicted(key string, value interface{}) {
+	entry := value.(*readCacheEntry)
+	c.bytes -= len(entry.rows)
+	if keys := c.stmts[entry.stmt]; keys != nil {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.stmts, entry.stmt)
+		}
+	}
+}
+
+// readCacheKey is the key of the rows of a query executed with the values
+// identified by valuesKey in keyspace, the keyspace of the session, with the
+// consistency cons.
+func readCacheKey(keyspace string, cons Consistency, valuesKey string) string {
+	return keyspace + "\x00" + cons.String() + "\x00" + valuesKey
+}
+
+// readCacheValuesKey identifies stmt executed with values.
+func readCacheValuesKey(stmt string, values []interface{}) string {
+	var b strings.Builder
+	b.WriteString(stmt)
+	for _, v := range values {
+		b.WriteByte(0)
+		// pointers are keyed by the value they point to, their address may be
+		// reused by a different value
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if rv.IsValid() && rv.Kind() != reflect.Ptr {
+			fmt.Fprintf(&b, "%T:%#v", rv.Interface(), rv.Interface())
+		} else {
+			b.WriteString("nil")
+		}
+	}
+	return b.String()
+}
+
+// get returns an iterator over the cached rows of the query.
+func (c *ReadCache) get(key string) (*Iter, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*readCacheEntry)
+	if c.clock.Now().After(entry.expires) {
+		c.lru.Remove(key)
+		return nil, false
+	}
+
+	return entry.iter(), true
+}
+
+// add caches the rows of iter if they fit a single page. It must be called
+// before iter is read.
+func (c *ReadCache) add(key, valuesKey, stmt string, iter *Iter) {
+	if c.maxBytes > 0 && iter.framer != nil && len(iter.framer.buf) > c.maxBytes {
+		return
+	}
+	entry := newReadCacheEntry(stmt, iter)
+	if entry == nil {
+		return
+	}
+	entry.expires = c.clock.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// replace an existing entry so that the size is accounted once
+	c.lru.Remove(key)
+	c.bytes += len(entry.rows)
+	keys := c.stmts[stmt]
+	if keys == nil {
+		keys = make(map[string]string)
+		c.stmts[stmt] = keys
+	}
+	keys[key] = valuesKey
+	c.lru.Add(key, entry)
+
+	for c.maxBytes > 0 && c.bytes > c.maxBytes && c.lru.Len() > 0 {
+		c.lru.RemoveOldest()
+	}
+}
+
+// Invalidate removes the cached results of the statement with the given
+// values, in every keyspace and at every consistency.
+func (c *ReadCache) Invalidate(stmt string, values ...interface{}) {
+	valuesKey := readCacheValuesKey(stmt, values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, v := range c.stmts[stmt] {
+		if v == valuesKey {
+			c.lru.Remove(key)
+		}
+	}
+}
+
+// InvalidateStatement removes the cached results of the statement for all
+// values.
+func (c *ReadCache) InvalidateStatement(stmt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.stmts[stmt] {
+		c.lru.Remove(key)
+	}
+}
+
+// Purge removes all cached results.
+func (c *ReadCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.lru.RemoveOldest()
+	}
+}
+
+// Len returns the number of cached results.
+func (c *ReadCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// ReadCache returns the read cache of the session, or nil if
+// ClusterConfig.ReadCache is not set.
+func (s *Session) ReadCache() *ReadCache {
+	return s.readCache
+}
@@ -0,0 +1,142 @@
+package gocql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func newReadCacheSession(t *testing.T, cfg *gocql.ReadCacheConfig, clock gocql.Clock) (*gocql.Session, *gocqltest.Server) {
+	t.Helper()
+
+	srv := gocqltest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.On(`SELECT name FROM countries WHERE code = ?`).
+		Params(gocqltest.Column{Name: "code", Type: gocqltest.Text}).
+		Handle([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, func(req *gocqltest.Request) gocqltest.Response {
+			var code string
+			if err := req.Scan(&code); err != nil {
+				return gocqltest.Response{Err: err}
+			}
+			return gocqltest.Response{Rows: [][]interface{}{{"country " + code}}}
+		})
+
+	cluster := srv.ClusterConfig()
+	cluster.ReadCache = cfg
+	cluster.Clock = clock
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return session, srv
+}
+
+func countryName(t *testing.T, session *gocql.Session, code string) string {
+	t.Helper()
+	var name string
+	if err := session.Query(`SELECT name FROM countries WHERE code = ?`, code).Cached(true).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadCache(t *testing.T) {
+	session, srv := newReadCacheSession(t, &gocql.ReadCacheConfig{}, nil)
+
+	for i := 0; i < 3; i++ {
+		if name := countryName(t, session, "fr"); name != "country fr" {
+			t.Fatalf("unexpected name %q", name)
+		}
+	}
+	if name := countryName(t, session, "de"); name != "country de" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+
+	// queries not marked as cached always reach the server
+	var name string
+	if err := session.Query(`SELECT name FROM countries WHERE code = ?`, "fr").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+
+	session.ReadCache().Invalidate(`SELECT name FROM countries WHERE code = ?`, "fr")
+	countryName(t, session, "fr")
+	countryName(t, session, "de")
+	if n := len(srv.Requests()); n != 4 {
+		t.Fatalf("expected 4 requests after invalidating one result, got %d", n)
+	}
+
+	session.ReadCache().InvalidateStatement(`SELECT name FROM countries WHERE code = ?`)
+	if n := session.ReadCache().Len(); n != 0 {
+		t.Fatalf("expected an empty cache, got %d entries", n)
+	}
+}
+
+func TestReadCacheTTL(t *testing.T) {
+	clock := gocqltest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	session, srv := newReadCacheSession(t, &gocql.ReadCacheConfig{TTL: time.Minute}, clock)
+
+	countryName(t, session, "fr")
+	clock.Advance(59 * time.Second)
+	countryName(t, session, "fr")
+	clock.Advance(2 * time.Second)
+	countryName(t, session, "fr")
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("expected the expired result to be fetched again, got %d requests", n)
+	}
+}
+
+func TestReadCacheKeyspaceConsistency(t *testing.T) {
+	session, srv := newReadCacheSession(t, &gocql.ReadCacheConfig{}, nil)
+
+	countryName(t, session, "fr")
+	var name string
+	if err := session.Query(`SELECT name FROM countries WHERE code = ?`, "fr").Consistency(gocql.One).Cached(true).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.SetKeyspace(context.Background(), "other"); err != nil {
+		t.Fatal(err)
+	}
+	countryName(t, session, "fr")
+	if n := len(srv.Requests()); n != 3 {
+		t.Fatalf("expected the results of other consistencies and keyspaces not to be shared, got %d requests", n)
+	}
+	if n := session.ReadCache().Len(); n != 3 {
+		t.Fatalf("expected 3 cached results, got %d", n)
+	}
+
+	session.ReadCache().Invalidate(`SELECT name FROM countries WHERE code = ?`, "fr")
+	if n := session.ReadCache().Len(); n != 0 {
+		t.Fatalf("expected the results of every keyspace and consistency to be invalidated, got %d", n)
+	}
+}
+
+func TestReadCacheBounds(t *testing.T) {
+	session, srv := newReadCacheSession(t, &gocql.ReadCacheConfig{MaxEntries: 2}, nil)
+
+	for _, code := range []string{"fr", "de", "it", "fr"} {
+		countryName(t, session, code)
+	}
+	if n := session.ReadCache().Len(); n != 2 {
+		t.Fatalf("expected 2 cached results, got %d", n)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Fatalf("expected the least recently used result to be evicted, got %d requests", n)
+	}
+
+	session, srv = newReadCacheSession(t, &gocql.ReadCacheConfig{MaxBytes: 4}, nil)
+	countryName(t, session, "fr")
+	countryName(t, session, "fr")
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("expected results larger than MaxBytes not to be cached, got %d requests", n)
+	}
+}
@@ -0,0 +1,45 @@
+package gocql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ReadOnlyError is returned for statements which modify data or schema in a
+// read-only session, see ClusterConfig.ReadOnly. The statement isn't sent.
+type ReadOnlyError struct {
+	Statement string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("gocql: session is read-only, refusing to execute %q", e.Statement)
+}
+
+// isReadStatement reports whether stmt only reads, going by its first keyword.
+// Statements it doesn't recognize, including ones starting with a comment,
+// are considered writes so that a read-only session never lets a write
+// through.
+func isReadStatement(stmt string) bool {
+	stmt = strings.TrimLeftFunc(stmt, unicode.IsSpace)
+	keyword := stmt
+	if n := strings.IndexFunc(stmt, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ';' || r == '('
+	}); n >= 0 {
+		keyword = stmt[:n]
+	}
+	switch strings.ToLower(keyword) {
+	case "select", "list", "describe", "desc":
+		return true
+	}
+	return false
+}
+
+// checkReadOnly returns a *ReadOnlyError if the session is read-only and stmt
+// isn't a read.
+func (s *Session) checkReadOnly(stmt string) error {
+	if s.cfg.ReadOnly && !isReadStatement(stmt) {
+		return &ReadOnlyError{Statement: stmt}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestReadOnlySession(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"})
+
+	cluster := srv.ClusterConfig()
+	cluster.ReadOnly = true
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var name string
+	if err := session.Query(`SELECT name FROM users`).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	requests := len(srv.Requests())
+
+	writes := []string{
+		`INSERT INTO users (name) VALUES ('bob')`,
+		`  update users SET name = 'bob' WHERE id = 1`,
+		`DELETE FROM users WHERE id = 1`,
+		`TRUNCATE users`,
+		`CREATE TABLE users (id int PRIMARY KEY)`,
+		`ALTER TABLE users ADD email text`,
+		`DROP KEYSPACE accounts`,
+		`BEGIN BATCH INSERT INTO users (name) VALUES ('bob') APPLY BATCH`,
+		`/* select */ DELETE FROM users WHERE id = 1`,
+	}
+	for _, stmt := range writes {
+		err := session.Query(stmt).Exec()
+		var readOnlyErr *gocql.ReadOnlyError
+		if !errors.As(err, &readOnlyErr) || readOnlyErr.Statement != stmt {
+			t.Errorf("%s: expected a read-only error, got %v", stmt, err)
+		}
+	}
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO users (name) VALUES ('bob')`)
+	var readOnlyErr *gocql.ReadOnlyError
+	if err := session.ExecuteBatch(b); !errors.As(err, &readOnlyErr) {
+		t.Errorf("expected a read-only error for the batch, got %v", err)
+	}
+
+	if n := len(srv.Requests()); n != requests {
+		t.Fatalf("expected the writes not to be sent, got %d requests", n-requests)
+	}
+}
@@ -0,0 +1,63 @@
+package gocql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type registeredAddress struct {
+	Street string `cql:"street"`
+	City   string
+}
+
+func TestRegisterUDT(t *testing.T) {
+	gocql.RegisterUDT("ks.registered_address", reflect.TypeOf(registeredAddress{}))
+
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	address := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""),
+		KeySpace:   "ks",
+		Name:       "registered_address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: gocqltest.Text},
+			{Name: "City", Type: gocqltest.Text},
+		},
+	}
+	want := registeredAddress{Street: "1 main st", City: "springfield"}
+	srv.On(`SELECT home FROM ks.users`).Rows(
+		[]gocqltest.Column{{Name: "home", Type: address}},
+		[]interface{}{want})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	row := make(map[string]interface{})
+	if err := session.Query(`SELECT home FROM ks.users`).MapScan(row); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := row["home"].(registeredAddress); !ok || got != want {
+		t.Fatalf("expected %+v, got %#v", want, row["home"])
+	}
+
+	// the fields are mapped again once the definition of the UDT changes
+	address.Elements = append([]gocql.UDTField{{Name: "zip", Type: gocqltest.Text}}, address.Elements...)
+	data, err := gocql.Marshal(address, &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got registeredAddress
+	if err := gocql.Unmarshal(address, data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
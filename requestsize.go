@@ -0,0 +1,62 @@
+package gocql
+
+import "fmt"
+
+// RequestTooLargeError is returned for queries and batches exceeding
+// ClusterConfig.MaxRequestSize or ClusterConfig.MaxRequestValues once their
+// values are marshalled. The request isn't sent, nor retried.
+type RequestTooLargeError struct {
+	// Statement is the statement of the query, or the first statement of the
+	// batch.
+	Statement string
+
+	// Size is the size of the statements and values of the request in bytes
+	// and Values the number of its bound values.
+	Size   int
+	Values int
+
+	// MaxSize and MaxValues are the limits of the session.
+	MaxSize   int
+	MaxValues int
+}
+
+func (e *RequestTooLargeError) Error() string {
+	stmt := e.Statement
+	if len(stmt) > 100 {
+		stmt = stmt[:100] + "..."
+	}
+	if e.MaxValues > 0 && e.Values > e.MaxValues {
+		return fmt.Sprintf("gocql: request with %d values exceeds the limit of %d values: %q", e.Values, e.MaxValues, stmt)
+	}
+	return fmt.Sprintf("gocql: request of %d bytes exceeds the limit of %d bytes: %q", e.Size, e.MaxSize, stmt)
+}
+
+// requestSize sums the size and number of the values of a request.
+type requestSize struct {
+	size   int
+	values int
+}
+
+func (r *requestSize) add(stmt string, values []queryValues) {
+	r.size += len(stmt)
+	r.values += len(values)
+	for i := range values {
+		r.size += len(values[i].name) + len(values[i].value)
+	}
+}
+
+// checkRequestSize returns a *RequestTooLargeError if the request of stmt
+// exceeds the limits of the session.
+func (s *Session) checkRequestSize(stmt string, r requestSize) error {
+	maxSize, maxValues := s.cfg.MaxRequestSize, s.cfg.MaxRequestValues
+	if (maxSize > 0 && r.size > maxSize) || (maxValues > 0 && r.values > maxValues) {
+		return &RequestTooLargeError{
+			Statement: stmt,
+			Size:      r.size,
+			Values:    r.values,
+			MaxSize:   maxSize,
+			MaxValues: maxValues,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package gocql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestMaxRequestSize(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	const insert = `INSERT INTO blobs (id, data) VALUES (?, ?)`
+	srv.On(insert).Params(
+		gocqltest.Column{Name: "id", Type: gocqltest.Int},
+		gocqltest.Column{Name: "data", Type: gocqltest.Text},
+	)
+
+	cluster := srv.ClusterConfig()
+	cluster.MaxRequestSize = 1024
+	cluster.MaxRequestValues = 3
+	cluster.RetryPolicy = &gocql.SimpleRetryPolicy{NumRetries: 2}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(insert, 1, "small").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	requests := len(srv.Requests())
+
+	q := session.Query(insert, 2, strings.Repeat("x", 2048)).Idempotent(true)
+	err = q.Exec()
+	var tooLarge *gocql.RequestTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected a request too large error, got %v", err)
+	}
+	if tooLarge.Statement != insert || tooLarge.Size <= 2048 || tooLarge.MaxSize != 1024 {
+		t.Fatalf("unexpected error %+v", tooLarge)
+	}
+	if n := q.Attempts(); n != 1 {
+		t.Fatalf("expected the request not to be retried, got %d attempts", n)
+	}
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(insert, 3, "a")
+	b.Query(insert, 4, "b")
+	if err := session.ExecuteBatch(b); !errors.As(err, &tooLarge) || tooLarge.Values != 4 {
+		t.Fatalf("expected a request with too many values, got %v", err)
+	}
+
+	if n := len(srv.Requests()); n != requests {
+		t.Fatalf("expected the requests not to be sent, got %d requests", n-requests)
+	}
+}
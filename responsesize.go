@@ -0,0 +1,47 @@
+package gocql
+
+import "fmt"
+
+// ResponseTooLargeError is returned for responses exceeding
+// ClusterConfig.MaxResponseSize. The frame is discarded without being decoded,
+// the connection stays usable, and the request isn't retried.
+type ResponseTooLargeError struct {
+	// Size is the size of the frame body in bytes, after decompression if the
+	// body is compressed.
+	Size    int
+	MaxSize int
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("gocql: response of %d bytes exceeds the limit of %d bytes", e.Size, e.MaxSize)
+}
+
+// TooManyRowsError is returned by iterators of queries returning more rows
+// than Query.MaxRows, counting the rows of all the pages. The page exceeding
+// the limit isn't decoded, the rows of the pages before it are.
+type TooManyRowsError struct {
+	Statement string
+	// Rows is the number of rows of the pages fetched so far.
+	Rows    int
+	MaxRows int
+}
+
+func (e *TooManyRowsError) Error() string {
+	stmt := e.Statement
+	if len(stmt) > 100 {
+		stmt = stmt[:100] + "..."
+	}
+	return fmt.Sprintf("gocql: query returned %d rows, more than the limit of %d rows: %q", e.Rows, e.MaxRows, stmt)
+}
+
+// checkResponseSize returns a *ResponseTooLargeError if a frame body of size
+// bytes exceeds the limit of the session.
+func (c *Conn) checkResponseSize(size int) error {
+	if c.session == nil {
+		return nil
+	}
+	if max := c.session.cfg.MaxResponseSize; max > 0 && size > max {
+		return &ResponseTooLargeError{Size: size, MaxSize: max}
+	}
+	return nil
+}
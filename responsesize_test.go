@@ -0,0 +1,90 @@
+package gocql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{{Name: "data", Type: gocqltest.Text}}
+	srv.On(`SELECT data FROM blobs WHERE id = 1`).Rows(columns, []interface{}{strings.Repeat("x", 4096)})
+	srv.On(`SELECT data FROM blobs WHERE id = 2`).Rows(columns, []interface{}{"small"})
+
+	cluster := srv.ClusterConfig()
+	cluster.NumConns = 1
+	cluster.MaxResponseSize = 1024
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var data string
+	err = session.Query(`SELECT data FROM blobs WHERE id = 1`).Scan(&data)
+	var tooLarge *gocql.ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size <= 4096 || tooLarge.MaxSize != 1024 {
+		t.Fatalf("expected a response too large error, got %v", err)
+	}
+
+	// the connection is still usable
+	if err := session.Query(`SELECT data FROM blobs WHERE id = 2`).Scan(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data != "small" {
+		t.Fatalf("expected small, got %q", data)
+	}
+}
+
+func TestQueryMaxRows(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var rows [][]interface{}
+	for i := 0; i < 10; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, rows...)
+
+	cluster := srv.ClusterConfig()
+	cluster.MaxRows = 5
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(3).Iter()
+	var id, n int
+	for iter.Scan(&id) {
+		n++
+	}
+	err = iter.Close()
+	var tooMany *gocql.TooManyRowsError
+	if !errors.As(err, &tooMany) || tooMany.Rows != 6 || tooMany.MaxRows != 5 {
+		t.Fatalf("expected a too many rows error, got %v", err)
+	}
+	// the rows of the first page are returned
+	if n != 3 {
+		t.Fatalf("expected 3 rows before the error, got %d", n)
+	}
+
+	// the query replaces the limit of the cluster
+	iter = session.Query(`SELECT id FROM events`).PageSize(3).MaxRows(0).Iter()
+	n = 0
+	for iter.Scan(&id) {
+		n++
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Fatalf("expected 10 rows, got %d", n)
+	}
+}
@@ -0,0 +1,306 @@
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// retrySameHost retries queries NumRetries times on the same host.
+type retrySameHost struct {
+	gocql.SimpleRetryPolicy
+}
+
+func (retrySameHost) GetRetryType(error) gocql.RetryType {
+	return gocql.Retry
+}
+
+func TestPermanentErrorsNotRetried(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELEC name FROM users`).Error(gocql.ErrCodeSyntax, "line 1:0 no viable alternative")
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeReadTimeout, "read timeout")
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &retrySameHost{gocql.SimpleRetryPolicy{NumRetries: 2}}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELEC name FROM users`).Exec(); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("expected the syntax error not to be retried, got %d requests", n)
+	}
+
+	if err := session.Query(`SELECT name FROM users`).Exec(); err == nil {
+		t.Fatal("expected a read timeout")
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Fatalf("expected the read timeout to be attempted 3 times, got %d requests", n-1)
+	}
+}
+
+func TestAttemptedHosts(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeReadTimeout, "read timeout")
+	srv.On(`INSERT INTO users (name) VALUES ('a')`).Rows(nil)
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &retrySameHost{gocql.SimpleRetryPolicy{NumRetries: 2}}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	q := session.Query(`SELECT name FROM users`)
+	if err := q.Exec(); err == nil {
+		t.Fatal("expected a read timeout")
+	}
+	hosts := q.AttemptedHosts()
+	if len(hosts) != 3 || q.Attempts() != 3 {
+		t.Fatalf("expected 3 attempts, got %d with hosts %v", q.Attempts(), hosts)
+	}
+	for _, host := range hosts {
+		if !host.ConnectAddress().Equal(hosts[0].ConnectAddress()) {
+			t.Fatalf("expected every attempt on the same host, got %v", hosts)
+		}
+	}
+	if q.Latency() <= 0 {
+		t.Fatalf("expected a latency, got %d", q.Latency())
+	}
+
+	// a reused query only lists the hosts of its last execution, while its
+	// attempts keep adding up
+	if err := q.Exec(); err == nil {
+		t.Fatal("expected a read timeout")
+	}
+	if hosts := q.AttemptedHosts(); len(hosts) == 0 || len(hosts) != q.Attempts()-3 {
+		t.Fatalf("expected the hosts of the attempts after the first 3, got %d with hosts %v", q.Attempts(), hosts)
+	}
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO users (name) VALUES ('a')`)
+	if len(b.AttemptedHosts()) != 0 {
+		t.Fatal("expected no attempted hosts before execution")
+	}
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := b.AttemptedHosts(); len(hosts) != 1 || b.Attempts() != 1 {
+		t.Fatalf("expected 1 attempt, got %d with hosts %v", b.Attempts(), hosts)
+	}
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := b.AttemptedHosts(); len(hosts) != 1 || b.Attempts() != 2 {
+		t.Fatalf("expected 1 host of 2 attempts, got %d with hosts %v", b.Attempts(), hosts)
+	}
+}
+
+func TestOverloadedBackoff(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeOverloaded, "overloaded")
+	srv.On(`SELECT name FROM nodes`).Error(gocql.ErrCodeBootstrapping, "bootstrapping")
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &retrySameHost{gocql.SimpleRetryPolicy{NumRetries: 2}}
+	cluster.OverloadedBackoff = 20 * time.Millisecond
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	start := time.Now()
+	err = session.Query(`SELECT name FROM users`).Exec()
+	var overloaded *gocql.RequestErrOverloaded
+	if !errors.As(err, &overloaded) {
+		t.Fatalf("expected an overloaded error, got %v", err)
+	}
+	// the backoff is doubled with a jitter of half the backoff: at least
+	// 10ms, then 30ms
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected the retries to back off, took %v", elapsed)
+	}
+	if n := session.Stats().Overloaded; n != 3 {
+		t.Fatalf("expected 3 overloaded errors, got %d", n)
+	}
+
+	err = session.Query(`SELECT name FROM nodes`).Exec()
+	var bootstrapping *gocql.RequestErrBootstrapping
+	if !errors.As(err, &bootstrapping) {
+		t.Fatalf("expected a bootstrapping error, got %v", err)
+	}
+}
+
+// backoffSameHost retries queries on the same host with an exponential
+// backoff.
+type backoffSameHost struct {
+	gocql.ExponentialBackoffRetryPolicy
+}
+
+func (backoffSameHost) GetRetryType(error) gocql.RetryType {
+	return gocql.Retry
+}
+
+func TestExponentialBackoffRetryPolicy(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeReadTimeout, "read timeout")
+
+	clock := gocqltest.NewClock(time.Now())
+	cluster := srv.ClusterConfig()
+	cluster.Clock = clock
+	// no request timeouts nor connections opened in the background, the only
+	// timer of the queries is their backoff
+	cluster.Timeout = 0
+	cluster.NumConns = 1
+	cluster.RetryPolicy = &backoffSameHost{gocql.ExponentialBackoffRetryPolicy{
+		NumRetries: 1, Min: time.Second, Max: time.Minute, Jitter: 0.5,
+	}}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	timers := clock.Timers()
+
+	// the query waits for the clock between attempts
+	q := session.Query(`SELECT name FROM users`)
+	errc := make(chan error, 1)
+	go func() { errc <- q.Exec() }()
+	clock.WaitForTimers(timers + 1)
+	clock.Advance(2 * time.Second)
+	if err := <-errc; err == nil {
+		t.Fatal("expected a read timeout")
+	}
+	if q.Attempts() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", q.Attempts())
+	}
+
+	// the backoff ends with the context of the query
+	ctx, cancel := context.WithCancel(context.Background())
+	q = session.Query(`SELECT name FROM users`).WithContext(ctx)
+	go func() { errc <- q.Exec() }()
+	clock.WaitForTimers(timers + 1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if q.Attempts() != 1 {
+		t.Fatalf("expected no attempt after the context was canceled, got %d", q.Attempts())
+	}
+}
+
+// consistencyObserver records the consistency levels of the attempts.
+type consistencyObserver struct {
+	mu     sync.Mutex
+	levels []gocql.Consistency
+}
+
+func (o *consistencyObserver) ObserveQuery(_ context.Context, q gocql.ObservedQuery) {
+	o.mu.Lock()
+	o.levels = append(o.levels, q.Consistency)
+	o.mu.Unlock()
+}
+
+func TestDowngradingConsistencyObserved(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT name FROM users`).Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+		if req.Consistency != gocql.One {
+			return gocqltest.Response{Err: &gocqltest.Error{Code: gocql.ErrCodeReadTimeout, Message: "read timeout"}}
+		}
+		return gocqltest.Response{}
+	})
+
+	observer := &consistencyObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.QueryObserver = observer
+	cluster.RetryPolicy = &gocql.DowngradingConsistencyRetryPolicy{
+		ConsistencyLevelsToTry: []gocql.Consistency{gocql.LocalQuorum, gocql.One},
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	q := session.Query(`SELECT name FROM users`).Consistency(gocql.Quorum)
+	if err := q.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if q.GetConsistency() != gocql.One {
+		t.Fatalf("expected the query to be downgraded to ONE, got %v", q.GetConsistency())
+	}
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	want := []gocql.Consistency{gocql.Quorum, gocql.LocalQuorum, gocql.One}
+	if fmt.Sprint(observer.levels) != fmt.Sprint(want) {
+		t.Fatalf("expected attempts at %v, got %v", want, observer.levels)
+	}
+}
+
+func TestNonIdempotentNotRetriedAfterAmbiguousErrors(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`UPDATE counters SET n = n + 1 WHERE id = 1`).Error(gocql.ErrCodeWriteTimeout, "write timeout")
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &retrySameHost{gocql.SimpleRetryPolicy{NumRetries: 2}}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	attempts := func(exec func() error) int {
+		t.Helper()
+		before := len(srv.Requests())
+		if err := exec(); err == nil {
+			t.Fatal("expected a write timeout")
+		}
+		return len(srv.Requests()) - before
+	}
+	update := `UPDATE counters SET n = n + 1 WHERE id = 1`
+
+	if n := attempts(session.Query(update).Exec); n != 1 {
+		t.Fatalf("expected the non idempotent query not to be retried, got %d attempts", n)
+	}
+	if n := attempts(session.Query(update).Idempotent(true).Exec); n != 3 {
+		t.Fatalf("expected the idempotent query to be attempted 3 times, got %d attempts", n)
+	}
+
+	batch := func(idempotent bool) func() error {
+		return func() error {
+			b := session.NewBatch(gocql.UnloggedBatch).Idempotent(idempotent)
+			b.Query(update)
+			return session.ExecuteBatch(b)
+		}
+	}
+	if n := attempts(batch(false)); n != 1 {
+		t.Fatalf("expected the non idempotent batch not to be retried, got %d attempts", n)
+	}
+	if n := attempts(batch(true)); n != 3 {
+		t.Fatalf("expected the idempotent batch to be attempted 3 times, got %d attempts", n)
+	}
+}
@@ -0,0 +1,82 @@
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func newUsersSession(t *testing.T) *gocql.Session {
+	t.Helper()
+
+	srv := gocqltest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.On(`SELECT id, name, email FROM users`).
+		Rows([]gocqltest.Column{
+			{Name: "id", Type: gocqltest.Int},
+			{Name: "name", Type: gocqltest.Text},
+			{Name: "email", Type: gocqltest.Text},
+		}, []interface{}{1, "alice", "alice@example.com"}, []interface{}{2, "bob", "bob@example.com"})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return session
+}
+
+func TestScanCountError(t *testing.T) {
+	session := newUsersSession(t)
+
+	var id int
+	var name string
+	err := session.Query(`SELECT id, name, email FROM users`).Scan(&id, &name)
+	var countErr *gocql.ScanCountError
+	if !errors.As(err, &countErr) {
+		t.Fatalf("expected a *ScanCountError, got %v", err)
+	}
+	if countErr.Have != 2 || countErr.Want != 3 {
+		t.Fatalf("expected 2 destinations for 3 columns, got %+v", countErr)
+	}
+	if len(countErr.Unmatched) != 1 || countErr.Unmatched[0].Name != "email" {
+		t.Fatalf("expected email to be unmatched, got %v", countErr.Unmatched)
+	}
+	if msg := "gocql: not enough columns to scan into: have 2 want 3, unmatched columns: email text"; err.Error() != msg {
+		t.Fatalf("expected %q, got %q", msg, err.Error())
+	}
+}
+
+func TestScanPrefix(t *testing.T) {
+	session := newUsersSession(t)
+
+	iter := session.Query(`SELECT id, name, email FROM users`).ScanPrefix(true).Iter()
+	var ids []int
+	var names []string
+	var id int
+	var name string
+	for iter.Scan(&id, &name) {
+		ids = append(ids, id)
+		names = append(names, name)
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[1] != 2 || names[1] != "bob" {
+		t.Fatalf("unexpected rows %v %v", ids, names)
+	}
+
+	scanner := session.Query(`SELECT id, name, email FROM users`).ScanPrefix(true).Iter().Scanner()
+	n := 0
+	for scanner.Next() {
+		if err := scanner.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d: %v", n, err)
+	}
+}
@@ -0,0 +1,219 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+// ScanAll appends all the rows of the iterator, fetching the further pages, to
+// the slice dest points to and closes the iterator, returning the error of
+// Close.
+//
+// The elements of the slice are structs, or pointers to structs, whose fields
+// receive the columns of the same name, ignoring case, or named by their cql
+// tag. Every column must have a field, fields without a column are left
+// untouched and fields tagged cql:"-" are ignored:
+//
+//	type User struct {
+//		ID        gocql.UUID `cql:"id"`
+//		FirstName string     `cql:"first_name"`
+//		Email     string
+//	}
+//
+//	var users []User
+//	err := session.Query(`SELECT id, first_name, email FROM users`).Iter().ScanAll(&users)
+//
+// For queries selecting a single column the elements can be of any type the
+// column unmarshals to instead:
+//
+//	var ids []gocql.UUID
+//	err := session.Query(`SELECT id FROM users`).Iter().ScanAll(&ids)
+func (iter *Iter) ScanAll(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		iter.Close()
+		return fmt.Errorf("gocql: ScanAll expects a pointer to a slice, got %T", dest)
+	}
+	if iter.err != nil {
+		return iter.Close()
+	}
+
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	base, isPtr := elemType, elemType.Kind() == reflect.Ptr
+	if isPtr {
+		base = elemType.Elem()
+	}
+
+	columns := iter.Columns()
+	var fields [][]int
+	if scanIntoStruct(base, columns) {
+		var err error
+		if fields, err = structColumnFields(base, columns); err != nil {
+			iter.Close()
+			return err
+		}
+	} else if len(columns) != 1 {
+		iter.Close()
+		return fmt.Errorf("gocql: ScanAll into %s expects a single column, got %d", slice.Type(), len(columns))
+	}
+
+	for {
+		row := reflect.New(base)
+		var dests []interface{}
+		if fields == nil {
+			d, err := columnDests(row.Elem(), columns[0])
+			if err != nil {
+				iter.Close()
+				return err
+			}
+			dests = d
+		} else {
+			dests = make([]interface{}, 0, len(fields))
+			for i, index := range fields {
+				d, err := columnDests(row.Elem().FieldByIndex(index), columns[i])
+				if err != nil {
+					iter.Close()
+					return err
+				}
+				dests = append(dests, d...)
+			}
+		}
+
+		if !iter.Scan(dests...) {
+			break
+		}
+		if slice.Len() == slice.Cap() {
+			// make room for the rows left in the page, the current one included
+			slice = growSlice(slice, iter.numRows-iter.pos+1)
+		}
+		if isPtr {
+			slice = reflect.Append(slice, row)
+		} else {
+			slice = reflect.Append(slice, row.Elem())
+		}
+	}
+
+	v.Elem().Set(slice)
+	return iter.Close()
+}
+
+// growSlice returns slice with room for at least n more elements, doubling
+// its capacity at least so that growing it once per page is amortized.
+func growSlice(slice reflect.Value, n int) reflect.Value {
+	if slice.Cap()-slice.Len() >= n {
+		return slice
+	}
+	capacity := slice.Len() + n
+	if double := 2 * slice.Cap(); double > capacity {
+		capacity = double
+	}
+	grown := reflect.MakeSlice(slice.Type(), slice.Len(), capacity)
+	reflect.Copy(grown, slice)
+	return grown
+}
+
+// scanIntoStruct reports whether the rows are scanned into the fields of
+// structs of type t, rather than into values of type t for a single column
+// such as a UDT or a timestamp.
+func scanIntoStruct(t reflect.Type, columns []ColumnInfo) bool {
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return false
+	}
+	if len(columns) == 1 {
+		switch columns[0].TypeInfo.Type() {
+		case TypeUDT, TypeTuple:
+			return false
+		}
+	}
+	return true
+}
+
+// structColumnFields returns the index of the field of t receiving each column.
+func structColumnFields(t reflect.Type, columns []ColumnInfo) ([][]int, error) {
+	byName := structFieldsByName(t)
+	fields := make([][]int, len(columns))
+	for i, col := range columns {
+		index, ok := byName[strings.ToLower(col.Name)]
+		if !ok {
+			return nil, fmt.Errorf("gocql: no field of %s for column %q", t, col.Name)
+		}
+		fields[i] = index
+	}
+	return fields, nil
+}
+
+// structFieldsByName returns the index of the exported fields of t by their
+// lower case column name, their cql tag or else their name.
+func structFieldsByName(t reflect.Type) map[string][]int {
+	byName := make(map[string][]int)
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous || throughPointer(t, sf.Index) {
+			continue
+		}
+		name := sf.Tag.Get("cql")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		name = strings.ToLower(name)
+		if _, ok := byName[name]; !ok {
+			byName[name] = sf.Index
+		}
+	}
+	return byName
+}
+
+// throughPointer reports whether the field of t at index is promoted from an
+// embedded pointer, which may be nil.
+func throughPointer(t reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		if t.FieldByIndex(index[:i]).Type.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
+// columnDests returns the destinations of Scan for column, a single pointer to
+// v or, for tuples, pointers to the elements of v which Scan expects one per
+// element of the tuple.
+func columnDests(v reflect.Value, column ColumnInfo) ([]interface{}, error) {
+	tuple, ok := column.TypeInfo.(TupleTypeInfo)
+	if !ok {
+		return []interface{}{v.Addr().Interface()}, nil
+	}
+
+	n := len(tuple.Elems)
+	switch {
+	case v.Kind() == reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+	case v.Kind() == reflect.Struct && v.NumField() == n, v.Kind() == reflect.Array && v.Len() == n:
+	default:
+		return nil, fmt.Errorf("gocql: can't scan tuple column %q of %d elements into %s", column.Name, n, v.Type())
+	}
+
+	dests := make([]interface{}, n)
+	for i := range dests {
+		var elem reflect.Value
+		if v.Kind() == reflect.Struct {
+			elem = v.Field(i)
+		} else {
+			elem = v.Index(i)
+		}
+		if !elem.CanSet() {
+			return nil, fmt.Errorf("gocql: can't scan tuple column %q into unexported fields of %s", column.Name, v.Type())
+		}
+		dests[i] = elem.Addr().Interface()
+	}
+	return dests, nil
+}
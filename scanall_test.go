@@ -0,0 +1,164 @@
+package gocql_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestIterScanAll(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "first_name", Type: gocqltest.Text},
+		{Name: "email", Type: gocqltest.Text},
+	}
+	var rows, idRows [][]interface{}
+	for i := 0; i < 5; i++ {
+		rows = append(rows, []interface{}{i, "name", "user@example.com"})
+		idRows = append(idRows, []interface{}{i})
+	}
+	srv.On(`SELECT id, first_name, email FROM users`).Rows(columns, rows...)
+	srv.On(`SELECT id FROM users`).Rows(columns[:1], idRows...)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	type base struct {
+		ID int
+	}
+	type user struct {
+		base
+		FirstName string `cql:"first_name"`
+		Email     string
+		Ignored   string `cql:"-"`
+	}
+
+	var users []user
+	if err := session.Query(`SELECT id, first_name, email FROM users`).PageSize(2).Iter().ScanAll(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 5 {
+		t.Fatalf("expected 5 users of 3 pages, got %d", len(users))
+	}
+	for i, u := range users {
+		if want := (user{base: base{ID: i}, FirstName: "name", Email: "user@example.com"}); u != want {
+			t.Fatalf("user %d: expected %+v, got %+v", i, want, u)
+		}
+	}
+
+	var ptrs []*user
+	if err := session.Query(`SELECT id, first_name, email FROM users`).Iter().ScanAll(&ptrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(ptrs) != 5 || ptrs[4].ID != 4 {
+		t.Fatalf("unexpected users %v", ptrs)
+	}
+
+	var ids []int
+	if err := session.Query(`SELECT id FROM users`).Iter().ScanAll(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+
+	type partial struct {
+		ID int
+	}
+	var partials []partial
+	if err := session.Query(`SELECT id, first_name, email FROM users`).Iter().ScanAll(&partials); err == nil {
+		t.Fatal("expected an error for columns without a field")
+	}
+	if err := session.Query(`SELECT id, first_name, email FROM users`).Iter().ScanAll(&ids); err == nil {
+		t.Fatal("expected an error scanning several columns into ints")
+	}
+	if err := session.Query(`SELECT id FROM users`).Iter().ScanAll(ids); err == nil {
+		t.Fatal("expected an error for a slice which isn't a pointer")
+	}
+
+	srv.On(`SELECT id FROM missing`).Error(gocql.ErrCodeInvalid, "unconfigured table missing")
+	if err := session.Query(`SELECT id FROM missing`).Iter().ScanAll(&ids); err == nil {
+		t.Fatal("expected the error of the query")
+	}
+}
+
+type pageBytesObserver struct {
+	pageBytes []int
+}
+
+func (o *pageBytesObserver) ObserveQuery(_ context.Context, q gocql.ObservedQuery) {
+	o.pageBytes = append(o.pageBytes, q.PageBytes)
+}
+
+func TestIterSizeHints(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{{Name: "id", Type: gocqltest.Int}}
+	srv.On(`SELECT id FROM users`).Rows(columns, []interface{}{1}, []interface{}{2}, []interface{}{3})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	observer := &pageBytesObserver{}
+	iter := session.Query(`SELECT id FROM users`).Observer(observer).Iter()
+	if iter.NumRows() != 3 || iter.PageBytes() == 0 {
+		t.Fatalf("expected 3 rows and the size of the page before scanning, got %d rows of %d bytes", iter.NumRows(), iter.PageBytes())
+	}
+	if len(observer.pageBytes) != 1 || observer.pageBytes[0] != iter.PageBytes() {
+		t.Fatalf("expected the size of the page %d to be observed, got %v", iter.PageBytes(), observer.pageBytes)
+	}
+
+	var ids []int
+	if err := iter.ScanAll(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+	if cap(ids) != 3 {
+		t.Fatalf("expected the slice to be sized for the rows of the page, got a capacity of %d", cap(ids))
+	}
+}
+
+func TestIterPageBytesPages(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{{Name: "name", Type: gocqltest.Text}}
+	srv.On(`SELECT name FROM users`).Rows(columns, []interface{}{"a"}, []interface{}{"b"}, []interface{}{"a much longer name"})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	observer := &pageBytesObserver{}
+	iter := session.Query(`SELECT name FROM users`).PageSize(2).Observer(observer).Iter()
+	var pageBytes []int
+	var name string
+	for iter.Scan(&name) {
+		if len(pageBytes) == 0 || pageBytes[len(pageBytes)-1] != iter.PageBytes() {
+			pageBytes = append(pageBytes, iter.PageBytes())
+		}
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(observer.pageBytes) != 2 || !reflect.DeepEqual(pageBytes, observer.pageBytes) {
+		t.Fatalf("expected the sizes of the pages %v, got %v", observer.pageBytes, pageBytes)
+	}
+}
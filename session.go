@@ -35,8 +35,10 @@ type Session struct {
 	pageSize            int
 	prefetch            float64
 	routingKeyInfoCache routingKeyInfoLRU
+	parsedStmtCache     routingKeyInfoLRU // statements parsed by parsedRoutingKey
 	schemaDescriber     *schemaDescriber
 	trace               Tracer
+	queryTimeout        time.Duration
 	queryObserver       QueryObserver
 	batchObserver       BatchObserver
 	connectObserver     ConnectObserver
@@ -49,13 +51,41 @@ type Session struct {
 	connCfg *ConnConfig
 
 	executor *queryExecutor
-	pool     *policyConnPool
-	policy   HostSelectionPolicy
+	// middleware wraps executor if ClusterConfig.Middleware is set.
+	middleware QueryExecutor
+	// readCache is set if ClusterConfig.ReadCache is set.
+	readCache *ReadCache
+	// coalescer collapses identical concurrent queries, see Query.Coalesce.
+	coalescer *coalescer
+	// prefetchSem limits background page fetches if
+	// ClusterConfig.MaxConcurrentPrefetches is set.
+	prefetchSem chan struct{}
+	// compression counts the work of the compressors of the connections.
+	compression *compressionCounters
+	// stats are the totals returned by Session.Stats.
+	stats *sessionCounters
+	// experiments are the experiments enabled by ClusterConfig.Experiments.
+	experiments experiments
+	// traceSampling is nil unless ClusterConfig.TraceSampling is set.
+	traceSampling *traceSampling
+	// tableWarnings counts the warnings of the server by table, see
+	// Session.TableWarnings.
+	tableWarnings *tableWarnings
+	pool          *policyConnPool
+	policy        HostSelectionPolicy
 
 	ring     ring
 	metadata clusterMetadata
+	// contactPorts holds the ports of the contact points by address, set once
+	// by init, see contactPorts.
+	contactPorts map[string]int
+	// eventSubscribers receive the events of the server, see
+	// Session.SubscribeEvents.
+	eventSubscribers eventSubscribers
 
 	mu sync.RWMutex
+	// keyspace is protected by mu, see SetKeyspace.
+	keyspace string
 
 	control *controlConn
 
@@ -69,6 +99,26 @@ type Session struct {
 
 	cfg ClusterConfig
 
+	// profiles holds the execution profiles by name, see ClusterConfig.ExecutionProfiles.
+	profiles map[string]*ExecutionProfile
+
+	// statements holds the registered statements by name, see ClusterConfig.Statements.
+	statements map[string]string
+
+	// fireAndForget executes the writes of Query.FireAndForget.
+	fireAndForget *fireAndForgetQueue
+
+	// tableDefaults holds the defaults of tables by keyspace.table, see
+	// ClusterConfig.TableDefaults.
+	tableDefaults map[string]TableDefaults
+
+	// transformers holds the transformers of columns by keyspace.table.column,
+	// see ClusterConfig.ColumnTransformers.
+	transformers map[string]ColumnTransformer
+
+	// dcConsistency is a copy of ClusterConfig.DCConsistency.
+	dcConsistency *DCConsistency
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -98,7 +148,7 @@ func addrsToHosts(addrs []string, defaultPort int, logger StdLogger) ([]*HostInf
 		if err != nil {
 			// Try other hosts if unable to resolve DNS name
 			if _, ok := err.(*net.DNSError); ok {
-				logger.Printf("gocql: dns error: %v\n", err)
+				logWarn(logger, "dns error", LogField{"err", err})
 				continue
 			}
 			return nil, err
@@ -112,6 +162,25 @@ func addrsToHosts(addrs []string, defaultPort int, logger StdLogger) ([]*HostInf
 	return hosts, nil
 }
 
+// contactPorts returns the ports of the contact points by IP address, so that
+// the port of a contact point is used for the host discovered at its address.
+// Addresses of several contact points with different ports are left out.
+func contactPorts(hosts []*HostInfo) map[string]int {
+	ports := make(map[string]int, len(hosts))
+	ambiguous := make(map[string]bool)
+	for _, host := range hosts {
+		addr := host.ConnectAddress().String()
+		if port, ok := ports[addr]; ok && port != host.Port() {
+			ambiguous[addr] = true
+		}
+		ports[addr] = host.Port()
+	}
+	for addr := range ambiguous {
+		delete(ports, addr)
+	}
+	return ports
+}
+
 // NewSession wraps an existing Node.
 func NewSession(cfg ClusterConfig) (*Session, error) {
 	// Check that hosts in the ClusterConfig is not empty
@@ -124,6 +193,11 @@ func NewSession(cfg ClusterConfig) (*Session, error) {
 		return nil, errors.New("Can't use both Authenticator and AuthProvider in cluster config.")
 	}
 
+	profiles, err := newProfiles(cfg.ExecutionProfiles)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: we should take a context in here at some point
 	ctx, cancel := context.WithCancel(context.TODO())
 
@@ -132,19 +206,32 @@ func NewSession(cfg ClusterConfig) (*Session, error) {
 		prefetch:        0.25,
 		cfg:             cfg,
 		pageSize:        cfg.PageSize,
+		keyspace:        cfg.Keyspace,
 		stmtsLRU:        &preparedLRU{lru: lru.New(cfg.MaxPreparedStmts)},
 		connectObserver: cfg.ConnectObserver,
 		ctx:             ctx,
 		cancel:          cancel,
 		logger:          cfg.logger(),
+		profiles:        profiles,
+		statements:      copyStatements(cfg.Statements),
+		fireAndForget:   newFireAndForgetQueue(cfg.FireAndForget),
+		tableDefaults:   newTableDefaults(cfg.TableDefaults),
+		transformers:    newColumnTransformers(cfg.ColumnTransformers),
+		dcConsistency:   newDCConsistency(cfg.DCConsistency),
+		compression:     &compressionCounters{},
+		stats:           &sessionCounters{},
+		tableWarnings:   &tableWarnings{tables: make(map[string]*TableWarnings)},
+		experiments:     newExperiments(cfg.Experiments),
 	}
 
 	s.schemaDescriber = newSchemaDescriber(s)
+	s.traceSampling = newTraceSampling(s, cfg.TraceSampling)
 
-	s.nodeEvents = newEventDebouncer("NodeEvents", s.handleNodeEvent, s.logger)
-	s.schemaEvents = newEventDebouncer("SchemaEvents", s.handleSchemaEvent, s.logger)
+	s.nodeEvents = newEventDebouncer("NodeEvents", s.handleNodeEvent, s.logger, cfg.clock())
+	s.schemaEvents = newEventDebouncer("SchemaEvents", s.handleSchemaEvent, s.logger, cfg.clock())
 
 	s.routingKeyInfoCache.lru = lru.New(cfg.MaxRoutingKeyInfo)
+	s.parsedStmtCache.lru = lru.New(cfg.MaxRoutingKeyInfo)
 
 	s.hostSource = &ringDescriber{session: s}
 	s.ringRefresher = newRefreshDebouncer(ringRefreshDebounceTime, func() error { return refreshRing(s.hostSource) })
@@ -154,12 +241,32 @@ func NewSession(cfg ClusterConfig) (*Session, error) {
 	}
 	s.pool = cfg.PoolConfig.buildPool(s)
 
-	s.policy = cfg.PoolConfig.HostSelectionPolicy
+	s.policy = wrapProfilePolicies(cfg.PoolConfig.HostSelectionPolicy, profiles)
 	s.policy.Init(s)
 
 	s.executor = &queryExecutor{
-		pool:   s.pool,
-		policy: cfg.PoolConfig.HostSelectionPolicy,
+		pool:    s.pool,
+		policy:  cfg.PoolConfig.HostSelectionPolicy,
+		stats:   s.stats,
+		metrics: cfg.MetricsRecorder,
+		clock:   cfg.clock(),
+
+		tableWarnings:     s.tableWarnings,
+		overloadedBackoff: cfg.OverloadedBackoff,
+	}
+	if cfg.HedgedReads != nil {
+		hedgedReads := *cfg.HedgedReads
+		s.executor.hedgedReads = &hedgedReads
+	}
+	if len(cfg.Middleware) > 0 {
+		s.middleware = chainMiddleware(QueryExecutorFunc(s.executor.executeQuery), cfg.Middleware)
+	}
+	s.coalescer = newCoalescer(s.stats)
+	if cfg.ReadCache != nil {
+		s.readCache = newReadCache(cfg.ReadCache, cfg.clock())
+	}
+	if cfg.MaxConcurrentPrefetches > 0 {
+		s.prefetchSem = make(chan struct{}, cfg.MaxConcurrentPrefetches)
 	}
 
 	s.queryObserver = cfg.QueryObserver
@@ -197,23 +304,12 @@ func (s *Session) init() error {
 		return err
 	}
 	s.ring.endpoints = hosts
+	s.contactPorts = contactPorts(hosts)
 
 	if !s.cfg.disableControlConn {
 		s.control = createControlConn(s)
-		if s.cfg.ProtoVersion == 0 {
-			proto, err := s.control.discoverProtocol(hosts)
-			if err != nil {
-				return fmt.Errorf("unable to discover protocol version: %v", err)
-			} else if proto == 0 {
-				return errors.New("unable to discovery protocol version")
-			}
-
-			// TODO(zariel): we really only need this in 1 place
-			s.cfg.ProtoVersion = proto
-			s.connCfg.ProtoVersion = proto
-		}
-
-		if err := s.control.connect(hosts); err != nil {
+		discovered := s.cfg.ProtoVersion == 0
+		if err := s.connectInitial(hosts, discovered); err != nil {
 			return err
 		}
 
@@ -224,6 +320,7 @@ func (s *Session) init() error {
 				return err
 			}
 			s.policy.SetPartitioner(partitioner)
+			s.metadata.setPartitioner(partitioner)
 			filteredHosts := make([]*HostInfo, 0, len(newHosts))
 			for _, host := range newHosts {
 				if !s.cfg.filterHost(host) {
@@ -232,6 +329,21 @@ func (s *Session) init() error {
 			}
 
 			hosts = filteredHosts
+
+			// the protocol was discovered on one host, nodes of older versions
+			// wouldn't accept it while the cluster is being upgraded
+			if proto := clusterProtocol(filteredHosts); discovered && proto > 0 && proto < s.cfg.ProtoVersion {
+				logInfo(s.logger, "using protocol version supported by all hosts",
+					LogField{"version", proto}, LogField{"configured", s.cfg.ProtoVersion})
+				s.cfg.ProtoVersion = proto
+				s.connCfg.ProtoVersion = proto
+
+				s.control.close()
+				s.control = createControlConn(s)
+				if err := s.control.connect(s.ring.endpoints); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -376,7 +488,7 @@ func (s *Session) reconnectDownedHosts(intv time.Duration) {
 				for _, h := range hosts {
 					buf.WriteString("[" + h.ConnectAddress().String() + ":" + h.State().String() + "]")
 				}
-				s.logger.Println(buf.String())
+				logDebug(s.logger, buf.String())
 			}
 
 			for _, h := range hosts {
@@ -427,6 +539,62 @@ func (s *Session) SetTrace(trace Tracer) {
 	s.mu.Unlock()
 }
 
+// SetSerialConsistency sets the default serial consistency of queries and
+// batches of this session, initially ClusterConfig.SerialConsistency. This
+// setting can also be changed on a per-query basis.
+func (s *Session) SetSerialConsistency(cons SerialConsistency) {
+	s.mu.Lock()
+	s.cfg.SerialConsistency = cons
+	s.mu.Unlock()
+}
+
+// SetRetryPolicy sets the default retry policy of queries and batches of this
+// session, initially ClusterConfig.RetryPolicy. This setting can also be
+// changed on a per-query basis.
+func (s *Session) SetRetryPolicy(r RetryPolicy) {
+	s.mu.Lock()
+	s.cfg.RetryPolicy = r
+	s.mu.Unlock()
+}
+
+// SetIdempotence sets whether queries of this session are idempotent by
+// default, initially ClusterConfig.DefaultIdempotence. This setting can also
+// be changed on a per-query basis.
+func (s *Session) SetIdempotence(idempotent bool) {
+	s.mu.Lock()
+	s.cfg.DefaultIdempotence = idempotent
+	s.mu.Unlock()
+}
+
+// SetQueryTimeout sets the default total time spent executing a query or
+// batch of this session, including retries and fetching a page. A value <= 0
+// disables the timeout, leaving only ClusterConfig.Timeout to limit every
+// single request. This setting can also be changed per query with execution
+// profiles.
+func (s *Session) SetQueryTimeout(d time.Duration) {
+	s.mu.Lock()
+	s.queryTimeout = d
+	s.mu.Unlock()
+}
+
+// SetQueryObserver sets the default query observer of this session,
+// initially ClusterConfig.QueryObserver. This setting can also be changed on
+// a per-query basis.
+func (s *Session) SetQueryObserver(observer QueryObserver) {
+	s.mu.Lock()
+	s.queryObserver = observer
+	s.mu.Unlock()
+}
+
+// SetBatchObserver sets the default batch observer of this session,
+// initially ClusterConfig.BatchObserver. This setting can also be changed on
+// a per-batch basis.
+func (s *Session) SetBatchObserver(observer BatchObserver) {
+	s.mu.Lock()
+	s.batchObserver = observer
+	s.mu.Unlock()
+}
+
 // Query generates a new query object for interacting with the database.
 // Further details of the query may be tweaked using the resulting query
 // value before the query is executed. Query is automatically prepared
@@ -474,6 +642,10 @@ func (s *Session) Close() {
 	s.isClosing = true
 	s.sessionStateMu.Unlock()
 
+	// let the fire and forget writes in flight complete before closing their
+	// connections
+	s.fireAndForget.close()
+
 	if s.pool != nil {
 		s.pool.Close()
 	}
@@ -494,6 +666,8 @@ func (s *Session) Close() {
 		s.ringRefresher.stop()
 	}
 
+	s.eventSubscribers.close()
+
 	if s.cancel != nil {
 		s.cancel()
 	}
@@ -522,22 +696,50 @@ func (s *Session) executeQuery(qry *Query) (it *Iter) {
 	if s.Closed() {
 		return &Iter{err: ErrSessionClosed}
 	}
-
-	iter, err := s.executor.executeQuery(qry)
-	if err != nil {
+	if err := s.checkReadOnly(qry.stmt); err != nil {
 		return &Iter{err: err}
 	}
-	if iter == nil {
-		panic("nil iter")
+	s.applyTableDefaults(qry)
+
+	var cacheKey, valuesKey string
+	cached := qry.cached && s.readCache != nil && qry.pageState == nil && qry.binding == nil
+	if cached {
+		valuesKey = readCacheValuesKey(qry.stmt, qry.values)
+		cacheKey = readCacheKey(s.Keyspace(), qry.cons, valuesKey)
+		if iter, ok := s.readCache.get(cacheKey); ok {
+			return iter
+		}
 	}
 
+	execute := func() *Iter {
+		iter, err := s.execute(qry)
+		if err != nil {
+			return &Iter{err: err}
+		}
+		if iter == nil {
+			panic("nil iter")
+		}
+		return iter
+	}
+	var iter *Iter
+	if qry.coalesce && qry.pageState == nil && qry.binding == nil {
+		iter = s.coalescer.do(qry, execute)
+	} else {
+		iter = execute()
+	}
+
+	if cached {
+		s.readCache.add(cacheKey, valuesKey, qry.stmt, iter)
+	}
 	return iter
 }
 
+// removeHost removes a host which left the ring. Its connections are closed
+// once the requests in flight on them are done, see ClusterConfig.DrainTimeout.
 func (s *Session) removeHost(h *HostInfo) {
 	s.policy.RemoveHost(h)
 	hostID := h.HostID()
-	s.pool.removeHost(hostID)
+	s.pool.drainHost(hostID, s.cfg.DrainTimeout)
 	s.ring.removeHost(hostID)
 }
 
@@ -636,13 +838,16 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string) (*routingKeyI
 	if len(info.request.pkeyColumns) > 0 {
 		// proto v4 dont need to calculate primary key columns
 		types := make([]TypeInfo, len(info.request.pkeyColumns))
+		names := make([]string, len(info.request.pkeyColumns))
 		for i, col := range info.request.pkeyColumns {
 			types[i] = info.request.columns[col].TypeInfo
+			names[i] = info.request.columns[col].Name
 		}
 
 		routingKeyInfo := &routingKeyInfo{
 			indexes:  info.request.pkeyColumns,
 			types:    types,
+			names:    names,
 			keyspace: keyspace,
 			table:    table,
 		}
@@ -676,6 +881,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string) (*routingKeyI
 	routingKeyInfo := &routingKeyInfo{
 		indexes:  make([]int, size),
 		types:    make([]TypeInfo, size),
+		names:    make([]string, size),
 		keyspace: keyspace,
 		table:    table,
 	}
@@ -690,6 +896,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string) (*routingKeyI
 				// there may be many such bound columns, pick the first
 				routingKeyInfo.indexes[keyIndex] = argIndex
 				routingKeyInfo.types[keyIndex] = boundColumn.TypeInfo
+				routingKeyInfo.names[keyIndex] = boundColumn.Name
 				break
 			}
 		}
@@ -724,7 +931,20 @@ func (s *Session) executeBatch(batch *Batch) *Iter {
 		return &Iter{err: ErrTooManyStmts}
 	}
 
-	iter, err := s.executor.executeQuery(batch)
+	if batch.profileErr != nil {
+		return &Iter{err: batch.profileErr}
+	}
+	if batch.entryErr != nil {
+		return &Iter{err: batch.entryErr}
+	}
+	for i := range batch.Entries {
+		if err := s.checkReadOnly(batch.Entries[i].Stmt); err != nil {
+			return &Iter{err: err}
+		}
+	}
+
+	batch.metrics.resetHosts()
+	iter, err := s.execute(batch)
 	if err != nil {
 		return &Iter{err: err}
 	}
@@ -771,13 +991,15 @@ func (s *Session) MapExecuteBatchCAS(batch *Batch, dest map[string]interface{})
 		return false, nil, err
 	}
 	iter.MapScan(dest)
-	applied = dest["[applied]"].(bool)
-	delete(dest, "[applied]")
+	applied, err = casApplied(dest)
 
 	// we usually close here, but instead of closing, just returin an error
 	// if MapScan failed. Although Close just returns err, using Close
 	// here might be confusing as we are not actually closing the iter
-	return applied, iter, iter.err
+	if iter.err != nil {
+		return false, iter, iter.err
+	}
+	return applied, iter, err
 }
 
 type hostMetrics struct {
@@ -795,6 +1017,8 @@ type queryMetrics struct {
 	// totalAttempts is total number of attempts.
 	// Equal to sum of all hostMetrics' Attempts.
 	totalAttempts int
+	// hosts are the hosts of the attempts of the last execution, in order.
+	hosts []*HostInfo
 }
 
 // preFilledQueryMetrics initializes new queryMetrics based on per-host supplied data.
@@ -838,6 +1062,22 @@ func (qm *queryMetrics) attempts() int {
 	return attempts
 }
 
+// attemptedHosts returns the hosts of the attempts, in order.
+func (qm *queryMetrics) attemptedHosts() []*HostInfo {
+	qm.l.Lock()
+	hosts := make([]*HostInfo, len(qm.hosts))
+	copy(hosts, qm.hosts)
+	qm.l.Unlock()
+	return hosts
+}
+
+// resetHosts forgets the hosts of the attempts of the previous execution.
+func (qm *queryMetrics) resetHosts() {
+	qm.l.Lock()
+	qm.hosts = qm.hosts[:0]
+	qm.l.Unlock()
+}
+
 func (qm *queryMetrics) latency() int64 {
 	qm.l.Lock()
 	var (
@@ -865,6 +1105,10 @@ func (qm *queryMetrics) attempt(addAttempts int, addLatency time.Duration,
 	totalAttempts := qm.totalAttempts
 	qm.totalAttempts += addAttempts
 
+	for i := 0; i < addAttempts; i++ {
+		qm.hosts = append(qm.hosts, host)
+	}
+
 	updateHostMetrics := qm.hostMetricsLocked(host)
 	updateHostMetrics.Attempts += addAttempts
 	updateHostMetrics.TotalLatency += addLatency.Nanoseconds()
@@ -888,6 +1132,7 @@ type Query struct {
 	routingKey            []byte
 	pageState             []byte
 	prefetch              float64
+	prefetchPages         int
 	trace                 Tracer
 	observer              QueryObserver
 	session               *Session
@@ -895,18 +1140,53 @@ type Query struct {
 	rt                    RetryPolicy
 	spec                  SpeculativeExecutionPolicy
 	binding               func(q *QueryInfo) ([]interface{}, error)
+	namedValues           map[string]interface{}
 	serialCons            SerialConsistency
 	defaultTimestamp      bool
 	defaultTimestampValue int64
 	disableSkipMetadata   bool
 	context               context.Context
 	idempotent            bool
+	cached                bool
+	coalesce              bool
+	scanPrefix            bool
 	customPayload         map[string][]byte
+	tags                  map[string]string
+	logFields             []LogField
 	metrics               *queryMetrics
 	refCount              uint32
 
+	// timeout and policy are set by execution profiles.
+	timeout time.Duration
+	policy  HostSelectionPolicy
+	// profileErr is returned when executing the query if Profile failed.
+	profileErr error
+
+	// explicit are the settings which table defaults don't replace.
+	explicit querySettings
+
+	// connTimeout replaces the timeout of the connection if it is longer, for
+	// statements which are expected to take longer, see Session.Truncate.
+	connTimeout time.Duration
+	// ttlErr is returned when executing the query if WithTTL failed.
+	ttlErr error
+	// name is the name of the registered statement of the query and stmtErr
+	// is returned when executing the query if it is not registered, see
+	// Session.Stmt.
+	name    string
+	stmtErr error
+	// compression overrides the compression of the request, see Compression.
+	compression Compression
+	// hostID is the host the query is pinned to by SetHost.
+	hostID string
+
 	disableAutoPage bool
 
+	// maxRows limits the rows of all the pages of the query, fetchedRows
+	// counts the rows of the pages before the current one.
+	maxRows     int
+	fetchedRows int
+
 	// getKeyspace is field so that it can be overriden in tests
 	getKeyspace func() string
 
@@ -940,6 +1220,8 @@ func (q *Query) defaultsFromSession() {
 	q.serialCons = s.cfg.SerialConsistency
 	q.defaultTimestamp = s.cfg.DefaultTimestamp
 	q.idempotent = s.cfg.DefaultIdempotence
+	q.timeout = s.queryTimeout
+	q.maxRows = s.cfg.MaxRows
 	q.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
 
 	q.spec = &NonSpeculativeExecution{}
@@ -951,6 +1233,12 @@ func (q Query) Statement() string {
 	return q.stmt
 }
 
+// SetStatement replaces the statement of the query, for example to rewrite
+// it in a Middleware.
+func (q *Query) SetStatement(stmt string) {
+	q.stmt = stmt
+}
+
 // Values returns the values passed in via Bind.
 // This can be used by a wrapper type that needs to access the bound values.
 func (q Query) Values() []interface{} {
@@ -959,6 +1247,9 @@ func (q Query) Values() []interface{} {
 
 // String implements the stringer interface.
 func (q Query) String() string {
+	if len(q.tags) > 0 {
+		return fmt.Sprintf("[query statement=%q values=%+v consistency=%s tags=%v]", q.stmt, q.values, q.cons, q.tags)
+	}
 	return fmt.Sprintf("[query statement=%q values=%+v consistency=%s]", q.stmt, q.values, q.cons)
 }
 
@@ -967,6 +1258,13 @@ func (q *Query) Attempts() int {
 	return q.metrics.attempts()
 }
 
+// AttemptedHosts returns the host of every attempt of the last execution of
+// the query, in the order of the attempts. A host appears once per attempt,
+// so retries on the same host are repeated.
+func (q *Query) AttemptedHosts() []*HostInfo {
+	return q.metrics.attemptedHosts()
+}
+
 func (q *Query) AddAttempts(i int, host *HostInfo) {
 	q.metrics.attempt(i, 0, host, false)
 }
@@ -985,6 +1283,7 @@ func (q *Query) AddLatency(l int64, host *HostInfo) {
 // is used.
 func (q *Query) Consistency(c Consistency) *Query {
 	q.cons = c
+	q.explicit |= settingConsistency
 	return q
 }
 
@@ -1005,6 +1304,58 @@ func (q *Query) CustomPayload(customPayload map[string][]byte) *Query {
 	return q
 }
 
+// Tag attaches metadata to the query, for example the feature or tenant it is
+// executed for. Tags are passed to middleware, query observers and, if
+// ClusterConfig.TagPayloadPrefix is set, sent in the custom payload.
+func (q *Query) Tag(key, value string) *Query {
+	if q.tags == nil {
+		q.tags = make(map[string]string)
+	}
+	q.tags[key] = value
+	return q
+}
+
+// Tags returns the tags of the query. Do not modify the returned map.
+func (q *Query) Tags() map[string]string {
+	return q.tags
+}
+
+// LogField attaches a structured field to the messages logged about the query,
+// and passes it to query observers, see ContextWithLogFields.
+func (q *Query) LogField(key string, value interface{}) *Query {
+	q.logFields = append(q.logFields, LogField{Key: key, Value: value})
+	return q
+}
+
+// LogFields returns the log fields of the context of the query followed by the
+// fields attached with LogField.
+func (q *Query) LogFields() []LogField {
+	return logFields(q.context, q.logFields)
+}
+
+func (q *Query) requestPayload() map[string][]byte {
+	if q.session == nil {
+		return q.customPayload
+	}
+	return tagsPayload(q.customPayload, q.tags, q.session.cfg.TagPayloadPrefix)
+}
+
+// tagsPayload returns payload with tags added under the key prefix+tag. If
+// prefix is empty tags are not sent and payload is returned unchanged.
+func tagsPayload(payload map[string][]byte, tags map[string]string, prefix string) map[string][]byte {
+	if prefix == "" || len(tags) == 0 {
+		return payload
+	}
+	merged := make(map[string][]byte, len(payload)+len(tags))
+	for k, v := range tags {
+		merged[prefix+k] = []byte(v)
+	}
+	for k, v := range payload {
+		merged[k] = v
+	}
+	return merged
+}
+
 func (q *Query) Context() context.Context {
 	if q.context == nil {
 		return context.Background()
@@ -1032,6 +1383,16 @@ func (q *Query) Observer(observer QueryObserver) *Query {
 // available in Cassandra 2 and onwards.
 func (q *Query) PageSize(n int) *Query {
 	q.pageSize = n
+	q.explicit |= settingPageSize
+	return q
+}
+
+// MaxRows makes the iterator of the query fail with a *TooManyRowsError once
+// the pages fetched return more than n rows, so that a query selecting an
+// unexpectedly large partition doesn't exhaust the memory of the client. Zero
+// disables the limit. It replaces ClusterConfig.MaxRows.
+func (q *Query) MaxRows(n int) *Query {
+	q.maxRows = n
 	return q
 }
 
@@ -1058,6 +1419,27 @@ func (q *Query) WithTimestamp(timestamp int64) *Query {
 	return q
 }
 
+// WithTTL sets the time to live of the data written by an INSERT or UPDATE
+// statement by adding USING TTL to the statement or replacing the TTL of an
+// existing USING clause. d is rounded down to seconds, a zero TTL means the
+// data doesn't expire. Executing the query fails if the statement is not an
+// INSERT or UPDATE.
+func (q *Query) WithTTL(d time.Duration) *Query {
+	if d < 0 {
+		q.ttlErr = fmt.Errorf("gocql: negative TTL %v", d)
+		return q
+	}
+	stmt, err := stmtWithTTL(q.stmt, int(d/time.Second))
+	if err != nil {
+		q.ttlErr = err
+		return q
+	}
+	q.stmt = stmt
+	q.ttlErr = nil
+	q.explicit |= settingTTL
+	return q
+}
+
 // RoutingKey sets the routing key to use when a token aware connection
 // pool is used to optimize the routing of this query.
 func (q *Query) RoutingKey(routingKey []byte) *Query {
@@ -1097,16 +1479,22 @@ func (q *Query) attempt(keyspace string, end, start time.Time, iter *Iter, host
 
 	if q.observer != nil {
 		q.observer.ObserveQuery(q.Context(), ObservedQuery{
-			Keyspace:  keyspace,
-			Statement: q.stmt,
-			Values:    q.values,
-			Start:     start,
-			End:       end,
-			Rows:      iter.numRows,
-			Host:      host,
-			Metrics:   metricsForHost,
-			Err:       iter.err,
-			Attempt:   attempt,
+			Keyspace:      keyspace,
+			Statement:     q.stmt,
+			Values:        q.values,
+			Start:         start,
+			End:           end,
+			Rows:          iter.numRows,
+			PageBytes:     iter.pageBytes,
+			Host:          host,
+			Metrics:       metricsForHost,
+			Err:           iter.err,
+			Attempt:       attempt,
+			Tags:          q.tags,
+			LogFields:     q.LogFields(),
+			Warnings:      parseWarnings(iter.Warnings()),
+			StatementName: q.name,
+			Consistency:   q.cons,
 		})
 	}
 }
@@ -1129,7 +1517,7 @@ func (q *Query) Keyspace() string {
 	}
 	// TODO(chbannis): this should be parsed from the query or we should let
 	// this be set by users.
-	return q.session.cfg.Keyspace
+	return q.session.Keyspace()
 }
 
 // Table returns name of the table the query will be executed against.
@@ -1146,7 +1534,7 @@ func (q *Query) Table() string {
 func (q *Query) GetRoutingKey() ([]byte, error) {
 	if q.routingKey != nil {
 		return q.routingKey, nil
-	} else if q.binding != nil && len(q.values) == 0 {
+	} else if q.binding != nil && len(q.values) == 0 && q.namedValues == nil {
 		// If this query was created using session.Bind we wont have the query
 		// values yet, so we have to pass down to the next policy.
 		// TODO: Remove this and handle this case
@@ -1159,13 +1547,36 @@ func (q *Query) GetRoutingKey() ([]byte, error) {
 		return nil, err
 	}
 
-	if routingKeyInfo != nil {
-		q.routingInfo.mu.Lock()
-		q.routingInfo.keyspace = routingKeyInfo.keyspace
-		q.routingInfo.table = routingKeyInfo.table
-		q.routingInfo.mu.Unlock()
+	if routingKeyInfo == nil {
+		if q.namedValues != nil {
+			// the markers of the parsed statement are bound by position
+			return nil, nil
+		}
+		// the partition key may be restricted by literals, or the statement
+		// not be described by its prepared metadata, try to parse it
+		routingKey, table, err := q.session.parsedRoutingKey(q.stmt, q.Keyspace(), q.values)
+		if table != nil {
+			q.routingInfo.mu.Lock()
+			q.routingInfo.keyspace = table.Keyspace
+			q.routingInfo.table = table.Name
+			q.routingInfo.mu.Unlock()
+		}
+		return routingKey, err
+	}
+
+	q.routingInfo.mu.Lock()
+	q.routingInfo.keyspace = routingKeyInfo.keyspace
+	q.routingInfo.table = routingKeyInfo.table
+	q.routingInfo.mu.Unlock()
+	values := q.values
+	if q.namedValues != nil {
+		var ok bool
+		if values, ok = namedRoutingValues(routingKeyInfo, q.namedValues); !ok {
+			// executing the query fails for the missing value
+			return nil, nil
+		}
 	}
-	return createRoutingKey(routingKeyInfo, q.values)
+	return createRoutingKey(routingKeyInfo, values)
 }
 
 func (q *Query) shouldPrepare() bool {
@@ -1198,12 +1609,85 @@ func (q *Query) Prefetch(p float64) *Query {
 	return q
 }
 
+// PrefetchPages sets how many pages are fetched ahead once the prefetch
+// threshold of the current page is reached. Pages are still fetched one after
+// the other, each one needs the paging state of the previous one, but without
+// waiting for the rows to be read, which helps sequential scans over high
+// latency links. Buffered pages are held in memory.
+// Default: 1
+func (q *Query) PrefetchPages(n int) *Query {
+	q.prefetchPages = n
+	return q
+}
+
 // RetryPolicy sets the policy to use when retrying the query.
 func (q *Query) RetryPolicy(r RetryPolicy) *Query {
 	q.rt = r
 	return q
 }
 
+// Profile applies the settings of the execution profile with the given name,
+// see ClusterConfig.ExecutionProfiles. Settings applied after Profile override
+// the profile. Executing the query fails with ErrNoProfile if the profile
+// does not exist.
+func (q *Query) Profile(name string) *Query {
+	profile, err := q.session.profile(name)
+	if err != nil {
+		q.profileErr = err
+		return q
+	}
+
+	if profile.Consistency != Any {
+		q.cons = profile.Consistency
+		q.explicit |= settingConsistency
+	}
+	if profile.SerialConsistency != 0 {
+		q.serialCons = profile.SerialConsistency
+	}
+	if profile.RetryPolicy != nil {
+		q.rt = profile.RetryPolicy
+	}
+	if profile.PageSize > 0 {
+		q.pageSize = profile.PageSize
+		q.explicit |= settingPageSize
+	}
+	if profile.Timeout > 0 {
+		q.timeout = profile.Timeout
+	}
+	q.policy = profile.HostSelectionPolicy
+	q.profileErr = nil
+	return q
+}
+
+func (q *Query) queryTimeout() time.Duration {
+	return q.timeout
+}
+
+func (q *Query) hostSelectionPolicy() HostSelectionPolicy {
+	return q.policy
+}
+
+// SetHost pins the query to the host with the given host ID, see
+// HostInfo.HostID, instead of the hosts picked by the host selection policy.
+// The query and its further pages are only executed on that host, it is
+// retried on the same host but never on another one. This is needed to query
+// node-local tables such as system_views.clients. An empty hostID unpins the
+// query.
+func (q *Query) SetHost(hostID string) *Query {
+	q.hostID = hostID
+	return q
+}
+
+// HostID returns the host ID the query is pinned to by SetHost, an empty
+// string if it isn't pinned.
+func (q *Query) HostID() string {
+	return q.hostID
+}
+
+func (q *Query) targetHostID() string {
+	return q.hostID
+}
+
 // SetSpeculativeExecutionPolicy sets the execution policy
 func (q *Query) SetSpeculativeExecutionPolicy(sp SpeculativeExecutionPolicy) *Query {
 	q.spec = sp
@@ -1216,7 +1700,8 @@ func (q *Query) speculativeExecutionPolicy() SpeculativeExecutionPolicy {
 }
 
 // IsIdempotent returns whether the query is marked as idempotent.
-// Non-idempotent query won't be retried.
+// Non-idempotent query won't be retried after errors which leave unknown
+// whether it was applied, such as write timeouts.
 // See "Retries and speculative execution" in package docs for more details.
 func (q *Query) IsIdempotent() bool {
 	return q.idempotent
@@ -1224,10 +1709,39 @@ func (q *Query) IsIdempotent() bool {
 
 // Idempotent marks the query as being idempotent or not depending on
 // the value.
-// Non-idempotent query won't be retried.
+// Non-idempotent query won't be retried after errors which leave unknown
+// whether it was applied, such as write timeouts.
 // See "Retries and speculative execution" in package docs for more details.
 func (q *Query) Idempotent(value bool) *Query {
 	q.idempotent = value
+	q.explicit |= settingIdempotent
+	return q
+}
+
+// Cached sets whether the rows of the query are served from the read cache of
+// the session, see ClusterConfig.ReadCache. Cached rows can be stale, use it
+// for data that rarely changes.
+func (q *Query) Cached(value bool) *Query {
+	q.cached = value
+	return q
+}
+
+// Coalesce sets whether the query shares the request of an identical query
+// already in flight, with the same statement, values, consistency and page
+// size, instead of sending its own. It protects hot partitions from bursts of
+// identical reads, such as when a cached value expires for many clients at
+// once. Only results fitting a single page are shared, and observers only see
+// the request which was sent. Use it for reads only.
+func (q *Query) Coalesce(value bool) *Query {
+	q.coalesce = value
+	return q
+}
+
+// ScanPrefix sets whether rows can be scanned into fewer destinations than
+// the selected columns, in which case the columns after the destinations are
+// skipped. By default a mismatch is reported with a *ScanCountError.
+func (q *Query) ScanPrefix(value bool) *Query {
+	q.scanPrefix = value
 	return q
 }
 
@@ -1271,7 +1785,16 @@ func (q *Query) NoSkipMetadata() *Query {
 	return q
 }
 
-// Exec executes the query without returning any rows.
+// Compression overrides whether the requests of the query are compressed, see
+// Compression.
+func (q *Query) Compression(c Compression) *Query {
+	q.compression = c
+	return q
+}
+
+// Exec executes the query without returning any rows. Whether a conditional
+// statement was applied is not reported, use ExecCAS for lightweight
+// transactions.
 func (q *Query) Exec() error {
 	return q.Iter().Close()
 }
@@ -1290,12 +1813,26 @@ func (q *Query) Iter() *Iter {
 	if isUseStatement(q.stmt) {
 		return &Iter{err: ErrUseStmt}
 	}
+	if q.profileErr != nil {
+		return &Iter{err: q.profileErr}
+	}
+	if q.ttlErr != nil {
+		return &Iter{err: q.ttlErr}
+	}
+	if q.stmtErr != nil {
+		return &Iter{err: q.stmtErr}
+	}
+	q.metrics.resetHosts()
 	// if the query was specifically run on a connection then re-use that
 	// connection when fetching the next results
+	var iter *Iter
 	if q.conn != nil {
-		return q.conn.executeQuery(q.Context(), q)
+		iter = q.conn.executeQuery(q.Context(), q)
+	} else {
+		iter = q.session.executeQuery(q)
 	}
-	return q.session.executeQuery(q)
+	iter.scanPrefix = q.scanPrefix
+	return iter
 }
 
 // MapScan executes the query, copies the columns of the first selected
@@ -1360,10 +1897,69 @@ func (q *Query) MapScanCAS(dest map[string]interface{}) (applied bool, err error
 		return false, err
 	}
 	iter.MapScan(dest)
-	applied = dest["[applied]"].(bool)
-	delete(dest, "[applied]")
+	applied, err = casApplied(dest)
+	if closeErr := iter.Close(); closeErr != nil {
+		return false, closeErr
+	}
+	return applied, err
+}
+
+// ExecResult is the result of a statement executed with Query.ExecCAS.
+// Cassandra doesn't report the number of rows changed by a statement.
+type ExecResult struct {
+	// Conditional is set if the statement is a lightweight transaction,
+	// i.e. the result has an [applied] column.
+	Conditional bool
+	// Applied reports whether a conditional statement was applied. It is
+	// always true for unconditional statements.
+	Applied bool
+	// Previous holds the existing values of the row which didn't match the
+	// condition of a statement which was not applied, keyed by column name.
+	Previous map[string]interface{}
+}
+
+// ExecCAS executes the query and reports whether it was applied. Unlike Exec,
+// which ignores the result, a lightweight transaction such as an
+// INSERT .. IF NOT EXISTS which was not applied is reported with Applied
+// false. The iterator of the query is always closed.
+func (q *Query) ExecCAS() (ExecResult, error) {
+	q.disableSkipMetadata = true
+	iter := q.Iter()
+	if iter.err != nil {
+		return ExecResult{}, iter.Close()
+	}
 
-	return applied, iter.Close()
+	res := ExecResult{Applied: true}
+	cols := iter.Columns()
+	if len(cols) == 0 || cols[0].Name != "[applied]" {
+		return res, iter.Close()
+	}
+
+	row := make(map[string]interface{}, len(cols))
+	if !iter.MapScan(row) {
+		if err := iter.Close(); err != nil {
+			return ExecResult{}, err
+		}
+		return ExecResult{}, ErrNotFound
+	}
+	res.Conditional = true
+	res.Applied, _ = casApplied(row)
+	if !res.Applied {
+		res.Previous = row
+	}
+	return res, iter.Close()
+}
+
+// casApplied removes the [applied] column of a lightweight transaction from
+// dest and returns its value.
+func casApplied(dest map[string]interface{}) (bool, error) {
+	v, ok := dest["[applied]"]
+	if !ok {
+		return false, ErrNotLWT
+	}
+	delete(dest, "[applied]")
+	applied, _ := v.(bool)
+	return applied, nil
 }
 
 // Release releases a query back into a pool of queries. Released Queries
@@ -1378,6 +1974,56 @@ func (q *Query) Release() {
 	q.decRefCount()
 }
 
+// Clone returns a copy of the query which can be customized and executed
+// independently of q. Setters modify the query they are called on, so a base
+// query shared between goroutines must be cloned before being customized:
+//
+//	base := session.Query(`SELECT name FROM users WHERE id = ?`).Consistency(gocql.One)
+//	...
+//	err := base.Clone().Bind(id).WithContext(ctx).Scan(&name)
+//
+// The bound values themselves are not copied. Clone must not be called
+// concurrently with setters of q.
+func (q *Query) Clone() *Query {
+	c := queryPool.Get().(*Query)
+	routingInfo := c.routingInfo
+	*c = *q
+	c.refCount = 1
+
+	if q.values != nil {
+		c.values = append([]interface{}(nil), q.values...)
+	}
+	if q.routingKey != nil {
+		c.routingKey = copyBytes(q.routingKey)
+	}
+	if q.pageState != nil {
+		c.pageState = copyBytes(q.pageState)
+	}
+	if q.customPayload != nil {
+		c.customPayload = make(map[string][]byte, len(q.customPayload))
+		for k, v := range q.customPayload {
+			c.customPayload[k] = v
+		}
+	}
+	if q.tags != nil {
+		c.tags = make(map[string]string, len(q.tags))
+		for k, v := range q.tags {
+			c.tags[k] = v
+		}
+	}
+	if q.logFields != nil {
+		c.logFields = append([]LogField(nil), q.logFields...)
+	}
+	c.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
+
+	q.routingInfo.mu.RLock()
+	routingInfo.keyspace = q.routingInfo.keyspace
+	routingInfo.table = q.routingInfo.table
+	q.routingInfo.mu.RUnlock()
+	c.routingInfo = routingInfo
+	return c
+}
+
 // reset zeroes out all fields of a query so that it can be safely pooled.
 func (q *Query) reset() {
 	*q = Query{routingInfo: &queryRoutingInfo{}, refCount: 1}
@@ -1414,8 +2060,21 @@ type Iter struct {
 	next    *nextIter
 	host    *HostInfo
 
+	// pageBytes is the size of the body of the response frame of the page.
+	pageBytes int
+
 	framer *framer
 	closed int32
+
+	// scanPrefix is set by Query.ScanPrefix.
+	scanPrefix bool
+
+	// transformers are the transformers of the columns, nil if no column is
+	// transformed.
+	transformers []ColumnTransformer
+
+	// structScan are the fields of the struct of the last ScanStruct.
+	structScan *structScan
 }
 
 // Host returns the host which the query was sent to.
@@ -1448,9 +2107,10 @@ type Scanner interface {
 }
 
 type iterScanner struct {
-	iter  *Iter
-	cols  [][]byte
-	valid bool
+	iter       *Iter
+	cols       [][]byte
+	valid      bool
+	scanPrefix bool
 }
 
 func (is *iterScanner) Next() bool {
@@ -1513,17 +2173,26 @@ func (is *iterScanner) Scan(dest ...interface{}) error {
 	iter := is.iter
 	// currently only support scanning into an expand tuple, such that its the same
 	// as scanning in more values from a single column
-	if len(dest) != iter.meta.actualColCount {
-		return fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), iter.meta.actualColCount)
+	if err := checkScanDest(iter.meta.columns, iter.meta.actualColCount, len(dest), is.scanPrefix); err != nil {
+		return err
 	}
 
 	// i is the current position in dest, could posible replace it and just use
 	// slices of dest
 	i := 0
 	var err error
-	for _, col := range iter.meta.columns {
-		var n int
-		n, err = scanColumn(is.cols[i], col, dest[i:])
+	for c, col := range iter.meta.columns {
+		if i >= len(dest) {
+			break
+		}
+		var (
+			n int
+			p []byte
+		)
+		if p, err = iter.decodeColumn(c, is.cols[c]); err != nil {
+			break
+		}
+		n, err = scanColumn(p, col, dest[i:])
 		if err != nil {
 			break
 		}
@@ -1549,7 +2218,72 @@ func (iter *Iter) Scanner() Scanner {
 		return nil
 	}
 
-	return &iterScanner{iter: iter, cols: make([][]byte, len(iter.meta.columns))}
+	return &iterScanner{iter: iter, cols: make([][]byte, len(iter.meta.columns)), scanPrefix: iter.scanPrefix}
+}
+
+// ScanCountError is returned when rows are scanned into a number of
+// destinations which doesn't match the selected columns. The elements of a
+// tuple column are scanned into a destination each.
+type ScanCountError struct {
+	// Have is the number of destinations and Want the number of destinations
+	// the columns are scanned into.
+	Have, Want int
+	// Unmatched are the columns with no destination if there are fewer
+	// destinations than columns.
+	Unmatched []ColumnInfo
+	// SplitTuple is set if a prefix scan ends in the middle of a tuple.
+	SplitTuple bool
+}
+
+func (e *ScanCountError) Error() string {
+	var b strings.Builder
+	if e.Have < e.Want {
+		fmt.Fprintf(&b, "gocql: not enough columns to scan into: have %d want %d", e.Have, e.Want)
+	} else {
+		fmt.Fprintf(&b, "gocql: too many columns to scan into: have %d want %d", e.Have, e.Want)
+	}
+	if e.SplitTuple {
+		b.WriteString(", the last destinations don't cover all the elements of a tuple")
+	}
+	for i, col := range e.Unmatched {
+		if i == 0 {
+			b.WriteString(", unmatched columns: ")
+		} else {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s %v", col.Name, col.TypeInfo)
+	}
+	return b.String()
+}
+
+// checkScanDest returns a *ScanCountError if have destinations can't be
+// scanned into, want is the number of destinations of all columns. With
+// prefix, fewer destinations are accepted if they end with a column.
+func checkScanDest(columns []ColumnInfo, want, have int, prefix bool) error {
+	if have == want {
+		return nil
+	}
+
+	err := &ScanCountError{Have: have, Want: want}
+	if have < want {
+		i := 0
+		for _, col := range columns {
+			n := 1
+			if tuple, ok := col.TypeInfo.(TupleTypeInfo); ok {
+				n = len(tuple.Elems)
+			}
+			if i >= have {
+				err.Unmatched = append(err.Unmatched, col)
+			} else if i+n > have {
+				err.SplitTuple = true
+			}
+			i += n
+		}
+		if prefix && !err.SplitTuple {
+			return nil
+		}
+	}
+	return err
 }
 
 func (iter *Iter) readColumn() ([]byte, error) {
@@ -1570,33 +2304,44 @@ func (iter *Iter) Scan(dest ...interface{}) bool {
 	}
 
 	if iter.pos >= iter.numRows {
-		if iter.next != nil {
-			*iter = *iter.next.fetch()
+		if iter.next != nil && iter.switchPage() {
 			return iter.Scan(dest...)
 		}
 		return false
 	}
 
 	if iter.next != nil && iter.pos >= iter.next.pos {
-		iter.next.fetchAsync()
+		pages := iter.next.qry.prefetchPages
+		if pages < 1 {
+			pages = 1
+		}
+		iter.next.prefetch(pages)
 	}
 
 	// currently only support scanning into an expand tuple, such that its the same
 	// as scanning in more values from a single column
-	if len(dest) != iter.meta.actualColCount {
-		iter.err = fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), iter.meta.actualColCount)
+	if err := checkScanDest(iter.meta.columns, iter.meta.actualColCount, len(dest), iter.scanPrefix); err != nil {
+		iter.err = err
 		return false
 	}
 
 	// i is the current position in dest, could posible replace it and just use
 	// slices of dest
 	i := 0
-	for _, col := range iter.meta.columns {
+	for c, col := range iter.meta.columns {
 		colBytes, err := iter.readColumn()
 		if err != nil {
 			iter.err = err
 			return false
 		}
+		if i >= len(dest) {
+			// the columns after a scanned prefix are skipped
+			continue
+		}
+		if colBytes, err = iter.decodeColumn(c, colBytes); err != nil {
+			iter.err = err
+			return false
+		}
 
 		n, err := scanColumn(colBytes, col, dest[i:])
 		if err != nil {
@@ -1610,6 +2355,35 @@ func (iter *Iter) Scan(dest ...interface{}) bool {
 	return true
 }
 
+// switchPage moves the iterator to the next page, waiting for it to be fetched.
+// If fetching the page failed the iterator reports the error once the rows of
+// the previous pages were consumed, keeping their metadata.
+func (iter *Iter) switchPage() bool {
+	if atomic.LoadInt32(&iter.closed) == 1 {
+		return false
+	}
+
+	next := iter.next.fetch()
+	if next.err != nil {
+		iter.err = next.err
+		iter.next = nil
+		iter.framer = nil
+		if next.host != nil {
+			iter.host = next.host
+		}
+		return false
+	}
+
+	iter.pos = next.pos
+	iter.meta = next.meta
+	iter.numRows = next.numRows
+	iter.next = next.next
+	iter.host = next.host
+	iter.framer = next.framer
+	iter.pageBytes = next.pageBytes
+	return true
+}
+
 // GetCustomPayload returns any parsed custom payload results if given in the
 // response from Cassandra. Note that the result is not a copy.
 //
@@ -1641,6 +2415,9 @@ func (iter *Iter) Close() error {
 		if iter.framer != nil {
 			iter.framer = nil
 		}
+		if iter.next != nil {
+			iter.next.wait()
+		}
 	}
 
 	return iter.err
@@ -1675,6 +2452,13 @@ func (iter *Iter) NumRows() int {
 	return iter.numRows
 }
 
+// PageBytes returns the size in bytes of the body of the response frame of
+// the current page, as received and so after compression. Like NumRows it is
+// known before the rows are scanned, to size the buffers receiving them.
+func (iter *Iter) PageBytes() int {
+	return iter.pageBytes
+}
+
 // nextIter holds state for fetching a single page in an iterator.
 // single page might be attempted multiple times due to retries.
 type nextIter struct {
@@ -1683,12 +2467,80 @@ type nextIter struct {
 	oncea sync.Once
 	once  sync.Once
 	next  *Iter
+
+	// depth is the number of pages to prefetch starting with this one and
+	// done is set once the page was fetched, both accessed atomically.
+	depth int32
+	done  int32
+	// running is closed once the background fetch of the page and the
+	// prefetches it started are done, it is set by the first call of oncea.
+	running chan struct{}
 }
 
-func (n *nextIter) fetchAsync() {
+// prefetch fetches the page in the background and, once it arrived, the pages
+// after it, until depth pages are fetched ahead.
+func (n *nextIter) prefetch(depth int) {
+	for {
+		cur := atomic.LoadInt32(&n.depth)
+		if int32(depth) <= cur || atomic.CompareAndSwapInt32(&n.depth, cur, int32(depth)) {
+			break
+		}
+	}
+
+	// the page is fetched already, continue with the next one
+	if atomic.LoadInt32(&n.done) == 1 {
+		n.prefetchNext()
+		return
+	}
+
+	var sem chan struct{}
+	if n.qry.session != nil {
+		sem = n.qry.session.prefetchSem
+	}
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		default:
+			// the page is fetched once it is needed
+			return
+		}
+	}
+	started := false
 	n.oncea.Do(func() {
-		go n.fetch()
+		started = true
+		n.running = make(chan struct{})
+		go func() {
+			defer close(n.running)
+			n.fetch()
+			if sem != nil {
+				<-sem
+			}
+			n.prefetchNext()
+		}()
 	})
+	if !started && sem != nil {
+		<-sem
+	}
+}
+
+func (n *nextIter) prefetchNext() {
+	if depth := atomic.LoadInt32(&n.depth); depth > 1 && n.next.next != nil {
+		n.next.next.prefetch(int(depth) - 1)
+	}
+}
+
+// wait stops the page from being prefetched and waits for a background fetch
+// in flight, then closes the prefetched page, waiting for the pages after it.
+func (n *nextIter) wait() {
+	// once oncea is done running is set if the page is fetched in the
+	// background, and no fetch can be started anymore.
+	n.oncea.Do(func() {})
+	if n.running != nil {
+		<-n.running
+	}
+	if atomic.LoadInt32(&n.done) == 1 {
+		n.next.Close()
+	}
 }
 
 func (n *nextIter) fetch() *Iter {
@@ -1700,6 +2552,10 @@ func (n *nextIter) fetch() *Iter {
 		} else {
 			n.next = n.qry.session.executeQuery(n.qry)
 		}
+		if n.qry.session != nil {
+			n.qry.session.stats.page()
+		}
+		atomic.StoreInt32(&n.done, 1)
 	})
 	return n.next
 }
@@ -1710,6 +2566,8 @@ type Batch struct {
 	Cons                  Consistency
 	routingKey            []byte
 	CustomPayload         map[string][]byte
+	tags                  map[string]string
+	logFields             []LogField
 	rt                    RetryPolicy
 	spec                  SpeculativeExecutionPolicy
 	trace                 Tracer
@@ -1723,6 +2581,18 @@ type Batch struct {
 	keyspace              string
 	metrics               *queryMetrics
 
+	// timeout and policy are set by execution profiles.
+	timeout time.Duration
+	policy  HostSelectionPolicy
+	// profileErr is returned when executing the batch if Profile failed.
+	profileErr error
+	// entryErr is returned when executing the batch if AddStruct failed.
+	entryErr error
+	// compression overrides the compression of the request, see Compression.
+	compression Compression
+	// idempotent overrides the idempotence of the entries, see Idempotent.
+	idempotent *bool
+
 	// routingInfo is a pointer because Query can be copied and copyable struct can't hold a mutex.
 	routingInfo *queryRoutingInfo
 }
@@ -1748,10 +2618,11 @@ func (s *Session) NewBatch(typ BatchType) *Batch {
 		serialCons:       s.cfg.SerialConsistency,
 		trace:            s.trace,
 		observer:         s.batchObserver,
+		timeout:          s.queryTimeout,
 		session:          s,
 		Cons:             s.cons,
 		defaultTimestamp: s.cfg.DefaultTimestamp,
-		keyspace:         s.cfg.Keyspace,
+		keyspace:         s.keyspace,
 		metrics:          &queryMetrics{m: make(map[string]*hostMetrics)},
 		spec:             &NonSpeculativeExecution{},
 		routingInfo:      &queryRoutingInfo{},
@@ -1789,6 +2660,12 @@ func (b *Batch) Attempts() int {
 	return b.metrics.attempts()
 }
 
+// AttemptedHosts returns the host of every attempt of the last execution of
+// the batch, in the order of the attempts.
+func (b *Batch) AttemptedHosts() []*HostInfo {
+	return b.metrics.attemptedHosts()
+}
+
 func (b *Batch) AddAttempts(i int, host *HostInfo) {
 	b.metrics.attempt(i, 0, host, false)
 }
@@ -1821,7 +2698,12 @@ func (b *Batch) Context() context.Context {
 	return b.context
 }
 
+// IsIdempotent returns whether the batch is marked as idempotent, by
+// Idempotent or else by marking all its entries idempotent.
 func (b *Batch) IsIdempotent() bool {
+	if b.idempotent != nil {
+		return *b.idempotent
+	}
 	for _, entry := range b.Entries {
 		if !entry.Idempotent {
 			return false
@@ -1839,6 +2721,20 @@ func (b *Batch) SpeculativeExecutionPolicy(sp SpeculativeExecutionPolicy) *Batch
 	return b
 }
 
+// Compression overrides whether the request of the batch is compressed, see
+// Compression.
+func (b *Batch) Compression(c Compression) *Batch {
+	b.compression = c
+	return b
+}
+
+// Idempotent marks the batch as being idempotent or not, whatever the
+// idempotence of its entries, see Query.Idempotent.
+func (b *Batch) Idempotent(value bool) *Batch {
+	b.idempotent = &value
+	return b
+}
+
 // Query adds the query to the batch operation
 func (b *Batch) Query(stmt string, args ...interface{}) {
 	b.Entries = append(b.Entries, BatchEntry{Stmt: stmt, Args: args})
@@ -1861,6 +2757,46 @@ func (b *Batch) RetryPolicy(r RetryPolicy) *Batch {
 	return b
 }
 
+// Profile applies the settings of the execution profile with the given name,
+// see ClusterConfig.ExecutionProfiles. The page size of the profile is ignored.
+// Settings applied after Profile override the profile. Executing the batch
+// fails with ErrNoProfile if the profile does not exist.
+func (b *Batch) Profile(name string) *Batch {
+	profile, err := b.session.profile(name)
+	if err != nil {
+		b.profileErr = err
+		return b
+	}
+
+	if profile.Consistency != Any {
+		b.Cons = profile.Consistency
+	}
+	if profile.SerialConsistency != 0 {
+		b.serialCons = profile.SerialConsistency
+	}
+	if profile.RetryPolicy != nil {
+		b.rt = profile.RetryPolicy
+	}
+	if profile.Timeout > 0 {
+		b.timeout = profile.Timeout
+	}
+	b.policy = profile.HostSelectionPolicy
+	b.profileErr = nil
+	return b
+}
+
+func (b *Batch) queryTimeout() time.Duration {
+	return b.timeout
+}
+
+func (b *Batch) hostSelectionPolicy() HostSelectionPolicy {
+	return b.policy
+}
+
+func (b *Batch) targetHostID() string {
+	return ""
+}
+
 func (b *Batch) withContext(ctx context.Context) ExecutableQuery {
 	return b.WithContext(ctx)
 }
@@ -1922,6 +2858,38 @@ func (b *Batch) WithTimestamp(timestamp int64) *Batch {
 	return b
 }
 
+// Tag attaches metadata to the batch, see Query.Tag.
+func (b *Batch) Tag(key, value string) *Batch {
+	if b.tags == nil {
+		b.tags = make(map[string]string)
+	}
+	b.tags[key] = value
+	return b
+}
+
+// Tags returns the tags of the batch. Do not modify the returned map.
+func (b *Batch) Tags() map[string]string {
+	return b.tags
+}
+
+// LogField attaches a structured field to the batch, see Query.LogField.
+func (b *Batch) LogField(key string, value interface{}) *Batch {
+	b.logFields = append(b.logFields, LogField{Key: key, Value: value})
+	return b
+}
+
+// LogFields returns the log fields of the batch, see Query.LogFields.
+func (b *Batch) LogFields() []LogField {
+	return logFields(b.context, b.logFields)
+}
+
+func (b *Batch) requestPayload() map[string][]byte {
+	if b.session == nil {
+		return b.CustomPayload
+	}
+	return tagsPayload(b.CustomPayload, b.tags, b.session.cfg.TagPayloadPrefix)
+}
+
 func (b *Batch) attempt(keyspace string, end, start time.Time, iter *Iter, host *HostInfo) {
 	latency := end.Sub(start)
 	attempt, metricsForHost := b.metrics.attempt(1, latency, host, b.observer != nil)
@@ -1945,10 +2913,14 @@ func (b *Batch) attempt(keyspace string, end, start time.Time, iter *Iter, host
 		Start:      start,
 		End:        end,
 		// Rows not used in batch observations // TODO - might be able to support it when using BatchCAS
-		Host:    host,
-		Metrics: metricsForHost,
-		Err:     iter.err,
-		Attempt: attempt,
+		Host:        host,
+		Metrics:     metricsForHost,
+		Err:         iter.err,
+		Attempt:     attempt,
+		Tags:        b.tags,
+		LogFields:   b.LogFields(),
+		Warnings:    parseWarnings(iter.Warnings()),
+		Consistency: b.Cons,
 	})
 }
 
@@ -1970,6 +2942,9 @@ func (b *Batch) GetRoutingKey() ([]byte, error) {
 	routingKeyInfo, err := b.session.routingKeyInfo(b.Context(), entry.Stmt)
 	if err != nil {
 		return nil, err
+	} else if routingKeyInfo == nil {
+		routingKey, _, err := b.session.parsedRoutingKey(entry.Stmt, b.Keyspace(), entry.Args)
+		return routingKey, err
 	}
 
 	return createRoutingKey(routingKeyInfo, entry.Args)
@@ -2055,8 +3030,10 @@ type routingKeyInfoLRU struct {
 }
 
 type routingKeyInfo struct {
-	indexes  []int
-	types    []TypeInfo
+	indexes []int
+	types   []TypeInfo
+	// names are the names of the bind markers of the routing key columns.
+	names    []string
 	keyspace string
 	table    string
 }
@@ -2104,52 +3081,36 @@ type traceWriter struct {
 }
 
 // NewTraceWriter returns a simple Tracer implementation that outputs
-// the event log in a textual format.
+// the event log in a textual format. Traces are read in the background
+// by a TraceCollector.
 func NewTraceWriter(session *Session, w io.Writer) Tracer {
-	return &traceWriter{session: session, w: w}
+	t := &traceWriter{session: session, w: w}
+	return NewTraceCollector(session, TraceCollectorConfig{}, t.write)
 }
 
 func (t *traceWriter) Trace(traceId []byte) {
-	var (
-		coordinator string
-		duration    int
-	)
-	iter := t.session.control.query(`SELECT coordinator, duration
-			FROM system_traces.sessions
-			WHERE session_id = ?`, traceId)
+	t.write(t.session.readTrace(traceId))
+}
+
+func (t *traceWriter) write(trace *TraceSession, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	iter.Scan(&coordinator, &duration)
-	if err := iter.Close(); err != nil {
-		t.mu.Lock()
+	if err != nil && len(trace.Events) == 0 {
 		fmt.Fprintln(t.w, "Error:", err)
-		t.mu.Unlock()
 		return
 	}
 
-	var (
-		timestamp time.Time
-		activity  string
-		source    string
-		elapsed   int
-		thread    string
-	)
-
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	fmt.Fprintf(t.w, "Tracing session %016x (coordinator: %s, duration: %v):\n",
-		traceId, coordinator, time.Duration(duration)*time.Microsecond)
-
-	iter = t.session.control.query(`SELECT event_id, activity, source, source_elapsed, thread
-			FROM system_traces.events
-			WHERE session_id = ?`, traceId)
+		trace.ID, trace.Coordinator, trace.Duration)
 
-	for iter.Scan(&timestamp, &activity, &source, &elapsed, &thread) {
+	for _, event := range trace.Events {
 		fmt.Fprintf(t.w, "%s: %s [%s] (source: %s, elapsed: %d)\n",
-			timestamp.Format("2006/01/02 15:04:05.999999"), activity, thread, source, elapsed)
+			event.Time.Format("2006/01/02 15:04:05.999999"), event.Activity, event.Thread, event.Source,
+			event.SourceElapsed/time.Microsecond)
 	}
 
-	if err := iter.Close(); err != nil {
+	if err != nil {
 		fmt.Fprintln(t.w, "Error:", err)
 	}
 }
@@ -2169,6 +3130,9 @@ type ObservedQuery struct {
 	// In paginated queries, rows from previous scans are not counted.
 	// Rows is not used in batch queries and remains at the default value
 	Rows int
+	// PageBytes is the size in bytes of the body of the response frame of the
+	// page, as received and so after compression, see Iter.PageBytes.
+	PageBytes int
 
 	// Host is the informations about the host that performed the query
 	Host *HostInfo
@@ -2183,6 +3147,26 @@ type ObservedQuery struct {
 	// Attempt is the index of attempt at executing this query.
 	// The first attempt is number zero and any retries have non-zero attempt number.
 	Attempt int
+
+	// Tags are the tags of the query, see Query.Tag.
+	// Do not modify the tags here, they are shared with multiple goroutines.
+	Tags map[string]string
+
+	// LogFields are the log fields of the query, see Query.LogFields.
+	LogFields []LogField
+
+	// Warnings are the warnings of the server about the query, such as
+	// tombstones scanned, see ParseWarning.
+	Warnings []QueryWarning
+
+	// StatementName is the name of the registered statement of the query, see
+	// Session.Stmt, or empty.
+	StatementName string
+
+	// Consistency is the consistency level of the attempt, which retry
+	// policies such as DowngradingConsistencyRetryPolicy may lower for the
+	// next attempts.
+	Consistency Consistency
 }
 
 // QueryObserver is the interface implemented by query observers / stat collectors.
@@ -2220,6 +3204,21 @@ type ObservedBatch struct {
 	// Attempt is the index of attempt at executing this query.
 	// The first attempt is number zero and any retries have non-zero attempt number.
 	Attempt int
+
+	// Tags are the tags of the batch, see Batch.Tag.
+	// Do not modify the tags here, they are shared with multiple goroutines.
+	Tags map[string]string
+
+	// LogFields are the log fields of the batch, see Batch.LogFields.
+	LogFields []LogField
+
+	// Warnings are the warnings of the server about the batch, such as its
+	// size exceeding the threshold of the server, see ParseWarning.
+	Warnings []QueryWarning
+
+	// Consistency is the consistency level of the attempt, see
+	// ObservedQuery.Consistency.
+	Consistency Consistency
 }
 
 // BatchObserver is the interface implemented by batch observers / stat collectors.
@@ -2269,6 +3268,7 @@ var (
 	ErrNoKeyspace           = errors.New("no keyspace provided")
 	ErrKeyspaceDoesNotExist = errors.New("keyspace does not exist")
 	ErrNoMetadata           = errors.New("no metadata available")
+	ErrNotLWT               = errors.New("gocql: result has no [applied] column, statement is not a lightweight transaction")
 )
 
 type ErrProtocol struct{ error }
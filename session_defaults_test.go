@@ -0,0 +1,143 @@
+package gocql
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopQueryObserver struct{}
+
+func (nopQueryObserver) ObserveQuery(context.Context, ObservedQuery) {}
+
+type nopBatchObserver struct{}
+
+func (nopBatchObserver) ObserveBatch(context.Context, ObservedBatch) {}
+
+func TestSessionQueryDefaults(t *testing.T) {
+	rt := &SimpleRetryPolicy{NumRetries: 3}
+	s := &Session{cons: Quorum}
+	s.SetSerialConsistency(LocalSerial)
+	s.SetRetryPolicy(rt)
+	s.SetIdempotence(true)
+	s.SetQueryTimeout(time.Second)
+	s.SetQueryObserver(nopQueryObserver{})
+	s.SetBatchObserver(nopBatchObserver{})
+
+	qry := s.Query("SELECT * FROM events")
+	if qry.serialCons != LocalSerial {
+		t.Errorf("expected serial consistency %v, got %v", LocalSerial, qry.serialCons)
+	}
+	if qry.rt != rt {
+		t.Errorf("expected retry policy %v, got %v", rt, qry.rt)
+	}
+	if !qry.IsIdempotent() {
+		t.Error("expected query to be idempotent")
+	}
+	if qry.queryTimeout() != time.Second {
+		t.Errorf("expected timeout %v, got %v", time.Second, qry.queryTimeout())
+	}
+	if qry.observer != (nopQueryObserver{}) {
+		t.Errorf("expected query observer, got %v", qry.observer)
+	}
+
+	qry = s.Query("SELECT * FROM events").Idempotent(false).SerialConsistency(Serial)
+	if qry.IsIdempotent() || qry.serialCons != Serial {
+		t.Error("expected per query settings to override session defaults")
+	}
+
+	b := s.NewBatch(LoggedBatch)
+	if b.serialCons != LocalSerial || b.rt != rt || b.queryTimeout() != time.Second {
+		t.Errorf("unexpected batch defaults serial=%v retry=%v timeout=%v", b.serialCons, b.rt, b.queryTimeout())
+	}
+	if b.observer != (nopBatchObserver{}) {
+		t.Errorf("expected batch observer, got %v", b.observer)
+	}
+}
+
+func TestProfileKeepsSessionTimeout(t *testing.T) {
+	profiles, err := newProfiles(map[string]*ExecutionProfile{"fast": {Consistency: One}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Session{cons: Quorum, profiles: profiles}
+	s.SetQueryTimeout(time.Second)
+
+	qry := s.Query("SELECT * FROM events").Profile("fast")
+	if qry.queryTimeout() != time.Second {
+		t.Errorf("expected session timeout %v, got %v", time.Second, qry.queryTimeout())
+	}
+}
+
+func TestQueryClone(t *testing.T) {
+	s := &Session{cons: Quorum}
+	base := s.Query("SELECT name FROM users WHERE id = ?", 1).
+		PageSize(10).
+		Tag("route", "users").
+		CustomPayload(map[string][]byte{"k": []byte("v")})
+
+	clone := base.Clone().Consistency(One).PageSize(20).Bind(2).Tag("route", "admins")
+	clone.customPayload["k"] = []byte("other")
+
+	if base.cons != Quorum || base.pageSize != 10 || base.values[0] != 1 {
+		t.Fatalf("expected the base query to be unchanged, got consistency %v, page size %d and values %v",
+			base.cons, base.pageSize, base.values)
+	}
+	if base.tags["route"] != "users" || string(base.customPayload["k"]) != "v" {
+		t.Fatalf("expected the tags and payload of the base query to be unchanged, got %v and %v", base.tags, base.customPayload)
+	}
+	if clone.cons != One || clone.pageSize != 20 || clone.values[0] != 2 || clone.stmt != base.stmt {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	if clone.metrics == base.metrics || clone.routingInfo == base.routingInfo {
+		t.Fatal("expected the clone to have its own metrics and routing info")
+	}
+
+	// releasing the clone doesn't affect the base query
+	clone.Release()
+	if base.stmt != "SELECT name FROM users WHERE id = ?" {
+		t.Fatal("expected the base query to survive releasing its clone")
+	}
+}
+
+func TestSessionNewBatchConcurrentSetter(t *testing.T) {
+	s := &Session{cons: Quorum, keyspace: "example"}
+
+	// NewBatch must not take the read lock of the session twice, a setter
+	// waiting between the two would deadlock it
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100000; j++ {
+				if b := s.NewBatch(LoggedBatch); b.Keyspace() != "example" {
+					t.Errorf("expected keyspace example, got %q", b.Keyspace())
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				s.SetConsistency(One)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("NewBatch deadlocked with a concurrent setter")
+	}
+}
@@ -0,0 +1,59 @@
+//go:build go1.21
+// +build go1.21
+
+package gocql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// NewSlogLogger returns a logger for ClusterConfig.Logger logging the messages
+// of the driver to logger, at their level and with their fields as attributes:
+//
+//	cluster.Logger = gocql.NewSlogLogger(slog.Default())
+//
+// The messages logged with the Print methods of StdLogger are logged at the
+// info level.
+func NewSlogLogger(logger *slog.Logger) StdLogger {
+	return &slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+var _ StructuredLogger = (*slogLogger)(nil)
+
+func (l *slogLogger) log(level slog.Level, msg string, fields []LogField) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (l *slogLogger) Debug(msg string, fields ...LogField) { l.log(slog.LevelDebug, msg, fields) }
+func (l *slogLogger) Info(msg string, fields ...LogField)  { l.log(slog.LevelInfo, msg, fields) }
+func (l *slogLogger) Warn(msg string, fields ...LogField)  { l.log(slog.LevelWarn, msg, fields) }
+func (l *slogLogger) Error(msg string, fields ...LogField) { l.log(slog.LevelError, msg, fields) }
+
+// The messages of the Print methods often end with a new line, which slog
+// doesn't expect.
+func (l *slogLogger) Print(v ...interface{}) {
+	l.log(slog.LevelInfo, strings.TrimSuffix(fmt.Sprint(v...), "\n"), nil)
+}
+
+func (l *slogLogger) Printf(format string, v ...interface{}) {
+	l.log(slog.LevelInfo, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"), nil)
+}
+
+func (l *slogLogger) Println(v ...interface{}) {
+	l.log(slog.LevelInfo, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
@@ -0,0 +1,71 @@
+//go:build go1.21
+// +build go1.21
+
+package gocql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of loggers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSlogLogger(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var out syncBuffer
+	cluster := srv.ClusterConfig()
+	cluster.NumConns = 1
+	cluster.Logger = gocql.NewSlogLogger(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	// the statement can't be prepared, its bind marker isn't stubbed
+	cluster.RegisterStatement("missing", `SELECT * FROM missing WHERE id = ?`)
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Close()
+
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("unexpected log line %q: %v", line, err)
+		}
+		if record["level"] == "DEBUG" || record["level"] == "INFO" {
+			t.Errorf("unexpected record below the level of the handler %v", record)
+		}
+		if record["msg"] == "unable to prepare statement" {
+			found = true
+			if record["level"] != "WARN" || record["name"] != "missing" || record["err"] == nil {
+				t.Errorf("unexpected record %v", record)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected the failure to prepare the statement to be logged, got\n%s", out.String())
+	}
+}
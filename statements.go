@@ -0,0 +1,71 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownStatement is returned when executing a query of Session.Stmt
+// whose statement was not registered.
+var ErrUnknownStatement = errors.New("gocql: statement not registered")
+
+// RegisterStatement declares the statement stmt under name, see
+// ClusterConfig.Statements. The sessions created with the config prepare it on
+// every host as soon as they connect and execute it with Session.Stmt:
+//
+//	cluster.RegisterStatement("get_user", `SELECT name FROM users WHERE id = ?`)
+//	...
+//	err := session.Stmt("get_user").Bind(id).Scan(&name)
+func (cfg *ClusterConfig) RegisterStatement(name, stmt string) {
+	if cfg.Statements == nil {
+		cfg.Statements = make(map[string]string)
+	}
+	cfg.Statements[name] = stmt
+}
+
+// Stmt returns a query of the statement registered under name, see
+// ClusterConfig.RegisterStatement. Bind its values with Query.Bind. The name
+// of the statement is passed to query observers as
+// ObservedQuery.StatementName. Executing the query fails with
+// ErrUnknownStatement if no statement was registered under name.
+func (s *Session) Stmt(name string) *Query {
+	stmt, ok := s.statements[name]
+	qry := s.Query(stmt)
+	qry.name = name
+	if !ok {
+		qry.stmtErr = fmt.Errorf("%w: %q", ErrUnknownStatement, name)
+	}
+	return qry
+}
+
+// StatementName returns the name of the registered statement of the query,
+// see Session.Stmt, or an empty string.
+func (q *Query) StatementName() string {
+	return q.name
+}
+
+// prepareStatements prepares the registered statements of the session with
+// conn, a new connection. Statements are prepared once per host and keyspace,
+// the further connections to the host find them in the cache of prepared
+// statements. The failures are logged, executing the statement reports them.
+func (s *Session) prepareStatements(conn *Conn) {
+	for name, stmt := range s.statements {
+		if _, err := conn.prepareStatement(s.ctx, stmt, nil); err != nil {
+			logWarn(s.logger, "unable to prepare statement", LogField{"name", name},
+				LogField{"host", conn.host.ConnectAddress()}, LogField{"err", err})
+		}
+	}
+}
+
+// copyStatements returns a copy of the registered statements, which are not
+// modified by the session.
+func copyStatements(statements map[string]string) map[string]string {
+	if len(statements) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(statements))
+	for name, stmt := range statements {
+		c[name] = stmt
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type statementNameObserver struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (o *statementNameObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.mu.Lock()
+	o.names = append(o.names, q.StatementName)
+	o.mu.Unlock()
+}
+
+func TestRegisteredStatements(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	const getUser = `SELECT name FROM ks.users WHERE id = ?`
+	srv.On(getUser).
+		Params(gocqltest.Column{Name: "id", Type: gocqltest.Int}).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"alice"})
+
+	observer := &statementNameObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.QueryObserver = observer
+	cluster.RegisterStatement("get_user", getUser)
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if prepares := srv.Prepares(); len(prepares) != 1 || prepares[0] != getUser {
+		t.Fatalf("expected the registered statement to be prepared once on connect, got %q", prepares)
+	}
+
+	var name string
+	if err := session.Stmt("get_user").Bind(1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" {
+		t.Fatalf("expected alice, got %q", name)
+	}
+	if prepares := srv.Prepares(); len(prepares) != 1 {
+		t.Fatalf("expected the statement to be executed without preparing it again, got %q", prepares)
+	}
+
+	observer.mu.Lock()
+	names := observer.names
+	observer.mu.Unlock()
+	if len(names) != 1 || names[0] != "get_user" {
+		t.Fatalf("expected the observer to receive the name of the statement, got %q", names)
+	}
+
+	if err := session.Stmt("get_users").Bind(1).Exec(); !errors.Is(err, gocql.ErrUnknownStatement) {
+		t.Fatalf("expected %v, got %v", gocql.ErrUnknownStatement, err)
+	}
+}
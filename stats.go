@@ -0,0 +1,243 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+)
+
+// SessionStats are the totals of the requests of a session, see
+// Session.Stats. They are cheaper to maintain than observers and cover the
+// queries of the session which are executed without one.
+type SessionStats struct {
+	// Queries is the number of queries and batches executed, including the
+	// fetching of further pages but not retries.
+	Queries int64
+	// Pages is the number of further pages fetched by iterators.
+	Pages int64
+	// Retries is the number of times a query or batch was attempted again
+	// after an error.
+	Retries int64
+
+	// TimeoutErrors is the number of queries which failed with a read or write
+	// timeout of the coordinator, or of the client.
+	TimeoutErrors int64
+	// UnavailableErrors is the number of queries which failed because not
+	// enough replicas were alive.
+	UnavailableErrors int64
+	// RequestErrors is the number of queries which failed because of the
+	// request itself, such as syntax or authorization errors, which are not
+	// retried.
+	RequestErrors int64
+	// ServerErrors is the number of queries which failed with any other error
+	// response.
+	ServerErrors int64
+	// ConnectionErrors is the number of queries which failed because no
+	// connection was available or their connection failed.
+	ConnectionErrors int64
+	// OtherErrors is the number of queries which failed with an error of the
+	// client, such as a marshalling error.
+	OtherErrors int64
+
+	// Overloaded is the number of overloaded errors received, including the
+	// ones of attempts which were retried. Compared to Queries it is the rate
+	// at which the cluster sheds the load of the session.
+	Overloaded int64
+
+	// Coalesced is the number of queries which shared the request of an
+	// identical query in flight instead of sending their own, see
+	// Query.Coalesce.
+	Coalesced int64
+
+	// Hedges is the number of speculative executions started, by a
+	// SpeculativeExecutionPolicy or ClusterConfig.HedgedReads. Of the queries
+	// which sent hedges, HedgeWins were answered by a hedge first and
+	// PrimaryWins by their first execution. Many more primary wins than hedge
+	// wins mean that the delay of the hedges is too short.
+	Hedges      int64
+	HedgeWins   int64
+	PrimaryWins int64
+
+	// RemoteAttempts is the number of attempts at executing queries and
+	// batches sent to hosts which the host selection policy doesn't consider
+	// local, such as the hosts of other datacenters DCAwareRoundRobinPolicy
+	// falls back to. Compared to Queries it is the share of the traffic of the
+	// session crossing datacenters.
+	RemoteAttempts int64
+
+	// OrphanedStreams is the number of requests abandoned on their connection
+	// before their response arrived, because they timed out or were
+	// canceled, see ClusterConfig.MaxOrphanedStreams.
+	OrphanedStreams int64
+
+	// BytesRead and BytesWritten are the sizes of the frames read and written
+	// by the connections of the session, after compression.
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// sessionCounters are updated atomically, sessionCounters must be allocated on
+// its own to be 64-bit aligned. A nil *sessionCounters discards updates.
+type sessionCounters struct {
+	queries           int64
+	pages             int64
+	retries           int64
+	timeoutErrors     int64
+	unavailableErrors int64
+	requestErrors     int64
+	serverErrors      int64
+	connectionErrors  int64
+	otherErrors       int64
+	overloaded        int64
+	coalesced         int64
+	hedges            int64
+	hedgeWins         int64
+	primaryWins       int64
+	remoteAttempts    int64
+	orphanedStreams   int64
+	bytesRead         int64
+	bytesWritten      int64
+}
+
+func (c *sessionCounters) query() {
+	if c != nil {
+		atomic.AddInt64(&c.queries, 1)
+	}
+}
+
+func (c *sessionCounters) page() {
+	if c != nil {
+		atomic.AddInt64(&c.pages, 1)
+	}
+}
+
+func (c *sessionCounters) coalesce() {
+	if c != nil {
+		atomic.AddInt64(&c.coalesced, 1)
+	}
+}
+
+func (c *sessionCounters) hedge() {
+	if c != nil {
+		atomic.AddInt64(&c.hedges, 1)
+	}
+}
+
+// hedgeWon counts the execution answering a query which sent hedges.
+func (c *sessionCounters) hedgeWon(hedge bool) {
+	if c == nil {
+		return
+	}
+	if hedge {
+		atomic.AddInt64(&c.hedgeWins, 1)
+	} else {
+		atomic.AddInt64(&c.primaryWins, 1)
+	}
+}
+
+func (c *sessionCounters) remoteAttempt() {
+	if c != nil {
+		atomic.AddInt64(&c.remoteAttempts, 1)
+	}
+}
+
+func (c *sessionCounters) orphan(n int) {
+	if c != nil {
+		atomic.AddInt64(&c.orphanedStreams, int64(n))
+	}
+}
+
+func (c *sessionCounters) retry() {
+	if c != nil {
+		atomic.AddInt64(&c.retries, 1)
+	}
+}
+
+// attempted counts the errors of attempts which are counted even if the query
+// is retried.
+func (c *sessionCounters) attempted(err error) {
+	if c == nil || err == nil {
+		return
+	}
+	var overloaded *RequestErrOverloaded
+	if errors.As(err, &overloaded) {
+		atomic.AddInt64(&c.overloaded, 1)
+	}
+}
+
+func (c *sessionCounters) read(n int) {
+	if c != nil {
+		atomic.AddInt64(&c.bytesRead, int64(n))
+	}
+}
+
+func (c *sessionCounters) written(n int) {
+	if c != nil {
+		atomic.AddInt64(&c.bytesWritten, int64(n))
+	}
+}
+
+// failed counts err in its category.
+func (c *sessionCounters) failed(err error) {
+	if c == nil || err == nil {
+		return
+	}
+
+	var reqErr RequestError
+	switch {
+	case errors.As(err, &reqErr):
+		switch {
+		case reqErr.Code() == ErrCodeReadTimeout || reqErr.Code() == ErrCodeWriteTimeout:
+			atomic.AddInt64(&c.timeoutErrors, 1)
+		case reqErr.Code() == ErrCodeUnavailable:
+			atomic.AddInt64(&c.unavailableErrors, 1)
+		case isPermanentError(err):
+			atomic.AddInt64(&c.requestErrors, 1)
+		default:
+			atomic.AddInt64(&c.serverErrors, 1)
+		}
+	case errors.Is(err, ErrTimeoutNoResponse), errors.Is(err, context.DeadlineExceeded):
+		atomic.AddInt64(&c.timeoutErrors, 1)
+	case errors.Is(err, ErrNoConnections), errors.Is(err, ErrConnectionClosed), isNetError(err):
+		atomic.AddInt64(&c.connectionErrors, 1)
+	default:
+		atomic.AddInt64(&c.otherErrors, 1)
+	}
+}
+
+func isNetError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *sessionCounters) stats() SessionStats {
+	return SessionStats{
+		Queries:           atomic.LoadInt64(&c.queries),
+		Pages:             atomic.LoadInt64(&c.pages),
+		Retries:           atomic.LoadInt64(&c.retries),
+		TimeoutErrors:     atomic.LoadInt64(&c.timeoutErrors),
+		UnavailableErrors: atomic.LoadInt64(&c.unavailableErrors),
+		RequestErrors:     atomic.LoadInt64(&c.requestErrors),
+		ServerErrors:      atomic.LoadInt64(&c.serverErrors),
+		ConnectionErrors:  atomic.LoadInt64(&c.connectionErrors),
+		OtherErrors:       atomic.LoadInt64(&c.otherErrors),
+		Overloaded:        atomic.LoadInt64(&c.overloaded),
+		Coalesced:         atomic.LoadInt64(&c.coalesced),
+		Hedges:            atomic.LoadInt64(&c.hedges),
+		HedgeWins:         atomic.LoadInt64(&c.hedgeWins),
+		PrimaryWins:       atomic.LoadInt64(&c.primaryWins),
+		RemoteAttempts:    atomic.LoadInt64(&c.remoteAttempts),
+		OrphanedStreams:   atomic.LoadInt64(&c.orphanedStreams),
+		BytesRead:         atomic.LoadInt64(&c.bytesRead),
+		BytesWritten:      atomic.LoadInt64(&c.bytesWritten),
+	}
+}
+
+// Stats returns a snapshot of the totals of the requests of the session.
+func (s *Session) Stats() SessionStats {
+	if s.stats == nil {
+		return SessionStats{}
+	}
+	return s.stats.stats()
+}
@@ -0,0 +1,102 @@
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestSessionStats(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	var rows [][]interface{}
+	for i := 0; i < 5; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, rows...)
+	srv.On(`SELEC id FROM events`).Error(gocql.ErrCodeSyntax, "line 1:0 no viable alternative")
+	srv.On(`SELECT name FROM users`).Error(gocql.ErrCodeReadTimeout, "read timeout")
+	srv.On(`SELECT name FROM nodes`).Error(gocql.ErrCodeUnavailable, "unavailable")
+
+	cluster := srv.ClusterConfig()
+	cluster.RetryPolicy = &retrySameHost{gocql.SimpleRetryPolicy{NumRetries: 2}}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	before := session.Stats()
+	if before.BytesRead == 0 || before.BytesWritten == 0 {
+		t.Fatalf("expected the bytes of the connection setup to be counted, got %+v", before)
+	}
+
+	iter := session.Query(`SELECT id FROM events`).PageSize(2).Iter()
+	var id int
+	for iter.Scan(&id) {
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	session.Query(`SELEC id FROM events`).Exec()
+	session.Query(`SELECT name FROM users`).Exec()
+	session.Query(`SELECT name FROM nodes`).Idempotent(true).Exec()
+
+	stats := session.Stats()
+	// 3 pages of events, the syntax error, the read timeout and the
+	// unavailable error
+	if n := stats.Queries - before.Queries; n != 6 {
+		t.Fatalf("expected 6 queries, got %d", n)
+	}
+	if n := stats.Pages - before.Pages; n != 2 {
+		t.Fatalf("expected 2 further pages, got %d", n)
+	}
+	// the read timeout and the unavailable error are retried twice, the
+	// syntax error isn't
+	if n := stats.Retries - before.Retries; n != 4 {
+		t.Fatalf("expected 4 retries, got %d", n)
+	}
+	if stats.RequestErrors != 1 || stats.TimeoutErrors != 1 || stats.UnavailableErrors != 1 {
+		t.Fatalf("unexpected errors: %+v", stats)
+	}
+	if stats.ServerErrors != 0 || stats.ConnectionErrors != 0 || stats.OtherErrors != 0 {
+		t.Fatalf("unexpected errors: %+v", stats)
+	}
+	if stats.BytesRead <= before.BytesRead || stats.BytesWritten <= before.BytesWritten {
+		t.Fatalf("expected the bytes of the queries to be counted, got %+v", stats)
+	}
+}
+
+func TestSessionStatsRemoteAttempts(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`SELECT id FROM events`).Rows([]gocqltest.Column{{Name: "id", Type: gocqltest.Int}}, []interface{}{1})
+
+	// the only host of the server is in datacenter1
+	cluster := srv.ClusterConfig()
+	cluster.PoolConfig.HostSelectionPolicy = gocql.DCAwareRoundRobinPolicy("dc2")
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELECT id FROM events`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if n := session.Stats().RemoteAttempts; n != 1 {
+		t.Fatalf("expected 1 remote attempt, got %d", n)
+	}
+
+	cluster = srv.ClusterConfig()
+	cluster.PoolConfig.HostSelectionPolicy = gocql.DCAwareRoundRobinPolicy("dc2", gocql.NoRemoteDCs())
+	// the hosts of the other datacenters are ignored, no connection is opened
+	// to them
+	if _, err := cluster.CreateSession(); !errors.Is(err, gocql.ErrNoConnectionsStarted) {
+		t.Fatalf("expected %v without remote datacenters, got %v", gocql.ErrNoConnectionsStarted, err)
+	}
+}
@@ -0,0 +1,116 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structScan caches the fields of the struct an iterator scans rows into.
+type structScan struct {
+	typ     reflect.Type
+	columns int
+	fields  [][]int
+}
+
+// ScanStruct scans the next row into the struct dest points to, as Scan does
+// for its destinations. The fields of the struct receive the columns as with
+// ScanAll, the columns of the same name, ignoring case, or named by their cql
+// tag, and fields of struct types receive the columns of UDTs:
+//
+//	type Address struct {
+//		Street string `cql:"street"`
+//		City   string `cql:"city"`
+//	}
+//
+//	type User struct {
+//		ID      gocql.UUID `cql:"id"`
+//		Name    string     `cql:"name"`
+//		Address Address    `cql:"address"`
+//	}
+//
+//	iter := session.Query(`SELECT id, name, address FROM users`).Iter()
+//	var user User
+//	for iter.ScanStruct(&user) {
+//		...
+//	}
+//	err := iter.Close()
+//
+// Every column must have a field, the error is returned by Close.
+func (iter *Iter) ScanStruct(dest interface{}) bool {
+	if iter.err != nil {
+		return false
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		iter.err = fmt.Errorf("gocql: ScanStruct expects a pointer to a struct, got %T", dest)
+		return false
+	}
+	v = v.Elem()
+
+	columns := iter.Columns()
+	if iter.structScan == nil || iter.structScan.typ != v.Type() || iter.structScan.columns != len(columns) {
+		fields, err := structColumnFields(v.Type(), columns)
+		if err != nil {
+			iter.err = err
+			return false
+		}
+		iter.structScan = &structScan{typ: v.Type(), columns: len(columns), fields: fields}
+	}
+
+	dests := make([]interface{}, 0, len(columns))
+	for i, index := range iter.structScan.fields {
+		d, err := columnDests(v.FieldByIndex(index), columns[i])
+		if err != nil {
+			iter.err = err
+			return false
+		}
+		dests = append(dests, d...)
+	}
+	return iter.Scan(dests...)
+}
+
+// BindStruct sets the values of the query from the fields of v, a struct or a
+// pointer to a struct, bound to the markers of the same name, ignoring case,
+// or named by their cql tag, as BindMap binds the values of a map:
+//
+//	user := User{ID: id, Name: "alice", Address: Address{Street: "1 main st", City: "springfield"}}
+//	err := session.Query(`INSERT INTO users (id, name, address) VALUES (:id, :name, :address)`).BindStruct(user).Exec()
+//
+// Fields of struct types are marshaled as UDTs. Executing the query fails if
+// a marker has no field, the fields without a marker are ignored.
+func (q *Query) BindStruct(v interface{}) *Query {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		err := fmt.Errorf("gocql: BindStruct expects a struct, got %T", v)
+		q.values = nil
+		q.namedValues = nil
+		q.binding = func(*QueryInfo) ([]interface{}, error) { return nil, err }
+		q.pageState = nil
+		return q
+	}
+
+	byName := structFieldsByName(rv.Type())
+	named := make(map[string]interface{}, len(byName))
+	for name, index := range byName {
+		named[name] = rv.FieldByIndex(index).Interface()
+	}
+	q.values = nil
+	q.namedValues = named
+	q.binding = func(info *QueryInfo) ([]interface{}, error) {
+		values := make([]interface{}, len(info.Args))
+		for i, arg := range info.Args {
+			value, ok := named[strings.ToLower(arg.Name)]
+			if !ok {
+				return nil, fmt.Errorf("gocql: no field of %s bound to the marker %s", rv.Type(), arg.Name)
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	q.pageState = nil
+	return q
+}
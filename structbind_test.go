@@ -0,0 +1,132 @@
+package gocql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type structAddress struct {
+	Street string `cql:"street"`
+	City   string `cql:"city"`
+}
+
+type structUser struct {
+	ID      int           `cql:"id"`
+	Name    string        `cql:"name"`
+	Address structAddress `cql:"address"`
+	Ignored string        `cql:"-"`
+}
+
+var structAddressType = gocql.UDTTypeInfo{
+	NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""),
+	KeySpace:   "ks",
+	Name:       "address",
+	Elements: []gocql.UDTField{
+		{Name: "street", Type: gocqltest.Text},
+		{Name: "city", Type: gocqltest.Text},
+	},
+}
+
+func TestIterScanStruct(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	want := []structUser{
+		{ID: 1, Name: "alice", Address: structAddress{Street: "1 main st", City: "springfield"}},
+		{ID: 2, Name: "bob", Address: structAddress{Street: "2 elm st", City: "shelbyville"}},
+	}
+	columns := []gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "name", Type: gocqltest.Text},
+		{Name: "address", Type: structAddressType},
+	}
+	var rows [][]interface{}
+	for _, u := range want {
+		rows = append(rows, []interface{}{u.ID, u.Name, u.Address})
+	}
+	srv.On(`SELECT id, name, address FROM ks.users`).Rows(columns, rows...).PageSize(1)
+	srv.On(`SELECT id, email FROM ks.users`).Rows([]gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "email", Type: gocqltest.Text},
+	}, []interface{}{1, "alice@example.com"})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT id, name, address FROM ks.users`).Iter()
+	var got []structUser
+	user := structUser{Ignored: "kept"}
+	for iter.ScanStruct(&user) {
+		got = append(got, user)
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d users, got %+v", len(want), got)
+	}
+	for i := range want {
+		want[i].Ignored = "kept"
+		if got[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], got[i])
+		}
+	}
+
+	iter = session.Query(`SELECT id, email FROM ks.users`).Iter()
+	if iter.ScanStruct(&user) {
+		t.Fatal("expected scanning a column without a field to fail")
+	}
+	if err := iter.Close(); err == nil || !strings.Contains(err.Error(), `column "email"`) {
+		t.Fatalf("expected an error for the column without a field, got %v", err)
+	}
+}
+
+func TestQueryBindStruct(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	const stmt = `INSERT INTO ks.users (id, name, address) VALUES (:id, :name, :address)`
+	srv.On(stmt).Params(
+		gocqltest.Column{Name: "id", Type: gocqltest.Int},
+		gocqltest.Column{Name: "name", Type: gocqltest.Text},
+		gocqltest.Column{Name: "address", Type: structAddressType},
+	).Rows(nil)
+	srv.On(`UPDATE ks.users SET email = :email WHERE id = :id`).Params(
+		gocqltest.Column{Name: "email", Type: gocqltest.Text},
+		gocqltest.Column{Name: "id", Type: gocqltest.Int},
+	).Rows(nil)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	want := structUser{ID: 1, Name: "alice", Address: structAddress{Street: "1 main st", City: "springfield"}}
+	if err := session.Query(stmt).BindStruct(&want).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 || len(reqs[0].Values) != 3 {
+		t.Fatalf("expected a request with 3 values, got %+v", reqs)
+	}
+	var got structUser
+	if err := reqs[0].Scan(&got.ID, &got.Name, &got.Address); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	err = session.Query(`UPDATE ks.users SET email = :email WHERE id = :id`).BindStruct(want).Exec()
+	if err == nil || !strings.Contains(err.Error(), "marker email") {
+		t.Fatalf("expected an error for the marker without a field, got %v", err)
+	}
+	if err := session.Query(stmt).BindStruct(42).Exec(); err == nil {
+		t.Fatal("expected binding an int to fail")
+	}
+}
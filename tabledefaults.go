@@ -0,0 +1,93 @@
+package gocql
+
+import (
+	"time"
+)
+
+// TableDefaults are settings applied to the queries of a table, see
+// ClusterConfig.TableDefaults. They take precedence over the defaults of the
+// session, but not over the settings of the query itself or of its execution
+// profile. Zero values leave the corresponding setting untouched.
+type TableDefaults struct {
+	// Consistency of the queries of the table. As Any is the zero value of
+	// Consistency it can't be a default.
+	Consistency Consistency
+
+	// Idempotent marks the queries of the table as idempotent.
+	Idempotent bool
+
+	// PageSize of the queries of the table.
+	PageSize int
+
+	// TTL of the data written by INSERT and UPDATE statements, see
+	// Query.WithTTL. The statements which already have a TTL keep it.
+	TTL time.Duration
+}
+
+// querySettings are the settings of a query which are set explicitly, and
+// therefore not replaced by table defaults.
+type querySettings uint8
+
+const (
+	settingConsistency querySettings = 1 << iota
+	settingIdempotent
+	settingPageSize
+	settingTTL
+	// settingTableDefaults is set once table defaults were looked up.
+	settingTableDefaults
+)
+
+// newTableDefaults returns a copy of defaults so that changes to the cluster
+// config don't affect a session that is already running.
+func newTableDefaults(defaults map[string]TableDefaults) map[string]TableDefaults {
+	if len(defaults) == 0 {
+		return nil
+	}
+	copied := make(map[string]TableDefaults, len(defaults))
+	for table, d := range defaults {
+		copied[table] = d
+	}
+	return copied
+}
+
+// applyTableDefaults applies the defaults of the table of qry, once. The table
+// is known from the metadata of prepared statements, so the statements without
+// values, which aren't prepared, keep the defaults of the session.
+func (s *Session) applyTableDefaults(qry *Query) {
+	if len(s.tableDefaults) == 0 || qry.explicit&settingTableDefaults != 0 {
+		return
+	}
+	qry.explicit |= settingTableDefaults
+	if len(qry.values) == 0 && qry.binding == nil {
+		return
+	}
+
+	conn := s.getConn()
+	if conn == nil {
+		return
+	}
+	info, err := conn.prepareStatement(qry.Context(), qry.stmt, nil)
+	if err != nil {
+		// the error fails the query when it is executed
+		return
+	}
+	d, ok := s.tableDefaults[info.request.keyspace+"."+info.request.table]
+	if !ok {
+		return
+	}
+
+	if d.Consistency != Any && qry.explicit&settingConsistency == 0 {
+		qry.cons = d.Consistency
+	}
+	if d.Idempotent && qry.explicit&settingIdempotent == 0 {
+		qry.idempotent = true
+	}
+	if d.PageSize > 0 && qry.explicit&settingPageSize == 0 {
+		qry.pageSize = d.PageSize
+	}
+	if d.TTL > 0 && qry.explicit&settingTTL == 0 {
+		if stmt, err := stmtWithDefaultTTL(qry.stmt, int(d.TTL/time.Second)); err == nil {
+			qry.stmt = stmt
+		}
+	}
+}
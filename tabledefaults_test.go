@@ -0,0 +1,75 @@
+package gocql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestTableDefaults(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	id := gocqltest.Column{Name: "id", Type: gocqltest.Int}
+	srv.On(`SELECT name FROM orders WHERE id = ?`).Table("orders").Params(id).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"a"})
+	srv.On(`INSERT INTO orders (id) VALUES (?)`).Table("orders").Params(id)
+	srv.On(`INSERT INTO orders (id) VALUES (?) USING TTL 60`).Table("orders").Params(id)
+	srv.On(`INSERT INTO orders (id) VALUES (?) USING TTL 5`).Table("orders").Params(id)
+	srv.On(`SELECT name FROM users WHERE id = ?`).Table("users").Params(id).
+		Rows([]gocqltest.Column{{Name: "name", Type: gocqltest.Text}}, []interface{}{"b"})
+
+	cluster := srv.ClusterConfig()
+	cluster.Keyspace = "shop"
+	cluster.Consistency = gocql.Quorum
+	cluster.TableDefaults = map[string]gocql.TableDefaults{
+		"shop.orders": {Consistency: gocql.LocalOne, PageSize: 10, TTL: time.Minute},
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	lastRequest := func() gocqltest.Request {
+		requests := srv.Requests()
+		return requests[len(requests)-1]
+	}
+
+	var name string
+	if err := session.Query(`SELECT name FROM orders WHERE id = ?`, 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if req := lastRequest(); req.Consistency != gocql.LocalOne || req.PageSize != 10 {
+		t.Fatalf("expected the defaults of the table, got consistency %v and page size %d", req.Consistency, req.PageSize)
+	}
+
+	if err := session.Query(`SELECT name FROM orders WHERE id = ?`, 1).Consistency(gocql.All).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if req := lastRequest(); req.Consistency != gocql.All || req.PageSize != 10 {
+		t.Fatalf("expected the consistency of the query, got consistency %v and page size %d", req.Consistency, req.PageSize)
+	}
+
+	if err := session.Query(`INSERT INTO orders (id) VALUES (?)`, 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if req := lastRequest(); req.Statement != `INSERT INTO orders (id) VALUES (?) USING TTL 60` {
+		t.Fatalf("expected the TTL of the table, got %q", req.Statement)
+	}
+	if err := session.Query(`INSERT INTO orders (id) VALUES (?) USING TTL 5`, 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if req := lastRequest(); req.Statement != `INSERT INTO orders (id) VALUES (?) USING TTL 5` {
+		t.Fatalf("expected the TTL of the statement to be kept, got %q", req.Statement)
+	}
+
+	if err := session.Query(`SELECT name FROM users WHERE id = ?`, 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if req := lastRequest(); req.Consistency != gocql.Quorum {
+		t.Fatalf("expected the consistency of the session for other tables, got %v", req.Consistency)
+	}
+}
@@ -0,0 +1,89 @@
+package gocql_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type tagsObserver struct {
+	mu      sync.Mutex
+	queries []map[string]string
+	batches []map[string]string
+}
+
+func (o *tagsObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.mu.Lock()
+	o.queries = append(o.queries, q.Tags)
+	o.mu.Unlock()
+}
+
+func (o *tagsObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.mu.Lock()
+	o.batches = append(o.batches, b.Tags)
+	o.mu.Unlock()
+}
+
+func TestQueryTags(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	observer := &tagsObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.QueryObserver = observer
+	cluster.BatchObserver = observer
+	cluster.TagPayloadPrefix = "tag."
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	tags := map[string]string{"feature": "checkout", "tenant": "acme"}
+
+	q := session.Query(`INSERT INTO orders (id) VALUES (1)`).
+		Tag("feature", "checkout").
+		Tag("tenant", "acme").
+		CustomPayload(map[string][]byte{"other": []byte("value")})
+	if !reflect.DeepEqual(q.Tags(), tags) {
+		t.Fatalf("unexpected tags %v", q.Tags())
+	}
+	if err := q.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := session.NewBatch(gocql.LoggedBatch).Tag("feature", "checkout").Tag("tenant", "acme")
+	b.Query(`INSERT INTO orders (id) VALUES (2)`)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(observer.queries) != 1 || !reflect.DeepEqual(observer.queries[0], tags) {
+		t.Fatalf("unexpected observed query tags %v", observer.queries)
+	}
+	if len(observer.batches) != 1 || !reflect.DeepEqual(observer.batches[0], tags) {
+		t.Fatalf("unexpected observed batch tags %v", observer.batches)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	expected := map[string][]byte{
+		"tag.feature": []byte("checkout"),
+		"tag.tenant":  []byte("acme"),
+		"other":       []byte("value"),
+	}
+	if !reflect.DeepEqual(reqs[0].CustomPayload, expected) {
+		t.Fatalf("unexpected query payload %q", reqs[0].CustomPayload)
+	}
+	delete(expected, "other")
+	if !reflect.DeepEqual(reqs[1].CustomPayload, expected) {
+		t.Fatalf("unexpected batch payload %q", reqs[1].CustomPayload)
+	}
+}
@@ -141,20 +141,28 @@ type tokenRing struct {
 	hosts []*HostInfo
 }
 
+// newPartitioner returns the partitioner of the given class name.
+func newPartitioner(name string) (partitioner, error) {
+	if strings.HasSuffix(name, "Murmur3Partitioner") {
+		return murmur3Partitioner{}, nil
+	} else if strings.HasSuffix(name, "OrderedPartitioner") {
+		return orderedPartitioner{}, nil
+	} else if strings.HasSuffix(name, "RandomPartitioner") {
+		return randomPartitioner{}, nil
+	}
+	return nil, fmt.Errorf("unsupported partitioner '%s'", name)
+}
+
 func newTokenRing(partitioner string, hosts []*HostInfo) (*tokenRing, error) {
 	tokenRing := &tokenRing{
 		hosts: hosts,
 	}
 
-	if strings.HasSuffix(partitioner, "Murmur3Partitioner") {
-		tokenRing.partitioner = murmur3Partitioner{}
-	} else if strings.HasSuffix(partitioner, "OrderedPartitioner") {
-		tokenRing.partitioner = orderedPartitioner{}
-	} else if strings.HasSuffix(partitioner, "RandomPartitioner") {
-		tokenRing.partitioner = randomPartitioner{}
-	} else {
-		return nil, fmt.Errorf("unsupported partitioner '%s'", partitioner)
+	p, err := newPartitioner(partitioner)
+	if err != nil {
+		return nil, err
 	}
+	tokenRing.partitioner = p
 
 	for _, host := range hosts {
 		for _, strToken := range host.Tokens() {
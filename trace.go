@@ -0,0 +1,188 @@
+package gocql
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTraceIncomplete is reported by a TraceCollector for traces which were
+// still not complete after all attempts to read them.
+var ErrTraceIncomplete = errors.New("gocql: trace is incomplete")
+
+// TraceSession is the trace of a query read from the system_traces tables.
+type TraceSession struct {
+	ID          []byte
+	Coordinator string
+	// Duration is zero until the coordinator completed the trace.
+	Duration time.Duration
+	Events   []TraceEvent
+}
+
+// TraceEvent is an event of a trace.
+type TraceEvent struct {
+	Time          time.Time
+	Activity      string
+	Source        string
+	SourceElapsed time.Duration
+	Thread        string
+}
+
+// readTrace reads the trace traceID on the control connection. It returns
+// ErrNotFound if the trace session wasn't written yet.
+func (s *Session) readTrace(traceID []byte) (*TraceSession, error) {
+	trace := &TraceSession{ID: traceID}
+
+	var duration int
+	iter := s.control.query(`SELECT coordinator, duration
+			FROM system_traces.sessions
+			WHERE session_id = ?`, traceID)
+	found := iter.Scan(&trace.Coordinator, &duration)
+	if err := iter.Close(); err != nil {
+		return trace, err
+	}
+	if !found {
+		return trace, ErrNotFound
+	}
+	trace.Duration = time.Duration(duration) * time.Microsecond
+
+	var (
+		event   TraceEvent
+		elapsed int
+	)
+	iter = s.control.query(`SELECT event_id, activity, source, source_elapsed, thread
+			FROM system_traces.events
+			WHERE session_id = ?`, traceID)
+	for iter.Scan(&event.Time, &event.Activity, &event.Source, &elapsed, &event.Thread) {
+		event.SourceElapsed = time.Duration(elapsed) * time.Microsecond
+		trace.Events = append(trace.Events, event)
+	}
+	return trace, iter.Close()
+}
+
+// TraceCollectorConfig configures a TraceCollector.
+type TraceCollectorConfig struct {
+	// QueueSize is the number of traces waiting to be read. Traces are
+	// dropped when the queue is full.
+	// Default: 128
+	QueueSize int
+
+	// Attempts is the number of times a trace is read until it is complete.
+	// Default: 5
+	Attempts int
+
+	// Backoff is the delay before reading a trace, doubled after every
+	// attempt.
+	// Default: 50ms
+	Backoff time.Duration
+}
+
+// TraceCollector is a Tracer which reads traces on a background goroutine
+// instead of the goroutine executing the query. Cassandra writes traces
+// asynchronously, so traces are read after a delay and read again, with an
+// exponential backoff, until the coordinator completed them.
+type TraceCollector struct {
+	// dropped is accessed atomically and first to be 64-bit aligned.
+	dropped int64
+
+	session  *Session
+	handler  func(trace *TraceSession, err error)
+	attempts int
+	backoff  time.Duration
+	queue    chan []byte
+	start    sync.Once
+}
+
+// NewTraceCollector returns a TraceCollector reading the traces of the
+// queries of session and calling handler with each trace, from a single
+// goroutine. If a trace couldn't be read, handler is called with the error
+// and a trace which only has the ID set. The goroutine stops when the session
+// is closed.
+func NewTraceCollector(session *Session, cfg TraceCollectorConfig, handler func(trace *TraceSession, err error)) *TraceCollector {
+	c := &TraceCollector{
+		session:  session,
+		handler:  handler,
+		attempts: cfg.Attempts,
+		backoff:  cfg.Backoff,
+	}
+	if c.attempts <= 0 {
+		c.attempts = 5
+	}
+	if c.backoff <= 0 {
+		c.backoff = 50 * time.Millisecond
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 128
+	}
+	c.queue = make(chan []byte, queueSize)
+	return c
+}
+
+// Trace queues the trace to be read without blocking.
+func (c *TraceCollector) Trace(traceID []byte) {
+	c.start.Do(func() {
+		go c.run()
+	})
+
+	select {
+	case c.queue <- copyBytes(traceID):
+	default:
+		atomic.AddInt64(&c.dropped, 1)
+	}
+}
+
+// Dropped returns the number of traces dropped because the queue was full.
+func (c *TraceCollector) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+func (c *TraceCollector) run() {
+	for {
+		select {
+		case traceID := <-c.queue:
+			if !c.collect(traceID) {
+				return
+			}
+		case <-c.session.ctx.Done():
+			return
+		}
+	}
+}
+
+// collect reads the trace until it is complete and hands it to the handler.
+// It returns false if the session was closed.
+func (c *TraceCollector) collect(traceID []byte) bool {
+	timer := time.NewTimer(c.backoff)
+	defer timer.Stop()
+
+	backoff := c.backoff
+	var (
+		trace *TraceSession
+		err   error
+	)
+	for i := 0; i < c.attempts; i++ {
+		if i > 0 {
+			backoff *= 2
+			timer.Reset(backoff)
+		}
+		select {
+		case <-timer.C:
+		case <-c.session.ctx.Done():
+			return false
+		}
+
+		trace, err = c.session.readTrace(traceID)
+		if err == ErrNotFound || (err == nil && trace.Duration == 0) {
+			err = ErrTraceIncomplete
+			continue
+		}
+		break
+	}
+	if err != nil {
+		trace = &TraceSession{ID: traceID}
+	}
+	c.handler(trace, err)
+	return true
+}
@@ -0,0 +1,117 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestTraceCollector(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	traceID := gocql.TimeUUID()
+	sessionID := gocqltest.Column{Name: "session_id", Type: gocqltest.UUID}
+
+	var (
+		mu    sync.Mutex
+		reads int
+	)
+	srv.On(`SELECT coordinator, duration FROM system_traces.sessions WHERE session_id = ?`).
+		Params(sessionID).
+		Handle([]gocqltest.Column{{Name: "coordinator", Type: gocqltest.Text}, {Name: "duration", Type: gocqltest.Int}},
+			func(req *gocqltest.Request) gocqltest.Response {
+				mu.Lock()
+				defer mu.Unlock()
+				reads++
+				switch reads {
+				case 1:
+					// the trace session wasn't written yet
+					return gocqltest.Response{}
+				case 2:
+					// the coordinator didn't complete the trace yet
+					return gocqltest.Response{Rows: [][]interface{}{{"127.0.0.1", 0}}}
+				}
+				return gocqltest.Response{Rows: [][]interface{}{{"127.0.0.1", 1500}}}
+			})
+	srv.On(`SELECT event_id, activity, source, source_elapsed, thread FROM system_traces.events WHERE session_id = ?`).
+		Params(sessionID).
+		Rows([]gocqltest.Column{
+			{Name: "event_id", Type: gocqltest.TimeUUID},
+			{Name: "activity", Type: gocqltest.Text},
+			{Name: "source", Type: gocqltest.Text},
+			{Name: "source_elapsed", Type: gocqltest.Int},
+			{Name: "thread", Type: gocqltest.Text},
+		}, []interface{}{gocql.TimeUUID(), "Parsing statement", "127.0.0.1", 20, "Native-Transport-Requests-1"})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	traces := make(chan *gocql.TraceSession, 1)
+	collector := gocql.NewTraceCollector(session, gocql.TraceCollectorConfig{Backoff: time.Millisecond},
+		func(trace *gocql.TraceSession, err error) {
+			if err != nil {
+				t.Errorf("unexpected error reading trace %x: %v", trace.ID, err)
+			}
+			traces <- trace
+		})
+
+	start := time.Now()
+	collector.Trace(traceID.Bytes())
+	if time.Since(start) > 10*time.Millisecond {
+		t.Fatal("expected Trace not to block")
+	}
+
+	select {
+	case trace := <-traces:
+		if trace.Duration != 1500*time.Microsecond || trace.Coordinator != "127.0.0.1" {
+			t.Fatalf("unexpected trace %+v", trace)
+		}
+		if len(trace.Events) != 1 || trace.Events[0].Activity != "Parsing statement" || trace.Events[0].SourceElapsed != 20*time.Microsecond {
+			t.Fatalf("unexpected events %+v", trace.Events)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the trace to be collected")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if reads != 3 {
+		t.Fatalf("expected the trace to be read until complete, got %d reads", reads)
+	}
+}
+
+func TestTraceCollectorIncomplete(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT coordinator, duration FROM system_traces.sessions WHERE session_id = ?`).
+		Params(gocqltest.Column{Name: "session_id", Type: gocqltest.UUID}).
+		Rows([]gocqltest.Column{{Name: "coordinator", Type: gocqltest.Text}, {Name: "duration", Type: gocqltest.Int}})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	errs := make(chan error, 1)
+	collector := gocql.NewTraceCollector(session, gocql.TraceCollectorConfig{Attempts: 2, Backoff: time.Millisecond},
+		func(trace *gocql.TraceSession, err error) {
+			errs <- err
+		})
+	collector.Trace(gocql.TimeUUID().Bytes())
+
+	select {
+	case err := <-errs:
+		if err != gocql.ErrTraceIncomplete {
+			t.Fatalf("expected %v, got %v", gocql.ErrTraceIncomplete, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the trace to be given up")
+	}
+}
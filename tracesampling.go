@@ -0,0 +1,134 @@
+package gocql
+
+import (
+	"sync"
+	"time"
+)
+
+// TraceSampling traces a sample of the queries and batches of a session
+// which have no Tracer of their own, see ClusterConfig.TraceSampling. The
+// traces are read by a TraceCollector and passed to Handler:
+//
+//	cluster.TraceSampling = &gocql.TraceSampling{
+//		Sampler: gocql.RateLimitedTraceSampler(1),
+//		Handler: func(trace *gocql.TraceSession, err error) {
+//			...
+//		},
+//	}
+type TraceSampling struct {
+	// Sampler decides which attempts of the requests are traced.
+	Sampler TraceSampler
+
+	// Handler is called with each trace, or the error reading it, from a
+	// single goroutine.
+	Handler func(trace *TraceSession, err error)
+
+	// Collector configures the TraceCollector reading the traces.
+	Collector TraceCollectorConfig
+}
+
+// TraceSample is an attempt of a query or a batch which may be traced.
+type TraceSample struct {
+	// Statement is the statement of the query, empty for batches.
+	Statement string
+	// Attempt is the number of the attempts of the request before this one,
+	// 0 for its first attempt.
+	Attempt int
+	// Time is the time of the attempt, by ClusterConfig.Clock.
+	Time time.Time
+}
+
+// TraceSampler decides which requests are traced by TraceSampling. Sample is
+// called concurrently.
+type TraceSampler interface {
+	Sample(s TraceSample) bool
+}
+
+// TraceSamplerFunc is a func implementing TraceSampler.
+type TraceSamplerFunc func(s TraceSample) bool
+
+func (f TraceSamplerFunc) Sample(s TraceSample) bool {
+	return f(s)
+}
+
+// ProbabilisticTraceSampler traces each request with the probability p,
+// between 0 and 1.
+func ProbabilisticTraceSampler(p float64) TraceSampler {
+	return TraceSamplerFunc(func(s TraceSample) bool {
+		if s.Attempt > 0 {
+			// the request was already sampled for its first attempt
+			return false
+		}
+		mutRandr.Lock()
+		sampled := randr.Float64() < p
+		mutRandr.Unlock()
+		return sampled
+	})
+}
+
+// RateLimitedTraceSampler traces up to perSecond requests per second, the
+// first request after an interval of 1/perSecond.
+func RateLimitedTraceSampler(perSecond float64) TraceSampler {
+	return &rateLimitedTraceSampler{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+type rateLimitedTraceSampler struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (r *rateLimitedTraceSampler) Sample(s TraceSample) bool {
+	if s.Attempt > 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s.Time.Before(r.next) {
+		return false
+	}
+	r.next = s.Time.Add(r.interval)
+	return true
+}
+
+// RetryTraceSampler traces the attempts which retry a request, following an
+// error or, with a SpeculativeExecutionPolicy, a slow attempt. Whether a
+// request is traced is decided when it is sent, so the attempts which fail
+// can't be traced themselves, but the retries show how the cluster responds
+// to the same request.
+func RetryTraceSampler() TraceSampler {
+	return TraceSamplerFunc(func(s TraceSample) bool {
+		return s.Attempt > 0
+	})
+}
+
+// traceSampling is the TraceSampling of a session.
+type traceSampling struct {
+	sampler   TraceSampler
+	collector *TraceCollector
+	clock     Clock
+}
+
+func newTraceSampling(s *Session, cfg *TraceSampling) *traceSampling {
+	if cfg == nil || cfg.Sampler == nil || cfg.Handler == nil {
+		return nil
+	}
+	return &traceSampling{
+		sampler:   cfg.Sampler,
+		collector: NewTraceCollector(s, cfg.Collector, cfg.Handler),
+		clock:     s.cfg.clock(),
+	}
+}
+
+// tracer returns the tracer of an attempt of a request: its own tracer, or
+// the collector of the sampled traces if the attempt is sampled.
+func (t *traceSampling) tracer(tracer Tracer, stmt string, attempt int) Tracer {
+	if tracer != nil || t == nil {
+		return tracer
+	}
+	if t.sampler.Sample(TraceSample{Statement: stmt, Attempt: attempt, Time: t.clock.Now()}) {
+		return t.collector
+	}
+	return nil
+}
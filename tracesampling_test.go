@@ -0,0 +1,130 @@
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestTraceSampling(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+	srv.On(`SELECT * FROM sampled`).Rows(nil)
+	srv.On(`SELECT * FROM unsampled`).Rows(nil)
+	srv.On(`SELECT coordinator, duration FROM system_traces.sessions WHERE session_id = ?`).
+		Params(gocqltest.Column{Name: "session_id", Type: gocqltest.UUID}).
+		Rows([]gocqltest.Column{{Name: "coordinator", Type: gocqltest.Text}, {Name: "duration", Type: gocqltest.Int}},
+			[]interface{}{"127.0.0.1", 1500})
+	srv.On(`SELECT event_id, activity, source, source_elapsed, thread FROM system_traces.events WHERE session_id = ?`).
+		Params(gocqltest.Column{Name: "session_id", Type: gocqltest.UUID}).
+		Rows([]gocqltest.Column{
+			{Name: "event_id", Type: gocqltest.TimeUUID},
+			{Name: "activity", Type: gocqltest.Text},
+			{Name: "source", Type: gocqltest.Text},
+			{Name: "source_elapsed", Type: gocqltest.Int},
+			{Name: "thread", Type: gocqltest.Text},
+		})
+
+	var (
+		mu      sync.Mutex
+		samples []gocql.TraceSample
+	)
+	traces := make(chan *gocql.TraceSession, 2)
+	cluster := srv.ClusterConfig()
+	cluster.TraceSampling = &gocql.TraceSampling{
+		Sampler: gocql.TraceSamplerFunc(func(s gocql.TraceSample) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			samples = append(samples, s)
+			return s.Statement != `SELECT * FROM unsampled`
+		}),
+		Handler: func(trace *gocql.TraceSession, err error) {
+			if err != nil {
+				t.Errorf("unexpected error reading trace %x: %v", trace.ID, err)
+			}
+			traces <- trace
+		},
+		Collector: gocql.TraceCollectorConfig{Backoff: time.Millisecond},
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Query(`SELECT * FROM unsampled`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Query(`SELECT * FROM sampled`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	batch := session.NewBatch(gocql.UnloggedBatch)
+	batch.Query(`INSERT INTO sampled (id) VALUES (1)`)
+	if err := session.ExecuteBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case trace := <-traces:
+			if trace.Coordinator != "127.0.0.1" || trace.Duration != 1500*time.Microsecond {
+				t.Fatalf("unexpected trace %+v", trace)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the traces of the sampled query and batch to be collected")
+		}
+	}
+
+	traced := make(map[string]bool)
+	for _, req := range srv.Requests() {
+		traced[req.Statement] = req.Traced
+	}
+	if !traced[`SELECT * FROM sampled`] || !traced[`INSERT INTO sampled (id) VALUES (1)`] || traced[`SELECT * FROM unsampled`] {
+		t.Fatalf("expected only the sampled requests to be traced, got %v", traced)
+	}
+	if traced[`SELECT coordinator, duration FROM system_traces.sessions WHERE session_id = ?`] {
+		t.Fatal("expected the reads of the traces not to be traced")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(samples) != 3 || samples[2].Statement != "" || samples[0].Attempt != 0 || samples[0].Time.IsZero() {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+}
+
+func TestTraceSamplers(t *testing.T) {
+	start := time.Now()
+	limited := gocql.RateLimitedTraceSampler(10)
+	for _, test := range []struct {
+		offset time.Duration
+		want   bool
+	}{
+		{0, true},
+		{50 * time.Millisecond, false},
+		{100 * time.Millisecond, true},
+		{150 * time.Millisecond, false},
+		{300 * time.Millisecond, true},
+	} {
+		if got := limited.Sample(gocql.TraceSample{Time: start.Add(test.offset)}); got != test.want {
+			t.Errorf("expected the rate limited sampler to sample at +%v: %v, got %v", test.offset, test.want, got)
+		}
+	}
+
+	if gocql.ProbabilisticTraceSampler(0).Sample(gocql.TraceSample{}) {
+		t.Error("expected a probability of 0 not to sample")
+	}
+	if !gocql.ProbabilisticTraceSampler(1).Sample(gocql.TraceSample{}) {
+		t.Error("expected a probability of 1 to sample")
+	}
+	if gocql.ProbabilisticTraceSampler(1).Sample(gocql.TraceSample{Attempt: 1}) {
+		t.Error("expected the retries not to be sampled again")
+	}
+
+	retries := gocql.RetryTraceSampler()
+	if retries.Sample(gocql.TraceSample{}) || !retries.Sample(gocql.TraceSample{Attempt: 1}) {
+		t.Error("expected only the retries to be sampled")
+	}
+}
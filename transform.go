@@ -0,0 +1,95 @@
+package gocql
+
+// ColumnTransformer transforms the encoded values of a column, for example to
+// encrypt them on the client, see ClusterConfig.ColumnTransformers. The column
+// stores the transformed values, as a blob, while the application binds and
+// scans values of Type.
+//
+// Transformers only apply to values bound to prepared statements and to the
+// rows scanned from the results. Null values aren't transformed.
+type ColumnTransformer interface {
+	// Type is the type values of the column are marshalled to before Encode
+	// and unmarshalled from after Decode.
+	Type() TypeInfo
+	// Encode transforms the marshalled value of col before it is sent.
+	Encode(col ColumnInfo, data []byte) ([]byte, error)
+	// Decode reverses Encode for a value of col which was read.
+	Decode(col ColumnInfo, data []byte) ([]byte, error)
+}
+
+// newColumnTransformers returns a copy of transformers so that changes to the
+// cluster config don't affect a session that is already running.
+func newColumnTransformers(transformers map[string]ColumnTransformer) map[string]ColumnTransformer {
+	if len(transformers) == 0 {
+		return nil
+	}
+	copied := make(map[string]ColumnTransformer, len(transformers))
+	for column, t := range transformers {
+		copied[column] = t
+	}
+	return copied
+}
+
+// columnTransformers returns the transformers of columns by index, or nil if
+// none of the columns are transformed.
+func (s *Session) columnTransformers(columns []ColumnInfo) []ColumnTransformer {
+	if s == nil || len(s.transformers) == 0 {
+		return nil
+	}
+	var transformers []ColumnTransformer
+	for i, col := range columns {
+		t, ok := s.transformers[col.Keyspace+"."+col.Table+"."+col.Name]
+		if !ok {
+			continue
+		}
+		if transformers == nil {
+			transformers = make([]ColumnTransformer, len(columns))
+		}
+		transformers[i] = t
+	}
+	return transformers
+}
+
+// marshalTransformedValue is like marshalQueryValue for a column transformed
+// by t.
+func marshalTransformedValue(t ColumnTransformer, col ColumnInfo, value interface{}, dst *queryValues) error {
+	if err := marshalQueryValue(t.Type(), value, dst); err != nil {
+		return err
+	}
+	if dst.isUnset || dst.value == nil {
+		return nil
+	}
+	encoded, err := t.Encode(col, dst.value)
+	if err != nil {
+		return err
+	}
+	dst.value = encoded
+	return nil
+}
+
+// transformColumns makes the iterator decode the columns transformed by
+// transformers, which are reported with the type of their transformer.
+func (iter *Iter) transformColumns(transformers []ColumnTransformer) {
+	if transformers == nil {
+		return
+	}
+	// the columns may be shared with the prepared statement
+	columns := make([]ColumnInfo, len(iter.meta.columns))
+	copy(columns, iter.meta.columns)
+	for i, t := range transformers {
+		if t != nil {
+			columns[i].TypeInfo = t.Type()
+		}
+	}
+	iter.meta.columns = columns
+	iter.transformers = transformers
+}
+
+// decodeColumn returns the value p read for column i, decoded if the column
+// is transformed.
+func (iter *Iter) decodeColumn(i int, p []byte) ([]byte, error) {
+	if iter.transformers == nil || iter.transformers[i] == nil || p == nil {
+		return p, nil
+	}
+	return iter.transformers[i].Decode(iter.meta.columns[i], p)
+}
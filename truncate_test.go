@@ -0,0 +1,41 @@
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestTruncate(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	srv.On(`TRUNCATE shop.orders`).Handle(nil, func(req *gocqltest.Request) gocqltest.Response {
+		time.Sleep(200 * time.Millisecond)
+		return gocqltest.Response{}
+	})
+	srv.On(`TRUNCATE shop.users`).Error(gocql.ErrCodeTruncate, "truncate timed out")
+
+	cluster := srv.ClusterConfig()
+	cluster.Timeout = 50 * time.Millisecond
+	cluster.TruncateTimeout = 5 * time.Second
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if err := session.Truncate(context.Background(), "shop.orders"); err != nil {
+		t.Fatalf("expected the truncation to outlast the timeout of the connection, got %v", err)
+	}
+
+	err = session.Truncate(context.Background(), "shop.users")
+	var truncateErr *gocql.RequestErrTruncate
+	if !errors.As(err, &truncateErr) || truncateErr.Message() != "truncate timed out" {
+		t.Fatalf("expected a truncate error, got %#v", err)
+	}
+}
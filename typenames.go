@@ -0,0 +1,97 @@
+package gocql
+
+import "strings"
+
+// CQLTypeName returns the name of the type described by info as written in
+// CQL, such as map<text, frozen<list<int>>>, for tools which render or check
+// the columns of results. User defined types are named without their
+// keyspace and custom types by their class name in quotes.
+//
+// Types nested in collections, tuples and user defined types are frozen, as
+// CQL requires. The protocol doesn't tell whether a column itself is frozen,
+// tuples always are and the declared types of columns are in the metadata of
+// their table, see Session.KeyspaceMetadata.
+func CQLTypeName(info TypeInfo) string {
+	var b strings.Builder
+	writeCQLTypeName(&b, info, false)
+	return b.String()
+}
+
+func writeCQLTypeName(b *strings.Builder, info TypeInfo, nested bool) {
+	if info == nil {
+		b.WriteString("unknown")
+		return
+	}
+
+	frozen := nested && isComplexType(info.Type())
+	if frozen {
+		b.WriteString("frozen<")
+	}
+
+	switch t := info.(type) {
+	case CollectionType:
+		b.WriteString(t.typ.String())
+		b.WriteByte('<')
+		if t.typ == TypeMap {
+			writeCQLTypeName(b, t.Key, true)
+			b.WriteString(", ")
+		}
+		writeCQLTypeName(b, t.Elem, true)
+		b.WriteByte('>')
+	case TupleTypeInfo:
+		b.WriteString("tuple<")
+		for i, elem := range t.Elems {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeCQLTypeName(b, elem, true)
+		}
+		b.WriteByte('>')
+	case UDTTypeInfo:
+		b.WriteString(t.Name)
+	default:
+		if info.Type() == TypeCustom {
+			b.WriteString("'" + EscapeString(info.Custom()) + "'")
+		} else {
+			b.WriteString(info.Type().String())
+		}
+	}
+
+	if frozen {
+		b.WriteByte('>')
+	}
+}
+
+// isComplexType reports whether values of typ are frozen when nested in
+// another type.
+func isComplexType(typ Type) bool {
+	switch typ {
+	case TypeList, TypeSet, TypeMap, TypeTuple, TypeUDT:
+		return true
+	}
+	return false
+}
+
+// TypeName returns the CQL name of the type of the column, see CQLTypeName.
+func (c ColumnInfo) TypeName() string {
+	return CQLTypeName(c.TypeInfo)
+}
+
+// UDTFields returns the fields of the user defined type of the column, in
+// their order, or nil if the column is of another type.
+func (c ColumnInfo) UDTFields() []UDTField {
+	if udt, ok := c.TypeInfo.(UDTTypeInfo); ok {
+		return udt.Elements
+	}
+	return nil
+}
+
+// CustomClass returns the class name of the type of the column if it is a
+// custom type, such as org.apache.cassandra.db.marshal.DateRangeType, or an
+// empty string.
+func (c ColumnInfo) CustomClass() string {
+	if c.TypeInfo == nil || c.TypeInfo.Type() != TypeCustom {
+		return ""
+	}
+	return c.TypeInfo.Custom()
+}
@@ -0,0 +1,76 @@
+package gocql_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestColumnTypeNames(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	address := gocql.UDTTypeInfo{
+		NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""),
+		KeySpace:   "ks",
+		Name:       "address",
+		Elements: []gocql.UDTField{
+			{Name: "street", Type: gocqltest.Text},
+			{Name: "tags", Type: gocqltest.Set(gocqltest.Text)},
+		},
+	}
+	dateRange := "org.apache.cassandra.db.marshal.DateRangeType"
+	columns := []gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "scores", Type: gocqltest.List(gocqltest.Int)},
+		{Name: "history", Type: gocqltest.Map(gocqltest.Text, gocqltest.List(gocqltest.Int))},
+		{Name: "point", Type: gocqltest.Tuple(gocqltest.Int, address)},
+		{Name: "home", Type: address},
+		{Name: "period", Type: gocql.NewNativeType(4, gocql.TypeCustom, dateRange)},
+	}
+	srv.On(`SELECT * FROM ks.users`).Rows(columns)
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	iter := session.Query(`SELECT * FROM ks.users`).Iter()
+	got := iter.Columns()
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"int",
+		"list<int>",
+		"map<text, frozen<list<int>>>",
+		"tuple<int, frozen<address>>",
+		"address",
+		"'" + dateRange + "'",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d columns, got %v", len(want), got)
+	}
+	for i, col := range got {
+		if name := col.TypeName(); name != want[i] {
+			t.Errorf("column %s: expected type %s, got %s", col.Name, want[i], name)
+		}
+	}
+
+	fields := got[4].UDTFields()
+	if len(fields) != 2 || fields[1].Name != "tags" || gocql.CQLTypeName(fields[1].Type) != "set<text>" {
+		t.Fatalf("unexpected fields of the address type %v", fields)
+	}
+	if got[0].UDTFields() != nil {
+		t.Fatal("expected no fields for a column of a native type")
+	}
+	if class := got[5].CustomClass(); class != dateRange {
+		t.Fatalf("expected custom class %s, got %q", dateRange, class)
+	}
+	if class := got[0].CustomClass(); class != "" {
+		t.Fatalf("expected no custom class for a native type, got %q", class)
+	}
+}
@@ -0,0 +1,111 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// udtTypes are the Go types registered for UDTs by RegisterUDT, by name.
+var udtTypes sync.Map
+
+// RegisterUDT declares typ, a struct, as the Go type of the user defined type
+// name, as in the schema and optionally qualified by its keyspace, as in
+// "ks.address":
+//
+//	gocql.RegisterUDT("address", reflect.TypeOf(Address{}))
+//
+// Values of the UDT read without a destination of their own, such as with
+// MapScan, SliceMap or TypeInfo.NewWithError, are then of type typ instead of
+// map[string]interface{}. The fields are mapped to the elements of the UDT as
+// when marshaling any struct, by their cql tag or their name.
+//
+// Registering a type is not needed to marshal or unmarshal it, the mapping of
+// the fields of every struct to the elements of a UDT is computed once and
+// cached. RegisterUDT is meant to be called in init functions and panics if
+// typ is not a struct.
+func RegisterUDT(name string, typ reflect.Type) {
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("gocql: RegisterUDT of %s with %v which is not a struct", name, typ))
+	}
+	udtTypes.Store(name, typ)
+}
+
+// registeredUDT returns the type registered for the UDT udt, nil if there is
+// none.
+func registeredUDT(udt UDTTypeInfo) reflect.Type {
+	if typ, ok := udtTypes.Load(udt.KeySpace + "." + udt.Name); ok {
+		return typ.(reflect.Type)
+	}
+	if typ, ok := udtTypes.Load(udt.Name); ok {
+		return typ.(reflect.Type)
+	}
+	return nil
+}
+
+// udtFields maps the elements of a UDT to the fields of a struct.
+type udtFields struct {
+	// elements are the names of the elements of the UDT the fields were
+	// mapped for, the definition of the UDT may change.
+	elements []string
+	// fields are the indexes of the fields of the elements, in their order,
+	// nil if the struct has no field for the element.
+	fields [][]int
+}
+
+type udtFieldsKey struct {
+	typ      reflect.Type
+	keyspace string
+	name     string
+}
+
+// udtFieldsCache caches the udtFields of a struct type and a UDT by
+// udtFieldsKey.
+var udtFieldsCache sync.Map
+
+// matches reports whether f was mapped for the elements of udt.
+func (f *udtFields) matches(udt UDTTypeInfo) bool {
+	if len(f.elements) != len(udt.Elements) {
+		return false
+	}
+	for i, e := range udt.Elements {
+		if f.elements[i] != e.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedUDTFields returns the fields of the struct type t the elements of udt
+// are marshaled from and unmarshaled into: the fields tagged with their name,
+// or else named like them.
+func cachedUDTFields(t reflect.Type, udt UDTTypeInfo) *udtFields {
+	key := udtFieldsKey{typ: t, keyspace: udt.KeySpace, name: udt.Name}
+	if cached, ok := udtFieldsCache.Load(key); ok {
+		if f := cached.(*udtFields); f.matches(udt) {
+			return f
+		}
+	}
+
+	tagged := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("cql"); tag != "" {
+			tagged[tag] = i
+		}
+	}
+
+	f := &udtFields{
+		elements: make([]string, len(udt.Elements)),
+		fields:   make([][]int, len(udt.Elements)),
+	}
+	for i, e := range udt.Elements {
+		f.elements[i] = e.Name
+		if index, ok := tagged[e.Name]; ok {
+			f.fields[i] = []int{index}
+		} else if sf, ok := t.FieldByName(e.Name); ok {
+			f.fields[i] = sf.Index
+		}
+	}
+	udtFieldsCache.Store(key, f)
+	return f
+}
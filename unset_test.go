@@ -0,0 +1,46 @@
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestUnsetValueProtocolVersion(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	const insert = `INSERT INTO users (id, name) VALUES (?, ?)`
+	srv.On(insert).Params(
+		gocqltest.Column{Name: "id", Type: gocqltest.Int},
+		gocqltest.Column{Name: "name", Type: gocqltest.Text},
+	)
+
+	for _, proto := range []int{3, 4} {
+		cluster := srv.ClusterConfig()
+		cluster.ProtoVersion = proto
+		session, err := cluster.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = session.Query(insert, 1, gocql.UnsetValue).Exec()
+		if proto < 4 && !errors.Is(err, gocql.ErrUnsetValueUnsupported) {
+			t.Errorf("protocol %d: expected ErrUnsetValueUnsupported, got %v", proto, err)
+		} else if proto >= 4 && err != nil {
+			t.Errorf("protocol %d: %v", proto, err)
+		}
+
+		b := session.NewBatch(gocql.UnloggedBatch)
+		b.Query(insert, 1, gocql.UnsetValue)
+		err = session.ExecuteBatch(b)
+		if proto < 4 && !errors.Is(err, gocql.ErrUnsetValueUnsupported) {
+			t.Errorf("protocol %d: expected ErrUnsetValueUnsupported for the batch, got %v", proto, err)
+		} else if proto >= 4 && err != nil {
+			t.Errorf("protocol %d: batch: %v", proto, err)
+		}
+		session.Close()
+	}
+}
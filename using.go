@@ -0,0 +1,298 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrTTLNotSupported is returned when executing a query on which WithTTL was
+// called and the statement is neither an INSERT nor an UPDATE.
+var ErrTTLNotSupported = errors.New("gocql: TTL can only be set on INSERT and UPDATE statements")
+
+// stmtToken is a token of a statement: a word, such as a keyword, an
+// identifier or a number, a string literal, a quoted identifier, a bind
+// marker or a symbol.
+type stmtToken struct {
+	text       string
+	start, end int
+	// depth is the number of parentheses the token is in.
+	depth int
+}
+
+// isMarker reports whether the token is a positional or a named bind marker.
+func (t *stmtToken) isMarker() bool {
+	return t.text == "?" || len(t.text) > 1 && t.text[0] == ':'
+}
+
+func isCQLNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isCQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isCQLSymbol reports whether c is a symbol, a token of its own. A dot is part
+// of the numbers it is in.
+func isCQLSymbol(stmt string, i int) bool {
+	switch c := stmt[i]; c {
+	case '(', ')', '[', ']', '{', '}', ',', ';', '=', '<', '>', '!', '+', '*', '/', '%', ':', '?':
+		return true
+	case '.':
+		return i == 0 || i+1 == len(stmt) || !isCQLDigit(stmt[i-1]) || !isCQLDigit(stmt[i+1])
+	}
+	return false
+}
+
+// tokenizeStmt splits stmt into tokens, skipping comments. Quoted strings and
+// identifiers are single tokens including their quotes. ok is false if stmt
+// has an unterminated string, quoted identifier or comment, the tokens up to
+// it are returned.
+func tokenizeStmt(stmt string) (tokens []stmtToken, ok bool) {
+	var depth int
+	add := func(start, end int) {
+		text := stmt[start:end]
+		if text == ")" {
+			depth--
+		}
+		tokens = append(tokens, stmtToken{text: text, start: start, end: end, depth: depth})
+		if text == "(" {
+			depth++
+		}
+	}
+	for i := 0; i < len(stmt); {
+		c, rest := stmt[i], stmt[i:]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(rest, "--") || strings.HasPrefix(rest, "//"):
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			i += n
+		case strings.HasPrefix(rest, "/*"):
+			n := strings.Index(rest[2:], "*/")
+			if n < 0 {
+				return tokens, false
+			}
+			i += n + 4
+		case c == '\'' || c == '"':
+			j := 1
+			for {
+				n := strings.IndexByte(rest[j:], c)
+				if n < 0 {
+					return tokens, false
+				}
+				j += n + 1
+				// quotes are escaped by doubling them
+				if j < len(rest) && rest[j] == c {
+					j++
+					continue
+				}
+				break
+			}
+			add(i, i+j)
+			i += j
+		case strings.HasPrefix(rest, "$$"):
+			n := strings.Index(rest[2:], "$$")
+			if n < 0 {
+				return tokens, false
+			}
+			add(i, i+n+4)
+			i += n + 4
+		case c == ':' && len(rest) > 1 && (rest[1] == '_' || unicode.IsLetter(rune(rest[1]))):
+			n := 1
+			for n < len(rest) && isCQLNameChar(rest[n]) {
+				n++
+			}
+			add(i, i+n)
+			i += n
+		case c == '<' && strings.HasPrefix(rest, "<=") || c == '>' && strings.HasPrefix(rest, ">=") || c == '!' && strings.HasPrefix(rest, "!="):
+			add(i, i+2)
+			i += 2
+		case isCQLSymbol(stmt, i):
+			add(i, i+1)
+			i++
+		default:
+			j := i + 1
+			for j < len(stmt) && !strings.ContainsRune(" \t\n\r'\"", rune(stmt[j])) && !isCQLSymbol(stmt, j) &&
+				!strings.HasPrefix(stmt[j:], "--") && !strings.HasPrefix(stmt[j:], "$$") {
+				j++
+			}
+			add(i, j)
+			i = j
+		}
+	}
+	return tokens, true
+}
+
+// topLevelTokens returns the tokens of stmt outside of parentheses, without
+// the parentheses and the separators.
+func topLevelTokens(stmt string) []stmtToken {
+	all, _ := tokenizeStmt(stmt)
+	var tokens []stmtToken
+	for _, tok := range all {
+		if tok.depth == 0 && tok.text != "(" && tok.text != ")" && tok.text != "," && tok.text != ";" {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// usingClauseEnd returns the index of the token following the USING clause of
+// an INSERT, UPDATE, DELETE or SELECT statement, len(tokens) if it is the last
+// clause, or -1 if the statement is of another kind.
+func usingClauseEnd(tokens []stmtToken) int {
+	if len(tokens) == 0 {
+		return -1
+	}
+
+	var next string
+	switch strings.ToUpper(tokens[0].text) {
+	case "INSERT", "SELECT":
+		return len(tokens)
+	case "UPDATE":
+		next = "SET"
+	case "DELETE":
+		next = "WHERE"
+	default:
+		return -1
+	}
+	for i, tok := range tokens {
+		if strings.EqualFold(tok.text, next) {
+			return i
+		}
+	}
+	return -1
+}
+
+// stmtWithUsing returns stmt with the parameter param of its USING clause set
+// to value, adding the clause or the parameter if needed. If the parameter is
+// already set it is only replaced if replace is true.
+func stmtWithUsing(stmt string, tokens []stmtToken, end int, param, value string, replace bool) (string, error) {
+	using := -1
+	for i := 0; i < end; i++ {
+		if strings.EqualFold(tokens[i].text, "USING") {
+			using = i
+			break
+		}
+	}
+
+	if using < 0 {
+		if end == len(tokens) {
+			trimmed := strings.TrimRight(stmt, " \t\n\r;")
+			return trimmed + " USING " + param + " " + value + stmt[len(trimmed):], nil
+		}
+		pos := tokens[end].start
+		return stmt[:pos] + "USING " + param + " " + value + " " + stmt[pos:], nil
+	}
+
+	for i := using + 1; i+1 < end; i++ {
+		if strings.EqualFold(tokens[i].text, param) {
+			if !replace {
+				return stmt, nil
+			}
+			tok := tokens[i+1]
+			if tok.text == "?" || strings.HasPrefix(tok.text, ":") {
+				return "", fmt.Errorf("gocql: %s of %q is set by a bind marker", param, stmt)
+			}
+			return stmt[:tok.start] + value + stmt[tok.end:], nil
+		}
+	}
+	pos := tokens[using].end
+	return stmt[:pos] + " " + param + " " + value + " AND" + stmt[pos:], nil
+}
+
+// serverTimeout returns the USING TIMEOUT for the time remaining until a
+// deadline, rounded down to the millisecond but at least 1ms.
+func serverTimeout(remaining time.Duration) time.Duration {
+	remaining = remaining.Truncate(time.Millisecond)
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+	return remaining
+}
+
+// stmtWithTimeout returns stmt with a USING TIMEOUT of serverTimeout(remaining).
+// stmt is returned unchanged if it already has a timeout or is not an INSERT,
+// UPDATE, DELETE or SELECT. Each timeout makes a distinct statement, so it is
+// only used for the statements which are not prepared.
+func stmtWithTimeout(stmt string, remaining time.Duration) string {
+	timeout := strconv.FormatInt(serverTimeout(remaining).Milliseconds(), 10) + "ms"
+	withTimeout, _ := addStmtTimeout(stmt, timeout)
+	return withTimeout
+}
+
+// stmtWithTimeoutMarker returns stmt with a USING TIMEOUT bound by a marker,
+// so that the statement is prepared once whatever the timeout, and the index
+// of the marker among the bind markers of the statement. stmt is returned
+// unchanged with an index of -1 as with stmtWithTimeout.
+func stmtWithTimeoutMarker(stmt string) (string, int) {
+	withTimeout, ok := addStmtTimeout(stmt, "?")
+	if !ok {
+		return stmt, -1
+	}
+	// the statements differ from where the clause, or the parameter, was
+	// added on
+	pos := 0
+	for pos < len(stmt) && stmt[pos] == withTimeout[pos] {
+		pos++
+	}
+	return withTimeout, countBindMarkers(stmt[:pos])
+}
+
+// addStmtTimeout returns stmt with the timeout of its USING clause set to
+// timeout, and whether it was changed.
+func addStmtTimeout(stmt, timeout string) (string, bool) {
+	tokens := topLevelTokens(stmt)
+	end := usingClauseEnd(tokens)
+	if end < 0 {
+		return stmt, false
+	}
+	withTimeout, err := stmtWithUsing(stmt, tokens, end, "TIMEOUT", timeout, false)
+	if err != nil || withTimeout == stmt {
+		return stmt, false
+	}
+	return withTimeout, true
+}
+
+// countBindMarkers returns the number of positional and named bind markers
+// of stmt outside of string literals, quoted identifiers and comments.
+func countBindMarkers(stmt string) int {
+	tokens, _ := tokenizeStmt(stmt)
+	var n int
+	for i := range tokens {
+		if tokens[i].isMarker() {
+			n++
+		}
+	}
+	return n
+}
+
+// stmtWithTTL returns stmt with its USING clause setting the TTL to ttl
+// seconds, adding the clause or replacing an existing TTL.
+func stmtWithTTL(stmt string, ttl int) (string, error) {
+	return setStmtTTL(stmt, ttl, true)
+}
+
+// stmtWithDefaultTTL is like stmtWithTTL but keeps an existing TTL.
+func stmtWithDefaultTTL(stmt string, ttl int) (string, error) {
+	return setStmtTTL(stmt, ttl, false)
+}
+
+func setStmtTTL(stmt string, ttl int, replace bool) (string, error) {
+	tokens := topLevelTokens(stmt)
+	if len(tokens) == 0 || !strings.EqualFold(tokens[0].text, "INSERT") && !strings.EqualFold(tokens[0].text, "UPDATE") {
+		return "", ErrTTLNotSupported
+	}
+	end := usingClauseEnd(tokens)
+	if end < 0 {
+		return "", fmt.Errorf("gocql: UPDATE statement without SET: %q", stmt)
+	}
+	return stmtWithUsing(stmt, tokens, end, "TTL", strconv.Itoa(ttl), replace)
+}
@@ -0,0 +1,125 @@
+package gocql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStmtWithTTL(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected string
+	}{
+		{`INSERT INTO users (id, name) VALUES (?, ?)`, `INSERT INTO users (id, name) VALUES (?, ?) USING TTL 60`},
+		{`INSERT INTO users (id) VALUES (?) IF NOT EXISTS;`, `INSERT INTO users (id) VALUES (?) IF NOT EXISTS USING TTL 60;`},
+		{`INSERT INTO users (id) VALUES (?) USING TIMESTAMP 5`, `INSERT INTO users (id) VALUES (?) USING TTL 60 AND TIMESTAMP 5`},
+		{`INSERT INTO users (id) VALUES (?) USING TTL 10`, `INSERT INTO users (id) VALUES (?) USING TTL 60`},
+		{`insert into users (id, name) values (1, 'using ttl 5')`, `insert into users (id, name) values (1, 'using ttl 5') USING TTL 60`},
+		{`UPDATE users SET name = ? WHERE id = ?`, `UPDATE users USING TTL 60 SET name = ? WHERE id = ?`},
+		{`UPDATE users USING TIMESTAMP 5 SET name = ? WHERE id = ?`, `UPDATE users USING TTL 60 AND TIMESTAMP 5 SET name = ? WHERE id = ?`},
+		{`UPDATE users USING TIMESTAMP 5 AND TTL 1 SET name = ? WHERE id = ?`, `UPDATE users USING TIMESTAMP 5 AND TTL 60 SET name = ? WHERE id = ?`},
+	}
+	for _, test := range tests {
+		stmt, err := stmtWithTTL(test.stmt, 60)
+		if err != nil {
+			t.Errorf("%s: %v", test.stmt, err)
+			continue
+		}
+		if stmt != test.expected {
+			t.Errorf("stmtWithTTL(%q) = %q, want %q", test.stmt, stmt, test.expected)
+		}
+	}
+
+	for _, stmt := range []string{
+		`SELECT * FROM users`,
+		`DELETE FROM users WHERE id = ?`,
+		`UPDATE users`,
+		`UPDATE users USING TTL ? SET name = ? WHERE id = ?`,
+	} {
+		if _, err := stmtWithTTL(stmt, 60); err == nil {
+			t.Errorf("expected error for %q", stmt)
+		}
+	}
+}
+
+func TestQueryWithTTL(t *testing.T) {
+	q := &Query{stmt: `INSERT INTO users (id) VALUES (?)`}
+	q.WithTTL(90 * time.Second).WithTTL(time.Hour)
+	if q.ttlErr != nil {
+		t.Fatal(q.ttlErr)
+	}
+	if expected := `INSERT INTO users (id) VALUES (?) USING TTL 3600`; q.stmt != expected {
+		t.Fatalf("expected %q, got %q", expected, q.stmt)
+	}
+
+	q = &Query{stmt: `SELECT * FROM users`}
+	if err := q.WithTTL(time.Minute).Iter().Close(); !errors.Is(err, ErrTTLNotSupported) {
+		t.Fatalf("expected %v, got %v", ErrTTLNotSupported, err)
+	}
+}
+
+func TestStmtWithTimeout(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected string
+	}{
+		{`SELECT * FROM users WHERE id = ?`, `SELECT * FROM users WHERE id = ? USING TIMEOUT 1234ms`},
+		{`SELECT * FROM users LIMIT 10;`, `SELECT * FROM users LIMIT 10 USING TIMEOUT 1234ms;`},
+		{`INSERT INTO users (id) VALUES (?) USING TTL 10`, `INSERT INTO users (id) VALUES (?) USING TIMEOUT 1234ms AND TTL 10`},
+		{`UPDATE users SET name = ? WHERE id = ?`, `UPDATE users USING TIMEOUT 1234ms SET name = ? WHERE id = ?`},
+		{`DELETE FROM users WHERE id = ?`, `DELETE FROM users USING TIMEOUT 1234ms WHERE id = ?`},
+		{`SELECT * FROM users USING TIMEOUT 5s`, `SELECT * FROM users USING TIMEOUT 5s`},
+		{`CREATE TABLE users (id int PRIMARY KEY)`, `CREATE TABLE users (id int PRIMARY KEY)`},
+	}
+	for _, test := range tests {
+		if stmt := stmtWithTimeout(test.stmt, 1234*time.Millisecond); stmt != test.expected {
+			t.Errorf("stmtWithTimeout(%q) = %q, want %q", test.stmt, stmt, test.expected)
+		}
+	}
+
+	if stmt := stmtWithTimeout(`SELECT * FROM users`, time.Microsecond); stmt != `SELECT * FROM users USING TIMEOUT 1ms` {
+		t.Errorf("expected minimum timeout, got %q", stmt)
+	}
+}
+
+func TestStmtWithTimeoutMarker(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected string
+		index    int
+	}{
+		{`SELECT * FROM users WHERE id = ?`, `SELECT * FROM users WHERE id = ? USING TIMEOUT ?`, 1},
+		{`INSERT INTO users (id, name) VALUES (?, :name) USING TTL ?`, `INSERT INTO users (id, name) VALUES (?, :name) USING TIMEOUT ? AND TTL ?`, 2},
+		{`UPDATE users SET name = ? WHERE id = ?`, `UPDATE users USING TIMEOUT ? SET name = ? WHERE id = ?`, 0},
+		{`DELETE FROM users WHERE id = ?`, `DELETE FROM users USING TIMEOUT ? WHERE id = ?`, 0},
+		{`SELECT * FROM users WHERE name = '?' AND id = ?`, `SELECT * FROM users WHERE name = '?' AND id = ? USING TIMEOUT ?`, 1},
+		{`SELECT * FROM users USING TIMEOUT 5s`, `SELECT * FROM users USING TIMEOUT 5s`, -1},
+		{`CREATE TABLE users (id int PRIMARY KEY)`, `CREATE TABLE users (id int PRIMARY KEY)`, -1},
+	}
+	for _, test := range tests {
+		stmt, index := stmtWithTimeoutMarker(test.stmt)
+		if stmt != test.expected || index != test.index {
+			t.Errorf("stmtWithTimeoutMarker(%q) = %q, %d, want %q, %d", test.stmt, stmt, index, test.expected, test.index)
+		}
+	}
+}
+
+func TestCountBindMarkers(t *testing.T) {
+	tests := []struct {
+		stmt  string
+		count int
+	}{
+		{`SELECT * FROM users WHERE id = ? AND name = :name`, 2},
+		{`INSERT INTO users (id, tags) VALUES (?, {'a': ?, 'b?': 1})`, 2},
+		{`SELECT * FROM users WHERE "a?" = ? -- and b = ?`, 1},
+		{`SELECT * FROM users WHERE id = ? /* ? */ AND day=?`, 2},
+		{`UPDATE users SET t = $$ :x ? $$ WHERE id = ?`, 1},
+		{`SELECT * FROM users WHERE m = {1:2}`, 0},
+	}
+	for _, test := range tests {
+		if n := countBindMarkers(test.stmt); n != test.count {
+			t.Errorf("countBindMarkers(%q) = %d, want %d", test.stmt, n, test.count)
+		}
+	}
+}
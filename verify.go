@@ -0,0 +1,109 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ReadTarget is how a read of CompareReads is executed.
+type ReadTarget struct {
+	// Consistency is the consistency level of the read, the consistency of
+	// the query if it is Any.
+	Consistency Consistency
+	// HostID, if set, pins the read to the replica with this host ID, see
+	// Query.SetHost.
+	HostID string
+}
+
+// RowMismatch is a row which differs between the reads of CompareReads.
+type RowMismatch struct {
+	// Key are the values of the key columns of the row, in their order.
+	Key []interface{}
+	// A and B are the row as returned by each read, nil if it was missing.
+	A, B map[string]interface{}
+}
+
+// CompareReads executes the query twice, as described by a and b, and returns
+// the rows which differ between the results, to verify that the replicas of a
+// partition agree, for example after a repair or a migration:
+//
+//	mismatches, err := session.Query(`SELECT * FROM users WHERE id = ?`, id).CompareReads(
+//		[]string{"id"},
+//		gocql.ReadTarget{Consistency: gocql.One, HostID: replica1},
+//		gocql.ReadTarget{Consistency: gocql.One, HostID: replica2})
+//
+// The rows are matched by the values of the key columns, which should be the
+// primary key of the table and be selected by the query, or by their position
+// if key is empty. The reads are executed one after the other, all the pages
+// of the results are read and compared.
+func (q *Query) CompareReads(key []string, a, b ReadTarget) ([]RowMismatch, error) {
+	rowsA, err := q.readAt(a)
+	if err != nil {
+		return nil, err
+	}
+	rowsB, err := q.readAt(b)
+	if err != nil {
+		return nil, err
+	}
+	return compareRows(key, rowsA, rowsB), nil
+}
+
+// readAt executes the query as described by target and returns its rows. The
+// consistency and host of the query are restored afterwards.
+func (q *Query) readAt(target ReadTarget) ([]map[string]interface{}, error) {
+	cons, hostID := q.cons, q.hostID
+	defer func() {
+		q.cons, q.hostID = cons, hostID
+	}()
+
+	if target.Consistency != Any {
+		q.cons = target.Consistency
+	}
+	if target.HostID != "" {
+		q.hostID = target.HostID
+	}
+	return q.Iter().SliceMap()
+}
+
+// compareRows returns the rows of a and b which differ, matched by the values
+// of the key columns, in the order of a and then of b.
+func compareRows(key []string, a, b []map[string]interface{}) []RowMismatch {
+	keyOf := func(i int, row map[string]interface{}) ([]interface{}, string) {
+		if len(key) == 0 {
+			return []interface{}{i}, fmt.Sprint(i)
+		}
+		values := make([]interface{}, len(key))
+		for j, column := range key {
+			values[j] = row[column]
+		}
+		return values, fmt.Sprintf("%#v", values)
+	}
+
+	indexB := make(map[string]int, len(b))
+	for i, row := range b {
+		_, k := keyOf(i, row)
+		indexB[k] = i
+	}
+
+	var mismatches []RowMismatch
+	matched := make([]bool, len(b))
+	for i, row := range a {
+		values, k := keyOf(i, row)
+		j, ok := indexB[k]
+		if !ok {
+			mismatches = append(mismatches, RowMismatch{Key: values, A: row})
+			continue
+		}
+		matched[j] = true
+		if !reflect.DeepEqual(row, b[j]) {
+			mismatches = append(mismatches, RowMismatch{Key: values, A: row, B: b[j]})
+		}
+	}
+	for j, row := range b {
+		if !matched[j] {
+			values, _ := keyOf(j, row)
+			mismatches = append(mismatches, RowMismatch{Key: values, B: row})
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,65 @@
+package gocql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestCompareReads(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	columns := []gocqltest.Column{
+		{Name: "id", Type: gocqltest.Int},
+		{Name: "day", Type: gocqltest.Int},
+		{Name: "total", Type: gocqltest.Int},
+	}
+	// the write of day 2 was missed by a replica which also has a stale total
+	// for day 3
+	srv.On(`SELECT * FROM ks.sales WHERE id = ?`).Params(columns[0]).Handle(columns, func(req *gocqltest.Request) gocqltest.Response {
+		if req.Consistency == gocql.All {
+			return gocqltest.Response{Rows: [][]interface{}{{1, 1, 10}, {1, 2, 20}, {1, 3, 30}}}
+		}
+		return gocqltest.Response{Rows: [][]interface{}{{1, 1, 10}, {1, 3, 25}}}
+	})
+
+	session, err := srv.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	q := session.Query(`SELECT * FROM ks.sales WHERE id = ?`, 1).Consistency(gocql.LocalQuorum)
+	mismatches, err := q.CompareReads([]string{"id", "day"}, gocql.ReadTarget{Consistency: gocql.All}, gocql.ReadTarget{Consistency: gocql.One})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gocql.RowMismatch{
+		{
+			Key: []interface{}{1, 2},
+			A:   map[string]interface{}{"id": 1, "day": 2, "total": 20},
+		},
+		{
+			Key: []interface{}{1, 3},
+			A:   map[string]interface{}{"id": 1, "day": 3, "total": 30},
+			B:   map[string]interface{}{"id": 1, "day": 3, "total": 25},
+		},
+	}
+	if !reflect.DeepEqual(mismatches, want) {
+		t.Fatalf("expected mismatches %v, got %v", want, mismatches)
+	}
+	if q.GetConsistency() != gocql.LocalQuorum {
+		t.Fatalf("expected the consistency of the query to be restored, got %v", q.GetConsistency())
+	}
+
+	mismatches, err = q.CompareReads(nil, gocql.ReadTarget{Consistency: gocql.One}, gocql.ReadTarget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches between reads at the same consistency, got %v", mismatches)
+	}
+}
@@ -0,0 +1,207 @@
+package gocql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WarningKind is the kind of a warning of the server, see ParseWarning.
+type WarningKind int
+
+const (
+	// WarningOther is a warning of another kind, only its message is known.
+	WarningOther WarningKind = iota
+	// WarningTombstones is a read which scanned more tombstones than the
+	// tombstone_warn_threshold of the server.
+	WarningTombstones
+	// WarningLargeBatch is a batch larger than the
+	// batch_size_warn_threshold of the server.
+	WarningLargeBatch
+	// WarningUnloggedBatch is an unlogged batch covering more partitions
+	// than the unlogged_batch_across_partitions_warn_threshold of the server.
+	WarningUnloggedBatch
+)
+
+func (k WarningKind) String() string {
+	switch k {
+	case WarningTombstones:
+		return "tombstones"
+	case WarningLargeBatch:
+		return "large batch"
+	case WarningUnloggedBatch:
+		return "unlogged batch"
+	default:
+		return "other"
+	}
+}
+
+// QueryWarning is a warning sent by the server with the response of a query or
+// batch, parsed into its kind and counts.
+type QueryWarning struct {
+	Kind    WarningKind
+	Message string
+
+	// Tables are the tables the warning is about, as keyspace.table, or just
+	// table if the server didn't send the keyspace.
+	Tables []string
+
+	// LiveRows and Tombstones are the live rows and tombstone cells read, for
+	// WarningTombstones.
+	LiveRows   int
+	Tombstones int
+
+	// Size and Threshold are the size of the batch and the threshold of the
+	// server in bytes, for WarningLargeBatch.
+	Size      int64
+	Threshold int64
+
+	// Partitions is the number of partitions of the batch, for
+	// WarningUnloggedBatch.
+	Partitions int
+}
+
+var (
+	// Read 1 live rows and 1001 tombstone cells for query SELECT * FROM ks.t
+	// WHERE ... (see tombstone_warn_threshold), or before Cassandra 3.0:
+	// Read 1 live and 1001 tombstone cells in ks.t for key: ...
+	tombstoneWarningRe = regexp.MustCompile(`(?i)^Read (\d+) live (?:rows )?and (\d+) tombstone(?:d)? cells (?:for query .*?\bFROM\s+([\w."]+)|in ([\w.]+))`)
+	// Batch for [ks.t] is of size 6.000KiB, exceeding specified threshold of
+	// 5.000KiB by 1.000KiB., or before Cassandra 4.0: Batch of prepared
+	// statements for [ks.t] is of size 6144, exceeding specified threshold of
+	// 5120 by 1024.
+	largeBatchWarningRe = regexp.MustCompile(`(?i)^Batch (?:of prepared statements )?for \[([^\]]*)\] is of size ([\d.]+)\s*(\w*), exceeding specified threshold of ([\d.]+)\s*(\w*)`)
+	// Unlogged batch covering 20 partitions detected against table [ks.t].
+	unloggedBatchWarningRe = regexp.MustCompile(`(?i)^Unlogged batch covering (\d+) partitions detected against tables? \[([^\]]*)\]`)
+)
+
+// ParseWarning parses a warning of the server, such as the ones returned by
+// Iter.Warnings. Warnings it doesn't recognize are of kind WarningOther.
+func ParseWarning(msg string) QueryWarning {
+	w := QueryWarning{Kind: WarningOther, Message: msg}
+	trimmed := strings.TrimSpace(msg)
+
+	if m := tombstoneWarningRe.FindStringSubmatch(trimmed); m != nil {
+		w.Kind = WarningTombstones
+		w.LiveRows, _ = strconv.Atoi(m[1])
+		w.Tombstones, _ = strconv.Atoi(m[2])
+		table := m[3]
+		if table == "" {
+			table = m[4]
+		}
+		w.Tables = warningTables(table)
+	} else if m := largeBatchWarningRe.FindStringSubmatch(trimmed); m != nil {
+		w.Kind = WarningLargeBatch
+		w.Tables = warningTables(m[1])
+		w.Size = warningBytes(m[2], m[3])
+		w.Threshold = warningBytes(m[4], m[5])
+	} else if m := unloggedBatchWarningRe.FindStringSubmatch(trimmed); m != nil {
+		w.Kind = WarningUnloggedBatch
+		w.Partitions, _ = strconv.Atoi(m[1])
+		w.Tables = warningTables(m[2])
+	}
+	return w
+}
+
+// parseWarnings parses the warnings of a response.
+func parseWarnings(msgs []string) []QueryWarning {
+	if len(msgs) == 0 {
+		return nil
+	}
+	warnings := make([]QueryWarning, len(msgs))
+	for i, msg := range msgs {
+		warnings[i] = ParseWarning(msg)
+	}
+	return warnings
+}
+
+// warningTables splits a comma separated list of tables, removing quotes.
+func warningTables(list string) []string {
+	var tables []string
+	for _, table := range strings.Split(list, ",") {
+		table = strings.Trim(strings.TrimSpace(strings.ReplaceAll(table, `"`, "")), ".;")
+		if table != "" {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// warningBytes returns the number of bytes of a size with unit, such as
+// "5.000" and "KiB".
+func warningBytes(size, unit string) int64 {
+	n, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(unit) {
+	case "kib", "kb":
+		n *= 1 << 10
+	case "mib", "mb":
+		n *= 1 << 20
+	case "gib", "gb":
+		n *= 1 << 30
+	}
+	return int64(n)
+}
+
+// TableWarnings are the numbers of warnings of the server about a table, see
+// Session.TableWarnings.
+type TableWarnings struct {
+	Tombstones      int64
+	LargeBatches    int64
+	UnloggedBatches int64
+}
+
+// tableWarnings counts the warnings of the server by table.
+type tableWarnings struct {
+	mu     sync.Mutex
+	tables map[string]*TableWarnings
+}
+
+func (t *tableWarnings) record(msgs []string) {
+	if t == nil || len(msgs) == 0 {
+		return
+	}
+	for _, w := range parseWarnings(msgs) {
+		if w.Kind == WarningOther {
+			continue
+		}
+		t.mu.Lock()
+		for _, table := range w.Tables {
+			counts, ok := t.tables[table]
+			if !ok {
+				counts = &TableWarnings{}
+				t.tables[table] = counts
+			}
+			switch w.Kind {
+			case WarningTombstones:
+				counts.Tombstones++
+			case WarningLargeBatch:
+				counts.LargeBatches++
+			case WarningUnloggedBatch:
+				counts.UnloggedBatches++
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// TableWarnings returns the numbers of warnings of the server about tombstones
+// and batches received by the session, by keyspace.table, so that data model
+// problems can be tracked on dashboards. Use an observer to get the warnings
+// of every query, see ObservedQuery.Warnings.
+func (s *Session) TableWarnings() map[string]TableWarnings {
+	t := s.tableWarnings
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tables := make(map[string]TableWarnings, len(t.tables))
+	for table, counts := range t.tables {
+		tables[table] = *counts
+	}
+	return tables
+}
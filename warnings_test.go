@@ -0,0 +1,131 @@
+package gocql_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestParseWarning(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want gocql.QueryWarning
+	}{
+		{
+			msg: "Read 2 live rows and 1500 tombstone cells for query SELECT * FROM ks.events WHERE id = 1 LIMIT 5000 (see tombstone_warn_threshold)",
+			want: gocql.QueryWarning{
+				Kind: gocql.WarningTombstones, Tables: []string{"ks.events"}, LiveRows: 2, Tombstones: 1500,
+			},
+		},
+		{
+			msg: "Read 0 live and 1001 tombstone cells in ks.events for key: 1 (see tombstone_warn_threshold). 100 columns were requested, slices=[-]",
+			want: gocql.QueryWarning{
+				Kind: gocql.WarningTombstones, Tables: []string{"ks.events"}, Tombstones: 1001,
+			},
+		},
+		{
+			msg: "Batch for [ks.events, ks.users] is of size 6.000KiB, exceeding specified threshold of 5.000KiB by 1.000KiB.",
+			want: gocql.QueryWarning{
+				Kind: gocql.WarningLargeBatch, Tables: []string{"ks.events", "ks.users"}, Size: 6144, Threshold: 5120,
+			},
+		},
+		{
+			msg: "Batch of prepared statements for [ks.events] is of size 6144, exceeding specified threshold of 5120 by 1024.",
+			want: gocql.QueryWarning{
+				Kind: gocql.WarningLargeBatch, Tables: []string{"ks.events"}, Size: 6144, Threshold: 5120,
+			},
+		},
+		{
+			msg: "Unlogged batch covering 20 partitions detected against table [ks.events]. You should use a logged batch for atomicity, or asynchronous writes for performance.",
+			want: gocql.QueryWarning{
+				Kind: gocql.WarningUnloggedBatch, Tables: []string{"ks.events"}, Partitions: 20,
+			},
+		},
+		{
+			msg:  "Aggregation query used without partition key",
+			want: gocql.QueryWarning{Kind: gocql.WarningOther},
+		},
+	}
+
+	for _, test := range tests {
+		test.want.Message = test.msg
+		if got := gocql.ParseWarning(test.msg); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseWarning(%q):\ngot  %+v\nwant %+v", test.msg, got, test.want)
+		}
+	}
+}
+
+type warningsObserver struct {
+	mu       sync.Mutex
+	warnings []gocql.QueryWarning
+}
+
+func (o *warningsObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.mu.Lock()
+	o.warnings = append(o.warnings, q.Warnings...)
+	o.mu.Unlock()
+}
+
+func (o *warningsObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.mu.Lock()
+	o.warnings = append(o.warnings, b.Warnings...)
+	o.mu.Unlock()
+}
+
+func TestTableWarnings(t *testing.T) {
+	srv := gocqltest.NewServer()
+	defer srv.Close()
+
+	tombstones := "Read 1 live rows and 1200 tombstone cells for query SELECT * FROM ks.events WHERE id = 1 LIMIT 5000 (see tombstone_warn_threshold)"
+	columns := []gocqltest.Column{{Name: "id", Type: gocqltest.Int}}
+	srv.On(`SELECT id FROM ks.events WHERE id = 1`).Handle(columns, func(*gocqltest.Request) gocqltest.Response {
+		return gocqltest.Response{Rows: [][]interface{}{{1}}, Warnings: []string{tombstones}}
+	})
+	batch := "Batch for [ks.events] is of size 6.000KiB, exceeding specified threshold of 5.000KiB by 1.000KiB."
+	srv.On(`INSERT INTO ks.events (id) VALUES (1)`).Handle(nil, func(*gocqltest.Request) gocqltest.Response {
+		return gocqltest.Response{Warnings: []string{batch}}
+	})
+
+	observer := &warningsObserver{}
+	cluster := srv.ClusterConfig()
+	cluster.QueryObserver = observer
+	cluster.BatchObserver = observer
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 2; i++ {
+		var id int
+		if err := session.Query(`SELECT id FROM ks.events WHERE id = 1`).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query(`INSERT INTO ks.events (id) VALUES (1)`)
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]gocql.TableWarnings{"ks.events": {Tombstones: 2, LargeBatches: 1}}
+	if got := session.TableWarnings(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected table warnings %v, got %v", want, got)
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.warnings) != 3 {
+		t.Fatalf("expected 3 observed warnings, got %+v", observer.warnings)
+	}
+	if w := observer.warnings[0]; w.Kind != gocql.WarningTombstones || w.Tombstones != 1200 || w.Message != tombstones {
+		t.Fatalf("unexpected query warning %+v", w)
+	}
+	if w := observer.warnings[2]; w.Kind != gocql.WarningLargeBatch || w.Size != 6144 {
+		t.Fatalf("unexpected batch warning %+v", w)
+	}
+}